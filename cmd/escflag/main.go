@@ -0,0 +1,11 @@
+// escflag is the previous name of the pulumi-of command line tool, kept as an alias so existing scripts
+// running escflag bench keep working. It accepts all the commands of pulumi-of.
+//
+// Deprecated: use pulumi-of instead.
+package main
+
+import "github.com/bugcacher/open-feature-pulumi-esc-provider/cmd/internal/cli"
+
+func main() {
+	cli.Main("escflag")
+}
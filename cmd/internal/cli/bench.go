@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// countingTransport counts the requests sent to the Pulumi ESC API
+type countingTransport struct {
+	base     http.RoundTripper
+	requests atomic.Int64
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return t.base.RoundTrip(req)
+}
+
+type benchResult struct {
+	latencies []time.Duration
+	errors    map[openfeature.ErrorCode]int
+	dropped   int
+	elapsed   time.Duration
+	apiCalls  int64
+}
+
+// benchPaths are the evaluation paths benchmarked by default
+var benchPaths = []string{"cold", "cached", "bulk"}
+
+// benchConfig are the settings of the benchmark of an evaluation path
+type benchConfig struct {
+	keys        []string
+	flagType    pulumi.FlagType
+	qps         int
+	duration    time.Duration
+	concurrency int
+	cacheTTL    time.Duration
+}
+
+// providerFactory creates a provider sending its requests using the given http.Client
+type providerFactory func(httpClient *http.Client, opts ...pulumi.ProviderOption) (*pulumi.PulumiESCProvider, error)
+
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	var envFlags environmentFlags
+	envFlags.register(fs)
+	qps := fs.Int("qps", 100, "target evaluations per second")
+	duration := fs.Duration("duration", 10*time.Second, "duration of the benchmark of every path")
+	concurrency := fs.Int("concurrency", 64, "maximum number of concurrent evaluations")
+	keys := fs.String("flags", "", "comma separated flag keys to evaluate")
+	flagType := fs.String("type", string(pulumi.FlagType_String), "type of the evaluated flags (bool, string, int64, float64)")
+	paths := fs.String("paths", strings.Join(benchPaths, ","), "comma separated evaluation paths to benchmark (cold, cached, bulk)")
+	cacheTTL := fs.Duration("cache-ttl", time.Minute, "TTL of the cache of the cached path")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *qps <= 0 || *concurrency <= 0 {
+		return errors.New("-qps and -concurrency must be positive")
+	}
+	selected := strings.Split(*paths, ",")
+	for _, path := range selected {
+		switch path {
+		case "cold", "cached":
+			if *keys == "" {
+				return fmt.Errorf("-flags is required by the %s path", path)
+			}
+		case "bulk":
+		default:
+			return fmt.Errorf("unknown evaluation path %q", path)
+		}
+	}
+	config := benchConfig{
+		keys:        strings.Split(*keys, ","),
+		flagType:    pulumi.FlagType(*flagType),
+		qps:         *qps,
+		duration:    *duration,
+		concurrency: *concurrency,
+		cacheTTL:    *cacheTTL,
+	}
+	for i, path := range selected {
+		result, err := benchPath(envFlags.newProvider, http.DefaultTransport, path, config)
+		if err != nil {
+			return fmt.Errorf("failed to benchmark the %s path: %w", path, err)
+		}
+		if i > 0 {
+			fmt.Fprintln(os.Stdout)
+		}
+		printBenchResult(os.Stdout, path, config.qps, result)
+	}
+	return nil
+}
+
+// benchPath benchmarks an evaluation path using a provider of its own, so the Pulumi ESC API calls are
+// counted per path:
+//   - cold evaluates the flags with no cache, reading them from the Pulumi ESC API
+//   - cached evaluates the flags with a read-through cache, warmed up before the benchmark
+//   - bulk evaluates all the flags of the environment at once using the OFREP bulk evaluation
+func benchPath(newProvider providerFactory, base http.RoundTripper, path string, config benchConfig) (benchResult, error) {
+	evaluate := bulkEvaluate
+	if path != "bulk" {
+		var err error
+		if evaluate, err = evaluator(config.flagType); err != nil {
+			return benchResult{}, err
+		}
+	}
+	var opts []pulumi.ProviderOption
+	if path == "cached" {
+		opts = append(opts, pulumi.WithCache(config.cacheTTL, pulumi.CacheFillPolicy_ReadThrough))
+	}
+	transport := &countingTransport{base: base}
+	provider, err := newProvider(&http.Client{Transport: transport}, opts...)
+	if err != nil {
+		return benchResult{}, err
+	}
+	defer provider.Shutdown()
+
+	if path == "cached" {
+		for _, key := range config.keys {
+			evaluate(provider, key)
+		}
+	}
+	// Only count the requests made by the benchmarked evaluations
+	transport.requests.Store(0)
+
+	result := bench(provider, evaluate, config.keys, config.qps, config.duration, config.concurrency)
+	result.apiCalls = transport.requests.Load()
+	return result, nil
+}
+
+type evaluateFunc func(provider *pulumi.PulumiESCProvider, key string) openfeature.ProviderResolutionDetail
+
+func evaluator(flagType pulumi.FlagType) (evaluateFunc, error) {
+	ctx := context.Background()
+	switch flagType {
+	case pulumi.FlagType_Bool:
+		return func(p *pulumi.PulumiESCProvider, key string) openfeature.ProviderResolutionDetail {
+			return p.BooleanEvaluation(ctx, key, false, nil).ProviderResolutionDetail
+		}, nil
+	case pulumi.FlagType_String:
+		return func(p *pulumi.PulumiESCProvider, key string) openfeature.ProviderResolutionDetail {
+			return p.StringEvaluation(ctx, key, "", nil).ProviderResolutionDetail
+		}, nil
+	case pulumi.FlagType_Integer:
+		return func(p *pulumi.PulumiESCProvider, key string) openfeature.ProviderResolutionDetail {
+			return p.IntEvaluation(ctx, key, 0, nil).ProviderResolutionDetail
+		}, nil
+	case pulumi.FlagType_Float:
+		return func(p *pulumi.PulumiESCProvider, key string) openfeature.ProviderResolutionDetail {
+			return p.FloatEvaluation(ctx, key, 0, nil).ProviderResolutionDetail
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported flag type %q", flagType)
+}
+
+// bulkEvaluate evaluates all the flags of the environment using the OFREP bulk evaluation, ignoring the key
+func bulkEvaluate(provider *pulumi.PulumiESCProvider, _ string) openfeature.ProviderResolutionDetail {
+	recorder := httptest.NewRecorder()
+	provider.OFREPHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/ofrep/v1/evaluate/flags", strings.NewReader("{}")))
+	if recorder.Code != http.StatusOK {
+		return openfeature.ProviderResolutionDetail{
+			ResolutionError: openfeature.NewGeneralResolutionError(strings.TrimSpace(recorder.Body.String())),
+		}
+	}
+	return openfeature.ProviderResolutionDetail{}
+}
+
+// bench evaluates the keys in a round robin fashion at the given rate. Evaluations which can not
+// start because all workers are busy are dropped rather than queued, so the latencies are not
+// skewed by the load generator itself.
+func bench(provider *pulumi.PulumiESCProvider, evaluate evaluateFunc, keys []string, qps int, duration time.Duration, concurrency int) benchResult {
+	result := benchResult{errors: map[openfeature.ErrorCode]int{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, concurrency)
+
+	ticker := time.NewTicker(time.Second / time.Duration(qps))
+	defer ticker.Stop()
+	start := time.Now()
+	deadline := time.After(duration)
+	for i := 0; ; i++ {
+		select {
+		case <-deadline:
+			wg.Wait()
+			result.elapsed = time.Since(start)
+			return result
+		case <-ticker.C:
+		}
+		select {
+		case workers <- struct{}{}:
+		default:
+			result.dropped++
+			continue
+		}
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			evalStart := time.Now()
+			resolutionDetails := evaluate(provider, key)
+			latency := time.Since(evalStart)
+			mu.Lock()
+			defer mu.Unlock()
+			result.latencies = append(result.latencies, latency)
+			if code := resolutionDetails.ResolutionDetail().ErrorCode; code != "" {
+				result.errors[code]++
+			}
+		}(keys[i%len(keys)])
+	}
+}
+
+func printBenchResult(w io.Writer, path string, qps int, result benchResult) {
+	latencies := append([]time.Duration(nil), result.latencies...)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	evaluations := len(latencies)
+
+	fmt.Fprintf(w, "path:          %s\n", path)
+	fmt.Fprintf(w, "target qps:    %d\n", qps)
+	fmt.Fprintf(w, "achieved qps:  %.1f\n", float64(evaluations)/result.elapsed.Seconds())
+	fmt.Fprintf(w, "evaluations:   %d (%d dropped)\n", evaluations, result.dropped)
+	for code, count := range result.errors {
+		fmt.Fprintf(w, "errors:        %d %s\n", count, code)
+	}
+	fmt.Fprintf(w, "latency p50:   %s\n", percentile(latencies, 0.50))
+	fmt.Fprintf(w, "latency p90:   %s\n", percentile(latencies, 0.90))
+	fmt.Fprintf(w, "latency p99:   %s\n", percentile(latencies, 0.99))
+	fmt.Fprintf(w, "latency max:   %s\n", percentile(latencies, 1))
+	fmt.Fprintf(w, "api calls:     %d\n", result.apiCalls)
+	if evaluations > 0 {
+		fmt.Fprintf(w, "api calls/eval: %.2f\n", float64(result.apiCalls)/float64(evaluations))
+	}
+}
+
+// percentile returns the p-th percentile of the sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/pulumitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, time.Duration(0), percentile(nil, 0.5))
+	assert.Equal(t, 50*time.Millisecond, percentile(latencies, 0.50))
+	assert.Equal(t, 99*time.Millisecond, percentile(latencies, 0.99))
+	assert.Equal(t, 100*time.Millisecond, percentile(latencies, 1))
+	assert.Equal(t, time.Millisecond, percentile(latencies, 0))
+}
+
+func TestBenchPath(t *testing.T) {
+	server := pulumitest.NewServer(map[string]interface{}{
+		"DEBUG_MODE": true,
+		"configs":    map[string]interface{}{"MAX_RETRIES": 3},
+	})
+	defer server.Close()
+	newProvider := func(httpClient *http.Client, opts ...pulumi.ProviderOption) (*pulumi.PulumiESCProvider, error) {
+		return pulumi.NewPulumiESCProvider("test-org", "test-project", "test-env", "token", append(opts, pulumi.WithHTTPClient(httpClient))...)
+	}
+	config := benchConfig{
+		keys:        []string{"DEBUG_MODE"},
+		flagType:    pulumi.FlagType_Bool,
+		qps:         200,
+		duration:    200 * time.Millisecond,
+		concurrency: 4,
+		cacheTTL:    time.Minute,
+	}
+
+	results := map[string]benchResult{}
+	for _, path := range benchPaths {
+		result, err := benchPath(newProvider, server.Client().Transport, path, config)
+		require.NoError(t, err, path)
+		assert.NotEmpty(t, result.latencies, path)
+		assert.Empty(t, result.errors, path)
+		results[path] = result
+	}
+	assert.Positive(t, results["cold"].apiCalls)
+	assert.Zero(t, results["cached"].apiCalls, "the cached path must be served from the warmed up cache")
+	assert.Positive(t, results["bulk"].apiCalls)
+
+	_, err := benchPath(newProvider, server.Client().Transport, "cold", benchConfig{flagType: "object"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,135 @@
+// Package cli implements the pulumi-of command line tool, for working with flags stored in Pulumi ESC
+// environments using the same code paths as the OpenFeature Pulumi ESC provider.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+)
+
+type command struct {
+	description string
+	run         func(args []string) error
+}
+
+var commands = map[string]command{
+	"bench": {
+		description: "Exercise flag evaluations against an environment and report latencies",
+		run:         runBench,
+	},
+	"diff": {
+		description: "Compare the flag values of two environments",
+		run:         runDiff,
+	},
+	"export": {
+		description: "Export the resolved values of an environment as dotenv, JSON, YAML or OpenFeature bootstrap JSON",
+		run:         runExport,
+	},
+	"generate": {
+		description: "Generate typed Go accessors for the flags of an environment",
+		run:         runGenerate,
+	},
+	"get": {
+		description: "Evaluate a flag and print its resolution",
+		run:         runGet,
+	},
+	"list": {
+		description: "List the flags of an environment",
+		run:         runList,
+	},
+	"unused": {
+		description: "Report the flags of an environment which are not referenced in Go code",
+		run:         runUnused,
+	},
+	"validate": {
+		description: "Verify that flags exist in an environment and have the expected types",
+		run:         runValidate,
+	},
+	"watch": {
+		description: "Print flag value changes as JSON lines",
+		run:         runWatch,
+	},
+}
+
+// Main runs the command of the command line arguments and exits, using the given program name in messages
+func Main(name string) {
+	if len(os.Args) < 2 {
+		usage(name)
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage(name)
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "%s %s: %v\n", name, os.Args[1], err)
+		}
+		os.Exit(1)
+	}
+}
+
+func usage(name string) {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n", name)
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].description)
+	}
+}
+
+// environmentFlags are the flags identifying the Pulumi ESC environment, shared by all commands
+type environmentFlags struct {
+	org        string
+	project    string
+	env        string
+	backendUrl string
+	rootPath   string
+}
+
+func (f *environmentFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.org, "org", os.Getenv("PULUMI_ORG"), "Pulumi organization (defaults to $PULUMI_ORG)")
+	fs.StringVar(&f.project, "project", "", "Pulumi ESC project")
+	fs.StringVar(&f.env, "env", "", "Pulumi ESC environment")
+	fs.StringVar(&f.backendUrl, "backend-url", "", "custom Pulumi ESC backend URL")
+	fs.StringVar(&f.rootPath, "root-path", "", "property path of the flags in the environment, e.g. values.flags")
+}
+
+// newProvider creates a provider for the environment with the given options. The access token is read
+// from the PULUMI_ACCESS_TOKEN environment variable.
+func (f *environmentFlags) newProvider(httpClient *http.Client, opts ...pulumi.ProviderOption) (*pulumi.PulumiESCProvider, error) {
+	if f.org == "" || f.project == "" || f.env == "" {
+		return nil, errors.New("-org, -project and -env are required")
+	}
+	accessToken := os.Getenv("PULUMI_ACCESS_TOKEN")
+	if accessToken == "" {
+		return nil, errors.New("PULUMI_ACCESS_TOKEN env variable can not be empty")
+	}
+	if f.backendUrl != "" {
+		backendUrl, err := url.Parse(f.backendUrl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backend url: %w", err)
+		}
+		opts = append(opts, pulumi.WithCustomBackendUrl(*backendUrl))
+	}
+	if f.rootPath != "" {
+		opts = append(opts, pulumi.WithRootPath(f.rootPath))
+	}
+	if httpClient != nil {
+		opts = append(opts, pulumi.WithHTTPClient(httpClient))
+	}
+	return pulumi.NewPulumiESCProvider(f.org, f.project, f.env, accessToken, opts...)
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+)
+
+// valueDiff is a flag whose value differs between two environments
+type valueDiff struct {
+	Key string `json:"key"`
+	// Change is "removed" for flags only in the first environment, "added" for flags only in the second
+	// environment and "changed" for flags with different values
+	Change string      `json:"change"`
+	From   interface{} `json:"from,omitempty"`
+	To     interface{} `json:"to,omitempty"`
+}
+
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	var envFlags environmentFlags
+	envFlags.register(fs)
+	jsonOutput := fs.Bool("json", false, "print the differences as JSON")
+	showSecrets := fs.Bool("show-secrets", false, "compare and print the values of secrets instead of a redacted placeholder")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: pulumi-of diff [flags] <project>/<env> <project>/<env>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("expected two environments as <project>/<env>")
+	}
+	var opts []pulumi.ProviderOption
+	if !*showSecrets {
+		// Denied secrets are redacted from the exported values
+		opts = append(opts, pulumi.WithDenySecrets())
+	}
+
+	ctx := context.Background()
+	values := make([]map[string]interface{}, 2)
+	for i, name := range fs.Args() {
+		var err error
+		if values[i], err = readEnvironmentValues(ctx, envFlags, name, opts); err != nil {
+			return err
+		}
+	}
+	return printDiff(os.Stdout, fs.Arg(0), fs.Arg(1), diffValues(values[0], values[1]), *jsonOutput)
+}
+
+// readEnvironmentValues returns the resolved values of the environment named <project>/<env>
+func readEnvironmentValues(ctx context.Context, envFlags environmentFlags, name string, opts []pulumi.ProviderOption) (map[string]interface{}, error) {
+	project, env, ok := strings.Cut(name, "/")
+	if !ok || project == "" || env == "" {
+		return nil, fmt.Errorf("invalid environment %q, expected <project>/<env>", name)
+	}
+	envFlags.project, envFlags.env = project, env
+	provider, err := envFlags.newProvider(nil, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	defer provider.Shutdown()
+	values, err := provider.ExportSnapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return values, nil
+}
+
+// diffValues returns the flags whose values differ between the two sets of values, sorted by key.
+// Objects are compared by their nested values, so only the differing nested values are reported.
+func diffValues(from, to map[string]interface{}) []valueDiff {
+	fromValues, toValues := leafValues(from), leafValues(to)
+	diffs := []valueDiff{}
+	for key, fromValue := range fromValues {
+		toValue, ok := toValues[key]
+		switch {
+		case !ok:
+			diffs = append(diffs, valueDiff{Key: key, Change: "removed", From: fromValue})
+		case !reflect.DeepEqual(fromValue, toValue):
+			diffs = append(diffs, valueDiff{Key: key, Change: "changed", From: fromValue, To: toValue})
+		}
+	}
+	for key, toValue := range toValues {
+		if _, ok := fromValues[key]; !ok {
+			diffs = append(diffs, valueDiff{Key: key, Change: "added", To: toValue})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Key < diffs[j].Key
+	})
+	return diffs
+}
+
+// leafValues returns the values which are not objects keyed by their flag key
+func leafValues(values map[string]interface{}) map[string]interface{} {
+	leaves := pulumi.FlattenValues(values)
+	for key, value := range leaves {
+		if _, ok := value.(map[string]interface{}); ok {
+			delete(leaves, key)
+		}
+	}
+	return leaves
+}
+
+// printDiff prints the differences in a unified diff like format, or as JSON
+func printDiff(w io.Writer, fromName, toName string, diffs []valueDiff, jsonOutput bool) error {
+	if jsonOutput {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diffs)
+	}
+	if len(diffs) == 0 {
+		_, err := fmt.Fprintf(w, "%s and %s have the same values\n", fromName, toName)
+		return err
+	}
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", fromName, toName)
+	for _, diff := range diffs {
+		switch diff.Change {
+		case "removed":
+			fmt.Fprintf(w, "- %s: %s\n", diff.Key, formatValue(diff.From))
+		case "added":
+			fmt.Fprintf(w, "+ %s: %s\n", diff.Key, formatValue(diff.To))
+		default:
+			fmt.Fprintf(w, "~ %s: %s -> %s\n", diff.Key, formatValue(diff.From), formatValue(diff.To))
+		}
+	}
+	return nil
+}
+
+// formatValue returns the JSON representation of a value, so strings are distinguishable from other types
+func formatValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
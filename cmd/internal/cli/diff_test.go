@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffValues(t *testing.T) {
+	staging := map[string]interface{}{
+		"DEBUG_MODE": true,
+		"THEME":      "dark",
+		"REGIONS":    []interface{}{"eu"},
+		"configs":    map[string]interface{}{"MAX_RETRIES": 5.0, "BETA": true},
+	}
+	production := map[string]interface{}{
+		"DEBUG_MODE": false,
+		"THEME":      "dark",
+		"REGIONS":    []interface{}{"eu", "us"},
+		"configs":    map[string]interface{}{"MAX_RETRIES": 3.0, "TIMEOUT": 30.0},
+	}
+
+	assert.Empty(t, diffValues(staging, staging))
+	assert.Equal(t, []valueDiff{
+		{Key: "DEBUG_MODE", Change: "changed", From: true, To: false},
+		{Key: "REGIONS", Change: "changed", From: []interface{}{"eu"}, To: []interface{}{"eu", "us"}},
+		{Key: "configs.BETA", Change: "removed", From: true},
+		{Key: "configs.MAX_RETRIES", Change: "changed", From: 5.0, To: 3.0},
+		{Key: "configs.TIMEOUT", Change: "added", To: 30.0},
+	}, diffValues(staging, production))
+}
+
+func TestPrintDiff(t *testing.T) {
+	diffs := []valueDiff{
+		{Key: "THEME", Change: "changed", From: "dark", To: "light"},
+		{Key: "configs.BETA", Change: "removed", From: true},
+		{Key: "configs.TIMEOUT", Change: "added", To: 30.0},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, printDiff(&out, "app/staging", "app/prod", diffs, false))
+	assert.Equal(t, "--- app/staging\n+++ app/prod\n"+
+		"~ THEME: \"dark\" -> \"light\"\n"+
+		"- configs.BETA: true\n"+
+		"+ configs.TIMEOUT: 30\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, printDiff(&out, "app/staging", "app/prod", nil, false))
+	assert.Equal(t, "app/staging and app/prod have the same values\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, printDiff(&out, "app/staging", "app/prod", diffs[:1], true))
+	assert.JSONEq(t, `[{"key": "THEME", "change": "changed", "from": "dark", "to": "light"}]`, out.String())
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+)
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	var envFlags environmentFlags
+	envFlags.register(fs)
+	format := fs.String("format", "dotenv", "format of the export: dotenv, json, yaml or bootstrap")
+	prefix := fs.String("prefix", "", "prefix of the dotenv variables, e.g. APP_")
+	excludeSecrets := fs.Bool("exclude-secrets", false, "omit secret values from the dotenv export")
+	output := fs.String("o", "", "path of the exported file (defaults to stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	provider, err := envFlags.newProvider(nil)
+	if err != nil {
+		return err
+	}
+	defer provider.Shutdown()
+	exported, err := exportFlags(context.Background(), provider, *format, pulumi.DotenvOptions{Prefix: *prefix, ExcludeSecrets: *excludeSecrets})
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(exported)
+		return err
+	}
+	if err := os.WriteFile(*output, exported, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *output, err)
+	}
+	return nil
+}
+
+// exportFlags returns the resolved values of the environment in the given format
+func exportFlags(ctx context.Context, provider *pulumi.PulumiESCProvider, format string, dotenvOpts pulumi.DotenvOptions) ([]byte, error) {
+	var exported []byte
+	var err error
+	switch format {
+	case "dotenv":
+		exported, err = provider.ExportDotenv(ctx, dotenvOpts)
+	case "json", "yaml", "bootstrap":
+		exported, err = provider.ExportSnapshotEncoded(ctx, pulumi.SnapshotFormat(format))
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to export flags: %w", err)
+	}
+	return exported, nil
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportFlags(t *testing.T) {
+	provider, err := pulumi.NewStaticProvider(map[string]interface{}{
+		"DEBUG_MODE": true,
+		"configs":    map[string]interface{}{"THEME": "dark"},
+	})
+	require.NoError(t, err)
+	defer provider.Shutdown()
+	ctx := context.Background()
+
+	exported, err := exportFlags(ctx, provider, "dotenv", pulumi.DotenvOptions{Prefix: "APP_"})
+	require.NoError(t, err)
+	assert.Equal(t, "APP_CONFIGS_THEME=\"dark\"\nAPP_DEBUG_MODE=true\n", string(exported))
+
+	exported, err = exportFlags(ctx, provider, "json", pulumi.DotenvOptions{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"DEBUG_MODE": true, "configs": {"THEME": "dark"}}`, string(exported))
+
+	exported, err = exportFlags(ctx, provider, "bootstrap", pulumi.DotenvOptions{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"DEBUG_MODE": {"variants": {"value": true}, "defaultVariant": "value", "disabled": false},
+		"configs": {"variants": {"value": {"THEME": "dark"}}, "defaultVariant": "value", "disabled": false},
+		"configs.THEME": {"variants": {"value": "dark"}, "defaultVariant": "value", "disabled": false}
+	}`, string(exported))
+
+	_, err = exportFlags(ctx, provider, "toml", pulumi.DotenvOptions{})
+	assert.EqualError(t, err, `unsupported export format "toml"`)
+}
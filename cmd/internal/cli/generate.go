@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"strings"
+	"text/template"
+	"unicode"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+)
+
+// generatedAccessor is a typed accessor generated for a flag
+type generatedAccessor struct {
+	Key    string
+	Name   string
+	GoType string
+	Method string
+}
+
+// accessorMethods are the Go type and openfeature.IClient method of the flag types with generated accessors
+var accessorMethods = map[pulumi.FlagType][2]string{
+	pulumi.FlagType_Bool:    {"bool", "Boolean"},
+	pulumi.FlagType_String:  {"string", "String"},
+	pulumi.FlagType_Integer: {"int64", "Int"},
+	pulumi.FlagType_Float:   {"float64", "Float"},
+}
+
+var accessorsTemplate = template.Must(template.New("accessors").Parse(`// Code generated by pulumi-of generate; DO NOT EDIT.
+
+// Package {{ .Package }} provides typed accessors of the flags of the {{ .Environment }} Pulumi ESC environment.
+package {{ .Package }}
+
+import (
+	"context"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// Keys of the flags
+const (
+{{- range .Accessors }}
+	Key{{ .Name }} = {{ printf "%q" .Key }}
+{{- end }}
+)
+{{ range .Accessors }}
+// {{ .Name }} evaluates the {{ .Key }} flag
+func {{ .Name }}(ctx context.Context, client openfeature.IClient, defaultValue {{ .GoType }}, evalCtx openfeature.EvaluationContext) {{ .GoType }} {
+	return client.{{ .Method }}(ctx, Key{{ .Name }}, defaultValue, evalCtx)
+}
+{{ end }}`))
+
+func runGenerate(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	var envFlags environmentFlags
+	envFlags.register(fs)
+	packageName := fs.String("package", "flags", "package of the generated file")
+	output := fs.String("o", "", "path of the generated file (defaults to stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !token.IsIdentifier(*packageName) {
+		return fmt.Errorf("invalid package name %q", *packageName)
+	}
+	provider, err := envFlags.newProvider(nil)
+	if err != nil {
+		return err
+	}
+	defer provider.Shutdown()
+	flags, err := provider.ListFlags(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to list flags: %w", err)
+	}
+	source, err := generateAccessors(*packageName, envFlags.project+"/"+envFlags.env, flags)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(source)
+		return err
+	}
+	if err := os.WriteFile(*output, source, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *output, err)
+	}
+	return nil
+}
+
+// generateAccessors returns the formatted source of a Go file declaring a key constant and a typed accessor
+// for every boolean, string, integer and float flag. Objects are skipped, their nested values have accessors.
+func generateAccessors(packageName, environment string, flags []pulumi.FlagInfo) ([]byte, error) {
+	var accessors []generatedAccessor
+	keys := map[string]string{}
+	for _, info := range flags {
+		method, ok := accessorMethods[info.Type]
+		if !ok {
+			continue
+		}
+		name, err := accessorName(info.Key)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := keys[name]; ok {
+			return nil, fmt.Errorf("the accessors of %s and %s have the same name %s", key, info.Key, name)
+		}
+		keys[name] = info.Key
+		accessors = append(accessors, generatedAccessor{Key: info.Key, Name: name, GoType: method[0], Method: method[1]})
+	}
+	if len(accessors) == 0 {
+		return nil, errors.New("no flags to generate accessors for")
+	}
+
+	var buf bytes.Buffer
+	err := accessorsTemplate.Execute(&buf, map[string]interface{}{
+		"Package":     packageName,
+		"Environment": environment,
+		"Accessors":   accessors,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate accessors: %w", err)
+	}
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated accessors: %w", err)
+	}
+	return source, nil
+}
+
+// accessorName returns the exported Go identifier of a flag key, e.g. ConfigsDebugMode for configs.DEBUG_MODE
+func accessorName(key string) (string, error) {
+	var name strings.Builder
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		runes := []rune(strings.ToLower(word))
+		// camelCase words, e.g. maxRetries, keep their inner capitals
+		if strings.ToUpper(word) != word {
+			runes = []rune(word)
+		}
+		runes[0] = unicode.ToUpper(runes[0])
+		name.WriteString(string(runes))
+	}
+	if name.Len() == 0 {
+		return "", fmt.Errorf("can not generate an accessor name for %q", key)
+	}
+	if first := []rune(name.String())[0]; !unicode.IsLetter(first) {
+		return "Flag" + name.String(), nil
+	}
+	return name.String(), nil
+}
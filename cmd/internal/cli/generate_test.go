@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAccessors(t *testing.T) {
+	source, err := generateAccessors("flags", "app/prod", []pulumi.FlagInfo{
+		{Key: "DEBUG_MODE", Type: pulumi.FlagType_Bool},
+		{Key: "configs", Type: pulumi.FlagType_Object},
+		{Key: "configs.MAX_RETRIES", Type: pulumi.FlagType_Integer},
+		{Key: "configs.RATIO", Type: pulumi.FlagType_Float},
+		{Key: "theme-name", Type: pulumi.FlagType_String, Secret: true},
+	})
+	assert.NoError(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "flags_gen.go", source, parser.AllErrors)
+	assert.NoError(t, err, "the generated source must be valid Go")
+
+	code := string(source)
+	assert.Contains(t, code, "// Code generated by pulumi-of generate; DO NOT EDIT.")
+	assert.Contains(t, code, "package flags")
+	assert.Contains(t, code, "KeyDebugMode         = \"DEBUG_MODE\"")
+	assert.Contains(t, code, "func DebugMode(ctx context.Context, client openfeature.IClient, defaultValue bool, evalCtx openfeature.EvaluationContext) bool {\n\treturn client.Boolean(ctx, KeyDebugMode, defaultValue, evalCtx)")
+	assert.Contains(t, code, "func ConfigsMaxRetries(ctx context.Context, client openfeature.IClient, defaultValue int64, evalCtx openfeature.EvaluationContext) int64 {\n\treturn client.Int(")
+	assert.Contains(t, code, "func ConfigsRatio(ctx context.Context, client openfeature.IClient, defaultValue float64, evalCtx openfeature.EvaluationContext) float64 {\n\treturn client.Float(")
+	assert.Contains(t, code, "func ThemeName(ctx context.Context, client openfeature.IClient, defaultValue string, evalCtx openfeature.EvaluationContext) string {\n\treturn client.String(")
+	assert.NotContains(t, code, "func Configs(", "objects must be skipped")
+}
+
+func TestGenerateAccessors_errors(t *testing.T) {
+	_, err := generateAccessors("flags", "app/prod", []pulumi.FlagInfo{
+		{Key: "DEBUG_MODE", Type: pulumi.FlagType_Bool},
+		{Key: "debug.mode", Type: pulumi.FlagType_Bool},
+	})
+	assert.ErrorContains(t, err, "the accessors of DEBUG_MODE and debug.mode have the same name DebugMode")
+
+	_, err = generateAccessors("flags", "app/prod", []pulumi.FlagInfo{{Key: "configs", Type: pulumi.FlagType_Object}})
+	assert.ErrorContains(t, err, "no flags")
+}
+
+func TestAccessorName(t *testing.T) {
+	for key, expected := range map[string]string{
+		"DEBUG_MODE":          "DebugMode",
+		"configs.MAX_RETRIES": "ConfigsMaxRetries",
+		"maxRetries":          "MaxRetries",
+		"feature-x.enabled":   "FeatureXEnabled",
+		"2fa":                 "Flag2fa",
+	} {
+		name, err := accessorName(key)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, name, key)
+	}
+	_, err := accessorName("..")
+	assert.Error(t, err)
+}
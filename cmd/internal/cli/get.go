@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// contextFlag collects the evaluation context attributes given as key=value
+type contextFlag openfeature.FlattenedContext
+
+func (c contextFlag) String() string {
+	attributes := make([]string, 0, len(c))
+	for key, value := range c {
+		attributes = append(attributes, fmt.Sprintf("%s=%v", key, value))
+	}
+	return strings.Join(attributes, ",")
+}
+
+func (c contextFlag) Set(attribute string) error {
+	key, value, ok := strings.Cut(attribute, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("invalid context attribute %q, expected key=value", attribute)
+	}
+	c[key] = value
+	return nil
+}
+
+// evaluation is the printed resolution of a flag
+type evaluation struct {
+	Key          string                   `json:"key"`
+	Type         pulumi.FlagType          `json:"type"`
+	Value        interface{}              `json:"value"`
+	Reason       openfeature.Reason       `json:"reason"`
+	Variant      string                   `json:"variant,omitempty"`
+	ErrorCode    openfeature.ErrorCode    `json:"errorCode,omitempty"`
+	ErrorMessage string                   `json:"errorMessage,omitempty"`
+	Metadata     openfeature.FlagMetadata `json:"metadata,omitempty"`
+}
+
+func runGet(args []string) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	var envFlags environmentFlags
+	envFlags.register(fs)
+	flagType := fs.String("type", "", "type of the flag (bool, string, int64, float64, []string, map[string]string), inferred from its value if empty")
+	showSecrets := fs.Bool("show-secrets", false, "print the values of secrets instead of a redacted placeholder")
+	evalCtx := contextFlag{}
+	fs.Var(evalCtx, "context", "evaluation context attribute as key=value, can be repeated")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected exactly one flag key")
+	}
+	provider, err := envFlags.newProvider(nil)
+	if err != nil {
+		return err
+	}
+	defer provider.Shutdown()
+
+	result, err := getFlag(context.Background(), provider, fs.Arg(0), pulumi.FlagType(*flagType), openfeature.FlattenedContext(evalCtx), *showSecrets)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return err
+	}
+	if result.ErrorCode != "" {
+		return fmt.Errorf("evaluation failed: %s", result.ErrorCode)
+	}
+	return nil
+}
+
+// getFlag evaluates the flag using the provider method of its type. If the type is empty, it is inferred
+// from the value listed in the environment. Secret values are masked unless showSecrets is set.
+func getFlag(ctx context.Context, provider *pulumi.PulumiESCProvider, key string, flagType pulumi.FlagType, evalCtx openfeature.FlattenedContext, showSecrets bool) (*evaluation, error) {
+	if flagType == "" {
+		inferred, err := inferType(ctx, provider, key)
+		if err != nil {
+			return nil, err
+		}
+		flagType = inferred
+	}
+
+	var value interface{}
+	var resolution openfeature.ProviderResolutionDetail
+	switch flagType {
+	case pulumi.FlagType_Bool:
+		details := provider.BooleanEvaluation(ctx, key, false, evalCtx)
+		value, resolution = details.Value, details.ProviderResolutionDetail
+	case pulumi.FlagType_String:
+		details := provider.StringEvaluation(ctx, key, "", evalCtx)
+		value, resolution = details.Value, details.ProviderResolutionDetail
+	case pulumi.FlagType_Integer:
+		details := provider.IntEvaluation(ctx, key, 0, evalCtx)
+		value, resolution = details.Value, details.ProviderResolutionDetail
+	case pulumi.FlagType_Float:
+		details := provider.FloatEvaluation(ctx, key, 0, evalCtx)
+		value, resolution = details.Value, details.ProviderResolutionDetail
+	case pulumi.FlagType_StringSlice:
+		details := provider.StringSliceEvaluation(ctx, key, nil, evalCtx)
+		value, resolution = details.Value, details.ProviderResolutionDetail
+	case pulumi.FlagType_StringMap:
+		details := provider.StringMapEvaluation(ctx, key, nil, evalCtx)
+		value, resolution = details.Value, details.ProviderResolutionDetail
+	default:
+		return nil, fmt.Errorf("unsupported flag type %q", flagType)
+	}
+
+	details := openfeature.InterfaceEvaluationDetails{
+		Value: value,
+		EvaluationDetails: openfeature.EvaluationDetails{
+			FlagKey:          key,
+			ResolutionDetail: resolution.ResolutionDetail(),
+		},
+	}
+	if !showSecrets {
+		details = pulumi.MaskSecrets(details)
+	}
+	return &evaluation{
+		Key:          key,
+		Type:         flagType,
+		Value:        details.Value,
+		Reason:       details.Reason,
+		Variant:      details.Variant,
+		ErrorCode:    details.ErrorCode,
+		ErrorMessage: details.ErrorMessage,
+		Metadata:     details.FlagMetadata,
+	}, nil
+}
+
+// inferType returns the type of the flag listed in the environment. Flags missing from the environment
+// are evaluated as strings, so that the evaluation reports them as not found.
+func inferType(ctx context.Context, provider *pulumi.PulumiESCProvider, key string) (pulumi.FlagType, error) {
+	flags, err := provider.ListFlags(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to infer the type of %s: %w", key, err)
+	}
+	for _, info := range flags {
+		if info.Key != key {
+			continue
+		}
+		if info.Type == pulumi.FlagType_Object {
+			return "", fmt.Errorf("the type of %s can not be inferred, use -type", key)
+		}
+		return info.Type, nil
+	}
+	return pulumi.FlagType_String, nil
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFlag(t *testing.T) {
+	provider, err := pulumi.NewStaticProvider(map[string]interface{}{
+		"DEBUG_MODE": true,
+		"REGIONS":    []interface{}{"eu", "us"},
+		"configs":    map[string]interface{}{"MAX_RETRIES": 3},
+	})
+	require.NoError(t, err)
+	defer provider.Shutdown()
+	ctx := context.Background()
+
+	result, err := getFlag(ctx, provider, "DEBUG_MODE", "", nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, pulumi.FlagType_Bool, result.Type, "the type must be inferred")
+	assert.Equal(t, true, result.Value)
+	assert.Equal(t, openfeature.StaticReason, result.Reason)
+	assert.Empty(t, result.ErrorCode)
+
+	result, err = getFlag(ctx, provider, "configs.MAX_RETRIES", "", nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), result.Value)
+
+	result, err = getFlag(ctx, provider, "REGIONS", pulumi.FlagType_StringSlice, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"eu", "us"}, result.Value)
+
+	result, err = getFlag(ctx, provider, "DEBUG_MODE", pulumi.FlagType_Integer, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, openfeature.TypeMismatchCode, result.ErrorCode)
+
+	result, err = getFlag(ctx, provider, "missing", "", nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, openfeature.FlagNotFoundCode, result.ErrorCode)
+
+	_, err = getFlag(ctx, provider, "configs", "", nil, false)
+	assert.ErrorContains(t, err, "use -type")
+
+	_, err = getFlag(ctx, provider, "DEBUG_MODE", "duration", nil, false)
+	assert.ErrorContains(t, err, "unsupported flag type")
+}
+
+func TestContextFlag(t *testing.T) {
+	evalCtx := contextFlag{}
+	assert.NoError(t, evalCtx.Set("targetingKey=user-1"))
+	assert.NoError(t, evalCtx.Set("plan=pro=plus"))
+	assert.Equal(t, contextFlag{"targetingKey": "user-1", "plan": "pro=plus"}, evalCtx)
+	assert.Error(t, evalCtx.Set("plan"))
+	assert.Error(t, evalCtx.Set("=pro"))
+}
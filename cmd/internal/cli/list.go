@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+)
+
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	var envFlags environmentFlags
+	envFlags.register(fs)
+	jsonOutput := fs.Bool("json", false, "print the flags as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	provider, err := envFlags.newProvider(nil)
+	if err != nil {
+		return err
+	}
+	defer provider.Shutdown()
+	return listFlags(context.Background(), os.Stdout, provider, *jsonOutput)
+}
+
+// listFlags prints the key, type and secrecy of every flag of the environment
+func listFlags(ctx context.Context, w io.Writer, provider *pulumi.PulumiESCProvider, jsonOutput bool) error {
+	flags, err := provider.ListFlags(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list flags: %w", err)
+	}
+	if jsonOutput {
+		if flags == nil {
+			flags = []pulumi.FlagInfo{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(flags)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tTYPE\tSECRET")
+	for _, info := range flags {
+		fmt.Fprintf(tw, "%s\t%s\t%t\n", info.Key, info.Type, info.Secret)
+	}
+	return tw.Flush()
+}
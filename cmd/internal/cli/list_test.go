@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListFlags(t *testing.T) {
+	provider, err := pulumi.NewStaticProvider(map[string]interface{}{
+		"DEBUG_MODE": true,
+		"configs":    map[string]interface{}{"MAX_RETRIES": 3},
+	})
+	require.NoError(t, err)
+	defer provider.Shutdown()
+
+	var out bytes.Buffer
+	assert.NoError(t, listFlags(context.Background(), &out, provider, false))
+	assert.Equal(t, "KEY                  TYPE    SECRET\n"+
+		"DEBUG_MODE           bool    false\n"+
+		"configs              object  false\n"+
+		"configs.MAX_RETRIES  int64   false\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, listFlags(context.Background(), &out, provider, true))
+	assert.JSONEq(t, `[
+		{"key": "DEBUG_MODE", "type": "bool", "secret": false},
+		{"key": "configs", "type": "object", "secret": false},
+		{"key": "configs.MAX_RETRIES", "type": "int64", "secret": false}
+	]`, out.String())
+}
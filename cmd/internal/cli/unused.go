@@ -0,0 +1,291 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+)
+
+// evaluationMethods are the methods of the OpenFeature client and of the provider taking a flag key as
+// second argument, with their minimum number of arguments
+var evaluationMethods = map[string]int{
+	"Boolean":               4,
+	"BooleanValue":          4,
+	"BooleanValueDetails":   4,
+	"String":                4,
+	"StringValue":           4,
+	"StringValueDetails":    4,
+	"Int":                   4,
+	"IntValue":              4,
+	"IntValueDetails":       4,
+	"Float":                 4,
+	"FloatValue":            4,
+	"FloatValueDetails":     4,
+	"Object":                4,
+	"ObjectValue":           4,
+	"ObjectValueDetails":    4,
+	"BooleanEvaluation":     4,
+	"StringEvaluation":      4,
+	"IntEvaluation":         4,
+	"FloatEvaluation":       4,
+	"ObjectEvaluation":      4,
+	"StringSliceEvaluation": 4,
+	"StringMapEvaluation":   4,
+	"TimeEvaluation":        4,
+	"HasFlag":               2,
+}
+
+// flagReferences are the flag keys referenced in Go source files
+type flagReferences struct {
+	keys map[string]bool
+	// dynamic are the positions of evaluations whose flag key is not a constant
+	dynamic []token.Position
+}
+
+// unusedReport is the printed result of the unused command
+type unusedReport struct {
+	Unused  []pulumi.FlagInfo `json:"unused"`
+	Dynamic []string          `json:"dynamicReferences,omitempty"`
+}
+
+func runUnused(args []string) error {
+	fs := flag.NewFlagSet("unused", flag.ContinueOnError)
+	var envFlags environmentFlags
+	envFlags.register(fs)
+	jsonOutput := fs.Bool("json", false, "print the unused flags as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	dirs := []string{"."}
+	if fs.NArg() > 0 {
+		dirs = dirs[:0]
+		// Directories are walked recursively, so package patterns like ./... are accepted too
+		for _, dir := range fs.Args() {
+			if dir = strings.TrimSuffix(dir, "/..."); dir == "" || dir == "..." {
+				dir = "."
+			}
+			dirs = append(dirs, dir)
+		}
+	}
+	references, err := findFlagReferences(dirs)
+	if err != nil {
+		return err
+	}
+	provider, err := envFlags.newProvider(nil)
+	if err != nil {
+		return err
+	}
+	defer provider.Shutdown()
+	flags, err := provider.ListFlags(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to list flags: %w", err)
+	}
+	return printUnused(os.Stdout, unusedFlags(flags, references), references, *jsonOutput)
+}
+
+// findFlagReferences parses the Go files in the directories and collects the flag keys passed to
+// evaluations, as string literals or constants, and to the accessors generated by the generate command
+func findFlagReferences(dirs []string) (*flagReferences, error) {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				name := entry.Name()
+				if path != dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(path, ".go") {
+				return nil
+			}
+			file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			files = append(files, file)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	constants := stringConstants(files)
+	references := &flagReferences{keys: map[string]bool{}}
+	// Calls of generated accessors reference the flag evaluated by the accessor
+	accessors := map[string]string{}
+	for _, file := range files {
+		if !ast.IsGenerated(file) {
+			continue
+		}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Body != nil {
+				ast.Inspect(fn.Body, func(node ast.Node) bool {
+					if key, ok := evaluatedKey(node, constants); ok && key != "" {
+						accessors[fn.Name.Name] = key
+					}
+					return true
+				})
+			}
+		}
+	}
+	for _, file := range files {
+		if ast.IsGenerated(file) {
+			continue
+		}
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if key, ok := accessors[calledName(call)]; ok {
+				references.keys[key] = true
+				return true
+			}
+			if key, ok := evaluatedKey(call, constants); ok {
+				if key == "" {
+					references.dynamic = append(references.dynamic, fset.Position(call.Pos()))
+				} else {
+					references.keys[key] = true
+				}
+			}
+			return true
+		})
+	}
+	return references, nil
+}
+
+// stringConstants returns the values of the string constants declared in the files by name
+func stringConstants(files []*ast.File) map[string]string {
+	constants := map[string]string{}
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			decl, ok := node.(*ast.GenDecl)
+			if !ok || decl.Tok != token.CONST {
+				return true
+			}
+			for _, spec := range decl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				for i, name := range valueSpec.Names {
+					if i >= len(valueSpec.Values) {
+						break
+					}
+					if lit, ok := valueSpec.Values[i].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+						if value, err := strconv.Unquote(lit.Value); err == nil {
+							constants[name.Name] = value
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	return constants
+}
+
+// evaluatedKey returns the flag key of a call of an evaluation method and whether the node is such a call.
+// The key is empty if it is not a string literal or a known constant.
+func evaluatedKey(node ast.Node, constants map[string]string) (string, bool) {
+	call, ok := node.(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	minArgs, ok := evaluationMethods[selector.Sel.Name]
+	if !ok || len(call.Args) < minArgs {
+		return "", false
+	}
+	switch arg := call.Args[1].(type) {
+	case *ast.BasicLit:
+		if arg.Kind == token.STRING {
+			key, err := strconv.Unquote(arg.Value)
+			return key, err == nil
+		}
+	case *ast.Ident:
+		return constants[arg.Name], true
+	case *ast.SelectorExpr:
+		return constants[arg.Sel.Name], true
+	}
+	return "", true
+}
+
+// calledName returns the name of the called function or method
+func calledName(call *ast.CallExpr) string {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return fun.Name
+	case *ast.SelectorExpr:
+		return fun.Sel.Name
+	}
+	return ""
+}
+
+// unusedFlags returns the flags which are not referenced, nested in a referenced object or the parent
+// object of a referenced flag
+func unusedFlags(flags []pulumi.FlagInfo, references *flagReferences) []pulumi.FlagInfo {
+	unused := []pulumi.FlagInfo{}
+	for _, info := range flags {
+		if !referenced(info.Key, references.keys) {
+			unused = append(unused, info)
+		}
+	}
+	return unused
+}
+
+// referenced reports whether the flag, one of its parent objects or one of its nested values is referenced
+func referenced(key string, keys map[string]bool) bool {
+	for referencedKey := range keys {
+		if key == referencedKey || strings.HasPrefix(key, referencedKey+".") || strings.HasPrefix(referencedKey, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// printUnused prints the unused flags, and the evaluations whose flag key could not be determined
+func printUnused(w io.Writer, unused []pulumi.FlagInfo, references *flagReferences, jsonOutput bool) error {
+	report := unusedReport{Unused: unused}
+	for _, position := range references.dynamic {
+		report.Dynamic = append(report.Dynamic, position.String())
+	}
+	sort.Strings(report.Dynamic)
+	if jsonOutput {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	if len(unused) == 0 {
+		fmt.Fprintln(w, "no unused flags")
+	}
+	for _, info := range unused {
+		fmt.Fprintf(w, "%s (%s)\n", info.Key, info.Type)
+	}
+	if len(report.Dynamic) > 0 {
+		fmt.Fprintf(w, "\nthe flag keys of %d evaluations could not be determined, the flags they evaluate may be reported as unused:\n", len(report.Dynamic))
+		for _, position := range report.Dynamic {
+			fmt.Fprintf(w, "  %s\n", position)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const serviceSource = `package service
+
+import (
+	"context"
+
+	"example.com/service/flags"
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+const themeKey = "THEME"
+
+func handle(ctx context.Context, client *openfeature.Client, key string) {
+	client.Boolean(ctx, "DEBUG_MODE", false, openfeature.EvaluationContext{})
+	client.StringValue(ctx, themeKey, "dark", openfeature.EvaluationContext{})
+	flags.ConfigsMaxRetries(ctx, client, 3, openfeature.EvaluationContext{})
+	client.Float(ctx, key, 0, openfeature.EvaluationContext{})
+}
+`
+
+func TestFindFlagReferences(t *testing.T) {
+	dir := t.TempDir()
+	generated, err := generateAccessors("flags", "app/prod", []pulumi.FlagInfo{
+		{Key: "configs.MAX_RETRIES", Type: pulumi.FlagType_Integer},
+		{Key: "configs.TIMEOUT", Type: pulumi.FlagType_Integer},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "flags"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "flags", "flags_gen.go"), generated, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "service.go"), []byte(serviceSource), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "vendor"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vendor", "invalid.go"), []byte("not go"), 0o644))
+
+	references, err := findFlagReferences([]string{dir})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"DEBUG_MODE": true, "THEME": true, "configs.MAX_RETRIES": true}, references.keys,
+		"unused generated accessors must not be references")
+	require.Len(t, references.dynamic, 1)
+	assert.Equal(t, 16, references.dynamic[0].Line)
+
+	unused := unusedFlags([]pulumi.FlagInfo{
+		{Key: "DEBUG_MODE", Type: pulumi.FlagType_Bool},
+		{Key: "LEGACY_CHECKOUT", Type: pulumi.FlagType_Bool},
+		{Key: "THEME", Type: pulumi.FlagType_String},
+		{Key: "configs", Type: pulumi.FlagType_Object},
+		{Key: "configs.MAX_RETRIES", Type: pulumi.FlagType_Integer},
+		{Key: "configs.TIMEOUT", Type: pulumi.FlagType_Integer},
+	}, references)
+	assert.Equal(t, []pulumi.FlagInfo{
+		{Key: "LEGACY_CHECKOUT", Type: pulumi.FlagType_Bool},
+		{Key: "configs.TIMEOUT", Type: pulumi.FlagType_Integer},
+	}, unused)
+
+	var out bytes.Buffer
+	assert.NoError(t, printUnused(&out, unused, references, false))
+	assert.Contains(t, out.String(), "LEGACY_CHECKOUT (bool)\nconfigs.TIMEOUT (int64)\n")
+	assert.Contains(t, out.String(), "the flag keys of 1 evaluations could not be determined")
+}
+
+func TestFindFlagReferences_invalidSource(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.go"), []byte("not go"), 0o644))
+	_, err := findFlagReferences([]string{dir})
+	assert.ErrorContains(t, err, "failed to parse")
+}
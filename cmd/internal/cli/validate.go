@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+// validatedTypes are the flag types which can be validated
+var validatedTypes = map[pulumi.FlagType]bool{
+	pulumi.FlagType_Bool:        true,
+	pulumi.FlagType_String:      true,
+	pulumi.FlagType_Integer:     true,
+	pulumi.FlagType_Float:       true,
+	pulumi.FlagType_Object:      true,
+	pulumi.FlagType_StringSlice: true,
+	pulumi.FlagType_StringMap:   true,
+}
+
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	var envFlags environmentFlags
+	envFlags.register(fs)
+	file := fs.String("file", "", "JSON or YAML file mapping flag keys to their types")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var data []byte
+	if *file != "" {
+		var err error
+		if data, err = os.ReadFile(*file); err != nil {
+			return fmt.Errorf("failed to read %s: %w", *file, err)
+		}
+	}
+	requiredFlags, err := parseRequiredFlags(data, fs.Args())
+	if err != nil {
+		return err
+	}
+	if len(requiredFlags) == 0 {
+		return errors.New("no flags to validate, pass key=type arguments or -file")
+	}
+
+	// The provider verifies the required flags during its initialisation, like in services
+	provider, err := envFlags.newProvider(nil, pulumi.WithRequiredFlags(requiredFlags))
+	if err != nil {
+		return err
+	}
+	defer provider.Shutdown()
+	fmt.Fprintf(os.Stdout, "%d flags are valid\n", len(requiredFlags))
+	return nil
+}
+
+// parseRequiredFlags returns the flags of the JSON or YAML document, if any, and of the key=type arguments
+func parseRequiredFlags(data []byte, args []string) (map[string]pulumi.FlagType, error) {
+	requiredFlags := map[string]pulumi.FlagType{}
+	if len(data) > 0 {
+		if err := yaml.Unmarshal(data, &requiredFlags); err != nil {
+			return nil, fmt.Errorf("failed to decode flags: %w", err)
+		}
+	}
+	for _, arg := range args {
+		key, flagType, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid flag %q, expected key=type", arg)
+		}
+		requiredFlags[key] = pulumi.FlagType(flagType)
+	}
+	for key, flagType := range requiredFlags {
+		if !validatedTypes[flagType] {
+			return nil, fmt.Errorf("unsupported type %q of %s", flagType, key)
+		}
+	}
+	return requiredFlags, nil
+}
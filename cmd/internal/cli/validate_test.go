@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"testing"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRequiredFlags(t *testing.T) {
+	requiredFlags, err := parseRequiredFlags([]byte("DEBUG_MODE: bool\nconfigs.MAX_RETRIES: int64\n"), []string{"THEME=string", "DEBUG_MODE=string"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]pulumi.FlagType{
+		"DEBUG_MODE":          pulumi.FlagType_String,
+		"configs.MAX_RETRIES": pulumi.FlagType_Integer,
+		"THEME":               pulumi.FlagType_String,
+	}, requiredFlags, "arguments must override the file")
+
+	requiredFlags, err = parseRequiredFlags([]byte(`{"REGIONS": "[]string"}`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]pulumi.FlagType{"REGIONS": pulumi.FlagType_StringSlice}, requiredFlags)
+
+	_, err = parseRequiredFlags(nil, []string{"DEBUG_MODE"})
+	assert.ErrorContains(t, err, "expected key=type")
+
+	_, err = parseRequiredFlags(nil, []string{"DEBUG_MODE=boolean"})
+	assert.ErrorContains(t, err, `unsupported type "boolean"`)
+
+	_, err = parseRequiredFlags([]byte("- DEBUG_MODE"), nil)
+	assert.ErrorContains(t, err, "failed to decode flags")
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// flagChange is a JSON line printed for every changed flag
+type flagChange struct {
+	Time     time.Time   `json:"time"`
+	Key      string      `json:"key"`
+	Change   string      `json:"change"`
+	Old      interface{} `json:"old,omitempty"`
+	New      interface{} `json:"new,omitempty"`
+	Revision int32       `json:"revision,omitempty"`
+}
+
+// stateChange is a JSON line printed for every provider state change, e.g. when the Pulumi ESC API
+// becomes unavailable
+type stateChange struct {
+	Time    time.Time             `json:"time"`
+	Event   openfeature.EventType `json:"event"`
+	Message string                `json:"message,omitempty"`
+}
+
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	var envFlags environmentFlags
+	envFlags.register(fs)
+	interval := fs.Duration("interval", 10*time.Second, "interval between two reads of the environment")
+	showSecrets := fs.Bool("show-secrets", false, "print the values of secrets instead of a redacted placeholder")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("-interval must be positive")
+	}
+	opts := []pulumi.ProviderOption{pulumi.WithPolling(*interval)}
+	if !*showSecrets {
+		// Denied secrets are redacted from the exported values
+		opts = append(opts, pulumi.WithDenySecrets())
+	}
+	provider, err := envFlags.newProvider(nil, opts...)
+	if err != nil {
+		return err
+	}
+	defer provider.Shutdown()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return watch(ctx, os.Stdout, provider, fs.Args())
+}
+
+// watch prints a JSON line for every change of the given flags, or of all the flags if none are given,
+// and for every provider state change, until the context is done. Changes are the ones detected by the
+// polling of the provider, whose objects are reported for the object and for each of its changed nested
+// values.
+func watch(ctx context.Context, w io.Writer, provider *pulumi.PulumiESCProvider, keys []string) error {
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-provider.EventChannel():
+			// Configuration changes are printed from the changes of the flags, which carry their values
+			if event.EventType == openfeature.ProviderConfigChange {
+				continue
+			}
+			if err := encoder.Encode(stateChange{Time: time.Now().UTC(), Event: event.EventType, Message: event.Message}); err != nil {
+				return err
+			}
+		case event := <-provider.Changes():
+			for _, flagChanged := range event.Changes {
+				if !watched(flagChanged.Key, keys) {
+					continue
+				}
+				change := flagChange{
+					Time:     event.ReadAt.UTC(),
+					Key:      flagChanged.Key,
+					Change:   string(flagChanged.Type),
+					Old:      flagChanged.OldValue,
+					New:      flagChanged.NewValue,
+					Revision: event.Revision,
+				}
+				if err := encoder.Encode(change); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// watched reports whether the flag is one of the keys or nested in one of them
+func watched(key string, keys []string) bool {
+	if len(keys) == 0 {
+		return true
+	}
+	for _, watchedKey := range keys {
+		if key == watchedKey || strings.HasPrefix(key, watchedKey+".") || strings.HasPrefix(key, watchedKey+"[") {
+			return true
+		}
+	}
+	return false
+}
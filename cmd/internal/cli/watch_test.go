@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/pulumitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	server := pulumitest.NewServer(map[string]interface{}{
+		"DEBUG_MODE": false,
+		"THEME":      "dark",
+		"configs":    map[string]interface{}{"MAX_RETRIES": 3},
+	})
+	defer server.Close()
+	provider, err := pulumi.NewPulumiESCProvider("test-org", "test-project", "test-env", "token",
+		pulumi.WithHTTPClient(server.Client()), pulumi.WithPolling(10*time.Millisecond))
+	require.NoError(t, err)
+	defer provider.Shutdown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader, writer := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- watch(ctx, writer, provider, []string{"DEBUG_MODE", "configs"})
+		writer.Close()
+	}()
+
+	// Changes are only printed for the watched flags
+	server.SetValue("THEME", "light")
+	server.SetValue("DEBUG_MODE", true)
+	server.SetValue("configs.TIMEOUT", 30)
+	server.DeleteValue("configs.MAX_RETRIES")
+
+	changes := map[string]flagChange{}
+	scanner := bufio.NewScanner(reader)
+	for len(changes) < 4 && scanner.Scan() {
+		var change flagChange
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &change))
+		changes[change.Key] = change
+	}
+	cancel()
+	go io.Copy(io.Discard, reader)
+	assert.NoError(t, <-done)
+
+	assert.Equal(t, "changed", changes["DEBUG_MODE"].Change)
+	assert.Equal(t, false, changes["DEBUG_MODE"].Old)
+	assert.Equal(t, true, changes["DEBUG_MODE"].New)
+	assert.Equal(t, "changed", changes["configs"].Change)
+	assert.Equal(t, "added", changes["configs.TIMEOUT"].Change)
+	assert.Equal(t, float64(30), changes["configs.TIMEOUT"].New)
+	assert.Equal(t, "removed", changes["configs.MAX_RETRIES"].Change)
+	assert.Equal(t, float64(3), changes["configs.MAX_RETRIES"].Old)
+	assert.NotContains(t, changes, "THEME")
+}
+
+func TestWatched(t *testing.T) {
+	assert.True(t, watched("DEBUG_MODE", nil))
+	assert.True(t, watched("configs.MAX_RETRIES", []string{"configs"}))
+	assert.True(t, watched(`configs["service.v2"]`, []string{"configs"}))
+	assert.False(t, watched("configs2", []string{"configs"}))
+}
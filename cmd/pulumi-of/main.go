@@ -0,0 +1,9 @@
+// pulumi-of is a command line tool for working with flags stored in Pulumi ESC environments
+// using the same code paths as the OpenFeature Pulumi ESC provider.
+package main
+
+import "github.com/bugcacher/open-feature-pulumi-esc-provider/cmd/internal/cli"
+
+func main() {
+	cli.Main("pulumi-of")
+}
@@ -0,0 +1,209 @@
+package pulumi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/internal/definition"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"gopkg.in/yaml.v3"
+)
+
+// maxDefinitionUpdates is the number of times the definition of an environment is read and updated when
+// the update conflicts with concurrent writes of other clients
+const maxDefinitionUpdates = 3
+
+// definitionUpdate updates the values of the definition of an environment at the path of a flag, and reports
+// whether they changed
+type definitionUpdate func(values *yaml.Node, path []string) (bool, error)
+
+// SetFlag sets the value of a flag in the definition of the environment of the provider, creating the flag
+// if it does not exist, e.g. to toggle flags from internal admin tools. The definition is updated using
+// the Pulumi ESC API, which creates a new revision of the environment, and the provider is refreshed so
+// evaluations serve the new value. The update only applies if the definition did not change since it was
+// read, and is retried on conflicts with concurrent writes of other clients, failing with
+// ErrDefinitionConflict if they keep conflicting. Flags nested in a value which is not an object, e.g. a
+// string or a function call such as fn::secret, can not be set.
+func (p *PulumiESCProvider) SetFlag(ctx context.Context, key string, value interface{}) error {
+	valueNode := &yaml.Node{}
+	if err := valueNode.Encode(value); err != nil {
+		return fmt.Errorf("failed to encode the value of flag %s: %w", key, err)
+	}
+	return p.updateDefinition(ctx, key, func(values *yaml.Node, path []string) (bool, error) {
+		return true, definition.Set(values, path, valueNode)
+	})
+}
+
+// DeleteFlag removes a flag from the definition of the environment of the provider, like SetFlag.
+// Deleting a flag which is not defined is a no-op.
+func (p *PulumiESCProvider) DeleteFlag(ctx context.Context, key string) error {
+	return p.updateDefinition(ctx, key, func(values *yaml.Node, path []string) (bool, error) {
+		return definition.Delete(values, path), nil
+	})
+}
+
+// CreateFlagIfMissing sets the flag to the default value, like SetFlag, unless it already exists in the
+// environment, e.g. to let services register the flags they depend on at startup or in migrations. It reports
+// whether the flag was created, and fails if the existing flag is not of the type of the default value.
+// Integers and floats are considered the same type. Flags defined by imported environments exist and are
+// not redefined.
+func (p *PulumiESCProvider) CreateFlagIfMissing(ctx context.Context, key string, defaultValue interface{}) (bool, error) {
+	defaultType, err := valueFlagType(defaultValue)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode the value of flag %s: %w", key, err)
+	}
+	_, rawValue, _, err := p.readProperty(ctx, key)
+	if err == nil {
+		if flagType := inferFlagType(rawValue); flagType != defaultType && !(isNumberType(flagType) && isNumberType(defaultType)) {
+			return false, withSentinel(fmt.Errorf("flag %s already exists with type %s instead of %s", key, flagType, defaultType), ErrTypeMismatch)
+		}
+		return false, nil
+	}
+	if !isKeyNotFound(err) {
+		return false, fmt.Errorf("failed to read flag %s: %w", key, classifyAPIError(err))
+	}
+
+	valueNode := &yaml.Node{}
+	if err := valueNode.Encode(defaultValue); err != nil {
+		return false, fmt.Errorf("failed to encode the value of flag %s: %w", key, err)
+	}
+	created := false
+	err = p.updateDefinition(ctx, key, func(values *yaml.Node, path []string) (bool, error) {
+		// The flag may have been created since it was read
+		created = definition.Lookup(values, path) == nil
+		if !created {
+			return false, nil
+		}
+		return true, definition.Set(values, path, valueNode)
+	})
+	return created, err
+}
+
+// valueFlagType returns the FlagType of a Go value, as it would be read from the environment
+func valueFlagType(value interface{}) (FlagType, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	var rawValue interface{}
+	if err := decodeJSON(data, &rawValue); err != nil {
+		return "", err
+	}
+	return inferFlagType(normaliseValue(rawValue)), nil
+}
+
+// isNumberType reports whether the FlagType is a number
+func isNumberType(flagType FlagType) bool {
+	return flagType == FlagType_Integer || flagType == FlagType_Float
+}
+
+// updateDefinition applies the update to the values of the definition of the environment, at the path of
+// the flag, and saves the definition if the update reports a change. The definition is only saved if it did
+// not change since it was read, otherwise the update is applied again to the new definition.
+func (p *PulumiESCProvider) updateDefinition(ctx context.Context, key string, update definitionUpdate) error {
+	client, ok := p.escClient.(ESCDefinitionClient)
+	if !ok {
+		return errors.New("the pulumi esc client does not support updating environment definitions")
+	}
+	if key == "" {
+		return errors.New("flag key must not be empty")
+	}
+	propertyPath, err := parsePropertyPath(p.propertyPath(key))
+	if err != nil {
+		return err
+	}
+	path, ok := objectKeys(propertyPath)
+	if !ok {
+		return fmt.Errorf("flag key %q indexes an array, whose items can not be updated", key)
+	}
+
+	p.definitionMu.Lock()
+	defer p.definitionMu.Unlock()
+	projectName, envName, _ := p.environment()
+	reqCtx, cancel := p.requestContext(ctx)
+	defer cancel()
+	for attempt := 1; ; attempt++ {
+		updated, err := p.tryUpdateDefinition(reqCtx, client, projectName, envName, path, update)
+		if err == nil {
+			if !updated {
+				return nil
+			}
+			break
+		}
+		if !isDefinitionConflict(err) {
+			return err
+		}
+		if attempt == maxDefinitionUpdates {
+			return withSentinel(err, ErrDefinitionConflict)
+		}
+	}
+
+	if p.refreshes != nil {
+		p.requestRefresh()
+		return nil
+	}
+	return p.openSession()
+}
+
+// tryUpdateDefinition reads the definition of the environment, applies the update and saves the definition if
+// the update reports a change, unless the definition changed since it was read. It reports whether it was saved.
+func (p *PulumiESCProvider) tryUpdateDefinition(ctx context.Context, client ESCDefinitionClient, projectName, envName string, path []string, update definitionUpdate) (bool, error) {
+	conditionalClient, conditional := client.(ESCConditionalDefinitionClient)
+	var yamlDefinition, etag string
+	var err error
+	if conditional {
+		yamlDefinition, etag, err = conditionalClient.GetEnvironmentYaml(ctx, p.orgName, projectName, envName)
+	} else {
+		_, yamlDefinition, err = client.GetEnvironment(ctx, p.orgName, projectName, envName)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get pulumi esc environment definition: %w", classifyAPIError(err))
+	}
+	document, err := definition.Parse(yamlDefinition)
+	if err != nil {
+		return false, err
+	}
+	values, err := definition.Values(document)
+	if err != nil {
+		return false, err
+	}
+	if changed, err := update(values, path); err != nil || !changed {
+		return false, err
+	}
+	updated, err := yaml.Marshal(document)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode pulumi esc environment definition: %w", err)
+	}
+	var diagnostics *esc.EnvironmentDiagnostics
+	if conditional {
+		diagnostics, err = conditionalClient.UpdateEnvironmentYamlIfMatch(ctx, p.orgName, projectName, envName, string(updated), etag)
+	} else {
+		diagnostics, err = client.UpdateEnvironmentYaml(ctx, p.orgName, projectName, envName, string(updated))
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update pulumi esc environment definition: %w", classifyAPIError(err))
+	}
+	if diagnostics != nil && len(diagnostics.Diagnostics) > 0 {
+		messages := make([]string, len(diagnostics.Diagnostics))
+		for i, diagnostic := range diagnostics.Diagnostics {
+			messages[i] = diagnostic.Summary
+		}
+		return false, fmt.Errorf("invalid pulumi esc environment definition: %s", strings.Join(messages, "; "))
+	}
+	return true, nil
+}
+
+// isDefinitionConflict determines whether the given error indicates that the definition of an environment
+// changed since it was read
+func isDefinitionConflict(err error) bool {
+	var genErr *esc.GenericOpenAPIError
+	if !errors.As(err, &genErr) {
+		return false
+	}
+	statusCode := apiStatusCode(genErr)
+	return statusCode == http.StatusConflict || statusCode == http.StatusPreconditionFailed
+}
@@ -0,0 +1,159 @@
+package pulumi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetFlag(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": false, "configs.MAX_RETRIES": 3})
+	server.SetSecret("configs.API_KEY", "sk-12345")
+	server.SetRevision(1)
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	require.NoError(t, p.SetFlag(ctx, "DEBUG_MODE", true))
+	assert.True(t, p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil).Value)
+	assert.Equal(t, int32(2), p.Revision(), "the provider must read the new revision")
+
+	require.NoError(t, p.SetFlag(ctx, "configs.THEME", "dark"))
+	assert.Equal(t, "dark", p.StringEvaluation(ctx, "configs.THEME", "", nil).Value)
+	assert.Equal(t, int64(3), p.IntEvaluation(ctx, "configs.MAX_RETRIES", 0, nil).Value, "other flags must be kept")
+	details := p.StringEvaluation(ctx, "configs.API_KEY", "", nil)
+	assert.Equal(t, "sk-12345", details.Value, "secrets must be kept")
+	secret, err := details.FlagMetadata.GetBool("secret")
+	require.NoError(t, err)
+	assert.True(t, secret)
+
+	require.NoError(t, p.SetFlag(ctx, "new.nested.FLAG", map[string]interface{}{"enabled": true}))
+	assert.True(t, p.BooleanEvaluation(ctx, "new.nested.FLAG.enabled", false, nil).Value)
+}
+
+func TestDeleteFlag(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": false, "configs.MAX_RETRIES": 3})
+	server.SetRevision(1)
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	require.NoError(t, p.DeleteFlag(ctx, "configs.MAX_RETRIES"))
+	assert.Equal(t, int32(2), p.Revision())
+	assert.Error(t, p.IntEvaluation(ctx, "configs.MAX_RETRIES", 0, nil).Error())
+	assert.False(t, p.BooleanEvaluation(ctx, "DEBUG_MODE", true, nil).Value, "other flags must be kept")
+
+	require.NoError(t, p.DeleteFlag(ctx, "configs.MISSING"))
+	require.NoError(t, p.DeleteFlag(ctx, "MISSING.NESTED"))
+	assert.Equal(t, int32(2), p.Revision(), "deleting a missing flag must not update the environment")
+}
+
+func TestSetFlag_rootPath(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"flags.DEBUG_MODE": false})
+	p := newTestProvider(t, server, WithRootPath("flags"))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	require.NoError(t, p.SetFlag(ctx, "DEBUG_MODE", true))
+	assert.True(t, p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil).Value)
+}
+
+func TestSetFlag_refresh(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": false})
+	server.SetRevision(1)
+	p := newTestProvider(t, server, WithWebhook("secret"))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	require.NoError(t, p.SetFlag(ctx, "DEBUG_MODE", true))
+	select {
+	case event := <-p.Changes():
+		assert.Equal(t, []FlagChange{{Key: "DEBUG_MODE", Type: ChangeType_Changed, OldValue: false, NewValue: true}}, event.Changes)
+	case <-time.After(time.Second):
+		t.Fatal("the write must refresh the environment")
+	}
+}
+
+func TestCreateFlagIfMissing(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": false, "configs.MAX_RETRIES": 3})
+	server.SetRevision(1)
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	created, err := p.CreateFlagIfMissing(ctx, "configs.THEME", "dark")
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "dark", p.StringEvaluation(ctx, "configs.THEME", "", nil).Value)
+	assert.Equal(t, int32(2), p.Revision())
+
+	created, err = p.CreateFlagIfMissing(ctx, "configs.THEME", "light")
+	require.NoError(t, err)
+	assert.False(t, created, "existing flags must not be overwritten")
+	assert.Equal(t, "dark", p.StringEvaluation(ctx, "configs.THEME", "", nil).Value)
+
+	created, err = p.CreateFlagIfMissing(ctx, "configs.MAX_RETRIES", 2.5)
+	require.NoError(t, err, "integers and floats must be the same type")
+	assert.False(t, created)
+
+	_, err = p.CreateFlagIfMissing(ctx, "DEBUG_MODE", "yes")
+	assert.ErrorContains(t, err, "already exists with type bool instead of string")
+	assert.Equal(t, int32(2), p.Revision(), "the environment must only be updated when flags are created")
+}
+
+func TestSetFlag_conflict(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": false})
+	var patches, conflicts, writes int
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodPatch {
+			patches++
+			assert.NotEmpty(t, req.Header.Get("If-Match"), "updates must be conditional")
+			if patches <= conflicts {
+				// Another client updates the definition between the read and the update
+				writes++
+				server.SetValue("THEME", fmt.Sprintf("theme-%d", writes))
+			}
+		}
+		return server.Client().Transport.RoundTrip(req)
+	})
+	p := newTestProvider(t, server, WithHTTPClient(&http.Client{Transport: transport}))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	conflicts = 1
+	require.NoError(t, p.SetFlag(ctx, "DEBUG_MODE", true))
+	assert.Equal(t, 2, patches, "the update must be retried")
+	assert.True(t, p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil).Value)
+	assert.Equal(t, "theme-1", p.StringEvaluation(ctx, "THEME", "", nil).Value, "concurrent writes must be kept")
+
+	patches, conflicts = 0, maxDefinitionUpdates
+	err := p.SetFlag(ctx, "DEBUG_MODE", false)
+	assert.ErrorIs(t, err, ErrDefinitionConflict)
+	assert.Equal(t, maxDefinitionUpdates, patches)
+	assert.True(t, p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil).Value)
+}
+
+func TestSetFlag_notObject(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"THEME": "dark"})
+	server.SetSecret("configs.API_KEY", "sk-12345")
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	assert.ErrorContains(t, p.SetFlag(ctx, "THEME.nested", true), "THEME is not an object")
+	assert.ErrorContains(t, p.SetFlag(ctx, "configs.API_KEY.nested", true), "API_KEY is a function call")
+	assert.NoError(t, p.DeleteFlag(ctx, "configs.API_KEY.fn::secret"))
+	assert.Equal(t, "dark", p.StringEvaluation(ctx, "THEME", "", nil).Value)
+	assert.Equal(t, "sk-12345", p.StringEvaluation(ctx, "configs.API_KEY", "", nil).Value, "secrets must be kept")
+}
+
+func TestSetFlag_unsupportedClient(t *testing.T) {
+	p := newPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME, WithESCClient(&mockESCClient{}))
+	assert.Error(t, p.SetFlag(context.Background(), "DEBUG_MODE", true))
+	assert.Error(t, p.DeleteFlag(context.Background(), "DEBUG_MODE"))
+}
@@ -0,0 +1,123 @@
+package pulumi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+// requestIDHeader is the header of the Pulumi ESC API responses identifying the request
+const requestIDHeader = "X-Pulumi-Request-Id"
+
+// APIError is an error response of the Pulumi ESC API, e.g. to an initialisation with an invalid access token.
+// It is returned wrapped, so callers retrieve it with errors.As, and it unwraps to the esc.GenericOpenAPIError
+// returned by the Pulumi ESC client.
+type APIError struct {
+	// Code is the HTTP status code of the response
+	Code int
+	// Message is the error message of the response
+	Message string
+	// RequestID identifies the request for Pulumi support, or is empty if the response did not carry one
+	RequestID string
+
+	err *esc.GenericOpenAPIError
+}
+
+func (e *APIError) Error() string {
+	message := e.err.Error()
+	if e.Message != "" && !strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(message, fmt.Sprint(e.Code))), e.Message) {
+		message += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		message += fmt.Sprintf(" (request id %s)", e.RequestID)
+	}
+	return message
+}
+
+func (e *APIError) Unwrap() error {
+	return e.err
+}
+
+// newAPIError returns the APIError of the error of a Pulumi ESC API request, or the error itself if it is not
+// an error response or already is an APIError
+func newAPIError(err error, requestID string) error {
+	var apiErr *APIError
+	var genErr *esc.GenericOpenAPIError
+	if errors.As(err, &apiErr) || !errors.As(err, &genErr) {
+		return err
+	}
+	var errResp struct {
+		Message string `json:"message"`
+	}
+	// Error responses which are not JSON, e.g. from a proxy, have no message
+	_ = json.Unmarshal(genErr.Body(), &errResp)
+	apiErr = &APIError{
+		Code:      apiStatusCode(genErr),
+		Message:   errResp.Message,
+		RequestID: requestID,
+		err:       genErr,
+	}
+	if genErr == err {
+		return apiErr
+	}
+	return &apiErrorWrapper{err: err, apiErr: apiErr}
+}
+
+// apiErrorWrapper is an error wrapping an APIError, keeping the errors the GenericOpenAPIError was wrapped in
+type apiErrorWrapper struct {
+	err    error
+	apiErr *APIError
+}
+
+func (e *apiErrorWrapper) Error() string {
+	return e.err.Error()
+}
+
+func (e *apiErrorWrapper) Unwrap() []error {
+	return []error{e.apiErr, e.err}
+}
+
+// responseRecorder records the request ID of the last Pulumi ESC API response to the requests made with its context
+type responseRecorder struct {
+	mu        sync.Mutex
+	requestID string
+}
+
+// responseRecorderKey is the context key of the responseRecorder of a request
+type responseRecorderKey struct{}
+
+// recordResponses returns a context whose Pulumi ESC API requests record their request IDs, and a function
+// converting their errors into APIErrors
+func recordResponses(ctx context.Context) (context.Context, func(error) error) {
+	recorder := &responseRecorder{}
+	return context.WithValue(ctx, responseRecorderKey{}, recorder), func(err error) error {
+		if err == nil {
+			return nil
+		}
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		return newAPIError(err, recorder.requestID)
+	}
+}
+
+// requestIDTransport is a http.RoundTripper recording the request ID of the responses in the responseRecorder
+// of the request context
+type requestIDTransport struct {
+	base http.RoundTripper
+}
+
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if recorder, ok := req.Context().Value(responseRecorderKey{}).(*responseRecorder); ok && resp != nil {
+		recorder.mu.Lock()
+		recorder.requestID = resp.Header.Get(requestIDHeader)
+		recorder.mu.Unlock()
+	}
+	return resp, err
+}
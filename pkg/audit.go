@@ -0,0 +1,57 @@
+package pulumi
+
+import (
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// AuditRecord records an evaluation which returned the value of a Pulumi ESC secret
+type AuditRecord struct {
+	Key string `json:"key"`
+	// TargetingKey identifies who the secret was evaluated for, if set in the evaluation context
+	TargetingKey string `json:"targetingKey,omitempty"`
+	// Environment is the Pulumi ESC environment the secret was read from, formatted as org/project/env
+	Environment string             `json:"environment"`
+	Reason      openfeature.Reason `json:"reason"`
+	Timestamp   time.Time          `json:"timestamp"`
+}
+
+// AuditSink receives an AuditRecord every time a secret value is evaluated
+type AuditSink interface {
+	EmitAuditRecord(record AuditRecord)
+}
+
+// AuditSinkFunc is an adapter to allow the use of ordinary functions as AuditSink
+type AuditSinkFunc func(record AuditRecord)
+
+// EmitAuditRecord calls f(record)
+func (f AuditSinkFunc) EmitAuditRecord(record AuditRecord) {
+	f(record)
+}
+
+// WithAuditSink emits an AuditRecord to the given sink every time a value which Pulumi ESC marks
+// as secret is evaluated
+func WithAuditSink(sink AuditSink) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.auditSink = sink
+	}
+}
+
+// auditSecretAccess emits an AuditRecord if the evaluation returned a secret value
+func (p *PulumiESCProvider) auditSecretAccess(flag string, evalCtx openfeature.FlattenedContext, resolutionDetails openfeature.ProviderResolutionDetail) {
+	if p.auditSink == nil {
+		return
+	}
+	if secret, _ := resolutionDetails.FlagMetadata.GetBool("secret"); !secret {
+		return
+	}
+	targetingKey, _ := evalCtx[openfeature.TargetingKey].(string)
+	p.auditSink.EmitAuditRecord(AuditRecord{
+		Key:          flag,
+		TargetingKey: targetingKey,
+		Environment:  p.environmentPath(),
+		Reason:       resolutionDetails.Reason,
+		Timestamp:    time.Now(),
+	})
+}
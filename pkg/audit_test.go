@@ -0,0 +1,34 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAuditSink(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{STRING_FLAG_KEY: STRING_FLAG_VALUE})
+	server.SetSecret("configs.OPENAI_API_KEY", "sk-12345")
+
+	var records []AuditRecord
+	p := newTestProvider(t, server, WithAuditSink(AuditSinkFunc(func(record AuditRecord) {
+		records = append(records, record)
+	})))
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+
+	evalCtx := openfeature.FlattenedContext{openfeature.TargetingKey: "user-1"}
+	p.StringEvaluation(context.Background(), STRING_FLAG_KEY, DEFAULT_STRING_FLAG_VALUE, evalCtx)
+	assert.Empty(t, records, "evaluations of plain values must not be audited")
+
+	got := p.StringEvaluation(context.Background(), "configs.OPENAI_API_KEY", DEFAULT_STRING_FLAG_VALUE, evalCtx)
+	assert.Equal(t, "sk-12345", got.Value)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "configs.OPENAI_API_KEY", records[0].Key)
+		assert.Equal(t, "user-1", records[0].TargetingKey)
+		assert.Equal(t, "test-org/"+PROJECT_NAME+"/"+ENV_NAME, records[0].Environment)
+		assert.Equal(t, openfeature.StaticReason, records[0].Reason)
+		assert.False(t, records[0].Timestamp.IsZero())
+	}
+}
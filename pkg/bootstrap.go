@@ -0,0 +1,94 @@
+package pulumi
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// BootstrapFlag is a flag in the format of the flag configuration of the OpenFeature web and in-memory providers
+type BootstrapFlag struct {
+	Variants       map[string]interface{} `json:"variants"`
+	DefaultVariant string                 `json:"defaultVariant"`
+	Disabled       bool                   `json:"disabled"`
+}
+
+// ExportBootstrap returns the flags of the open environment in the format of the flag configuration of the
+// OpenFeature web and in-memory providers, keyed by flag key, so frontend bundles can be bootstrapped from the
+// same environment at page render. Every value listed by ListFlags is a flag with a single variant named "value",
+// like in FlagdConfiguration. Secrets are always omitted, including from the objects containing them, as the
+// flags are sent to browsers.
+//
+// With WithFlagDefinitions, flag definitions are exported with their variants instead. The default variant is
+// the variant with the largest weight of a multi-variate flag, and the "on" variant of other definitions, or the
+// "off" variant if their rollout is partial. Partial rollouts without off value and disabled or archived flags are
+// disabled. Prerequisites are not evaluated, and invalid definitions are omitted.
+func (p *PulumiESCProvider) ExportBootstrap(ctx context.Context) (map[string]BootstrapFlag, error) {
+	snapshot, err := p.readEnvironment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values := copyValues(snapshot.Values)
+	for _, secret := range snapshot.Secrets {
+		removeValue(values, secret)
+	}
+	flags := map[string]BootstrapFlag{}
+	p.collectBootstrapFlags(values, "", flags)
+	return flags, nil
+}
+
+// ExportBootstrapEncoded returns the flags returned by ExportBootstrap encoded as JSON
+func (p *PulumiESCProvider) ExportBootstrapEncoded(ctx context.Context) ([]byte, error) {
+	flags, err := p.ExportBootstrap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(flags, "", "  ")
+}
+
+// collectBootstrapFlags adds the flags of the values, and of their nested values, to flags
+func (p *PulumiESCProvider) collectBootstrapFlags(values map[string]interface{}, prefix string, flags map[string]BootstrapFlag) {
+	for key, value := range values {
+		key = joinPropertyPath(prefix, key)
+		if p.flagDefinitions {
+			definition, err := parseFlagDefinition(value)
+			if err != nil {
+				continue
+			}
+			if definition != nil {
+				flags[key] = definition.bootstrapFlag()
+				continue
+			}
+		}
+		flags[key] = BootstrapFlag{Variants: map[string]interface{}{flagdVariant: value}, DefaultVariant: flagdVariant}
+		if nested, ok := value.(map[string]interface{}); ok {
+			p.collectBootstrapFlags(nested, key, flags)
+		}
+	}
+}
+
+// bootstrapFlag returns the bootstrap flag of a structured flag definition
+func (d *flagDefinition) bootstrapFlag() BootstrapFlag {
+	if d.variants != nil {
+		flag := BootstrapFlag{Variants: d.variants, DefaultVariant: d.defaultVariant, Disabled: d.disabled}
+		heaviest := -1.0
+		// The distribution is sorted by variant name, so ties are won by the first variant
+		for _, weight := range d.distribution {
+			if weight.weight > heaviest {
+				flag.DefaultVariant, heaviest = weight.variant, weight.weight
+			}
+		}
+		return flag
+	}
+	flag := BootstrapFlag{Variants: map[string]interface{}{"on": d.value}, DefaultVariant: "on", Disabled: d.disabled}
+	if d.offValue != nil {
+		flag.Variants["off"] = d.offValue
+	}
+	if d.rollout != nil && *d.rollout < 100 {
+		if d.offValue != nil {
+			flag.DefaultVariant = "off"
+		} else {
+			flag.Disabled = true
+		}
+	}
+	return flag
+}
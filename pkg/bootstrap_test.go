@@ -0,0 +1,66 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportBootstrap(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"DEBUG_MODE":          true,
+		"configs.MAX_RETRIES": 3,
+	})
+	server.SetSecret("configs.API_KEY", "sk-12345")
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	flags, err := p.ExportBootstrap(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]BootstrapFlag{
+		"DEBUG_MODE":          {Variants: map[string]interface{}{"value": true}, DefaultVariant: "value"},
+		"configs":             {Variants: map[string]interface{}{"value": map[string]interface{}{"MAX_RETRIES": 3.0}}, DefaultVariant: "value"},
+		"configs.MAX_RETRIES": {Variants: map[string]interface{}{"value": 3.0}, DefaultVariant: "value"},
+	}, flags, "secrets must be omitted")
+
+	encoded, err := p.ExportSnapshotEncoded(ctx, SnapshotFormat_Bootstrap)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"DEBUG_MODE": {"variants": {"value": true}, "defaultVariant": "value", "disabled": false},
+		"configs": {"variants": {"value": {"MAX_RETRIES": 3}}, "defaultVariant": "value", "disabled": false},
+		"configs.MAX_RETRIES": {"variants": {"value": 3}, "defaultVariant": "value", "disabled": false}
+	}`, string(encoded))
+}
+
+func TestExportBootstrap_flagDefinitions(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"NEW_CHECKOUT": map[string]interface{}{"value": true, "offValue": false},
+		"NEW_SEARCH":   map[string]interface{}{"value": true, "offValue": false, "rollout": 20},
+		"NEW_BANNER":   map[string]interface{}{"value": "big", "rollout": 20},
+		"LEGACY":       map[string]interface{}{"value": true, "enabled": false},
+		"LAYOUT": map[string]interface{}{
+			"variants":     map[string]interface{}{"control": "classic", "v1": "grid", "v2": "list"},
+			"distribution": map[string]interface{}{"control": 25, "v1": 50, "v2": 25},
+		},
+		"INVALID": map[string]interface{}{"value": true, "rollout": 200},
+	})
+	p := newTestProvider(t, server, WithFlagDefinitions())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	flags, err := p.ExportBootstrap(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]BootstrapFlag{
+		"NEW_CHECKOUT": {Variants: map[string]interface{}{"on": true, "off": false}, DefaultVariant: "on"},
+		"NEW_SEARCH":   {Variants: map[string]interface{}{"on": true, "off": false}, DefaultVariant: "off"},
+		"NEW_BANNER":   {Variants: map[string]interface{}{"on": "big"}, DefaultVariant: "on", Disabled: true},
+		"LEGACY":       {Variants: map[string]interface{}{"on": true}, DefaultVariant: "on", Disabled: true},
+		"LAYOUT": {
+			Variants:       map[string]interface{}{"control": "classic", "v1": "grid", "v2": "list"},
+			DefaultVariant: "v1",
+		},
+	}, flags)
+}
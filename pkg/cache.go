@@ -0,0 +1,103 @@
+package pulumi
+
+import (
+	"sync"
+	"time"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+// CacheStatus reports whether an evaluated value was served from memory.
+// It is reported in the "cache" FlagMetadata key.
+type CacheStatus string
+
+const (
+	// CacheStatus_Hit is a value served from the values previously read by the provider
+	CacheStatus_Hit CacheStatus = "HIT"
+	// CacheStatus_Miss is a value read from the Pulumi ESC API because it was not served from memory
+	CacheStatus_Miss CacheStatus = "MISS"
+	// CacheStatus_Stale is a value served from the last known good snapshot because the read failed
+	CacheStatus_Stale CacheStatus = "STALE"
+	// CacheStatus_Bypass is a value read from the Pulumi ESC API while no cache is configured
+	CacheStatus_Bypass CacheStatus = "BYPASS"
+)
+
+const cacheMetadataKey = "cache"
+
+// metadataValue returns the status as FlagMetadata value. Constants are converted to interface values
+// without allocating, unlike the CacheStatus itself.
+func (s CacheStatus) metadataValue() interface{} {
+	switch s {
+	case CacheStatus_Hit:
+		return string(CacheStatus_Hit)
+	case CacheStatus_Miss:
+		return string(CacheStatus_Miss)
+	case CacheStatus_Stale:
+		return string(CacheStatus_Stale)
+	case CacheStatus_Bypass:
+		return string(CacheStatus_Bypass)
+	}
+	return string(s)
+}
+
+// missStatus returns the CacheStatus of a value read from the Pulumi ESC API
+func (p *PulumiESCProvider) missStatus() CacheStatus {
+	if p.lastKnownValues == nil && p.snapshots == nil {
+		return CacheStatus_Bypass
+	}
+	return CacheStatus_Miss
+}
+
+// cachedValue is a property value previously read from the ESC service
+type cachedValue struct {
+	escValue  *esc.Value
+	rawValue  interface{}
+	fetchedAt time.Time
+}
+
+// valueCache holds the last successfully read value of each property path
+type valueCache struct {
+	mu     sync.RWMutex
+	values map[string]cachedValue
+}
+
+func newValueCache() *valueCache {
+	return &valueCache{values: map[string]cachedValue{}}
+}
+
+func (c *valueCache) get(propertyPath string) (cachedValue, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, ok := c.values[propertyPath]
+	return value, ok
+}
+
+// size returns the number of cached values
+func (c *valueCache) size() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.values)
+}
+
+func (c *valueCache) set(propertyPath string, escValue *esc.Value, rawValue interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[propertyPath] = cachedValue{
+		escValue:  escValue,
+		rawValue:  rawValue,
+		fetchedAt: time.Now(),
+	}
+}
+
+// replace replaces all the cached values with the values of the given snapshot
+func (c *valueCache) replace(snapshot *environmentSnapshot) {
+	values := map[string]cachedValue{}
+	now := time.Now()
+	for propertyPath := range flattenValues(snapshot.Values) {
+		escValue, rawValue, _ := snapshot.lookup(propertyPath)
+		values[propertyPath] = cachedValue{escValue: escValue, rawValue: rawValue, fetchedAt: now}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values = values
+}
@@ -0,0 +1,139 @@
+package pulumi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+// CacheFillPolicy controls how the cache enabled by WithCache is filled
+type CacheFillPolicy string
+
+const (
+	// CacheFillPolicy_ReadThrough reads flags from the Pulumi ESC API when they are not cached or their cached
+	// value expired, and caches them
+	CacheFillPolicy_ReadThrough CacheFillPolicy = "READ_THROUGH"
+	// CacheFillPolicy_RefreshAhead is like CacheFillPolicy_ReadThrough, but cached values nearing expiry are
+	// refreshed in the background while they are served, so frequently evaluated flags never wait for the API
+	CacheFillPolicy_RefreshAhead CacheFillPolicy = "REFRESH_AHEAD"
+	// CacheFillPolicy_SnapshotOnly serves all the evaluations from a snapshot of the whole environment, read on
+	// initialisation and refreshed in the background every TTL, so evaluations never wait for the API
+	CacheFillPolicy_SnapshotOnly CacheFillPolicy = "SNAPSHOT_ONLY"
+)
+
+// refreshAheadFactor is the fraction of the TTL after which cached values are refreshed ahead of expiry
+const refreshAheadFactor = 0.8
+
+// errNoCacheSnapshot is returned for evaluations served from the snapshot before it could be read
+var errNoCacheSnapshot = errors.New("pulumi esc environment snapshot not read yet")
+
+// WithCache caches the values of the flags for the given TTL, filled according to the given policy, trading
+// consistency for latency: changes to the environment are served once the cached values expire. Values served
+// from the cache are reported with the HIT cache status. Structured flag definitions can override the TTL with
+// their ttl field, see WithFlagDefinitions.
+func WithCache(ttl time.Duration, policy CacheFillPolicy) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if ttl <= 0 {
+			return
+		}
+		p.cacheTTL = ttl
+		p.cachePolicy = policy
+		if p.lastKnownValues == nil {
+			p.lastKnownValues = newValueCache()
+		}
+	}
+}
+
+// snapshotOnly reports whether evaluations are served from the environment snapshot only
+func (p *PulumiESCProvider) snapshotOnly() bool {
+	return p.cacheTTL > 0 && p.cachePolicy == CacheFillPolicy_SnapshotOnly
+}
+
+// cachedProperty returns the cached value of a property path if it has not expired. Under the refresh-ahead
+// policy, values nearing expiry are refreshed in the background.
+func (p *PulumiESCProvider) cachedProperty(propertyPath string) (*esc.Value, interface{}, bool) {
+	cached, ok := p.lastKnownValues.get(propertyPath)
+	if !ok {
+		return nil, nil, false
+	}
+	ttl := p.flagTTL(cached.rawValue)
+	age := time.Since(cached.fetchedAt)
+	if age >= ttl {
+		return nil, nil, false
+	}
+	if p.cachePolicy == CacheFillPolicy_RefreshAhead && age >= time.Duration(refreshAheadFactor*float64(ttl)) {
+		p.refreshAhead(propertyPath)
+	}
+	return cached.escValue, cached.rawValue, true
+}
+
+// flagTTL returns the TTL of a cached value, declared by the ttl field of its structured flag definition or
+// set by WithCache
+func (p *PulumiESCProvider) flagTTL(rawValue interface{}) time.Duration {
+	if !p.flagDefinitions {
+		return p.cacheTTL
+	}
+	if definition, err := parseFlagDefinition(rawValue); err == nil && definition != nil && definition.ttl > 0 {
+		return definition.ttl
+	}
+	return p.cacheTTL
+}
+
+// refreshAhead reads a property value in the background and caches it, unless it is already being refreshed
+func (p *PulumiESCProvider) refreshAhead(propertyPath string) {
+	if ctx := p.lifecycleContext(); ctx == nil || ctx.Err() != nil {
+		return
+	}
+	if _, refreshing := p.refreshingPaths.LoadOrStore(propertyPath, struct{}{}); refreshing {
+		return
+	}
+	p.goBackground(func(ctx context.Context) {
+		defer p.refreshingPaths.Delete(propertyPath)
+		if escValue, rawValue, _, err := p.readPropertyOnce(ctx, propertyPath); err == nil {
+			p.lastKnownValues.set(propertyPath, escValue, rawValue)
+		}
+	})
+}
+
+// readSnapshotProperty reads a property value from the environment snapshot
+func (p *PulumiESCProvider) readSnapshotProperty(propertyPath string) (*esc.Value, interface{}, CacheStatus, error) {
+	snapshot := p.snapshot.Load()
+	if snapshot == nil {
+		return nil, nil, CacheStatus_Miss, errNoCacheSnapshot
+	}
+	escValue, rawValue, ok := snapshot.lookup(propertyPath)
+	if !ok {
+		return nil, nil, CacheStatus_Hit, errKeyNotFound
+	}
+	return escValue, rawValue, CacheStatus_Hit, nil
+}
+
+// refreshCacheSnapshot reads the environment snapshot evaluations are served from, persisting it if enabled
+func (p *PulumiESCProvider) refreshCacheSnapshot(ctx context.Context) error {
+	snapshot, err := p.readEnvironment(ctx)
+	if err != nil {
+		return err
+	}
+	if p.snapshots != nil {
+		return p.saveSnapshot(snapshot)
+	}
+	p.snapshot.Store(snapshot)
+	return nil
+}
+
+// runCacheSnapshotRefresh refreshes the environment snapshot every TTL until the context is done. Failed
+// refreshes keep serving the previous snapshot.
+func (p *PulumiESCProvider) runCacheSnapshotRefresh(ctx context.Context) {
+	ticker := time.NewTicker(p.cacheTTL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.updateStateAfterRead(p.refreshCacheSnapshot(ctx))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
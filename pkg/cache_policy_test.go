@@ -0,0 +1,94 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCache(t *testing.T) {
+	ctx := context.Background()
+	evalCtx := openfeature.FlattenedContext{}
+
+	t.Run("read-through", func(t *testing.T) {
+		server := newFakeESCServer(t, map[string]interface{}{"MAX_RETRIES": 3})
+		p := newTestProvider(t, server, WithCache(100*time.Millisecond, CacheFillPolicy_ReadThrough))
+		require.NoError(t, p.initialise(ctx))
+
+		details := p.IntEvaluation(ctx, "MAX_RETRIES", 0, evalCtx)
+		assert.Equal(t, int64(3), details.Value)
+		assert.Equal(t, string(CacheStatus_Miss), details.FlagMetadata[cacheMetadataKey])
+
+		server.SetValue("MAX_RETRIES", 5)
+		requests := server.Requests()
+		details = p.IntEvaluation(ctx, "MAX_RETRIES", 0, evalCtx)
+		assert.Equal(t, int64(3), details.Value, "the cached value must be served until it expires")
+		assert.Equal(t, string(CacheStatus_Hit), details.FlagMetadata[cacheMetadataKey])
+		assert.Equal(t, openfeature.CachedReason, details.Reason)
+		assert.Equal(t, requests, server.Requests())
+
+		time.Sleep(120 * time.Millisecond)
+		details = p.IntEvaluation(ctx, "MAX_RETRIES", 0, evalCtx)
+		assert.Equal(t, int64(5), details.Value)
+		assert.Equal(t, string(CacheStatus_Miss), details.FlagMetadata[cacheMetadataKey])
+	})
+
+	t.Run("refresh-ahead", func(t *testing.T) {
+		server := newFakeESCServer(t, map[string]interface{}{"MAX_RETRIES": 3})
+		p := newTestProvider(t, server, WithCache(200*time.Millisecond, CacheFillPolicy_RefreshAhead))
+		require.NoError(t, p.initialise(ctx))
+
+		assert.Equal(t, int64(3), p.IntEvaluation(ctx, "MAX_RETRIES", 0, evalCtx).Value)
+		server.SetValue("MAX_RETRIES", 5)
+		time.Sleep(170 * time.Millisecond)
+		details := p.IntEvaluation(ctx, "MAX_RETRIES", 0, evalCtx)
+		assert.Equal(t, int64(3), details.Value, "values nearing expiry must be served while they are refreshed")
+		assert.Equal(t, string(CacheStatus_Hit), details.FlagMetadata[cacheMetadataKey])
+
+		assert.Eventually(t, func() bool {
+			details := p.IntEvaluation(ctx, "MAX_RETRIES", 0, evalCtx)
+			return details.Value == 5 && details.FlagMetadata[cacheMetadataKey] == string(CacheStatus_Hit)
+		}, time.Second, 10*time.Millisecond, "the refreshed value must be served from the cache")
+	})
+
+	t.Run("snapshot-only", func(t *testing.T) {
+		server := newFakeESCServer(t, map[string]interface{}{"MAX_RETRIES": 3})
+		p := newTestProvider(t, server, WithCache(100*time.Millisecond, CacheFillPolicy_SnapshotOnly))
+		require.NoError(t, p.initialise(ctx))
+
+		server.SetValue("MAX_RETRIES", 5)
+		requests := server.Requests()
+		details := p.IntEvaluation(ctx, "MAX_RETRIES", 0, evalCtx)
+		assert.Equal(t, int64(3), details.Value)
+		assert.Equal(t, string(CacheStatus_Hit), details.FlagMetadata[cacheMetadataKey])
+		details = p.IntEvaluation(ctx, "MISSING", 7, evalCtx)
+		assert.Equal(t, openfeature.FlagNotFoundCode, details.ResolutionDetail().ErrorCode)
+		assert.Equal(t, requests, server.Requests(), "evaluations must not read from the Pulumi ESC API")
+
+		assert.Eventually(t, func() bool {
+			return p.IntEvaluation(ctx, "MAX_RETRIES", 0, evalCtx).Value == 5
+		}, time.Second, 10*time.Millisecond, "the snapshot must be refreshed every TTL")
+	})
+	t.Run("per-flag ttl", func(t *testing.T) {
+		server := newFakeESCServer(t, map[string]interface{}{
+			"VOLATILE": map[string]interface{}{"value": 1, "ttl": "50ms"},
+			"STABLE":   map[string]interface{}{"value": 1},
+			"INVALID":  map[string]interface{}{"value": 1, "ttl": "soon"},
+		})
+		p := newTestProvider(t, server, WithCache(time.Hour, CacheFillPolicy_ReadThrough), WithFlagDefinitions())
+		require.NoError(t, p.initialise(ctx))
+
+		assert.Equal(t, int64(1), p.IntEvaluation(ctx, "VOLATILE", 0, evalCtx).Value)
+		assert.Equal(t, int64(1), p.IntEvaluation(ctx, "STABLE", 0, evalCtx).Value)
+		server.SetValue("VOLATILE", map[string]interface{}{"value": 2, "ttl": "50ms"})
+		server.SetValue("STABLE", map[string]interface{}{"value": 2})
+		time.Sleep(80 * time.Millisecond)
+		assert.Equal(t, int64(2), p.IntEvaluation(ctx, "VOLATILE", 0, evalCtx).Value, "the ttl of the flag must override the TTL of the cache")
+		assert.Equal(t, int64(1), p.IntEvaluation(ctx, "STABLE", 0, evalCtx).Value)
+		assert.Equal(t, openfeature.ParseErrorCode, p.IntEvaluation(ctx, "INVALID", 0, evalCtx).ResolutionDetail().ErrorCode)
+	})
+}
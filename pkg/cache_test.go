@@ -0,0 +1,37 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheStatusMetadata(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{BOOL_FLAG_KEY: BOOL_FLAG_VALUE})
+	cacheStatus := func(details openfeature.BoolResolutionDetail) string {
+		status, _ := details.FlagMetadata.GetString(cacheMetadataKey)
+		return status
+	}
+
+	t.Run("bypass", func(t *testing.T) {
+		p := newTestProvider(t, server)
+		assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+		got := p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, false, nil)
+		assert.Equal(t, string(CacheStatus_Bypass), cacheStatus(got))
+		assert.Equal(t, openfeature.StaticReason, got.Reason)
+	})
+
+	t.Run("miss-then-hit", func(t *testing.T) {
+		p := newTestProvider(t, server, WithRateLimit(0.001, 1))
+		assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+		got := p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, false, nil)
+		assert.Equal(t, string(CacheStatus_Miss), cacheStatus(got))
+		assert.Equal(t, openfeature.StaticReason, got.Reason)
+
+		got = p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, false, nil)
+		assert.Equal(t, string(CacheStatus_Hit), cacheStatus(got))
+		assert.Equal(t, openfeature.CachedReason, got.Reason)
+	})
+}
@@ -0,0 +1,125 @@
+package pulumi
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+const (
+	// canonicalKeyMetadataKey is the FlagMetadata key of the key of the environment an evaluation matched
+	// case-insensitively
+	canonicalKeyMetadataKey = "canonicalKey"
+	// keyIndexTTL is the age after which the key index of an environment whose revision is unknown is rebuilt
+	keyIndexTTL = time.Minute
+)
+
+// WithCaseInsensitiveKeys resolves flag keys case-insensitively, e.g. "someflag" or "SOMEFLAG" to the someFlag
+// value of the environment, for environments mixing naming conventions. Keys are matched against an index of
+// the keys of the environment, rebuilt whenever its revision changes. Evaluations of keys matching another key
+// report the key of the environment in the canonicalKey flag metadata. Keys matching several keys of the
+// environment which differ only by case, none of them exactly, are not found.
+func WithCaseInsensitiveKeys() ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.caseInsensitiveKeys = true
+	}
+}
+
+// keyIndex maps the case-folded and canonical keys of an environment snapshot to their keys
+type keyIndex struct {
+	environment string
+	revision    int32
+	builtAt     time.Time
+	// keys are the keys by case-folded key, or empty if several keys fold to the same key
+	keys map[string]string
+	// canonicalKeys are the keys by canonical key, see WithKeyCanonicalization, or empty if several keys
+	// have the same canonical key
+	canonicalKeys map[string]string
+}
+
+func newKeyIndex(snapshot *environmentSnapshot) *keyIndex {
+	index := &keyIndex{
+		environment:   snapshot.Environment,
+		revision:      snapshot.Revision,
+		builtAt:       time.Now(),
+		keys:          map[string]string{},
+		canonicalKeys: map[string]string{},
+	}
+	for key := range flattenValues(snapshot.Values) {
+		addIndexKey(index.keys, strings.ToLower(key), key)
+		addIndexKey(index.canonicalKeys, canonicalizeKey(key), key)
+	}
+	return index
+}
+
+// addIndexKey adds a key to an index by its folded key, or marks the folded key as ambiguous if it is
+// already indexed
+func addIndexKey(keys map[string]string, folded, key string) {
+	if _, ok := keys[folded]; ok {
+		keys[folded] = ""
+	} else {
+		keys[folded] = key
+	}
+}
+
+// canonicalKey returns the key of the environment matching a key case-insensitively, or with
+// WithKeyCanonicalization by its canonical key, or the key itself if it matches no key, or several keys
+// and none exactly
+func (p *PulumiESCProvider) canonicalKey(ctx context.Context, key string) string {
+	index := p.currentKeyIndex(ctx)
+	if index == nil {
+		return key
+	}
+	if canonical := index.keys[strings.ToLower(key)]; canonical != "" {
+		return canonical
+	}
+	if p.keyCanonicalization {
+		if canonical := index.canonicalKeys[canonicalizeKey(key)]; canonical != "" {
+			return canonical
+		}
+	}
+	return key
+}
+
+// currentKeyIndex returns the key index of the current revision of the environment, building it from the
+// last known good snapshot if it is current, or by reading the environment. It returns nil if the environment
+// can not be read.
+func (p *PulumiESCProvider) currentKeyIndex(ctx context.Context) *keyIndex {
+	projectName, envName, _ := p.environment()
+	environment, revision := projectName+"/"+envName, p.Revision()
+	current := func(index *keyIndex) bool {
+		return index != nil && index.environment == environment && index.revision == revision &&
+			(revision > 0 || time.Since(index.builtAt) < keyIndexTTL)
+	}
+	if index := p.keyIndex.Load(); current(index) {
+		return index
+	}
+	p.keyIndexMu.Lock()
+	defer p.keyIndexMu.Unlock()
+	if index := p.keyIndex.Load(); current(index) {
+		return index
+	}
+	snapshot := p.snapshot.Load()
+	if snapshot == nil || snapshot.Environment != environment || snapshot.Revision != revision || revision == 0 {
+		var err error
+		if snapshot, err = p.readEnvironment(ctx); err != nil {
+			return nil
+		}
+	}
+	index := newKeyIndex(snapshot)
+	p.keyIndex.Store(index)
+	return index
+}
+
+// withCanonicalKey adds the key of the environment an evaluation matched to the metadata of its resolution
+func withCanonicalKey(resolutionDetails openfeature.ProviderResolutionDetail, canonicalKey string) openfeature.ProviderResolutionDetail {
+	metadata := make(openfeature.FlagMetadata, len(resolutionDetails.FlagMetadata)+1)
+	for key, value := range resolutionDetails.FlagMetadata {
+		metadata[key] = value
+	}
+	metadata[canonicalKeyMetadataKey] = canonicalKey
+	resolutionDetails.FlagMetadata = metadata
+	return resolutionDetails
+}
@@ -0,0 +1,50 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCaseInsensitiveKeys(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"someFlag":  true,
+		"SOME_FLAG": false,
+		"checkout":  map[string]interface{}{"newFlow": true},
+		"mode":      "a",
+		"MODE":      "b",
+	})
+	server.SetRevision(1)
+	ctx := context.Background()
+	p := newTestProvider(t, server, WithCaseInsensitiveKeys())
+	require.NoError(t, p.initialise(ctx))
+
+	got := p.BooleanEvaluation(ctx, "SOMEFLAG", false, nil)
+	assert.NoError(t, got.Error())
+	assert.True(t, got.Value)
+	canonicalKey, _ := got.FlagMetadata.GetString(canonicalKeyMetadataKey)
+	assert.Equal(t, "someFlag", canonicalKey)
+
+	got = p.BooleanEvaluation(ctx, "SOME_FLAG", true, nil)
+	assert.False(t, got.Value)
+	assert.NotContains(t, got.FlagMetadata, canonicalKeyMetadataKey, "exact matches must not report a canonical key")
+
+	got = p.BooleanEvaluation(ctx, "Checkout.NewFlow", false, nil)
+	assert.True(t, got.Value, "nested keys must be matched")
+	canonicalKey, _ = got.FlagMetadata.GetString(canonicalKeyMetadataKey)
+	assert.Equal(t, "checkout.newFlow", canonicalKey)
+
+	assert.Equal(t, "b", p.StringEvaluation(ctx, "MODE", "", nil).Value)
+	assert.Equal(t, openfeature.FlagNotFoundCode, p.StringEvaluation(ctx, "Mode", "", nil).ResolutionDetail().ErrorCode,
+		"ambiguous keys must not be matched")
+
+	// The index is rebuilt when the revision changes
+	server.SetValue("newFlag", true)
+	server.SetRevision(2)
+	assert.Equal(t, openfeature.FlagNotFoundCode, p.BooleanEvaluation(ctx, "NEWFLAG", false, nil).ResolutionDetail().ErrorCode)
+	require.NoError(t, p.openSession())
+	assert.True(t, p.BooleanEvaluation(ctx, "NEWFLAG", false, nil).Value)
+}
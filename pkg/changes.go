@@ -0,0 +1,138 @@
+package pulumi
+
+import (
+	"reflect"
+	"sort"
+	"time"
+)
+
+// ChangeType is the kind of change of a flag between two reads of the environment
+type ChangeType string
+
+const (
+	ChangeType_Added   ChangeType = "added"
+	ChangeType_Removed ChangeType = "removed"
+	ChangeType_Changed ChangeType = "changed"
+)
+
+// FlagChange is the change of a flag. Objects are reported as a whole and for each of their changed
+// nested values. Secrets denied using WithDenySecrets are replaced with a redacted placeholder.
+type FlagChange struct {
+	Key      string      `json:"key"`
+	Type     ChangeType  `json:"type"`
+	OldValue interface{} `json:"oldValue,omitempty"`
+	NewValue interface{} `json:"newValue,omitempty"`
+}
+
+// ChangeEvent describes the flags changed between two reads of the environment
+type ChangeEvent struct {
+	// Environment is the project and environment the values were read from, e.g. "my-project/dev"
+	Environment string `json:"environment"`
+	// PreviousRevision and Revision are the revisions of the environment the values were read at,
+	// or 0 if they are unknown
+	PreviousRevision int32 `json:"previousRevision,omitempty"`
+	Revision         int32 `json:"revision,omitempty"`
+	// PreviousReadAt and ReadAt are the times the values were read
+	PreviousReadAt time.Time    `json:"previousReadAt"`
+	ReadAt         time.Time    `json:"readAt"`
+	Changes        []FlagChange `json:"changes"`
+}
+
+// Changes returns a channel of the changes of the flags detected by WithPolling and SwitchEnvironment,
+// with their old and new values. Unlike PROVIDER_CONFIGURATION_CHANGED events, changes are not debounced.
+// Events are dropped when the channel buffer is full, so a channel which is not read never blocks the provider.
+func (p *PulumiESCProvider) Changes() <-chan ChangeEvent {
+	return p.changes
+}
+
+// emitChanges sends the changes between the snapshots without blocking, dropping them if the buffer is full.
+// The previous snapshot may be nil, in which case all the flags are added.
+func (p *PulumiESCProvider) emitChanges(previous, current *environmentSnapshot, changes []FlagChange) {
+	event := ChangeEvent{
+		Environment: current.Environment,
+		Revision:    current.Revision,
+		ReadAt:      current.SavedAt,
+		Changes:     changes,
+	}
+	if previous != nil {
+		event.PreviousRevision, event.PreviousReadAt = previous.Revision, previous.SavedAt
+	}
+	select {
+	case p.changes <- event:
+	default:
+	}
+}
+
+// flagChanges returns the changes of the flags between the snapshots, sorted by key, with the denied
+// secrets redacted. The previous snapshot may be nil, in which case all the flags are added.
+func (p *PulumiESCProvider) flagChanges(previous, current *environmentSnapshot) []FlagChange {
+	var previousValues map[string]interface{}
+	if previous != nil {
+		previousValues = previous.Values
+	}
+	changes := diffValues(previousValues, current.Values)
+	if !p.deniesSecrets(previous) && !p.deniesSecrets(current) {
+		return changes
+	}
+	// Secrets are compared in plain text, so changes of denied secrets are still reported
+	var previousRedacted map[string]interface{}
+	if previous != nil {
+		previousRedacted = flattenValues(p.redactedValues(previous))
+	}
+	currentRedacted := flattenValues(p.redactedValues(current))
+	for i := range changes {
+		if changes[i].Type != ChangeType_Added {
+			changes[i].OldValue = previousRedacted[changes[i].Key]
+		}
+		if changes[i].Type != ChangeType_Removed {
+			changes[i].NewValue = currentRedacted[changes[i].Key]
+		}
+	}
+	return changes
+}
+
+// deniesSecrets reports whether any secret of the snapshot is denied using WithDenySecrets
+func (p *PulumiESCProvider) deniesSecrets(snapshot *environmentSnapshot) bool {
+	if snapshot == nil {
+		return false
+	}
+	for _, secret := range snapshot.Secrets {
+		if p.secretDenied(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// diffValues returns the changes of the flags between two sets of values, sorted by key
+func diffValues(previous, current map[string]interface{}) []FlagChange {
+	before, after := flattenValues(previous), flattenValues(current)
+	var changes []FlagChange
+	for key, value := range before {
+		afterValue, ok := after[key]
+		switch {
+		case !ok:
+			changes = append(changes, FlagChange{Key: key, Type: ChangeType_Removed, OldValue: value})
+		case !reflect.DeepEqual(value, afterValue):
+			changes = append(changes, FlagChange{Key: key, Type: ChangeType_Changed, OldValue: value, NewValue: afterValue})
+		}
+	}
+	for key, value := range after {
+		if _, ok := before[key]; !ok {
+			changes = append(changes, FlagChange{Key: key, Type: ChangeType_Added, NewValue: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// changedKeys returns the keys of the changed flags
+func changedKeys(changes []FlagChange) []string {
+	keys := make([]string, 0, len(changes))
+	for _, change := range changes {
+		keys = append(keys, change.Key)
+	}
+	return keys
+}
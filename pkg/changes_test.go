@@ -0,0 +1,88 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChanges(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"DEBUG_MODE": false,
+		"THEME":      "dark",
+	})
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	previous, err := p.readEnvironment(ctx)
+	require.NoError(t, err)
+
+	server.SetValue("DEBUG_MODE", true)
+	server.SetValue("configs.MAX_RETRIES", 5)
+	server.DeleteValue("THEME")
+	snapshot, ok := p.poll(ctx, previous)
+	require.True(t, ok)
+
+	event := <-p.Changes()
+	assert.Equal(t, PROJECT_NAME+"/"+ENV_NAME, event.Environment)
+	assert.Equal(t, previous.SavedAt, event.PreviousReadAt)
+	assert.Equal(t, snapshot.SavedAt, event.ReadAt)
+	assert.Equal(t, []FlagChange{
+		{Key: "DEBUG_MODE", Type: ChangeType_Changed, OldValue: false, NewValue: true},
+		{Key: "THEME", Type: ChangeType_Removed, OldValue: "dark"},
+		{Key: "configs", Type: ChangeType_Added, NewValue: map[string]interface{}{"MAX_RETRIES": snapshot.Values["configs"].(map[string]interface{})["MAX_RETRIES"]}},
+		{Key: "configs.MAX_RETRIES", Type: ChangeType_Added, NewValue: snapshot.Values["configs"].(map[string]interface{})["MAX_RETRIES"]},
+	}, event.Changes)
+
+	_, ok = p.poll(ctx, snapshot)
+	require.True(t, ok)
+	assert.Empty(t, p.Changes(), "no event must be sent if no flag changed")
+}
+
+func TestChanges_deniedSecrets(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{})
+	server.SetSecret("configs.OPENAI_API_KEY", "sk-12345")
+	p := newTestProvider(t, server, WithDenySecrets())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	previous, err := p.readEnvironment(ctx)
+	require.NoError(t, err)
+
+	server.SetSecret("configs.OPENAI_API_KEY", "sk-67890")
+	_, ok := p.poll(ctx, previous)
+	require.True(t, ok)
+
+	event := <-p.Changes()
+	assert.Equal(t, []FlagChange{
+		{Key: "configs", Type: ChangeType_Changed, OldValue: map[string]interface{}{"OPENAI_API_KEY": maskedValue}, NewValue: map[string]interface{}{"OPENAI_API_KEY": maskedValue}},
+		{Key: "configs.OPENAI_API_KEY", Type: ChangeType_Changed, OldValue: maskedValue, NewValue: maskedValue},
+	}, event.Changes, "changes of denied secrets must be reported without their values")
+}
+
+func TestChanges_switchEnvironment(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	require.NoError(t, p.SwitchEnvironment(ctx, PROJECT_NAME, "green"))
+	event := <-p.Changes()
+	assert.Equal(t, PROJECT_NAME+"/green", event.Environment)
+	assert.False(t, event.ReadAt.IsZero())
+	assert.Empty(t, event.Changes, "the environments have the same values")
+}
+
+func TestChanges_fullBuffer(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	p := newTestProvider(t, server)
+	require.NoError(t, p.initialise(context.Background()))
+	snapshot, err := p.readEnvironment(context.Background())
+	require.NoError(t, err)
+
+	for i := 0; i < eventBufferSize+1; i++ {
+		p.emitChanges(nil, snapshot, p.flagChanges(nil, snapshot))
+	}
+	assert.Len(t, p.Changes(), eventBufferSize, "events must be dropped rather than block the provider")
+}
@@ -0,0 +1,149 @@
+package pulumi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+// DefinitionCheck is the result of checking a proposed definition of the environment against its current flags
+type DefinitionCheck struct {
+	// Diagnostics are the errors of the definition reported by Pulumi ESC
+	Diagnostics []string `json:"diagnostics,omitempty"`
+	// Removed are the keys of the current flags which the definition would remove, sorted
+	Removed []string `json:"removed,omitempty"`
+	// TypeChanges are the current flags whose type the definition would change, sorted by key
+	TypeChanges []FlagTypeChange `json:"typeChanges,omitempty"`
+}
+
+// FlagTypeChange is the change of the type of a flag
+type FlagTypeChange struct {
+	Key     string   `json:"key"`
+	OldType FlagType `json:"oldType"`
+	NewType FlagType `json:"newType"`
+}
+
+// Safe reports whether the definition is valid and keeps every current flag with its type
+func (c *DefinitionCheck) Safe() bool {
+	return len(c.Diagnostics) == 0 && len(c.Removed) == 0 && len(c.TypeChanges) == 0
+}
+
+// unknownValue is the value of a property which can not be evaluated without opening the environment,
+// e.g. the output of a provider
+type unknownValue struct{}
+
+// CheckDefinition evaluates a proposed YAML definition of the environment using the Pulumi ESC API, without
+// saving it, and reports the flags which would disappear or change type relative to the last known good
+// snapshot, or to the values of the environment if there is none. Values which are only known once the
+// environment is opened, e.g. the outputs of providers, are not compared. An invalid definition is reported
+// in the diagnostics and its flags are not compared.
+func (p *PulumiESCProvider) CheckDefinition(ctx context.Context, definition string) (*DefinitionCheck, error) {
+	client, ok := p.escClient.(ESCDefinitionClient)
+	if !ok {
+		return nil, errors.New("the pulumi esc client does not support checking environment definitions")
+	}
+	current := p.snapshot.Load()
+	if current == nil {
+		var err error
+		if current, err = p.readEnvironment(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	reqCtx, cancel := p.requestContext(ctx)
+	defer cancel()
+	checked, err := client.CheckEnvironmentYaml(reqCtx, p.orgName, definition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pulumi esc environment definition: %w", err)
+	}
+	check := &DefinitionCheck{}
+	for _, diagnostic := range checked.Diagnostics {
+		check.Diagnostics = append(check.Diagnostics, diagnostic.Summary)
+	}
+	if len(check.Diagnostics) > 0 {
+		return check, nil
+	}
+
+	proposed := &environmentSnapshot{Values: checkedValues(checked.Properties)}
+	if p.rootPath != "" {
+		proposed.restrictTo(p.rootPath)
+	}
+	before, after := flattenValues(current.Values), flattenValues(proposed.Values)
+	for key, value := range before {
+		afterValue, ok := after[key]
+		switch {
+		case isUnknown(after, key):
+		case !ok:
+			check.Removed = append(check.Removed, key)
+		case inferFlagType(value) != inferFlagType(afterValue):
+			check.TypeChanges = append(check.TypeChanges, FlagTypeChange{Key: key, OldType: inferFlagType(value), NewType: inferFlagType(afterValue)})
+		}
+	}
+	sort.Strings(check.Removed)
+	sort.Slice(check.TypeChanges, func(i, j int) bool {
+		return check.TypeChanges[i].Key < check.TypeChanges[j].Key
+	})
+	return check, nil
+}
+
+// isUnknown reports whether the flag, or the object containing it, is unknown
+func isUnknown(values map[string]interface{}, key string) bool {
+	for {
+		if _, ok := values[key].(unknownValue); ok {
+			return true
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			return false
+		}
+		key = key[:i]
+	}
+}
+
+// checkedValues converts the properties returned by the check of a definition to plain values
+func checkedValues(properties *map[string]esc.Value) map[string]interface{} {
+	values := map[string]interface{}{}
+	if properties == nil {
+		return values
+	}
+	for key, property := range *properties {
+		if property.Unknown != nil && *property.Unknown {
+			values[key] = unknownValue{}
+			continue
+		}
+		values[key] = checkedValue(property.Value)
+	}
+	return values
+}
+
+// checkedValue converts a value returned by the check of a definition, whose nested values carry their
+// trace, to a plain value
+func checkedValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if nested, ok := value["value"]; ok {
+			if _, ok := value["trace"]; ok {
+				if unknown, _ := value["unknown"].(bool); unknown {
+					return unknownValue{}
+				}
+				return checkedValue(nested)
+			}
+		}
+		values := make(map[string]interface{}, len(value))
+		for key, nested := range value {
+			values[key] = checkedValue(nested)
+		}
+		return values
+	case []interface{}:
+		values := make([]interface{}, len(value))
+		for i, nested := range value {
+			values[i] = checkedValue(nested)
+		}
+		return values
+	}
+	return value
+}
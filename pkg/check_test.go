@@ -0,0 +1,74 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDefinition(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": false, "THEME": "dark", "configs.MAX_RETRIES": 3})
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	check, err := p.CheckDefinition(ctx, "values:\n  DEBUG_MODE: \"yes\"\n  THEME: light\n  configs:\n    TIMEOUT: 1.5\n")
+	require.NoError(t, err)
+	assert.Empty(t, check.Diagnostics)
+	assert.Equal(t, []string{"configs.MAX_RETRIES"}, check.Removed)
+	assert.Equal(t, []FlagTypeChange{{Key: "DEBUG_MODE", OldType: FlagType_Bool, NewType: FlagType_String}}, check.TypeChanges)
+	assert.False(t, check.Safe())
+
+	check, err = p.CheckDefinition(ctx, "values:\n  DEBUG_MODE: true\n  THEME: light\n  NEW_FLAG: 1\n  configs:\n    MAX_RETRIES: 5\n")
+	require.NoError(t, err)
+	assert.True(t, check.Safe(), "changed values and added flags must be safe")
+
+	check, err = p.CheckDefinition(ctx, "values: [")
+	require.NoError(t, err)
+	assert.NotEmpty(t, check.Diagnostics)
+	assert.False(t, check.Safe())
+}
+
+func TestCheckDefinition_rootPath(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"flags.DEBUG_MODE": false, "other": 1})
+	p := newTestProvider(t, server, WithRootPath("flags"))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	check, err := p.CheckDefinition(ctx, "values:\n  flags:\n    DEBUG_MODE: 1\n")
+	require.NoError(t, err)
+	assert.Empty(t, check.Removed, "values outside of the root path must not be compared")
+	assert.Equal(t, []FlagTypeChange{{Key: "DEBUG_MODE", OldType: FlagType_Bool, NewType: FlagType_Integer}}, check.TypeChanges)
+}
+
+func TestCheckDefinition_unsupportedClient(t *testing.T) {
+	p := newPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME, WithESCClient(&mockESCClient{}))
+	_, err := p.CheckDefinition(context.Background(), "values: {}")
+	assert.Error(t, err)
+}
+
+func TestCheckedValues(t *testing.T) {
+	unknown := true
+	values := checkedValues(&map[string]esc.Value{
+		"DEBUG_MODE": {Value: true},
+		"configs": {Value: map[string]interface{}{
+			"MAX_RETRIES": map[string]interface{}{"value": 3.0, "trace": map[string]interface{}{}},
+			"TOKEN":       map[string]interface{}{"value": nil, "unknown": true, "trace": map[string]interface{}{}},
+		}},
+		"aws": {Unknown: &unknown},
+	})
+	assert.Equal(t, map[string]interface{}{
+		"DEBUG_MODE": true,
+		"configs":    map[string]interface{}{"MAX_RETRIES": 3.0, "TOKEN": unknownValue{}},
+		"aws":        unknownValue{},
+	}, values)
+
+	flattened := flattenValues(values)
+	assert.True(t, isUnknown(flattened, "aws.credentials.KEY"))
+	assert.True(t, isUnknown(flattened, "configs.TOKEN"))
+	assert.False(t, isUnknown(flattened, "configs.MAX_RETRIES"))
+	assert.False(t, isUnknown(flattened, "DEBUG_MODE"))
+}
@@ -0,0 +1,244 @@
+package pulumi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+// ESCClient is the subset of the Pulumi ESC API used by the provider.
+// Implement it to mock the Pulumi ESC API in unit tests or to wrap the Pulumi ESC client, and pass it
+// to the provider using WithESCClient.
+type ESCClient interface {
+	// OpenEnvironment opens the latest version of an environment and returns the open environment session
+	OpenEnvironment(ctx context.Context, org, projectName, envName string) (*esc.OpenEnvironment, error)
+	// OpenEnvironmentAtVersion opens the given revision of an environment and returns the open environment session
+	OpenEnvironmentAtVersion(ctx context.Context, org, projectName, envName, version string) (*esc.OpenEnvironment, error)
+	// ReadOpenEnvironment returns all the values of an open environment session
+	ReadOpenEnvironment(ctx context.Context, org, projectName, envName, openEnvID string) (*esc.Environment, map[string]interface{}, error)
+	// ReadEnvironmentProperty returns a single value of an open environment session
+	ReadEnvironmentProperty(ctx context.Context, org, projectName, envName, openEnvID, propPath string) (*esc.Value, interface{}, error)
+	// ListEnvironmentRevisions returns at most count revisions of an environment, from the newest to the oldest
+	ListEnvironmentRevisions(ctx context.Context, org, projectName, envName string, count int32) ([]esc.EnvironmentRevision, error)
+}
+
+// ESCChangeWatcher is implemented by ESCClients whose backend can notify changes of an environment, e.g. by
+// long-polling or streaming, and is used by WithLongPolling. The Pulumi ESC API does not support it.
+type ESCChangeWatcher interface {
+	// WaitForChange blocks until the latest revision of an environment is newer than the given revision, or
+	// until the context is done, and returns the latest revision. Implementations may return the given
+	// revision after a backend timeout, in which case the provider waits again.
+	WaitForChange(ctx context.Context, org, projectName, envName string, revision int32) (int32, error)
+}
+
+// ESCDefinitionClient is implemented by ESCClients which can read, check and update the definition of an
+// environment, and is used by SetFlag, DeleteFlag and CheckDefinition. The Pulumi ESC client implements it.
+type ESCDefinitionClient interface {
+	// GetEnvironment returns the definition of an environment along with its raw YAML definition
+	GetEnvironment(ctx context.Context, org, projectName, envName string) (*esc.EnvironmentDefinition, string, error)
+	// UpdateEnvironmentYaml replaces the definition of an environment and returns the diagnostics of the definition
+	UpdateEnvironmentYaml(ctx context.Context, org, projectName, envName, yaml string) (*esc.EnvironmentDiagnostics, error)
+	// CheckEnvironmentYaml evaluates a YAML definition without saving it and returns its properties, or the
+	// diagnostics of an invalid definition
+	CheckEnvironmentYaml(ctx context.Context, org, yaml string) (*esc.CheckEnvironment, error)
+}
+
+// ESCConditionalDefinitionClient is implemented by ESCDefinitionClients which can update the definition of an
+// environment only if it did not change since it was read, and is used by SetFlag, DeleteFlag and
+// CreateFlagIfMissing so concurrent writes of other clients are not overwritten. The Pulumi ESC client implements it.
+type ESCConditionalDefinitionClient interface {
+	// GetEnvironmentYaml returns the raw YAML definition of an environment along with its ETag
+	GetEnvironmentYaml(ctx context.Context, org, projectName, envName string) (string, string, error)
+	// UpdateEnvironmentYamlIfMatch replaces the definition of an environment if its ETag is the given ETag,
+	// and returns the diagnostics of the definition. It fails with 409 Conflict or 412 Precondition Failed
+	// if the definition changed.
+	UpdateEnvironmentYamlIfMatch(ctx context.Context, org, projectName, envName, yaml, etag string) (*esc.EnvironmentDiagnostics, error)
+}
+
+// sdkClient adapts the Pulumi ESC client to ESCClient
+type sdkClient struct {
+	*esc.EscClient
+}
+
+// newESCClient returns an ESCClient using the Pulumi ESC client with the given configuration
+func newESCClient(conf *esc.Configuration) ESCClient {
+	httpClient := http.DefaultClient
+	if conf.HTTPClient != nil {
+		httpClient = conf.HTTPClient
+	}
+	preconditionClient := *httpClient
+	preconditionClient.Transport = &preconditionTransport{base: httpClient.Transport}
+	conf.HTTPClient = &preconditionClient
+	return &sdkClient{EscClient: esc.NewClient(conf)}
+}
+
+// ifMatchKey is the context key of the ETag sent in the If-Match header of a request
+type ifMatchKey struct{}
+
+// preconditionTransport is a http.RoundTripper setting the If-Match header of the requests whose context
+// carries an ETag, as the Pulumi ESC client does not support conditional requests
+type preconditionTransport struct {
+	base http.RoundTripper
+}
+
+func (t *preconditionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	etag, _ := req.Context().Value(ifMatchKey{}).(string)
+	if etag == "" {
+		return base.RoundTrip(req)
+	}
+	// RoundTrippers must not modify the original request
+	req = req.Clone(req.Context())
+	req.Header.Set("If-Match", etag)
+	return base.RoundTrip(req)
+}
+
+// ReadEnvironmentProperty returns a single value of an open environment session, decoding integers losslessly
+func (c *sdkClient) ReadEnvironmentProperty(ctx context.Context, org, projectName, envName, openEnvID, propPath string) (*esc.Value, interface{}, error) {
+	value, resp, err := c.EscAPI.ReadOpenEnvironmentProperty(ctx, org, projectName, envName, openEnvID).Property(propPath).Execute()
+	if err != nil || value == nil {
+		return value, nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read property value: %w", err)
+	}
+	rawValue, err := decodePropertyValue(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return value, rawValue, nil
+}
+
+// ReadOpenEnvironment returns all the values of an open environment session, decoding integers losslessly
+func (c *sdkClient) ReadOpenEnvironment(ctx context.Context, org, projectName, envName, openEnvID string) (*esc.Environment, map[string]interface{}, error) {
+	env, resp, err := c.EscAPI.ReadOpenEnvironment(ctx, org, projectName, envName, openEnvID).Execute()
+	if err != nil || env == nil || env.Properties == nil {
+		return env, nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read environment values: %w", err)
+	}
+	var properties struct {
+		Properties map[string]interface{} `json:"properties"`
+	}
+	if err := decodeJSON(body, &properties); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode environment values: %w", err)
+	}
+	values := make(map[string]interface{}, len(properties.Properties))
+	for key, value := range properties.Properties {
+		values[key] = primitiveValue(value)
+	}
+	for key, value := range *env.Properties {
+		value.Value = escValues(value.Value)
+		(*env.Properties)[key] = value
+	}
+	return env, values, nil
+}
+
+// escValues converts the nested values of a Pulumi ESC value to esc.Value, as the Pulumi ESC client does
+func escValues(value interface{}) interface{} {
+	switch nested := value.(type) {
+	case map[string]interface{}:
+		values := make(map[string]esc.Value, len(nested))
+		for key, nestedValue := range nested {
+			values[key] = escValue(nestedValue)
+		}
+		return values
+	case []interface{}:
+		for i, nestedValue := range nested {
+			value := escValue(nestedValue)
+			nested[i] = &value
+		}
+		return nested
+	}
+	return value
+}
+
+// escValue decodes a nested Pulumi ESC value
+func escValue(rawValue interface{}) esc.Value {
+	var value esc.Value
+	data, err := json.Marshal(rawValue)
+	if err != nil || json.Unmarshal(data, &value) != nil {
+		return esc.Value{Value: rawValue}
+	}
+	value.Value = escValues(value.Value)
+	return value
+}
+
+// GetEnvironmentYaml returns the raw YAML definition of an environment along with its ETag
+func (c *sdkClient) GetEnvironmentYaml(ctx context.Context, org, projectName, envName string) (string, string, error) {
+	_, resp, err := c.EscAPI.GetEnvironment(ctx, org, projectName, envName).Execute()
+	if err != nil {
+		return "", "", err
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read environment definition: %w", err)
+	}
+	return string(body), resp.Header.Get("ETag"), nil
+}
+
+// UpdateEnvironmentYamlIfMatch replaces the definition of an environment if its ETag is the given ETag.
+// The definition is replaced unconditionally if the ETag is empty.
+func (c *sdkClient) UpdateEnvironmentYamlIfMatch(ctx context.Context, org, projectName, envName, yaml, etag string) (*esc.EnvironmentDiagnostics, error) {
+	ctx = context.WithValue(ctx, ifMatchKey{}, etag)
+	diagnostics, _, err := c.EscAPI.UpdateEnvironmentYaml(ctx, org, projectName, envName).Body(yaml).Execute()
+	return diagnostics, err
+}
+
+func (c *sdkClient) ListEnvironmentRevisions(ctx context.Context, org, projectName, envName string, count int32) ([]esc.EnvironmentRevision, error) {
+	revisions, _, err := c.EscAPI.ListEnvironmentRevisions(ctx, org, projectName, envName).Count(count).Execute()
+	return revisions, err
+}
+
+// CheckEnvironmentYaml evaluates a YAML definition without saving it. The diagnostics of an invalid definition
+// are returned without an error, and errors other than 400 Bad Request are returned as is, as the Pulumi ESC
+// client expects every error to carry diagnostics.
+func (c *sdkClient) CheckEnvironmentYaml(ctx context.Context, org, yaml string) (*esc.CheckEnvironment, error) {
+	check, _, err := c.EscAPI.CheckEnvironmentYaml(ctx, org).Body(yaml).Execute()
+	var apiErr *esc.GenericOpenAPIError
+	if err != nil && errors.As(err, &apiErr) {
+		if model, ok := apiErr.Model().(esc.CheckEnvironment); ok {
+			return &model, nil
+		}
+	}
+	return check, err
+}
+
+// WithESCClient makes the provider use the given client instead of the Pulumi ESC client.
+// WithCustomBackendUrl, WithHTTPClient and WithApplicationID have no effect on the given client.
+func WithESCClient(client ESCClient) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.escClient = client
+	}
+}
+
+// Client returns the Pulumi ESC client of the provider, so adjacent operations such as listing or updating
+// environments use the same transport, including its throttling, rate and concurrency limits. Requests must
+// be made with a context returned by AuthContext. It returns nil if the client set using WithESCClient does
+// not wrap the Pulumi ESC client.
+func (p *PulumiESCProvider) Client() *esc.EscClient {
+	if client, ok := p.escClient.(*sdkClient); ok {
+		return client.EscClient
+	}
+	return nil
+}
+
+// AuthContext returns a context derived from ctx which carries the access token of the provider, to make
+// requests using the client returned by Client
+func (p *PulumiESCProvider) AuthContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &credentialsContext{Context: ctx, apiKeys: p.escAuthCtx.Value(esc.ContextAPIKeys)}
+}
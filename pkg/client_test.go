@@ -0,0 +1,81 @@
+package pulumi
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockESCClient is an in-memory ESCClient serving plain values
+type mockESCClient struct {
+	values   map[string]interface{}
+	revision int32
+	opened   []string
+}
+
+func (c *mockESCClient) OpenEnvironment(ctx context.Context, org, projectName, envName string) (*esc.OpenEnvironment, error) {
+	c.opened = append(c.opened, "latest")
+	return &esc.OpenEnvironment{Id: "session-id"}, nil
+}
+
+func (c *mockESCClient) OpenEnvironmentAtVersion(ctx context.Context, org, projectName, envName, version string) (*esc.OpenEnvironment, error) {
+	c.opened = append(c.opened, version)
+	return &esc.OpenEnvironment{Id: "session-id"}, nil
+}
+
+func (c *mockESCClient) ReadOpenEnvironment(ctx context.Context, org, projectName, envName, openEnvID string) (*esc.Environment, map[string]interface{}, error) {
+	return &esc.Environment{}, c.values, nil
+}
+
+func (c *mockESCClient) ReadEnvironmentProperty(ctx context.Context, org, projectName, envName, openEnvID, propPath string) (*esc.Value, interface{}, error) {
+	value, ok := c.values[propPath]
+	if !ok {
+		return nil, nil, errors.New("key not found")
+	}
+	return &esc.Value{Value: value, Trace: esc.Trace{Def: &esc.Range{Environment: envName}}}, value, nil
+}
+
+func (c *mockESCClient) ListEnvironmentRevisions(ctx context.Context, org, projectName, envName string, count int32) ([]esc.EnvironmentRevision, error) {
+	return []esc.EnvironmentRevision{{Number: c.revision}}, nil
+}
+
+func TestWithESCClient(t *testing.T) {
+	client := &mockESCClient{values: map[string]interface{}{BOOL_FLAG_KEY: BOOL_FLAG_VALUE}, revision: 3}
+	p, err := NewPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME, "token", WithESCClient(client))
+	assert.NoError(t, err)
+	defer p.Shutdown()
+
+	assert.Equal(t, []string{"3"}, client.opened, "the environment must be opened at its latest revision")
+	details := p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, DEFAULT_BOOL_FLAG_VALUE, openfeature.FlattenedContext{})
+	assert.Equal(t, BOOL_FLAG_VALUE, details.Value)
+	assert.Equal(t, openfeature.StaticReason, details.Reason)
+
+	flags, err := p.ListFlags(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []FlagInfo{{Key: BOOL_FLAG_KEY, Type: FlagType_Bool}}, flags)
+}
+
+func TestClient(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{BOOL_FLAG_KEY: BOOL_FLAG_VALUE})
+	server.SetRevision(5)
+	server.SetAccessToken("token")
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	assert.NoError(t, p.initialise(ctx))
+
+	client := p.Client()
+	if assert.NotNil(t, client) {
+		requests := server.Requests()
+		revisions, _, err := client.EscAPI.ListEnvironmentRevisions(p.AuthContext(ctx), "test-org", PROJECT_NAME, ENV_NAME).Count(1).Execute()
+		assert.NoError(t, err, "requests must carry the access token of the provider")
+		assert.Equal(t, int32(5), revisions[0].Number)
+		assert.Equal(t, requests+1, server.Requests(), "requests must use the transport of the provider")
+	}
+
+	p = newPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME, WithESCClient(&mockESCClient{}))
+	assert.Nil(t, p.Client(), "custom clients do not wrap the Pulumi ESC client")
+}
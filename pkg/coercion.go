@@ -0,0 +1,98 @@
+package pulumi
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// booleanSpellings are the spellings of booleans accepted by WithBooleanCoercion, in lower case
+var booleanSpellings = map[string]bool{
+	"true":  true,
+	"false": false,
+	"on":    true,
+	"off":   false,
+	"yes":   true,
+	"no":    false,
+	"1":     true,
+	"0":     false,
+}
+
+// WithBooleanCoercion makes BooleanEvaluation accept the common spellings of booleans written by operators,
+// "on"/"off", "yes"/"no", "1"/"0" and "true"/"false" strings in any case, and the numbers 1 and 0, instead of
+// failing with TYPE_MISMATCH. Other strings still fail with TYPE_MISMATCH.
+func WithBooleanCoercion() ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.boolCoercion = true
+	}
+}
+
+// WithNumericStrings makes IntEvaluation and FloatEvaluation parse numeric strings, e.g. "8080" or "0.25",
+// as values migrated from environment variables are often stored as strings. Surrounding spaces are ignored,
+// and strings which are not decimal numbers, or not integers for IntEvaluation, still fail with TYPE_MISMATCH.
+func WithNumericStrings() ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.numericStrings = true
+	}
+}
+
+// coerceValue converts a raw value to the type of the evaluation if coercion is enabled for the type, and
+// returns it as is otherwise
+func (p *PulumiESCProvider) coerceValue(rawValue interface{}, flagType FlagType) interface{} {
+	switch {
+	case flagType == FlagType_Bool && p.boolCoercion:
+		if value, ok := coerceBool(rawValue); ok {
+			return value
+		}
+	case flagType == FlagType_Integer && p.numericStrings:
+		if value, ok := parseIntString(rawValue); ok {
+			return value
+		}
+	case flagType == FlagType_Float && p.numericStrings:
+		if value, ok := parseFloatString(rawValue); ok {
+			return value
+		}
+	}
+	return rawValue
+}
+
+// coerceBool converts a spelling of a boolean to bool
+func coerceBool(rawValue interface{}) (bool, bool) {
+	if number, ok := floatValue(rawValue); ok && (number == 0 || number == 1) {
+		return number == 1, true
+	}
+	spelling, ok := rawValue.(string)
+	if !ok {
+		return false, false
+	}
+	value, ok := booleanSpellings[strings.ToLower(strings.TrimSpace(spelling))]
+	return value, ok
+}
+
+// parseIntString parses a string of a decimal integer. Integers are returned as json.Number, like the
+// integers float64 can not represent exactly.
+func parseIntString(rawValue interface{}) (json.Number, bool) {
+	value, ok := rawValue.(string)
+	if !ok {
+		return "", false
+	}
+	value = strings.TrimSpace(value)
+	if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+		return "", false
+	}
+	return json.Number(value), true
+}
+
+// parseFloatString parses a string of a finite decimal number
+func parseFloatString(rawValue interface{}) (float64, bool) {
+	value, ok := rawValue.(string)
+	if !ok {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+		return 0, false
+	}
+	return number, true
+}
@@ -0,0 +1,76 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBooleanCoercion(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"ON":      "on",
+		"OFF":     "Off",
+		"YES":     " YES ",
+		"NO":      "no",
+		"ONE":     "1",
+		"ZERO":    0,
+		"TRUE":    "true",
+		"BOOL":    true,
+		"INVALID": "maybe",
+		"TWO":     2,
+	})
+	ctx := context.Background()
+
+	p := newTestProvider(t, server, WithBooleanCoercion())
+	require.NoError(t, p.initialise(ctx))
+	for key, want := range map[string]bool{"ON": true, "OFF": false, "YES": true, "NO": false, "ONE": true, "ZERO": false, "TRUE": true, "BOOL": true} {
+		got := p.BooleanEvaluation(ctx, key, !want, nil)
+		assert.NoError(t, got.Error(), key)
+		assert.Equal(t, want, got.Value, key)
+	}
+	assert.Equal(t, openfeature.TypeMismatchCode, p.BooleanEvaluation(ctx, "INVALID", false, nil).ResolutionDetail().ErrorCode)
+	assert.Equal(t, openfeature.TypeMismatchCode, p.BooleanEvaluation(ctx, "TWO", false, nil).ResolutionDetail().ErrorCode)
+	assert.Equal(t, "on", p.StringEvaluation(ctx, "ON", "", nil).Value, "strings must not be coerced for other types")
+
+	p = newTestProvider(t, server)
+	require.NoError(t, p.initialise(ctx))
+	assert.Equal(t, openfeature.TypeMismatchCode, p.BooleanEvaluation(ctx, "ON", false, nil).ResolutionDetail().ErrorCode)
+}
+
+func TestWithNumericStrings(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"PORT":        "8080",
+		"PADDED":      " 42 ",
+		"HUGE":        "9007199254740993",
+		"RATIO":       "0.25",
+		"NEGATIVE":    "-1e3",
+		"NOT_INTEGER": "1.5",
+		"NOT_NUMBER":  "eighty",
+		"INFINITY":    "Inf",
+	})
+	ctx := context.Background()
+
+	p := newTestProvider(t, server, WithNumericStrings())
+	require.NoError(t, p.initialise(ctx))
+	for key, want := range map[string]int64{"PORT": 8080, "PADDED": 42, "HUGE": 9007199254740993} {
+		got := p.IntEvaluation(ctx, key, 0, nil)
+		assert.NoError(t, got.Error(), key)
+		assert.Equal(t, want, got.Value, key)
+	}
+	for key, want := range map[string]float64{"RATIO": 0.25, "NEGATIVE": -1000, "PORT": 8080} {
+		got := p.FloatEvaluation(ctx, key, 0, nil)
+		assert.NoError(t, got.Error(), key)
+		assert.Equal(t, want, got.Value, key)
+	}
+	assert.Equal(t, openfeature.TypeMismatchCode, p.IntEvaluation(ctx, "NOT_INTEGER", 0, nil).ResolutionDetail().ErrorCode)
+	assert.Equal(t, openfeature.TypeMismatchCode, p.IntEvaluation(ctx, "NOT_NUMBER", 0, nil).ResolutionDetail().ErrorCode)
+	assert.Equal(t, openfeature.TypeMismatchCode, p.FloatEvaluation(ctx, "INFINITY", 0, nil).ResolutionDetail().ErrorCode)
+	assert.Equal(t, "8080", p.StringEvaluation(ctx, "PORT", "", nil).Value, "strings must still be served as strings")
+
+	p = newTestProvider(t, server)
+	require.NoError(t, p.initialise(ctx))
+	assert.Equal(t, openfeature.TypeMismatchCode, p.IntEvaluation(ctx, "PORT", 0, nil).ResolutionDetail().ErrorCode)
+}
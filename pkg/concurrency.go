@@ -0,0 +1,61 @@
+package pulumi
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// WithMaxConcurrentRequests limits the number of Pulumi ESC API requests in flight at the same time,
+// e.g. the reads of a burst of cold evaluations, to n. Requests over the limit wait for a slot until
+// their context is done. Providers created by a ClientPool with this option share the limit.
+func WithMaxConcurrentRequests(n int) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if n > 0 {
+			p.requestSlots = make(chan struct{}, n)
+		}
+	}
+}
+
+// concurrencyTransport is a http.RoundTripper which limits the number of requests in flight. A request
+// holds its slot until its response body is closed or read to the end, as the connection is in use until then.
+type concurrencyTransport struct {
+	base  http.RoundTripper
+	slots chan struct{}
+}
+
+func (t *concurrencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case t.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		<-t.slots
+		return nil, err
+	}
+	resp.Body = &slotBody{ReadCloser: resp.Body, release: func() { <-t.slots }}
+	return resp, nil
+}
+
+// slotBody is a response body releasing the slot of its request once
+type slotBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *slotBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.release)
+	}
+	return n, err
+}
+
+func (b *slotBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
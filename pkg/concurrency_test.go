@@ -0,0 +1,72 @@
+package pulumi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMaxConcurrentRequests(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			max := maxInFlight.Load()
+			if current <= max || maxInFlight.CompareAndSwap(max, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		_, _ = io.WriteString(w, "{}")
+	}))
+	defer server.Close()
+
+	p := newPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME, WithMaxConcurrentRequests(2))
+	client := p.newAPIHTTPClient(nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if assert.NoError(t, err) {
+				_, _ = io.ReadAll(resp.Body)
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), maxInFlight.Load())
+	assert.Empty(t, p.requestSlots, "all the slots must be released")
+}
+
+func TestWithMaxConcurrentRequests_contextDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	p := newPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME, WithMaxConcurrentRequests(1))
+	client := p.newAPIHTTPClient(nil)
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the request must wait for the slot of the unclosed response")
+
+	resp.Body.Close()
+	resp, err = client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
@@ -0,0 +1,45 @@
+package pulumi
+
+import (
+	"context"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// ContextEnricher returns the evaluation context of an evaluation enriched with standard attributes, e.g. the
+// pod name, region or build version of the service
+type ContextEnricher func(ctx context.Context, evalCtx openfeature.FlattenedContext) openfeature.FlattenedContext
+
+// WithContextEnricher enriches the evaluation context of every evaluation using the enricher before targeting
+// rules, rollouts and environment overrides run, so standard attributes do not have to be set by every caller.
+// The enricher is given a copy of the evaluation context, which it may modify and return, and decides whether
+// its attributes override the ones of the evaluation. Multiple enrichers are applied in order.
+func WithContextEnricher(enricher ContextEnricher) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if enricher != nil {
+			p.enrichers = append(p.enrichers, enricher)
+		}
+	}
+}
+
+// enrichContext returns the evaluation context enriched by the enrichers. The prerequisites of a flag are
+// evaluated with the context already enriched for the flag.
+func (p *PulumiESCProvider) enrichContext(ctx context.Context, evalCtx openfeature.FlattenedContext) openfeature.FlattenedContext {
+	if len(p.enrichers) == 0 {
+		return evalCtx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if ctx.Value(prerequisiteChainKey{}) != nil {
+		return evalCtx
+	}
+	for _, enrich := range p.enrichers {
+		enriched := make(openfeature.FlattenedContext, len(evalCtx))
+		for key, value := range evalCtx {
+			enriched[key] = value
+		}
+		evalCtx = enrich(ctx, enriched)
+	}
+	return evalCtx
+}
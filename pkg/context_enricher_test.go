@@ -0,0 +1,51 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithContextEnricher(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"CHECKOUT_ENABLED": map[string]interface{}{
+			"variants":       map[string]interface{}{"on": true, "off": false},
+			"defaultVariant": "off",
+			"rules":          []interface{}{map[string]interface{}{"when": map[string]interface{}{"region": "eu-west-1"}, "variant": "on"}},
+		},
+		"NEW_CHECKOUT": map[string]interface{}{
+			"value":         true,
+			"offValue":      false,
+			"prerequisites": []interface{}{"CHECKOUT_ENABLED"},
+		},
+	})
+	calls := 0
+	p := newTestProvider(t, server, WithFlagDefinitions(),
+		WithContextEnricher(func(ctx context.Context, evalCtx openfeature.FlattenedContext) openfeature.FlattenedContext {
+			calls++
+			evalCtx["region"] = "eu-west-1"
+			return evalCtx
+		}),
+		WithContextEnricher(func(ctx context.Context, evalCtx openfeature.FlattenedContext) openfeature.FlattenedContext {
+			assert.Equal(t, "eu-west-1", evalCtx["region"], "enrichers must be applied in order")
+			evalCtx["build"] = "1.4.2"
+			return evalCtx
+		}))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	evalCtx := openfeature.FlattenedContext{openfeature.TargetingKey: "user-1"}
+	got := p.BooleanEvaluation(ctx, "CHECKOUT_ENABLED", false, evalCtx)
+	assert.NoError(t, got.Error())
+	assert.True(t, got.Value, "targeting rules must see the enriched attributes")
+	assert.Equal(t, openfeature.TargetingMatchReason, got.Reason)
+	assert.Equal(t, openfeature.FlattenedContext{openfeature.TargetingKey: "user-1"}, evalCtx, "the evaluation context must not be modified")
+
+	calls = 0
+	got = p.BooleanEvaluation(ctx, "NEW_CHECKOUT", false, nil)
+	assert.True(t, got.Value, "prerequisites must be evaluated with the enriched context")
+	assert.Equal(t, 1, calls, "prerequisites must not be enriched again")
+}
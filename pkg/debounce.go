@@ -0,0 +1,118 @@
+package pulumi
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// WithConfigChangeDebounce coalesces the PROVIDER_CONFIGURATION_CHANGED events of changes made in quick
+// succession, e.g. an operator editing several flags one after the other, into a single event listing all
+// the changed flags. The event is emitted once no other change was detected during the quiet period, or
+// at the latest after the maximum wait set using WithConfigChangeMaxWait, so changes made continuously
+// are still notified.
+func WithConfigChangeDebounce(quietPeriod time.Duration) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if quietPeriod > 0 {
+			p.configChanges = &configChangeDebouncer{quietPeriod: quietPeriod}
+		}
+	}
+}
+
+// WithConfigChangeMaxWait bounds the delay of the PROVIDER_CONFIGURATION_CHANGED events debounced using
+// WithConfigChangeDebounce, which are emitted at the latest after maxWait since the first coalesced change.
+// It defaults to ten times the quiet period.
+func WithConfigChangeMaxWait(maxWait time.Duration) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if maxWait > 0 {
+			p.configChangeMaxWait = maxWait
+		}
+	}
+}
+
+// configChangeDebouncer accumulates the flags changed during the quiet period
+type configChangeDebouncer struct {
+	quietPeriod time.Duration
+	mu          sync.Mutex
+	timer       *time.Timer
+	firstChange time.Time
+	message     string
+	flags       map[string]bool
+}
+
+// emitConfigChange emits a PROVIDER_CONFIGURATION_CHANGED event for the changed flags, after the quiet
+// period if the events are debounced
+func (p *PulumiESCProvider) emitConfigChange(message string, flags []string) {
+	if p.configChanges == nil {
+		p.emit(configChangeEvent(message, flags))
+		return
+	}
+	d := p.configChanges
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.flags == nil {
+		d.flags = map[string]bool{}
+		d.firstChange = time.Now()
+	}
+	for _, flag := range flags {
+		d.flags[flag] = true
+	}
+	// The message of the most recent change describes the coalesced event
+	d.message = message
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	maxWait := p.configChangeMaxWait
+	if maxWait == 0 {
+		maxWait = 10 * d.quietPeriod
+	}
+	delay := min(d.quietPeriod, max(maxWait-time.Since(d.firstChange), 0))
+	d.timer = time.AfterFunc(delay, func() { p.flushConfigChanges() })
+}
+
+// flushConfigChanges emits a single event for the flags changed since the last event
+func (p *PulumiESCProvider) flushConfigChanges() {
+	d := p.configChanges
+	d.mu.Lock()
+	flags := make([]string, 0, len(d.flags))
+	for flag := range d.flags {
+		flags = append(flags, flag)
+	}
+	message := d.message
+	d.flags, d.timer, d.message = nil, nil, ""
+	d.mu.Unlock()
+	if message == "" {
+		// The changes were emitted by a timer stopped too late to be cancelled
+		return
+	}
+	sort.Strings(flags)
+	p.emit(configChangeEvent(message, flags))
+}
+
+// stopConfigChanges drops the pending changes, e.g. when the provider is shut down
+func (p *PulumiESCProvider) stopConfigChanges() {
+	if p.configChanges == nil {
+		return
+	}
+	d := p.configChanges
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.flags, d.timer, d.message = nil, nil, ""
+}
+
+// configChangeEvent returns a PROVIDER_CONFIGURATION_CHANGED event for the changed flags
+func configChangeEvent(message string, flags []string) openfeature.Event {
+	return openfeature.Event{
+		ProviderName: ProviderName,
+		EventType:    openfeature.ProviderConfigChange,
+		ProviderEventDetails: openfeature.ProviderEventDetails{
+			Message:     message,
+			FlagChanges: flags,
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package pulumi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithConfigChangeDebounce(t *testing.T) {
+	p := newPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME, WithConfigChangeDebounce(50*time.Millisecond))
+	p.emitConfigChange("pulumi esc environment changed", []string{"THEME"})
+	p.emitConfigChange("pulumi esc environment changed", []string{"DEBUG_MODE", "THEME"})
+	p.emitConfigChange("pulumi esc environment changed", []string{"configs.MAX_RETRIES"})
+	assert.Empty(t, p.events, "no event must be emitted during the quiet period")
+
+	select {
+	case event := <-p.EventChannel():
+		assert.Equal(t, openfeature.ProviderConfigChange, event.EventType)
+		assert.Equal(t, []string{"DEBUG_MODE", "THEME", "configs.MAX_RETRIES"}, event.FlagChanges)
+	case <-time.After(time.Second):
+		t.Fatal("missing configuration change event")
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, p.events, "the changes must be emitted in a single event")
+
+	p.emitConfigChange("pulumi esc environment changed", []string{"THEME"})
+	p.stopConfigChanges()
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, p.events, "pending changes must be dropped when the provider is shut down")
+}
+
+func TestEmitConfigChange_withoutDebounce(t *testing.T) {
+	p := newPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME)
+	p.emitConfigChange("pulumi esc environment changed", []string{"THEME"})
+	p.emitConfigChange("pulumi esc environment changed", []string{"DEBUG_MODE"})
+	assert.Len(t, p.events, 2)
+}
+
+func TestWithConfigChangeMaxWait(t *testing.T) {
+	p := newPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME,
+		WithConfigChangeDebounce(50*time.Millisecond), WithConfigChangeMaxWait(150*time.Millisecond))
+	started := time.Now()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Changes made continuously never leave a quiet period
+		for time.Since(started) < 400*time.Millisecond {
+			p.emitConfigChange("pulumi esc environment changed", []string{"THEME"})
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	defer func() { <-done }()
+
+	select {
+	case event := <-p.EventChannel():
+		assert.Equal(t, []string{"THEME"}, event.FlagChanges)
+		assert.Less(t, time.Since(started), 300*time.Millisecond, "the event must be emitted after the maximum wait")
+	case <-time.After(time.Second):
+		t.Fatal("continuous changes must be emitted after the maximum wait")
+	}
+}
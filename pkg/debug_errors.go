@@ -0,0 +1,95 @@
+package pulumi
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+const (
+	// httpStatusMetadataKey is the FlagMetadata key of the HTTP status of a failed Pulumi ESC API request
+	httpStatusMetadataKey = "httpStatus"
+	// errorBodyMetadataKey is the FlagMetadata key of the redacted error body of a failed Pulumi ESC API request
+	errorBodyMetadataKey = "errorBody"
+	// maxDebugErrorBodySize is the maximum size of the error bodies reported in FlagMetadata
+	maxDebugErrorBodySize = 2048
+	// redactedValue replaces the sensitive values of the error bodies
+	redactedValue = "[REDACTED]"
+)
+
+// sensitiveFields matches the names of the fields of error bodies whose values are redacted
+var sensitiveFields = regexp.MustCompile(`(?i)token|secret|password|authorization|credential|cookie`)
+
+// sensitiveText matches the sensitive values of error bodies which are not JSON, e.g. "token=..." or
+// "Authorization: Bearer ..."
+var sensitiveText = regexp.MustCompile(`(?i)((?:token|secret|password|authorization|credential|cookie)\w*["']?\s*[:=]\s*)(?:(?:bearer|token)\s+)?[^\s,;&"']+`)
+
+// WithDebugErrors reports the HTTP status and the raw error body of failed Pulumi ESC API requests in the
+// "httpStatus" and "errorBody" FlagMetadata keys of failed evaluations, to debug intermittent errors in
+// production. Values of fields such as tokens, secrets and passwords are redacted from the error bodies.
+func WithDebugErrors() ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.debugErrors = true
+	}
+}
+
+// addDebugErrorMetadata adds the HTTP status and the redacted error body of the error of a Pulumi ESC API
+// request to the metadata of its resolution details
+func addDebugErrorMetadata(err error, resolutionDetails *openfeature.ProviderResolutionDetail) {
+	var genErr *esc.GenericOpenAPIError
+	if !errors.As(err, &genErr) {
+		return
+	}
+	metadata := make(openfeature.FlagMetadata, len(resolutionDetails.FlagMetadata)+2)
+	for key, value := range resolutionDetails.FlagMetadata {
+		metadata[key] = value
+	}
+	if statusCode := apiStatusCode(genErr); statusCode != 0 {
+		metadata[httpStatusMetadataKey] = statusCode
+	}
+	metadata[errorBodyMetadataKey] = redactErrorBody(genErr.Body())
+	resolutionDetails.FlagMetadata = metadata
+}
+
+// redactErrorBody returns the error body with the values of its sensitive fields redacted, truncated to
+// maxDebugErrorBodySize
+func redactErrorBody(body []byte) string {
+	var redacted string
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err == nil {
+		encoded, _ := json.Marshal(redactJSON(value))
+		redacted = string(encoded)
+	} else {
+		redacted = sensitiveText.ReplaceAllString(strings.TrimSpace(string(body)), "${1}"+redactedValue)
+	}
+	if len(redacted) > maxDebugErrorBodySize {
+		redacted = strings.ToValidUTF8(redacted[:maxDebugErrorBodySize], "") + "…"
+	}
+	return redacted
+}
+
+// redactJSON redacts the values of the sensitive fields of a decoded JSON value, and the sensitive values
+// embedded in its strings
+func redactJSON(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if sensitiveFields.MatchString(key) {
+				value[key] = redactedValue
+			} else {
+				value[key] = redactJSON(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactJSON(item)
+		}
+	case string:
+		return sensitiveText.ReplaceAllString(value, "${1}"+redactedValue)
+	}
+	return value
+}
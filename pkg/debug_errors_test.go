@@ -0,0 +1,72 @@
+package pulumi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDebugErrors(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+
+	p := newTestProvider(t, server, WithDebugErrors())
+	require.NoError(t, p.initialise(ctx))
+	server.SetUnavailable(true)
+	details := p.BooleanEvaluation(ctx, "DEBUG_MODE", false, openfeature.FlattenedContext{})
+	require.Error(t, details.Error())
+	assert.Equal(t, http.StatusServiceUnavailable, details.FlagMetadata[httpStatusMetadataKey])
+	assert.JSONEq(t, `{"code":503,"message":"service unavailable"}`, details.FlagMetadata[errorBodyMetadataKey].(string))
+	assert.Equal(t, string(ErrorType_ProviderError), details.FlagMetadata[errorTypeMetadataKey])
+	server.SetUnavailable(false)
+
+	p = newTestProvider(t, server)
+	require.NoError(t, p.initialise(ctx))
+	server.SetUnavailable(true)
+	details = p.BooleanEvaluation(ctx, "DEBUG_MODE", false, openfeature.FlattenedContext{})
+	require.Error(t, details.Error())
+	assert.NotContains(t, details.FlagMetadata, httpStatusMetadataKey)
+	assert.NotContains(t, details.FlagMetadata, errorBodyMetadataKey)
+}
+
+func TestRedactErrorBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "json fields",
+			body: `{"code":401,"message":"unauthorized","accessToken":"pul-123","details":{"clientSecret":"s3cr3t"}}`,
+			want: `{"accessToken":"[REDACTED]","code":401,"details":{"clientSecret":"[REDACTED]"},"message":"unauthorized"}`,
+		},
+		{
+			name: "json strings",
+			body: `{"code":400,"message":"invalid request: token=pul-123"}`,
+			want: `{"code":400,"message":"invalid request: token=[REDACTED]"}`,
+		},
+		{
+			name: "text",
+			body: "upstream error\nAuthorization: Bearer pul-123\npassword=hunter2&user=me",
+			want: "upstream error\nAuthorization: [REDACTED]\npassword=[REDACTED]&user=me",
+		},
+		{
+			name: "empty",
+			body: "",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, redactErrorBody([]byte(tt.body)))
+		})
+	}
+
+	redacted := redactErrorBody([]byte(strings.Repeat("a", 2*maxDebugErrorBodySize)))
+	assert.Equal(t, strings.Repeat("a", maxDebugErrorBodySize)+"…", redacted)
+}
@@ -0,0 +1,328 @@
+package pulumi
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+const (
+	// PrerequisiteFailedReason is the reason of a flag served its off value because one of its prerequisites
+	// did not evaluate to true
+	PrerequisiteFailedReason openfeature.Reason = "PREREQUISITE_FAILED"
+	// prerequisiteMetadataKey is the flag metadata key of the prerequisite which failed
+	prerequisiteMetadataKey = "prerequisite"
+	// maxPrerequisiteDepth is the maximum length of a chain of prerequisites
+	maxPrerequisiteDepth = 10
+)
+
+// flagDefinitionFields are the fields of a structured flag definition
+var flagDefinitionFields = map[string]bool{
+	"value":          true,
+	"offValue":       true,
+	"prerequisites":  true,
+	"rollout":        true,
+	"salt":           true,
+	"variants":       true,
+	"defaultVariant": true,
+	"distribution":   true,
+	"rules":          true,
+	"metadata":       true,
+	"enabled":        true,
+	"deprecated":     true,
+	"archived":       true,
+	"ttl":            true,
+}
+
+// flagDefinition is a structured flag definition, an object of the environment such as
+//
+//	NEW_CHECKOUT:
+//	  value: true
+//	  offValue: false
+//	  prerequisites: [CHECKOUT_ENABLED]
+type flagDefinition struct {
+	value         interface{}
+	offValue      interface{}
+	prerequisites []string
+	// rollout is the percentage of the targeting keys served the value, or nil if it is served to all of them
+	rollout *float64
+	// salt is the salt of the bucketing input of the rollout, or empty for the flag key
+	salt string
+	// variants are the values of the variants of a multi-variate flag, by name
+	variants map[string]interface{}
+	// distribution are the weights of the variants of a multi-variate flag, sorted by variant name
+	distribution []variantWeight
+	// defaultVariant is the variant served by a multi-variate flag without distribution
+	defaultVariant string
+	// rules are the targeting rules of a multi-variate flag, evaluated in order
+	rules []targetingRule
+	// metadata is the metadata added to the flag metadata of the evaluations of the flag
+	metadata map[string]interface{}
+	disabled bool
+	// deprecated is the deprecation message of the flag, or empty if it is not deprecated
+	deprecated string
+	// ttl is the time the value of the flag is cached for, or 0 for the TTL of WithCache
+	ttl time.Duration
+}
+
+// prerequisiteChainKey is the context key of the flags whose prerequisites are being evaluated
+type prerequisiteChainKey struct{}
+
+// WithFlagDefinitions resolves the objects of the environment which have a value or variants key and only the
+// fields of a structured flag definition as flags, instead of as plain objects:
+//   - value is the value of the flag
+//   - offValue is the value served when a prerequisite fails, or the default value of the evaluation if omitted
+//   - prerequisites are the keys of boolean flags which must all evaluate to true for the value to be served.
+//     Otherwise the off value is served with the PREREQUISITE_FAILED reason and the key of the failed
+//     prerequisite in the prerequisite flag metadata.
+//   - rollout is the percentage of the targeting keys served the value, the others being served the off value,
+//     with the SPLIT reason and the "on" or "off" variant. See WithStickyBucketing.
+//   - variants are the values of the variants of a multi-variate flag by name, and distribution their weights,
+//     e.g. 50, 25 and 25 for the control, v1 and v2 variants. Targeting keys are assigned a variant in proportion
+//     to the weights, and served its value with the SPLIT reason and the name of the variant. It can not be
+//     combined with value and rollout.
+//   - defaultVariant is the variant served by a multi-variate flag instead of a distribution
+//   - rules are targeting rules of a multi-variate flag, evaluated in order before the distribution or the
+//     default variant. A rule has when conditions, matched when every attribute of the evaluation context is
+//     equal to the value or one of the list of values of its condition, and the variant it serves with the
+//     TARGETING_MATCH reason. If no rule matches, the default variant is served with the DEFAULT reason.
+//   - metadata are strings, numbers and booleans added to the flag metadata of the evaluations, e.g. the owner
+//     of the flag. The metadata set by the provider takes precedence.
+//   - enabled false disables the flag, which is then resolved to the default value of the evaluation with the
+//     DISABLED reason
+//   - archived true marks a flag which is no longer used, kept for reference, which is disabled
+//   - deprecated marks the flag as deprecated with a message, e.g. "use NEW_CHECKOUT_V2", reported in the
+//     deprecated flag metadata. See WithDeprecationWarnings.
+//   - salt is the salt of the bucketing input of the rollout or distribution, which defaults to the flag key.
+//     See WithBucketingHash.
+//   - ttl is the time the value of the flag is cached for, e.g. "5s" for a volatile flag or "1h" for a stable
+//     one, instead of the TTL of WithCache. It has no effect without WithCache or with its snapshot-only policy.
+//
+// Definitions with invalid fields fail with PARSE_ERROR.
+func WithFlagDefinitions() ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.flagDefinitions = true
+	}
+}
+
+// parseFlagDefinition returns the structured flag definition of a raw value, or nil if it is not one
+func parseFlagDefinition(rawValue interface{}) (*flagDefinition, error) {
+	fields, ok := rawValue.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	_, hasValue := fields["value"]
+	_, hasVariants := fields["variants"]
+	if !hasValue && !hasVariants {
+		return nil, nil
+	}
+	for field := range fields {
+		if !flagDefinitionFields[field] {
+			return nil, nil
+		}
+	}
+	definition := &flagDefinition{value: fields["value"], offValue: fields["offValue"]}
+	if prerequisites, ok := fields["prerequisites"]; ok {
+		keys, ok := stringSliceValue(prerequisites)
+		if !ok {
+			return nil, fmt.Errorf("prerequisites must be a list of flag keys")
+		}
+		definition.prerequisites = keys
+	}
+	if rollout, ok := fields["rollout"]; ok {
+		percentage, ok := floatValue(rollout)
+		if !ok || percentage < 0 || percentage > 100 {
+			return nil, fmt.Errorf("rollout must be a percentage between 0 and 100")
+		}
+		definition.rollout = &percentage
+	}
+	if enabled, ok := fields["enabled"]; ok {
+		value, ok := enabled.(bool)
+		if !ok {
+			return nil, fmt.Errorf("enabled must be a boolean")
+		}
+		definition.disabled = !value
+	}
+	if archived, ok := fields["archived"]; ok {
+		value, ok := archived.(bool)
+		if !ok {
+			return nil, fmt.Errorf("archived must be a boolean")
+		}
+		definition.disabled = definition.disabled || value
+	}
+	if deprecated, ok := fields["deprecated"]; ok {
+		if definition.deprecated, ok = deprecated.(string); !ok || definition.deprecated == "" {
+			return nil, fmt.Errorf("deprecated must be a non-empty message")
+		}
+	}
+	if salt, ok := fields["salt"]; ok {
+		if definition.salt, ok = salt.(string); !ok {
+			return nil, fmt.Errorf("salt must be a string")
+		}
+	}
+	if ttl, ok := fields["ttl"]; ok {
+		duration, isString := ttl.(string)
+		var err error
+		if definition.ttl, err = time.ParseDuration(duration); !isString || err != nil || definition.ttl <= 0 {
+			return nil, fmt.Errorf("ttl must be a positive duration, e.g. \"30s\"")
+		}
+	}
+	if hasVariants {
+		if hasValue || definition.rollout != nil {
+			return nil, fmt.Errorf("variants can not be combined with value and rollout")
+		}
+		if err := definition.parseVariants(fields["variants"], fields["distribution"], fields["defaultVariant"]); err != nil {
+			return nil, err
+		}
+	} else if _, ok := fields["defaultVariant"]; ok {
+		return nil, fmt.Errorf("defaultVariant requires variants")
+	}
+	if rules, ok := fields["rules"]; ok {
+		var err error
+		if definition.rules, err = parseRules(rules, definition.variants); err != nil {
+			return nil, err
+		}
+	}
+	if metadata, ok := fields["metadata"]; ok {
+		var err error
+		if definition.metadata, err = parseMetadata(metadata); err != nil {
+			return nil, err
+		}
+	}
+	return definition, nil
+}
+
+// parseVariants parses the variants and the distribution or the default variant of a multi-variate flag
+func (d *flagDefinition) parseVariants(rawVariants, rawDistribution, rawDefaultVariant interface{}) error {
+	variants, ok := rawVariants.(map[string]interface{})
+	if !ok || len(variants) == 0 {
+		return fmt.Errorf("variants must be an object of the values of the variants by name")
+	}
+	if rawDefaultVariant != nil {
+		if rawDistribution != nil {
+			return fmt.Errorf("defaultVariant can not be combined with distribution")
+		}
+		defaultVariant, _ := rawDefaultVariant.(string)
+		if _, ok := variants[defaultVariant]; !ok {
+			return fmt.Errorf("defaultVariant must be one of the variants")
+		}
+		d.defaultVariant = defaultVariant
+		d.variants = variants
+		return nil
+	}
+	distribution, ok := rawDistribution.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("distribution must be an object of the weights of the variants by name")
+	}
+	total := 0.0
+	for variant, rawWeight := range distribution {
+		if _, ok := variants[variant]; !ok {
+			return fmt.Errorf("distribution of unknown variant %q", variant)
+		}
+		weight, ok := floatValue(rawWeight)
+		if !ok || weight < 0 {
+			return fmt.Errorf("the weight of variant %q must be a positive number", variant)
+		}
+		d.distribution = append(d.distribution, variantWeight{variant: variant, weight: weight})
+		total += weight
+	}
+	if total == 0 {
+		return fmt.Errorf("the weights of the distribution must not all be 0")
+	}
+	sort.Slice(d.distribution, func(i, j int) bool {
+		return d.distribution[i].variant < d.distribution[j].variant
+	})
+	d.variants = variants
+	return nil
+}
+
+// resolveDefinition returns the value of a structured flag definition, the variant it was assigned by a rule,
+// a rollout or the default variant, if any, and the reason of the assignment, or an empty reason if the value
+// is static. If the flag short-circuited or failed, it also returns its resolution.
+func (p *PulumiESCProvider) resolveDefinition(ctx context.Context, propertyPath string, definition *flagDefinition, flagType FlagType, evalCtx openfeature.FlattenedContext) (interface{}, string, openfeature.Reason, *openfeature.ProviderResolutionDetail) {
+	if definition.disabled {
+		return nil, "", "", &openfeature.ProviderResolutionDetail{Reason: openfeature.DisabledReason}
+	}
+	if value, resolutionDetails := p.checkPrerequisites(ctx, propertyPath, definition, flagType, evalCtx); resolutionDetails != nil {
+		return value, "", "", resolutionDetails
+	}
+	for _, rule := range definition.rules {
+		if rule.matches(evalCtx) {
+			return definition.variants[rule.variant], rule.variant, openfeature.TargetingMatchReason, nil
+		}
+	}
+	if definition.defaultVariant != "" {
+		var reason openfeature.Reason
+		if len(definition.rules) > 0 {
+			reason = openfeature.DefaultReason
+		}
+		return definition.variants[definition.defaultVariant], definition.defaultVariant, reason, nil
+	}
+	if definition.rollout != nil || definition.variants != nil {
+		value, variant, resolutionDetails := p.resolveSplit(ctx, propertyPath, definition, flagType, evalCtx)
+		return value, variant, openfeature.SplitReason, resolutionDetails
+	}
+	return definition.value, "", "", nil
+}
+
+// fallbackVariant returns the variant served by a multi-variate flag to the evaluation contexts matching no
+// rule if it is static, or the first variant of its distribution otherwise
+func (d *flagDefinition) fallbackVariant() string {
+	if d.defaultVariant != "" {
+		return d.defaultVariant
+	}
+	return d.distribution[0].variant
+}
+
+// checkPrerequisites evaluates the prerequisites of a structured flag definition. If one of them failed,
+// it returns the off value and its resolution.
+func (p *PulumiESCProvider) checkPrerequisites(ctx context.Context, propertyPath string, definition *flagDefinition, flagType FlagType, evalCtx openfeature.FlattenedContext) (interface{}, *openfeature.ProviderResolutionDetail) {
+	if len(definition.prerequisites) == 0 {
+		return nil, nil
+	}
+	chain, _ := ctx.Value(prerequisiteChainKey{}).([]string)
+	for _, key := range chain {
+		if key == propertyPath {
+			return nil, &openfeature.ProviderResolutionDetail{
+				Reason:          openfeature.ErrorReason,
+				ResolutionError: openfeature.NewParseErrorResolutionError(fmt.Sprintf("%s is its own prerequisite", propertyPath)),
+			}
+		}
+	}
+	if len(chain) >= maxPrerequisiteDepth {
+		return nil, &openfeature.ProviderResolutionDetail{
+			Reason:          openfeature.ErrorReason,
+			ResolutionError: openfeature.NewParseErrorResolutionError(fmt.Sprintf("%s has more than %d levels of prerequisites", propertyPath, maxPrerequisiteDepth)),
+		}
+	}
+	ctx = context.WithValue(ctx, prerequisiteChainKey{}, append(chain[:len(chain):len(chain)], propertyPath))
+	for _, prerequisite := range definition.prerequisites {
+		value, resolutionDetails := p.resolveValue(ctx, prerequisite, FlagType_Bool, evalCtx)
+		if resolutionDetails.Error() == nil && value == true {
+			continue
+		}
+		if resolutionDetails := offValueTypeMismatch(propertyPath, definition, flagType); resolutionDetails != nil {
+			return nil, resolutionDetails
+		}
+		return definition.offValue, &openfeature.ProviderResolutionDetail{
+			Reason:       PrerequisiteFailedReason,
+			FlagMetadata: openfeature.FlagMetadata{prerequisiteMetadataKey: prerequisite},
+		}
+	}
+	return nil, nil
+}
+
+// offValueTypeMismatch returns the resolution of an off value which is not of the type of the evaluation,
+// or nil if it is of that type or omitted
+func offValueTypeMismatch(propertyPath string, definition *flagDefinition, flagType FlagType) *openfeature.ProviderResolutionDetail {
+	if definition.offValue == nil || validateType(definition.offValue, flagType) {
+		return nil
+	}
+	return &openfeature.ProviderResolutionDetail{
+		Reason:          openfeature.ErrorReason,
+		ResolutionError: openfeature.NewTypeMismatchResolutionError(fmt.Sprintf("the off value of %s is not of type %s", propertyPath, flagType)),
+	}
+}
@@ -0,0 +1,194 @@
+package pulumi
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithFlagDefinitions_prerequisites(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"CHECKOUT_ENABLED": false,
+		"NEW_CHECKOUT": map[string]interface{}{
+			"value":         true,
+			"offValue":      false,
+			"prerequisites": []interface{}{"CHECKOUT_ENABLED"},
+		},
+		"CHECKOUT_THEME": map[string]interface{}{
+			"value":         "dark",
+			"prerequisites": []interface{}{"NEW_CHECKOUT"},
+		},
+		"CYCLE_A": map[string]interface{}{"value": true, "offValue": false, "prerequisites": []interface{}{"CYCLE_B"}},
+		"CYCLE_B": map[string]interface{}{"value": true, "prerequisites": []interface{}{"CYCLE_A"}},
+	})
+	p := newTestProvider(t, server, WithFlagDefinitions())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	got := p.BooleanEvaluation(ctx, "NEW_CHECKOUT", true, nil)
+	assert.NoError(t, got.Error())
+	assert.False(t, got.Value, "the off value must be served")
+	assert.Equal(t, PrerequisiteFailedReason, got.Reason)
+	prerequisite, _ := got.FlagMetadata.GetString(prerequisiteMetadataKey)
+	assert.Equal(t, "CHECKOUT_ENABLED", prerequisite)
+
+	theme := p.StringEvaluation(ctx, "CHECKOUT_THEME", "light", nil)
+	assert.NoError(t, theme.Error())
+	assert.Equal(t, "light", theme.Value, "the default value must be served without off value")
+	assert.Equal(t, PrerequisiteFailedReason, theme.Reason)
+
+	server.SetValue("CHECKOUT_ENABLED", true)
+	got = p.BooleanEvaluation(ctx, "NEW_CHECKOUT", false, nil)
+	assert.NoError(t, got.Error())
+	assert.True(t, got.Value)
+	assert.Equal(t, openfeature.StaticReason, got.Reason)
+	assert.Equal(t, "dark", p.StringEvaluation(ctx, "CHECKOUT_THEME", "light", nil).Value)
+
+	got = p.BooleanEvaluation(ctx, "CYCLE_A", true, nil)
+	assert.False(t, got.Value, "circular prerequisites must fail")
+	assert.Equal(t, PrerequisiteFailedReason, got.Reason)
+}
+
+func TestWithFlagDefinitions_plainObjects(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"NEW_CHECKOUT": map[string]interface{}{"value": true},
+		"configs":      map[string]interface{}{"value": "x", "THEME": "dark"},
+	})
+	p := newTestProvider(t, server, WithFlagDefinitions())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	assert.True(t, p.BooleanEvaluation(ctx, "NEW_CHECKOUT", false, nil).Value)
+	got := p.StringEvaluation(ctx, "configs", "", nil)
+	assert.Equal(t, openfeature.TypeMismatchCode, got.ResolutionDetail().ErrorCode, "objects with other fields are not flag definitions")
+
+	p = newTestProvider(t, server)
+	require.NoError(t, p.initialise(ctx))
+	details := p.BooleanEvaluation(ctx, "NEW_CHECKOUT", false, nil)
+	assert.Equal(t, openfeature.TypeMismatchCode, details.ResolutionDetail().ErrorCode, "definitions must only be resolved with WithFlagDefinitions")
+}
+
+func TestWithFlagDefinitions_enabled(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"NEW_CHECKOUT": map[string]interface{}{"value": true, "enabled": false},
+		"NEW_SEARCH":   map[string]interface{}{"value": true, "enabled": true},
+		"INVALID":      map[string]interface{}{"value": true, "enabled": "no"},
+	})
+	p := newTestProvider(t, server, WithFlagDefinitions())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	got := p.BooleanEvaluation(ctx, "NEW_CHECKOUT", false, nil)
+	assert.NoError(t, got.Error(), "disabled flags must not be reported as missing")
+	assert.False(t, got.Value, "the default value must be served")
+	assert.Equal(t, openfeature.DisabledReason, got.Reason)
+
+	got = p.BooleanEvaluation(ctx, "NEW_SEARCH", false, nil)
+	assert.True(t, got.Value)
+	assert.Equal(t, openfeature.StaticReason, got.Reason)
+	assert.Equal(t, openfeature.ParseErrorCode, p.BooleanEvaluation(ctx, "INVALID", false, nil).ResolutionDetail().ErrorCode)
+}
+
+func TestWithFlagDefinitions_archived(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"OLD_CHECKOUT": map[string]interface{}{"value": true, "archived": true},
+		"INVALID":      map[string]interface{}{"value": true, "archived": "yes"},
+	})
+	p := newTestProvider(t, server, WithFlagDefinitions())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	got := p.BooleanEvaluation(ctx, "OLD_CHECKOUT", false, nil)
+	assert.NoError(t, got.Error())
+	assert.False(t, got.Value, "archived flags must serve the default value")
+	assert.Equal(t, openfeature.DisabledReason, got.Reason)
+	assert.Equal(t, openfeature.ParseErrorCode, p.BooleanEvaluation(ctx, "INVALID", false, nil).ResolutionDetail().ErrorCode)
+}
+
+func TestWithFlagDefinitions_deprecated(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"CHECKOUT_V1": map[string]interface{}{"value": true, "deprecated": "use CHECKOUT_V2"},
+		"SEARCH_V1":   map[string]interface{}{"value": true, "enabled": false, "deprecated": "use SEARCH_V2"},
+	})
+	var logs bytes.Buffer
+	p := newTestProvider(t, server, WithFlagDefinitions(), WithDeprecationWarnings(slog.New(slog.NewTextHandler(&logs, nil))))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	for i := 0; i < 2; i++ {
+		got := p.BooleanEvaluation(ctx, "CHECKOUT_V1", false, nil)
+		assert.True(t, got.Value)
+		deprecation, _ := got.FlagMetadata.GetString(deprecatedMetadataKey)
+		assert.Equal(t, "use CHECKOUT_V2", deprecation)
+	}
+	got := p.BooleanEvaluation(ctx, "SEARCH_V1", false, nil)
+	assert.Equal(t, openfeature.DisabledReason, got.Reason)
+	deprecation, _ := got.FlagMetadata.GetString(deprecatedMetadataKey)
+	assert.Equal(t, "use SEARCH_V2", deprecation)
+
+	assert.Equal(t, 1, strings.Count(logs.String(), "key=CHECKOUT_V1"), "deprecations must be logged once per flag")
+	assert.Contains(t, logs.String(), `level=WARN msg="deprecated flag evaluated" key=CHECKOUT_V1 deprecation="use CHECKOUT_V2"`)
+}
+
+func TestWithFlagDefinitions_targetingRules(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"CHECKOUT_LAYOUT": map[string]interface{}{
+			"variants":       map[string]interface{}{"classic": "classic", "grid": "grid", "list": "list"},
+			"defaultVariant": "classic",
+			"rules": []interface{}{
+				map[string]interface{}{"when": map[string]interface{}{"country": []interface{}{"FR", "DE"}, "plan": "enterprise"}, "variant": "grid"},
+				map[string]interface{}{"when": map[string]interface{}{"tier": 2}, "variant": "list"},
+			},
+			"metadata": map[string]interface{}{"owner": "checkout-team", "cache": "ignored"},
+		},
+		"SEARCH_BACKEND": map[string]interface{}{
+			"variants":       map[string]interface{}{"legacy": "solr", "new": "opensearch"},
+			"defaultVariant": "new",
+		},
+		"INVALID_RULE": map[string]interface{}{
+			"variants":       map[string]interface{}{"on": true, "off": false},
+			"defaultVariant": "off",
+			"rules":          []interface{}{map[string]interface{}{"when": map[string]interface{}{"beta": true}, "variant": "missing"}},
+		},
+		"INVALID_DEFAULT": map[string]interface{}{
+			"variants":       map[string]interface{}{"on": true},
+			"defaultVariant": "on",
+			"distribution":   map[string]interface{}{"on": 100},
+		},
+	})
+	p := newTestProvider(t, server, WithFlagDefinitions())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	got := p.StringEvaluation(ctx, "CHECKOUT_LAYOUT", "", openfeature.FlattenedContext{"country": "DE", "plan": "enterprise"})
+	assert.NoError(t, got.Error())
+	assert.Equal(t, "grid", got.Value)
+	assert.Equal(t, "grid", got.Variant)
+	assert.Equal(t, openfeature.TargetingMatchReason, got.Reason)
+	owner, _ := got.FlagMetadata.GetString("owner")
+	assert.Equal(t, "checkout-team", owner, "the metadata of the definition must be reported")
+	assert.Equal(t, string(CacheStatus_Bypass), got.FlagMetadata[cacheMetadataKey], "the metadata of the provider must take precedence")
+
+	got = p.StringEvaluation(ctx, "CHECKOUT_LAYOUT", "", openfeature.FlattenedContext{"country": "DE", "plan": "free", "tier": int64(2)})
+	assert.Equal(t, "list", got.Value, "numbers must match whatever their type")
+	assert.Equal(t, openfeature.TargetingMatchReason, got.Reason)
+
+	got = p.StringEvaluation(ctx, "CHECKOUT_LAYOUT", "", openfeature.FlattenedContext{"country": "US"})
+	assert.Equal(t, "classic", got.Value)
+	assert.Equal(t, "classic", got.Variant)
+	assert.Equal(t, openfeature.DefaultReason, got.Reason)
+
+	got = p.StringEvaluation(ctx, "SEARCH_BACKEND", "", nil)
+	assert.NoError(t, got.Error())
+	assert.Equal(t, "opensearch", got.Value)
+	assert.Equal(t, "new", got.Variant)
+	assert.Equal(t, openfeature.StaticReason, got.Reason)
+
+	assert.Equal(t, openfeature.ParseErrorCode, p.BooleanEvaluation(ctx, "INVALID_RULE", false, nil).ResolutionDetail().ErrorCode)
+	assert.Equal(t, openfeature.ParseErrorCode, p.BooleanEvaluation(ctx, "INVALID_DEFAULT", false, nil).ResolutionDetail().ErrorCode)
+}
@@ -0,0 +1,49 @@
+package pulumi
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// deprecatedMetadataKey is the flag metadata key of the deprecation message of a flag definition
+const deprecatedMetadataKey = "deprecated"
+
+// WithDeprecationWarnings logs a warning using the given logger, or slog.Default() if it is nil, the first time
+// a flag definition marked as deprecated is evaluated, to help drive the cleanup of flags still in use
+func WithDeprecationWarnings(logger *slog.Logger) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if logger == nil {
+			logger = slog.Default()
+		}
+		p.deprecationLogger = logger
+	}
+}
+
+// warnDeprecated logs the deprecation of the flag, once per flag
+func (p *PulumiESCProvider) warnDeprecated(ctx context.Context, flag, deprecation string) {
+	if deprecation == "" || p.deprecationLogger == nil {
+		return
+	}
+	if _, warned := p.warnedDeprecations.LoadOrStore(flag, true); warned {
+		return
+	}
+	p.deprecationLogger.LogAttrs(ctx, slog.LevelWarn, "deprecated flag evaluated",
+		slog.String("key", flag),
+		slog.String("deprecation", deprecation),
+	)
+}
+
+// withDeprecation adds the deprecation message, if any, to the flag metadata of the resolution
+func withDeprecation(resolutionDetails openfeature.ProviderResolutionDetail, deprecation string) openfeature.ProviderResolutionDetail {
+	if deprecation == "" {
+		return resolutionDetails
+	}
+	metadata := openfeature.FlagMetadata{deprecatedMetadataKey: deprecation}
+	for key, value := range resolutionDetails.FlagMetadata {
+		metadata[key] = value
+	}
+	resolutionDetails.FlagMetadata = metadata
+	return resolutionDetails
+}
@@ -0,0 +1,65 @@
+package pulumi
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// DomainEnvironment is the Pulumi ESC environment whose flags are served to an OpenFeature domain
+type DomainEnvironment struct {
+	Project string `json:"project" yaml:"project"`
+	Env     string `json:"env" yaml:"env"`
+}
+
+// DomainsConfig binds OpenFeature domains to Pulumi ESC environments of an organisation, e.g.
+// "checkout" to the checkout/prod environment and "search" to the search/prod environment.
+// The empty domain binds the default provider.
+type DomainsConfig struct {
+	Org     string                       `json:"org" yaml:"org"`
+	Domains map[string]DomainEnvironment `json:"domains" yaml:"domains"`
+}
+
+// RegisterDomains creates a provider for the environment of every domain of the config and registers it
+// as the OpenFeature provider of the domain. The providers share a ClientPool created with the access token
+// and the options, and the other options are applied to all of them.
+// If any provider can not be created or registered, the providers created so far are shut down.
+func RegisterDomains(config DomainsConfig, accessKey string, opts ...ProviderOption) (map[string]*PulumiESCProvider, error) {
+	pool, err := NewClientPool(accessKey, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make([]string, 0, len(config.Domains))
+	for domain := range config.Domains {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	providers := make(map[string]*PulumiESCProvider, len(domains))
+	shutdown := func() {
+		for _, provider := range providers {
+			provider.Shutdown()
+		}
+	}
+	for _, domain := range domains {
+		env := config.Domains[domain]
+		provider, err := pool.NewProvider(config.Org, env.Project, env.Env, opts...)
+		if err != nil {
+			shutdown()
+			return nil, fmt.Errorf("failed to create the provider of domain %q: %w", domain, err)
+		}
+		providers[domain] = provider
+		if domain == "" {
+			err = openfeature.SetProviderAndWait(provider)
+		} else {
+			err = openfeature.SetNamedProviderAndWait(domain, provider)
+		}
+		if err != nil {
+			shutdown()
+			return nil, fmt.Errorf("failed to register the provider of domain %q: %w", domain, err)
+		}
+	}
+	return providers, nil
+}
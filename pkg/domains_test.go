@@ -0,0 +1,46 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterDomains(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	defer openfeature.Shutdown()
+
+	providers, err := RegisterDomains(DomainsConfig{
+		Org: "test-org",
+		Domains: map[string]DomainEnvironment{
+			"checkout": {Project: "checkout", Env: "prod"},
+			"search":   {Project: "search", Env: "staging"},
+		},
+	}, "token", WithHTTPClient(server.Client()))
+	require.NoError(t, err)
+	require.Len(t, providers, 2)
+
+	for domain, env := range map[string]string{"checkout": "prod", "search": "staging"} {
+		details, err := openfeature.NewClient(domain).BooleanValueDetails(context.Background(), "DEBUG_MODE", false, openfeature.EvaluationContext{})
+		assert.NoError(t, err)
+		assert.True(t, details.Value, domain)
+		trace, ok := details.FlagMetadata["trace"].(esc.Trace)
+		require.True(t, ok)
+		assert.Equal(t, env, trace.Def.Environment, "the flags of %s must be read from its environment", domain)
+	}
+}
+
+func TestRegisterDomains_error(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	server.SetAccessToken("other-token")
+
+	_, err := RegisterDomains(DomainsConfig{
+		Org:     "test-org",
+		Domains: map[string]DomainEnvironment{"checkout": {Project: "checkout", Env: "prod"}},
+	}, "token", WithHTTPClient(server.Client()))
+	assert.ErrorContains(t, err, `failed to create the provider of domain "checkout"`)
+}
@@ -0,0 +1,122 @@
+package pulumi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DotenvOptions are the options of ExportDotenv
+type DotenvOptions struct {
+	// Prefix is prepended to the name of every variable, e.g. "APP_"
+	Prefix string
+	// ExcludeSecrets omits the values which Pulumi ESC marks as secret
+	ExcludeSecrets bool
+}
+
+// ExportDotenv returns the resolved values of the open environment as a dotenv file, so processes which only
+// read env files can consume the same flags. Every value which is not an object is a variable, named after its
+// flag key in upper case with the characters other than letters and digits replaced with underscores, e.g.
+// CONFIGS_MAX_RETRIES for configs.MAX_RETRIES. Strings are quoted and arrays are encoded as JSON. Secrets
+// denied using WithDenySecrets are always omitted. It fails if several flags map to the same variable.
+func (p *PulumiESCProvider) ExportDotenv(ctx context.Context, opts DotenvOptions) ([]byte, error) {
+	snapshot, err := p.readEnvironment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	flags := map[string]string{}
+	variables := map[string]string{}
+	for key, value := range flattenValues(snapshot.Values) {
+		if _, ok := value.(map[string]interface{}); ok {
+			continue
+		}
+		if p.dotenvOmitted(snapshot, key, opts) {
+			continue
+		}
+		name := opts.Prefix + dotenvName(key)
+		if other, ok := flags[name]; ok {
+			first, second := min(key, other), max(key, other)
+			return nil, fmt.Errorf("flags %s and %s are both exported as %s", first, second, name)
+		}
+		encoded, err := dotenvValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode flag %s: %w", key, err)
+		}
+		flags[name] = key
+		variables[name] = encoded
+	}
+
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s=%s\n", name, variables[name])
+	}
+	return buf.Bytes(), nil
+}
+
+// dotenvOmitted reports whether the flag is a secret which is omitted from the dotenv export
+func (p *PulumiESCProvider) dotenvOmitted(snapshot *environmentSnapshot, key string, opts DotenvOptions) bool {
+	for _, secret := range snapshot.Secrets {
+		if withinPath(key, secret) {
+			if opts.ExcludeSecrets || p.secretDenied(secret) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dotenvName returns the name of the variable of a flag key
+func dotenvName(key string) string {
+	// Quoted keys are named as if they were nested
+	if path, err := parsePropertyPath(key); err == nil {
+		if keys, ok := objectKeys(path); ok {
+			key = strings.Join(keys, ".")
+		}
+	}
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			name[i] = '_'
+		}
+	}
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		return "_" + string(name)
+	}
+	return string(name)
+}
+
+// dotenvValue returns the dotenv encoding of a value
+func dotenvValue(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return dotenvQuote(value), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case json.Number:
+		return value.String(), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return dotenvQuote(string(encoded)), nil
+}
+
+// dotenvQuote returns the string in double quotes, escaping the characters interpreted by dotenv parsers
+func dotenvQuote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`)
+	return `"` + replacer.Replace(value) + `"`
+}
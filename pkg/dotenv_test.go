@@ -0,0 +1,70 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportDotenv(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"DEBUG_MODE":          true,
+		"configs.MAX_RETRIES": 3,
+		"configs.RATIO":       0.25,
+		"configs.theme-name":  "dark \"blue\"\n$HOME",
+		"configs.REGIONS":     []interface{}{"eu", "us"},
+	})
+	server.SetSecret("configs.API_KEY", "sk-12345")
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	dotenv, err := p.ExportDotenv(ctx, DotenvOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, `CONFIGS_API_KEY="sk-12345"
+CONFIGS_MAX_RETRIES=3
+CONFIGS_RATIO=0.25
+CONFIGS_REGIONS="[\"eu\",\"us\"]"
+CONFIGS_THEME_NAME="dark \"blue\"\n\$HOME"
+DEBUG_MODE=true
+`, string(dotenv))
+
+	dotenv, err = p.ExportDotenv(ctx, DotenvOptions{Prefix: "APP_", ExcludeSecrets: true})
+	require.NoError(t, err)
+	assert.Equal(t, `APP_CONFIGS_MAX_RETRIES=3
+APP_CONFIGS_RATIO=0.25
+APP_CONFIGS_REGIONS="[\"eu\",\"us\"]"
+APP_CONFIGS_THEME_NAME="dark \"blue\"\n\$HOME"
+APP_DEBUG_MODE=true
+`, string(dotenv))
+}
+
+func TestExportDotenv_deniedSecrets(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	server.SetSecret("API_KEY", "sk-12345")
+	p := newTestProvider(t, server, WithDenySecrets())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	dotenv, err := p.ExportDotenv(ctx, DotenvOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "DEBUG_MODE=true\n", string(dotenv))
+}
+
+func TestExportDotenv_collision(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"configs.DEBUG": true, "configs_DEBUG": false})
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	_, err := p.ExportDotenv(ctx, DotenvOptions{})
+	assert.EqualError(t, err, "flags configs.DEBUG and configs_DEBUG are both exported as CONFIGS_DEBUG")
+}
+
+func TestDotenvName(t *testing.T) {
+	assert.Equal(t, "CONFIGS_MAX_RETRIES", dotenvName("configs.MAX_RETRIES"))
+	assert.Equal(t, "NEW_CHECKOUT_FLOW", dotenvName("new-checkout flow"))
+	assert.Equal(t, "_1PASSWORD", dotenvName("1password"))
+}
@@ -0,0 +1,51 @@
+package pulumi
+
+import (
+	"fmt"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// WithEnum restricts the values of the given flag to the allowed values. Evaluations of other values
+// fail with PARSE_ERROR instead of propagating invalid configuration.
+// Values which are not strings are compared using their default format, e.g. "50" for the integer 50.
+func WithEnum(key string, allowedValues ...string) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if p.enums == nil {
+			p.enums = map[string][]string{}
+		}
+		p.enums[key] = allowedValues
+	}
+}
+
+// enumAllowed reports whether the raw value of the given flag is one of its allowed values
+func (p *PulumiESCProvider) enumAllowed(propertyPath string, rawValue interface{}) bool {
+	allowedValues, ok := p.enums[propertyPath]
+	if !ok {
+		return true
+	}
+	value, ok := rawValue.(string)
+	if !ok {
+		value = fmt.Sprint(rawValue)
+	}
+	for _, allowedValue := range allowedValues {
+		if value == allowedValue {
+			return true
+		}
+	}
+	return false
+}
+
+// enumMessage describes a value of the given flag which is not one of its allowed values.
+// The value is not part of the message, as it may be a secret.
+func (p *PulumiESCProvider) enumMessage(propertyPath string) string {
+	return fmt.Sprintf("%s is not one of the allowed values %q", propertyPath, p.enums[propertyPath])
+}
+
+// enumResolution returns the resolution of a value which is not one of the allowed values of the given flag
+func (p *PulumiESCProvider) enumResolution(propertyPath string) openfeature.ProviderResolutionDetail {
+	return openfeature.ProviderResolutionDetail{
+		Reason:          openfeature.ErrorReason,
+		ResolutionError: openfeature.NewParseErrorResolutionError(p.enumMessage(propertyPath)),
+	}
+}
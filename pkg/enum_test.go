@@ -0,0 +1,37 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithEnum(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"LOG_LEVEL": "info",
+		"RETRIES":   3,
+	})
+	p := newTestProvider(t, server,
+		WithEnum("LOG_LEVEL", "debug", "info", "warn"),
+		WithEnum("RETRIES", "1", "2", "3"),
+	)
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+	ctx := context.Background()
+
+	assert.Equal(t, "info", p.StringEvaluation(ctx, "LOG_LEVEL", "warn", nil).Value)
+	assert.Equal(t, int64(3), p.IntEvaluation(ctx, "RETRIES", 1, nil).Value)
+
+	server.SetValue("LOG_LEVEL", "verbose")
+	details := p.StringEvaluation(ctx, "LOG_LEVEL", "warn", nil)
+	assert.Equal(t, "warn", details.Value)
+	assert.Equal(t, openfeature.ParseErrorCode, details.ResolutionDetail().ErrorCode)
+	assert.Equal(t, `LOG_LEVEL is not one of the allowed values ["debug" "info" "warn"]`, details.ResolutionDetail().ErrorMessage)
+
+	t.Run("required flags", func(t *testing.T) {
+		p := newTestProvider(t, server, WithEnum("LOG_LEVEL", "debug"), WithRequiredFlags(map[string]FlagType{"LOG_LEVEL": FlagType_String}))
+		err := p.Init(openfeature.EvaluationContext{})
+		assert.ErrorContains(t, err, "LOG_LEVEL is not one of the allowed values")
+	})
+}
@@ -0,0 +1,71 @@
+package pulumi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// EnvironmentFlagKey is the reserved flag key evaluating the whole environment with ObjectEvaluation.
+// The empty flag key is equivalent.
+const EnvironmentFlagKey = "*"
+
+// isEnvironmentFlag reports whether the flag key is reserved for the whole environment
+func isEnvironmentFlag(flag string) bool {
+	return flag == EnvironmentFlagKey || flag == ""
+}
+
+// resolveEnvironment resolves all the values of the environment as a map[string]interface{}, so services
+// which want the entire configuration don't need an evaluation per flag. Secrets denied using
+// WithDenySecrets are redacted, and the secret flag metadata is set if any other secret is returned, so
+// hooks mask and audit the evaluation. If the environment can not be read, the last known good snapshot is served.
+func (p *PulumiESCProvider) resolveEnvironment(ctx context.Context, evalCtx openfeature.FlattenedContext) (interface{}, openfeature.ProviderResolutionDetail) {
+	evalCtx = p.enrichContext(ctx, evalCtx)
+	if !p.tryRecover() {
+		return nil, openfeature.ProviderResolutionDetail{
+			Reason:          openfeature.ErrorReason,
+			ResolutionError: openfeature.NewProviderNotReadyResolutionError(fmt.Sprintf("provider is in %s state", p.Status())),
+		}
+	}
+	if environment, err := p.environmentOverride(evalCtx); err != nil || environment != "" {
+		message := fmt.Sprintf("the environment %s selected in the evaluation context can not be evaluated as a whole", environment)
+		if err != nil {
+			message = err.Error()
+		}
+		return nil, openfeature.ProviderResolutionDetail{
+			Reason:          openfeature.ErrorReason,
+			ResolutionError: openfeature.NewInvalidContextResolutionError(message),
+		}
+	}
+	cacheStatus := CacheStatus_Bypass
+	err := p.throttle.check()
+	var snapshot *environmentSnapshot
+	if err == nil {
+		snapshot, err = p.readEnvironment(ctx)
+	}
+	if err != nil {
+		if snapshot = p.snapshot.Load(); snapshot == nil {
+			return nil, p.apiErrorResolution(err)
+		}
+		cacheStatus = CacheStatus_Stale
+	}
+	reason := openfeature.StaticReason
+	if cacheStatus == CacheStatus_Stale {
+		reason = openfeature.CachedReason
+	}
+	metadata := openfeature.FlagMetadata{cacheMetadataKey: cacheStatus.metadataValue()}
+	if snapshot.Revision > 0 {
+		metadata[revisionMetadataKey] = int64(snapshot.Revision)
+	}
+	for _, secret := range snapshot.Secrets {
+		if !p.secretDenied(secret) {
+			metadata["secret"] = true
+			break
+		}
+	}
+	return p.redactedValues(snapshot), openfeature.ProviderResolutionDetail{
+		Reason:       reason,
+		FlagMetadata: metadata,
+	}
+}
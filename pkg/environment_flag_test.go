@@ -0,0 +1,68 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectEvaluation_environment(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"DEBUG_MODE": true,
+		"configs":    map[string]interface{}{"THEME": "dark"},
+	})
+	server.SetSecret("configs.GITHUB_TOKEN", "ghp-12345")
+	server.SetRevision(4)
+	p := newTestProvider(t, server, WithDenySecrets())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	for _, flag := range []string{EnvironmentFlagKey, ""} {
+		got := p.ObjectEvaluation(ctx, flag, nil, nil)
+		require.NoError(t, got.Error())
+		assert.Equal(t, openfeature.StaticReason, got.Reason)
+		assert.Equal(t, map[string]interface{}{
+			"DEBUG_MODE": true,
+			"configs":    map[string]interface{}{"THEME": "dark", "GITHUB_TOKEN": maskedValue},
+		}, got.Value)
+		revision, err := got.FlagMetadata.GetInt(revisionMetadataKey)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), revision)
+		_, err = got.FlagMetadata.GetBool("secret")
+		assert.Error(t, err, "denied secrets are redacted")
+	}
+}
+
+func TestObjectEvaluation_environmentSecrets(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	server.SetSecret("GITHUB_TOKEN", "ghp-12345")
+	var records []AuditRecord
+	p := newTestProvider(t, server, WithAuditSink(AuditSinkFunc(func(record AuditRecord) {
+		records = append(records, record)
+	})))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	got := p.ObjectEvaluation(ctx, EnvironmentFlagKey, nil, nil)
+	require.NoError(t, got.Error())
+	assert.Equal(t, "ghp-12345", got.Value.(map[string]interface{})["GITHUB_TOKEN"])
+	secret, err := got.FlagMetadata.GetBool("secret")
+	assert.NoError(t, err)
+	assert.True(t, secret, "the environment must be flagged as secret so hooks mask it")
+	assert.Len(t, records, 1, "the secret access must be audited")
+}
+
+func TestObjectEvaluation_environmentError(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	server.SetUnavailable(true)
+	got := p.ObjectEvaluation(ctx, EnvironmentFlagKey, "default", nil)
+	assert.Error(t, got.Error())
+	assert.Equal(t, "default", got.Value)
+}
@@ -0,0 +1,59 @@
+package pulumi
+
+import (
+	"sync"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// errorCounter counts failed evaluations by error category. The zero value is ready to use.
+type errorCounter struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+// record counts the evaluation if it failed
+func (c *errorCounter) record(resolutionDetails openfeature.ProviderResolutionDetail) {
+	category := errorCategory(resolutionDetails)
+	if category == "" {
+		return
+	}
+	c.mu.Lock()
+	if c.counts == nil {
+		c.counts = map[string]uint64{}
+	}
+	c.counts[category]++
+	c.mu.Unlock()
+}
+
+// snapshot returns a copy of the counts
+func (c *errorCounter) snapshot() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[string]uint64, len(c.counts))
+	for category, count := range c.counts {
+		counts[category] = count
+	}
+	return counts
+}
+
+// errorCategory returns the category of a failed evaluation, or "" if it succeeded: its ErrorType if the
+// error was classified, e.g. UNAUTHORIZED or RATE_LIMITED, and its error code otherwise, e.g. FLAG_NOT_FOUND
+func errorCategory(resolutionDetails openfeature.ProviderResolutionDetail) string {
+	errorCode := resolutionDetails.ResolutionDetail().ErrorCode
+	if errorCode == "" {
+		return ""
+	}
+	if errorType, ok := resolutionDetails.FlagMetadata[errorTypeMetadataKey].(string); ok && errorType != "" {
+		return errorType
+	}
+	return string(errorCode)
+}
+
+// ErrorCounts returns the number of failed evaluations since the provider was created by error category,
+// so dashboards can tell developer mistakes from Pulumi ESC outages at a glance. Errors classified by an
+// ErrorType are counted by type, e.g. UNAUTHORIZED, PERMISSION_DENIED, RATE_LIMITED or PROVIDER_ERROR, and
+// other errors by error code, e.g. FLAG_NOT_FOUND, TYPE_MISMATCH or GENERAL.
+func (p *PulumiESCProvider) ErrorCounts() map[string]uint64 {
+	return p.errorCounts.snapshot()
+}
@@ -0,0 +1,35 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPulumiESCProvider_ErrorCounts(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	assert.Empty(t, p.ErrorCounts())
+
+	p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+	p.BooleanEvaluation(ctx, "MISSING", false, nil)
+	p.BooleanEvaluation(ctx, "MISSING", false, nil)
+	p.StringEvaluation(ctx, "DEBUG_MODE", "", nil)
+	p.ObjectEvaluation(ctx, "DEBUG_MODE", nil, nil)
+	server.SetAccessToken("rotated-token")
+	p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+
+	counts := p.ErrorCounts()
+	assert.Equal(t, map[string]uint64{
+		"FLAG_NOT_FOUND": 2,
+		"TYPE_MISMATCH":  1,
+		"GENERAL":        1,
+		"UNAUTHORIZED":   1,
+	}, counts)
+	counts["GENERAL"] = 100
+	assert.Equal(t, uint64(1), p.ErrorCounts()["GENERAL"], "the returned counts must be a copy")
+}
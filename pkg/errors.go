@@ -0,0 +1,143 @@
+package pulumi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+// ErrorType classifies failed resolutions beyond the error codes defined by OpenFeature.
+// It is reported in the "errorType" FlagMetadata key.
+type ErrorType string
+
+const (
+	ErrorType_Unauthorized     ErrorType = "UNAUTHORIZED"
+	ErrorType_PermissionDenied ErrorType = "PERMISSION_DENIED"
+	ErrorType_RateLimited      ErrorType = "RATE_LIMITED"
+	ErrorType_ProviderError    ErrorType = "PROVIDER_ERROR"
+	ErrorType_SecretDenied     ErrorType = "SECRET_DENIED"
+	ErrorType_Timeout          ErrorType = "TIMEOUT"
+)
+
+const errorTypeMetadataKey = "errorType"
+
+// Sentinel errors matched with errors.Is by the errors returned by the constructor and the methods of the
+// provider, so callers can branch on failure modes. The errors keep the message of the underlying error.
+var (
+	// ErrFlagNotFound is matched by errors of flags missing from the environment
+	ErrFlagNotFound = errors.New("flag not found")
+	// ErrTypeMismatch is matched by errors of flags whose value is not of the expected type
+	ErrTypeMismatch = errors.New("type mismatch")
+	// ErrUnauthorized is matched by errors of access tokens rejected by the Pulumi ESC API, because they are
+	// invalid or expired, or have no access to the environment
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrSessionExpired is matched by errors of reads of an environment session which no longer exists
+	ErrSessionExpired = errors.New("pulumi esc environment session expired")
+	// ErrDefinitionConflict is matched by errors of updates of the definition of an environment which kept
+	// conflicting with concurrent writes of other clients
+	ErrDefinitionConflict = errors.New("pulumi esc environment definition changed concurrently")
+)
+
+// sentinelError is an error matching a sentinel error with errors.Is, with the message of the wrapped error
+type sentinelError struct {
+	err      error
+	sentinel error
+}
+
+func (e *sentinelError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sentinelError) Unwrap() []error {
+	return []error{e.err, e.sentinel}
+}
+
+// withSentinel returns the error matching the sentinel error
+func withSentinel(err, sentinel error) error {
+	return &sentinelError{err: err, sentinel: sentinel}
+}
+
+// classifyAPIError returns the error of a Pulumi ESC API request as an APIError, matching ErrUnauthorized or
+// ErrFlagNotFound if it is one of these failures
+func classifyAPIError(err error) error {
+	if err == nil {
+		return nil
+	}
+	err = newAPIError(err, "")
+	switch {
+	case isAuthErr(err):
+		return withSentinel(err, ErrUnauthorized)
+	case isKeyNotFound(err):
+		return withSentinel(err, ErrFlagNotFound)
+	}
+	return err
+}
+
+// apiErrorResolution maps an error returned while reading from the Pulumi ESC API to resolution details.
+// The OpenFeature SDK does not define error codes for these failures, so they are reported as general
+// errors classified by the "errorType" FlagMetadata key:
+//   - 401 as UNAUTHORIZED, the access token is invalid or expired
+//   - 403 as PERMISSION_DENIED, the access token has no access to the environment
+//   - 429 and client-side rate limiting as RATE_LIMITED
+//   - 5xx as PROVIDER_ERROR
+func (p *PulumiESCProvider) apiErrorResolution(err error) (resolutionDetails openfeature.ProviderResolutionDetail) {
+	if p.debugErrors {
+		defer addDebugErrorMetadata(err, &resolutionDetails)
+	}
+	var genErr *esc.GenericOpenAPIError
+	statusCode := 0
+	if errors.As(err, &genErr) {
+		statusCode = apiStatusCode(genErr)
+	}
+	if statusCode == http.StatusTooManyRequests {
+		if throttleErr := p.throttle.check(); throttleErr != nil {
+			err = throttleErr
+		}
+	}
+
+	var throttledErr *throttledError
+	switch {
+	case errors.As(err, &throttledErr):
+		return openfeature.ProviderResolutionDetail{
+			Reason:          openfeature.ErrorReason,
+			ResolutionError: openfeature.NewGeneralResolutionError(throttledErr.Error()),
+			FlagMetadata: openfeature.FlagMetadata{
+				errorTypeMetadataKey: string(ErrorType_RateLimited),
+				"rateLimited":        true,
+				"retryAfter":         throttledErr.retryAfter.Format(time.RFC3339),
+			},
+		}
+	case errors.Is(err, errRateLimited), statusCode == http.StatusTooManyRequests:
+		return errorResolution(openfeature.NewGeneralResolutionError(err.Error()), ErrorType_RateLimited)
+	case statusCode == http.StatusUnauthorized:
+		return errorResolution(
+			openfeature.NewGeneralResolutionError(fmt.Sprintf("unauthorized, the Pulumi access token is invalid or expired: %s", err)),
+			ErrorType_Unauthorized)
+	case statusCode == http.StatusForbidden:
+		return errorResolution(
+			openfeature.NewGeneralResolutionError(fmt.Sprintf("permission denied to environment %s: %s", p.environmentPath(), err)),
+			ErrorType_PermissionDenied)
+	case statusCode >= http.StatusInternalServerError:
+		return errorResolution(
+			openfeature.NewGeneralResolutionError(fmt.Sprintf("pulumi esc api error: %s", err)),
+			ErrorType_ProviderError)
+	}
+	return openfeature.ProviderResolutionDetail{
+		Reason:          openfeature.ErrorReason,
+		ResolutionError: openfeature.NewGeneralResolutionError(err.Error()),
+	}
+}
+
+func errorResolution(resolutionError openfeature.ResolutionError, errorType ErrorType) openfeature.ProviderResolutionDetail {
+	return openfeature.ProviderResolutionDetail{
+		Reason:          openfeature.ErrorReason,
+		ResolutionError: resolutionError,
+		FlagMetadata: openfeature.FlagMetadata{
+			errorTypeMetadataKey: string(errorType),
+		},
+	}
+}
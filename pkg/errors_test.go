@@ -0,0 +1,118 @@
+package pulumi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiError returns the error the esc client returns for a response with the given status code
+func apiError(t *testing.T, statusCode int) error {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		fmt.Fprintf(w, `{"code":%d,"message":"%s"}`, statusCode, http.StatusText(statusCode))
+	}))
+	defer server.Close()
+
+	conf := esc.NewConfiguration()
+	conf.Servers = esc.ServerConfigurations{{URL: server.URL + "/api/esc"}}
+	_, _, err := esc.NewClient(conf).ReadEnvironmentProperty(context.Background(), "test-org", PROJECT_NAME, ENV_NAME, "session-id", STRING_FLAG_KEY)
+	assert.Error(t, err)
+	return err
+}
+
+func TestPulumiESCProvider_apiErrorResolution(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		want       ErrorType
+	}{
+		{name: "unauthorized", statusCode: http.StatusUnauthorized, want: ErrorType_Unauthorized},
+		{name: "permission-denied", statusCode: http.StatusForbidden, want: ErrorType_PermissionDenied},
+		{name: "rate-limited", statusCode: http.StatusTooManyRequests, want: ErrorType_RateLimited},
+		{name: "provider-error", statusCode: http.StatusServiceUnavailable, want: ErrorType_ProviderError},
+		{name: "unclassified", statusCode: http.StatusConflict, want: ""},
+	}
+	p := &PulumiESCProvider{throttle: &apiThrottle{}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.apiErrorResolution(apiError(t, tt.statusCode))
+			assert.Equal(t, openfeature.ErrorReason, got.Reason)
+			assert.Equal(t, openfeature.GeneralCode, got.ResolutionDetail().ErrorCode)
+			errorType, _ := got.FlagMetadata.GetString(errorTypeMetadataKey)
+			assert.Equal(t, string(tt.want), errorType)
+		})
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true, "MAX_RETRIES": 3})
+
+	server.SetAccessToken("rotated-token")
+	err := newTestProvider(t, server).initialise(ctx)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	var genErr *esc.GenericOpenAPIError
+	assert.ErrorAs(t, err, &genErr, "the API error must still be available")
+	assert.Contains(t, err.Error(), "failed to open pulumi esc environment: 401")
+	server.SetAccessToken("token")
+
+	err = newTestProvider(t, server, WithRequiredFlags(map[string]FlagType{
+		"DEBUG_MODE": FlagType_String,
+		"MISSING":    FlagType_Bool,
+	})).initialise(ctx)
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.ErrorIs(t, err, ErrFlagNotFound)
+	assert.NotErrorIs(t, err, ErrUnauthorized)
+
+	p := newTestProvider(t, server)
+	require.NoError(t, p.initialise(ctx))
+	_, err = p.CreateFlagIfMissing(ctx, "MAX_RETRIES", "three")
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+
+	server.ExpireSessions()
+	_, err = p.ListFlags(ctx)
+	assert.ErrorIs(t, err, ErrSessionExpired)
+}
+
+func TestAPIError(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+
+	server.SetAccessToken("rotated-token")
+	err := newTestProvider(t, server).initialise(ctx)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.Code)
+	assert.Equal(t, "unauthorized", apiErr.Message)
+	assert.Regexp(t, `^req-\d+$`, apiErr.RequestID)
+	assert.Contains(t, apiErr.Error(), "(request id "+apiErr.RequestID+")")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	var genErr *esc.GenericOpenAPIError
+	assert.ErrorAs(t, err, &genErr, "the APIError must unwrap to the Pulumi ESC client error")
+
+	server.SetAccessToken("")
+	p := newTestProvider(t, server)
+	require.NoError(t, p.initialise(ctx))
+	server.SetUnavailable(true)
+	_, err = p.readEnvironment(ctx)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.Code)
+	assert.Equal(t, "service unavailable", apiErr.Message)
+	assert.NotEmpty(t, apiErr.RequestID)
+
+	// Errors of clients which do not go through the provider transports have no request ID
+	err = classifyAPIError(fmt.Errorf("failed to read: %w", apiError(t, http.StatusInternalServerError)))
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusInternalServerError, apiErr.Code)
+	assert.Empty(t, apiErr.RequestID)
+	assert.Contains(t, err.Error(), "failed to read: 500")
+}
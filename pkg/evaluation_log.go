@@ -0,0 +1,108 @@
+package pulumi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// EvaluationRecord is a single entry of the evaluation replay log
+type EvaluationRecord struct {
+	Key         string                `json:"key"`
+	Variant     string                `json:"variant,omitempty"`
+	Reason      openfeature.Reason    `json:"reason"`
+	ErrorCode   openfeature.ErrorCode `json:"errorCode,omitempty"`
+	ContextHash string                `json:"contextHash,omitempty"`
+	Revision    string                `json:"revision,omitempty"`
+	Timestamp   time.Time             `json:"timestamp"`
+}
+
+// evaluationLog is a fixed size ring buffer of the most recent evaluations
+type evaluationLog struct {
+	mu      sync.Mutex
+	records []EvaluationRecord
+	next    int
+	full    bool
+}
+
+func newEvaluationLog(size int) *evaluationLog {
+	return &evaluationLog{records: make([]EvaluationRecord, size)}
+}
+
+// add appends the record to the log, overwriting the oldest record once the log is full
+func (l *evaluationLog) add(record EvaluationRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records[l.next] = record
+	l.next = (l.next + 1) % len(l.records)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// list returns the logged records from the oldest to the newest
+func (l *evaluationLog) list() []EvaluationRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.full {
+		return append([]EvaluationRecord(nil), l.records[:l.next]...)
+	}
+	records := make([]EvaluationRecord, 0, len(l.records))
+	records = append(records, l.records[l.next:]...)
+	return append(records, l.records[:l.next]...)
+}
+
+// WithEvaluationLog keeps the given number of most recent evaluations in memory, so they can be
+// inspected after an incident using RecentEvaluations or EvaluationLogHandler
+func WithEvaluationLog(size int) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if size > 0 {
+			p.evaluationLog = newEvaluationLog(size)
+		}
+	}
+}
+
+// RecentEvaluations returns the evaluations kept by the evaluation log, from the oldest to the newest.
+// It returns nil if the provider was not configured using WithEvaluationLog.
+func (p *PulumiESCProvider) RecentEvaluations() []EvaluationRecord {
+	if p.evaluationLog == nil {
+		return nil
+	}
+	return p.evaluationLog.list()
+}
+
+// EvaluationLogHandler returns a http.Handler which dumps the evaluation log as JSON
+func (p *PulumiESCProvider) EvaluationLogHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		records := p.RecentEvaluations()
+		if records == nil {
+			records = []EvaluationRecord{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(records)
+	})
+}
+
+// recordEvaluation adds the outcome of an evaluation to the evaluation log, if enabled
+func (p *PulumiESCProvider) recordEvaluation(flag string, evalCtx openfeature.FlattenedContext, resolutionDetails openfeature.ProviderResolutionDetail) {
+	if p.evaluationLog == nil {
+		return
+	}
+	record := EvaluationRecord{
+		Key:       flag,
+		Variant:   resolutionDetails.Variant,
+		Reason:    resolutionDetails.Reason,
+		ErrorCode: resolutionDetails.ResolutionDetail().ErrorCode,
+		Timestamp: time.Now(),
+	}
+	if revision, err := resolutionDetails.FlagMetadata.GetInt(revisionMetadataKey); err == nil {
+		record.Revision = formatRevision(int32(revision))
+	}
+	if len(evalCtx) > 0 {
+		record.ContextHash = hashValue(evalCtx)
+	}
+	p.evaluationLog.add(record)
+}
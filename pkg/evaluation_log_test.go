@@ -0,0 +1,43 @@
+package pulumi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluationLog(t *testing.T) {
+	p := &PulumiESCProvider{}
+	WithEvaluationLog(2)(p)
+
+	p.recordEvaluation(STRING_FLAG_KEY, nil, openfeature.ProviderResolutionDetail{Reason: openfeature.StaticReason})
+	p.recordEvaluation(BOOL_FLAG_KEY, openfeature.FlattenedContext{openfeature.TargetingKey: "user-1"}, openfeature.ProviderResolutionDetail{Reason: openfeature.StaticReason})
+	p.recordEvaluation(NON_EXISTING_FLAG_KEY, nil, openfeature.ProviderResolutionDetail{
+		Reason:          openfeature.ErrorReason,
+		ResolutionError: openfeature.NewFlagNotFoundResolutionError(""),
+	})
+
+	records := p.RecentEvaluations()
+	assert.Len(t, records, 2)
+	assert.Equal(t, BOOL_FLAG_KEY, records[0].Key)
+	assert.NotEmpty(t, records[0].ContextHash)
+	assert.Equal(t, NON_EXISTING_FLAG_KEY, records[1].Key)
+	assert.Equal(t, openfeature.FlagNotFoundCode, records[1].ErrorCode)
+	assert.Empty(t, records[1].ContextHash)
+
+	recorder := httptest.NewRecorder()
+	p.EvaluationLogHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	var dumped []EvaluationRecord
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &dumped))
+	assert.Equal(t, []string{BOOL_FLAG_KEY, NON_EXISTING_FLAG_KEY}, []string{dumped[0].Key, dumped[1].Key})
+}
+
+func TestEvaluationLog_Disabled(t *testing.T) {
+	p := &PulumiESCProvider{}
+	p.recordEvaluation(STRING_FLAG_KEY, nil, openfeature.ProviderResolutionDetail{Reason: openfeature.StaticReason})
+	assert.Nil(t, p.RecentEvaluations())
+}
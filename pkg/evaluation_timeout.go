@@ -0,0 +1,48 @@
+package pulumi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// errEvaluationTimeout is the cause of the cancellation of reads exceeding the evaluation timeout
+var errEvaluationTimeout = errors.New("evaluation timed out")
+
+// WithEvaluationTimeout bounds the time an evaluation waits for the Pulumi ESC API. Evaluations whose read
+// does not complete within the timeout return the default value immediately with a GENERAL error classified
+// as TIMEOUT by the "errorType" FlagMetadata key, instead of blocking the request for the full transport
+// timeout. If the last known value of the flag is known, from the expired cache of WithCache or from the
+// snapshot of WithSnapshotPath, it is served with reason CACHED instead. Timed out reads do not change the
+// state of the provider.
+func WithEvaluationTimeout(timeout time.Duration) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if timeout > 0 {
+			p.evaluationTimeout = timeout
+		}
+	}
+}
+
+// readContext returns the context of the reads of an evaluation, cancelled once the evaluation timeout
+// elapsed if one is set
+func (p *PulumiESCProvider) readContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.evaluationTimeout == 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, p.evaluationTimeout, errEvaluationTimeout)
+}
+
+// isEvaluationTimeout reports whether the context was cancelled by the evaluation timeout
+func isEvaluationTimeout(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errEvaluationTimeout)
+}
+
+// timeoutResolution returns the resolution details of an evaluation which timed out
+func (p *PulumiESCProvider) timeoutResolution(propertyPath string) openfeature.ProviderResolutionDetail {
+	return errorResolution(
+		openfeature.NewGeneralResolutionError(fmt.Sprintf("evaluation of %s timed out after %s", propertyPath, p.evaluationTimeout)),
+		ErrorType_Timeout)
+}
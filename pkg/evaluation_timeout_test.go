@@ -0,0 +1,66 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithEvaluationTimeout(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	p := newTestProvider(t, server, WithEvaluationTimeout(20*time.Millisecond))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	details := p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+	require.NoError(t, details.Error())
+	assert.True(t, details.Value)
+
+	server.SetLatency(500 * time.Millisecond)
+	start := time.Now()
+	details = p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+	assert.Less(t, time.Since(start), 400*time.Millisecond, "the evaluation must not wait for the read")
+	assert.False(t, details.Value)
+	assert.Equal(t, openfeature.GeneralCode, details.ResolutionDetail().ErrorCode)
+	assert.Contains(t, details.ResolutionDetail().ErrorMessage, "evaluation of DEBUG_MODE timed out after 20ms")
+	assert.Equal(t, string(ErrorType_Timeout), details.FlagMetadata[errorTypeMetadataKey])
+	assert.Equal(t, openfeature.ReadyState, p.Status(), "timed out reads must not change the state of the provider")
+}
+
+func TestWithEvaluationTimeout_snapshot(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	p := newTestProvider(t, server, WithEvaluationTimeout(20*time.Millisecond), WithSnapshotPath(t.TempDir()+"/snapshot.json"))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	require.Eventually(t, func() bool { return p.snapshot.Load() != nil }, time.Second, 10*time.Millisecond)
+
+	server.SetLatency(500 * time.Millisecond)
+	details := p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+	require.NoError(t, details.Error())
+	assert.True(t, details.Value, "the last known value must be served")
+	assert.Equal(t, openfeature.CachedReason, details.Reason)
+}
+
+func TestWithEvaluationTimeout_cache(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	p := newTestProvider(t, server, WithEvaluationTimeout(20*time.Millisecond), WithCache(time.Millisecond, CacheFillPolicy_ReadThrough))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	require.True(t, p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil).Value)
+	time.Sleep(5 * time.Millisecond)
+
+	server.SetLatency(500 * time.Millisecond)
+	details := p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+	require.NoError(t, details.Error())
+	assert.True(t, details.Value, "the expired cached value must be served")
+	assert.Equal(t, openfeature.CachedReason, details.Reason)
+	cacheStatus, _ := details.FlagMetadata.GetString(cacheMetadataKey)
+	assert.Equal(t, string(CacheStatus_Stale), cacheStatus)
+
+	details = p.BooleanEvaluation(ctx, "MISSING", false, nil)
+	assert.Equal(t, string(ErrorType_Timeout), details.FlagMetadata[errorTypeMetadataKey], "flags without a last known value must time out")
+}
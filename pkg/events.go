@@ -0,0 +1,61 @@
+package pulumi
+
+import (
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// eventBufferSize is the number of provider events buffered for the OpenFeature SDK.
+// When the buffer is full, the oldest events are dropped in favour of the latest ones, so a provider which
+// is not registered never blocks and the latest state is always delivered.
+const eventBufferSize = 16
+
+// EventChannel implements openfeature.EventHandler
+func (p *PulumiESCProvider) EventChannel() <-chan openfeature.Event {
+	return p.events
+}
+
+// transitionLocked transitions the provider to the given state and emits the matching event if the
+// state changed. Transitions from and to NOT_READY are reported by the OpenFeature SDK itself.
+// It must be called with stateMu held.
+func (p *PulumiESCProvider) transitionLocked(state openfeature.State, message string) {
+	prev := p.state
+	p.state = state
+	if prev == state || prev == openfeature.NotReadyState || state == openfeature.NotReadyState {
+		return
+	}
+	details := openfeature.ProviderEventDetails{Message: message}
+	var eventType openfeature.EventType
+	switch state {
+	case openfeature.ReadyState:
+		eventType = openfeature.ProviderReady
+	case openfeature.StaleState:
+		eventType = openfeature.ProviderStale
+	case openfeature.ErrorState:
+		eventType = openfeature.ProviderError
+		details.ErrorCode = openfeature.GeneralCode
+	default:
+		return
+	}
+	p.emit(openfeature.Event{
+		ProviderName:         ProviderName,
+		EventType:            eventType,
+		ProviderEventDetails: details,
+	})
+}
+
+// emit sends the event without blocking, dropping the oldest buffered event if the buffer is full
+func (p *PulumiESCProvider) emit(event openfeature.Event) {
+	select {
+	case p.events <- event:
+		return
+	default:
+	}
+	select {
+	case <-p.events:
+	default:
+	}
+	select {
+	case p.events <- event:
+	default:
+	}
+}
@@ -0,0 +1,95 @@
+package pulumi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SnapshotFormat is the encoding of an exported snapshot
+type SnapshotFormat string
+
+const (
+	SnapshotFormat_JSON SnapshotFormat = "json"
+	SnapshotFormat_YAML SnapshotFormat = "yaml"
+	// SnapshotFormat_Bootstrap is the flag configuration of the OpenFeature web and in-memory providers,
+	// see ExportBootstrap
+	SnapshotFormat_Bootstrap SnapshotFormat = "bootstrap"
+)
+
+// ExportSnapshot returns all the resolved values of the open environment, e.g. to dump the effective
+// configuration for debugging or to hand it to other systems. Secrets denied using WithDenySecrets
+// are replaced with a redacted placeholder.
+func (p *PulumiESCProvider) ExportSnapshot(ctx context.Context) (map[string]interface{}, error) {
+	snapshot, err := p.readEnvironment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.redactedValues(snapshot), nil
+}
+
+// redactedValues returns a copy of the values of the snapshot with the denied secrets redacted
+func (p *PulumiESCProvider) redactedValues(snapshot *environmentSnapshot) map[string]interface{} {
+	values := copyValues(snapshot.Values)
+	for _, secret := range snapshot.Secrets {
+		if p.secretDenied(secret) {
+			redactValue(values, secret)
+		}
+	}
+	return values
+}
+
+// ExportSnapshotEncoded returns the values returned by ExportSnapshot encoded in the given format, or the
+// flags returned by ExportBootstrap for SnapshotFormat_Bootstrap
+func (p *PulumiESCProvider) ExportSnapshotEncoded(ctx context.Context, format SnapshotFormat) ([]byte, error) {
+	if format == SnapshotFormat_Bootstrap {
+		return p.ExportBootstrapEncoded(ctx)
+	}
+	values, err := p.ExportSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case SnapshotFormat_JSON:
+		return json.MarshalIndent(values, "", "  ")
+	case SnapshotFormat_YAML:
+		return yaml.Marshal(values)
+	}
+	return nil, fmt.Errorf("unsupported snapshot format %q", format)
+}
+
+// copyValues returns a deep copy of the nested maps of values
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if nested, ok := value.(map[string]interface{}); ok {
+			value = copyValues(nested)
+		}
+		copied[key] = value
+	}
+	return copied
+}
+
+// redactValue replaces the value of the given property path with a redacted placeholder
+func redactValue(values map[string]interface{}, propertyPath string) {
+	path, err := parsePropertyPath(propertyPath)
+	if err != nil {
+		return
+	}
+	keys, ok := objectKeys(path)
+	if !ok {
+		return
+	}
+	for _, key := range keys[:len(keys)-1] {
+		nested, ok := values[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		values = nested
+	}
+	if _, ok := values[keys[len(keys)-1]]; ok {
+		values[keys[len(keys)-1]] = maskedValue
+	}
+}
@@ -0,0 +1,39 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPulumiESCProvider_ExportSnapshot(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{BOOL_FLAG_KEY: BOOL_FLAG_VALUE})
+	server.SetSecret("configs.OPENAI_API_KEY", "sk-12345")
+	server.SetSecret("configs.GITHUB_TOKEN", "ghp-12345")
+
+	p := newTestProvider(t, server, WithDenySecrets("configs.GITHUB_TOKEN"))
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+
+	values, err := p.ExportSnapshot(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		BOOL_FLAG_KEY: BOOL_FLAG_VALUE,
+		"configs": map[string]interface{}{
+			"OPENAI_API_KEY": maskedValue,
+			"GITHUB_TOKEN":   "ghp-12345",
+		},
+	}, values)
+
+	encoded, err := p.ExportSnapshotEncoded(context.Background(), SnapshotFormat_JSON)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"SOME_BOOL_FLAG":true,"configs":{"OPENAI_API_KEY":"[secret]","GITHUB_TOKEN":"ghp-12345"}}`, string(encoded))
+
+	encoded, err = p.ExportSnapshotEncoded(context.Background(), SnapshotFormat_YAML)
+	assert.NoError(t, err)
+	assert.YAMLEq(t, "SOME_BOOL_FLAG: true\nconfigs:\n  OPENAI_API_KEY: '[secret]'\n  GITHUB_TOKEN: ghp-12345\n", string(encoded))
+
+	_, err = p.ExportSnapshotEncoded(context.Background(), "toml")
+	assert.ErrorContains(t, err, "unsupported snapshot format")
+}
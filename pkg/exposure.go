@@ -0,0 +1,128 @@
+package pulumi
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// ExposureCount is the number of evaluations of a flag which resolved to the same variant
+// and reason during an aggregation interval
+type ExposureCount struct {
+	Flag    string
+	Variant string
+	Reason  openfeature.Reason
+	Count   uint64
+}
+
+// ExposureSink receives the aggregated exposure counts at the end of every aggregation interval.
+// The counts never contain evaluation context attributes or any other user identifier.
+type ExposureSink interface {
+	EmitExposureCounts(start, end time.Time, counts []ExposureCount)
+}
+
+// ExposureSinkFunc is an adapter to allow the use of ordinary functions as ExposureSink
+type ExposureSinkFunc func(start, end time.Time, counts []ExposureCount)
+
+// EmitExposureCounts calls f(start, end, counts)
+func (f ExposureSinkFunc) EmitExposureCounts(start, end time.Time, counts []ExposureCount) {
+	f(start, end, counts)
+}
+
+type exposureKey struct {
+	flag    string
+	variant string
+	reason  openfeature.Reason
+}
+
+// exposureAggregator counts evaluations per flag and variant and periodically flushes the counts to a sink
+type exposureAggregator struct {
+	mu          sync.Mutex
+	sink        ExposureSink
+	interval    time.Duration
+	windowStart time.Time
+	counts      map[exposureKey]uint64
+}
+
+func newExposureAggregator(interval time.Duration, sink ExposureSink) *exposureAggregator {
+	return &exposureAggregator{
+		sink:        sink,
+		interval:    interval,
+		windowStart: time.Now(),
+		counts:      map[exposureKey]uint64{},
+	}
+}
+
+// WithExposureAggregation counts evaluations per flag and variant, and emits the counts to the sink
+// every interval. It gives visibility into rollouts without shipping per-user exposure events.
+func WithExposureAggregation(interval time.Duration, sink ExposureSink) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if interval <= 0 || sink == nil {
+			return
+		}
+		p.exposures = newExposureAggregator(interval, sink)
+	}
+}
+
+func (a *exposureAggregator) record(flag string, resolutionDetails openfeature.ProviderResolutionDetail) {
+	key := exposureKey{
+		flag:    flag,
+		variant: resolutionDetails.Variant,
+		reason:  resolutionDetails.Reason,
+	}
+	a.mu.Lock()
+	a.counts[key]++
+	a.mu.Unlock()
+}
+
+// run flushes the counts every interval until the context is done, and flushes
+// the counts of the current interval before returning
+func (a *exposureAggregator) run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.flush()
+		case <-ctx.Done():
+			a.flush()
+			return
+		}
+	}
+}
+
+// flush emits the counts of the current interval, if any, and starts a new interval
+func (a *exposureAggregator) flush() {
+	a.mu.Lock()
+	start, end := a.windowStart, time.Now()
+	counts := a.counts
+	a.windowStart = end
+	a.counts = map[exposureKey]uint64{}
+	a.mu.Unlock()
+
+	if len(counts) == 0 {
+		return
+	}
+	exposureCounts := make([]ExposureCount, 0, len(counts))
+	for key, count := range counts {
+		exposureCounts = append(exposureCounts, ExposureCount{
+			Flag:    key.flag,
+			Variant: key.variant,
+			Reason:  key.reason,
+			Count:   count,
+		})
+	}
+	sort.Slice(exposureCounts, func(i, j int) bool {
+		if exposureCounts[i].Flag != exposureCounts[j].Flag {
+			return exposureCounts[i].Flag < exposureCounts[j].Flag
+		}
+		if exposureCounts[i].Variant != exposureCounts[j].Variant {
+			return exposureCounts[i].Variant < exposureCounts[j].Variant
+		}
+		return exposureCounts[i].Reason < exposureCounts[j].Reason
+	})
+	a.sink.EmitExposureCounts(start, end, exposureCounts)
+}
@@ -0,0 +1,37 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExposureAggregator(t *testing.T) {
+	var emitted [][]ExposureCount
+	aggregator := newExposureAggregator(time.Hour, ExposureSinkFunc(func(start, end time.Time, counts []ExposureCount) {
+		assert.False(t, end.Before(start))
+		emitted = append(emitted, counts)
+	}))
+
+	aggregator.flush()
+	assert.Empty(t, emitted, "empty intervals must not be emitted")
+
+	static := openfeature.ProviderResolutionDetail{Reason: openfeature.StaticReason}
+	notFound := openfeature.ProviderResolutionDetail{Reason: openfeature.ErrorReason, ResolutionError: openfeature.NewFlagNotFoundResolutionError("")}
+	aggregator.record(STRING_FLAG_KEY, static)
+	aggregator.record(BOOL_FLAG_KEY, static)
+	aggregator.record(BOOL_FLAG_KEY, static)
+	aggregator.record(BOOL_FLAG_KEY, notFound)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	aggregator.run(ctx)
+
+	assert.Equal(t, [][]ExposureCount{{
+		{Flag: BOOL_FLAG_KEY, Reason: openfeature.ErrorReason, Count: 1},
+		{Flag: BOOL_FLAG_KEY, Reason: openfeature.StaticReason, Count: 2},
+		{Flag: STRING_FLAG_KEY, Reason: openfeature.StaticReason, Count: 1},
+	}}, emitted)
+}
@@ -0,0 +1,82 @@
+package pulumi
+
+import (
+	"expvar"
+	"sync"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// expvarMu serialises the creation of the expvar maps shared by providers publishing under the same name
+var expvarMu sync.Mutex
+
+// providerVars are the counters of a provider published using expvar
+type providerVars struct {
+	root   *expvar.Map
+	errors *expvar.Map
+	cache  *expvar.Map
+	flags  *expvar.Map
+}
+
+// WithExpvar publishes the counters of the provider using expvar under the given name, e.g.
+// "pulumi_esc_provider", so they are served by the /debug/vars handler without Prometheus:
+//
+//   - evaluations: the number of evaluations
+//   - flags: the number of evaluations by flag key, not counting flags which do not exist
+//   - errors: the number of failed evaluations by error category, e.g. FLAG_NOT_FOUND or RATE_LIMITED, see ErrorCounts
+//   - cache: the number of evaluated values by cache status, e.g. HIT
+//   - refreshes: the number of environment sessions opened
+//
+// Providers publishing under the same name share the counters. It panics, like expvar.Publish, if the name
+// is already published by another package.
+func WithExpvar(name string) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.vars = publishedVars(name)
+	}
+}
+
+// publishedVars returns the counters published under the name, publishing them if needed
+func publishedVars(name string) *providerVars {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	root, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		root = expvar.NewMap(name)
+	}
+	vars := &providerVars{root: root, errors: childMap(root, "errors"), cache: childMap(root, "cache"), flags: childMap(root, "flags")}
+	for _, counter := range []string{"evaluations", "refreshes"} {
+		if root.Get(counter) == nil {
+			root.Set(counter, new(expvar.Int))
+		}
+	}
+	return vars
+}
+
+// childMap returns the map of the key of a map, creating it if needed
+func childMap(parent *expvar.Map, key string) *expvar.Map {
+	if child, ok := parent.Get(key).(*expvar.Map); ok {
+		return child
+	}
+	child := new(expvar.Map).Init()
+	parent.Set(key, child)
+	return child
+}
+
+// recordEvaluation counts an evaluation of the flag, its error category and the cache status of its value
+func (v *providerVars) recordEvaluation(flag string, resolutionDetails openfeature.ProviderResolutionDetail) {
+	v.root.Add("evaluations", 1)
+	if resolutionDetails.ResolutionDetail().ErrorCode != openfeature.FlagNotFoundCode {
+		v.flags.Add(flag, 1)
+	}
+	if category := errorCategory(resolutionDetails); category != "" {
+		v.errors.Add(category, 1)
+	}
+	if cacheStatus, ok := resolutionDetails.FlagMetadata[cacheMetadataKey].(string); ok {
+		v.cache.Add(cacheStatus, 1)
+	}
+}
+
+// recordRefresh counts an opened environment session
+func (v *providerVars) recordRefresh() {
+	v.root.Add("refreshes", 1)
+}
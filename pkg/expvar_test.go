@@ -0,0 +1,46 @@
+package pulumi
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithExpvar(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	name := fmt.Sprintf("pulumi_esc_provider_test_%d", time.Now().UnixNano())
+	p := newTestProvider(t, server, WithExpvar(name))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+	p.BooleanEvaluation(ctx, "MISSING", false, nil)
+	p.StringEvaluation(ctx, "DEBUG_MODE", "", nil)
+
+	var vars struct {
+		Evaluations int64            `json:"evaluations"`
+		Errors      map[string]int64 `json:"errors"`
+		Cache       map[string]int64 `json:"cache"`
+		Flags       map[string]int64 `json:"flags"`
+		Refreshes   int64            `json:"refreshes"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get(name).String()), &vars))
+	assert.Equal(t, int64(3), vars.Evaluations)
+	assert.Equal(t, map[string]int64{"FLAG_NOT_FOUND": 1, "TYPE_MISMATCH": 1}, vars.Errors)
+	assert.Equal(t, map[string]int64{"BYPASS": 1}, vars.Cache)
+	assert.Equal(t, map[string]int64{"DEBUG_MODE": 2}, vars.Flags, "flags which do not exist must not be counted")
+	assert.Equal(t, int64(1), vars.Refreshes)
+
+	other := newTestProvider(t, server, WithExpvar(name))
+	require.NoError(t, other.initialise(ctx))
+	other.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get(name).String()), &vars))
+	assert.Equal(t, int64(4), vars.Evaluations, "providers publishing under the same name must share the counters")
+	assert.Equal(t, int64(2), vars.Refreshes)
+}
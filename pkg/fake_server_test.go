@@ -0,0 +1,28 @@
+package pulumi
+
+import (
+	"testing"
+
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/pulumitest"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+// newFakeESCServer starts a fake Pulumi ESC API serving the given values, closed when the test completes
+func newFakeESCServer(t *testing.T, values map[string]interface{}) *pulumitest.Server {
+	server := pulumitest.NewServer(values)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestProvider returns a provider in NOT_READY state using the given server as Pulumi ESC API.
+// It is shut down when the test completes.
+func newTestProvider(t *testing.T, server *pulumitest.Server, opts ...ProviderOption) *PulumiESCProvider {
+	p := newPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME, opts...)
+	conf := esc.NewConfiguration()
+	conf.Servers = esc.ServerConfigurations{{URL: server.URL + "/api/esc"}}
+	conf.HTTPClient = p.newAPIHTTPClient(p.httpClient)
+	p.escClient = newESCClient(conf)
+	p.escAuthCtx = esc.NewAuthContext("token")
+	t.Cleanup(p.Shutdown)
+	return p
+}
@@ -0,0 +1,116 @@
+package pulumi
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// FlagUsage is the number of evaluations of a flag and the time of its last evaluation
+type FlagUsage struct {
+	Key           string    `json:"key"`
+	Evaluations   uint64    `json:"evaluations"`
+	LastEvaluated time.Time `json:"lastEvaluated"`
+}
+
+// flagCounter counts the evaluations of a flag
+type flagCounter struct {
+	evaluations   atomic.Uint64
+	lastEvaluated atomic.Int64
+}
+
+// flagUsageTracker counts the evaluations of every flag
+type flagUsageTracker struct {
+	counters sync.Map
+}
+
+// WithFlagUsage counts the evaluations of every flag and records the time of their last evaluation, to report
+// flag usage and identify dead flags in production. Usage is read using FlagUsage and UnusedFlags.
+func WithFlagUsage() ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.flagUsage = &flagUsageTracker{}
+	}
+}
+
+// record counts the evaluation of a flag, unless the flag does not exist
+func (t *flagUsageTracker) record(flag string, resolutionDetails openfeature.ProviderResolutionDetail) {
+	if resolutionDetails.ResolutionDetail().ErrorCode == openfeature.FlagNotFoundCode {
+		return
+	}
+	counter, ok := t.counters.Load(flag)
+	if !ok {
+		counter, _ = t.counters.LoadOrStore(flag, &flagCounter{})
+	}
+	counter.(*flagCounter).evaluations.Add(1)
+	counter.(*flagCounter).lastEvaluated.Store(time.Now().UnixNano())
+}
+
+// FlagUsage returns the number of evaluations and the time of the last evaluation of every evaluated flag
+// since the provider was created, sorted by key. Evaluations of flags which do not exist are not counted.
+// It returns nil unless WithFlagUsage is set.
+func (p *PulumiESCProvider) FlagUsage() []FlagUsage {
+	if p.flagUsage == nil {
+		return nil
+	}
+	var usage []FlagUsage
+	p.flagUsage.counters.Range(func(key, counter interface{}) bool {
+		usage = append(usage, FlagUsage{
+			Key:           key.(string),
+			Evaluations:   counter.(*flagCounter).evaluations.Load(),
+			LastEvaluated: time.Unix(0, counter.(*flagCounter).lastEvaluated.Load()),
+		})
+		return true
+	})
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Key < usage[j].Key
+	})
+	return usage
+}
+
+// UnusedFlags returns the keys of the flags of the environment which were not evaluated during the given
+// period, e.g. 30 days, or since the provider was created, sorted by key. Objects are not flags, but their
+// nested values are, and flag definitions are flags when WithFlagDefinitions is set. Flags evaluated
+// by other replicas are unused as far as this provider knows. It returns nil unless WithFlagUsage is set.
+func (p *PulumiESCProvider) UnusedFlags(ctx context.Context, period time.Duration) ([]string, error) {
+	if p.flagUsage == nil {
+		return nil, nil
+	}
+	snapshot, err := p.readEnvironment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().Add(-period).UnixNano()
+	var unused []string
+	for _, key := range p.usageKeys(snapshot.Values, "") {
+		counter, ok := p.flagUsage.counters.Load(key)
+		if !ok || counter.(*flagCounter).lastEvaluated.Load() < since {
+			unused = append(unused, key)
+		}
+	}
+	sort.Strings(unused)
+	return unused, nil
+}
+
+// usageKeys returns the keys of the flags of the values whose usage is tracked
+func (p *PulumiESCProvider) usageKeys(values map[string]interface{}, prefix string) []string {
+	var keys []string
+	for key, value := range values {
+		key = joinPropertyPath(prefix, key)
+		nested, ok := value.(map[string]interface{})
+		if ok && p.flagDefinitions {
+			if definition, err := parseFlagDefinition(value); err != nil || definition != nil {
+				ok = false
+			}
+		}
+		if ok {
+			keys = append(keys, p.usageKeys(nested, key)...)
+		} else {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,60 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPulumiESCProvider_FlagUsage(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"DEBUG_MODE":          true,
+		"configs.MAX_RETRIES": 3,
+		"configs.TIMEOUT":     1.5,
+		"NEW_SEARCH":          map[string]interface{}{"value": true, "rollout": 50},
+	})
+	p := newTestProvider(t, server, WithFlagUsage(), WithFlagDefinitions())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	unused, err := p.UnusedFlags(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DEBUG_MODE", "NEW_SEARCH", "configs.MAX_RETRIES", "configs.TIMEOUT"}, unused)
+
+	before := time.Now()
+	p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+	p.StringEvaluation(ctx, "DEBUG_MODE", "", nil)
+	p.IntEvaluation(ctx, "configs.MAX_RETRIES", 0, nil)
+	p.BooleanEvaluation(ctx, "MISSING", false, nil)
+
+	usage := p.FlagUsage()
+	require.Len(t, usage, 2, "flags which do not exist must not be counted")
+	assert.Equal(t, "DEBUG_MODE", usage[0].Key)
+	assert.Equal(t, uint64(2), usage[0].Evaluations, "failed evaluations of existing flags must be counted")
+	assert.False(t, usage[0].LastEvaluated.Before(before))
+	assert.Equal(t, "configs.MAX_RETRIES", usage[1].Key)
+	assert.Equal(t, uint64(1), usage[1].Evaluations)
+
+	unused, err = p.UnusedFlags(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"NEW_SEARCH", "configs.TIMEOUT"}, unused)
+	unused, err = p.UnusedFlags(ctx, 0)
+	require.NoError(t, err)
+	assert.Len(t, unused, 4, "flags evaluated before the period must be unused")
+}
+
+func TestPulumiESCProvider_FlagUsage_disabled(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+
+	assert.Nil(t, p.FlagUsage())
+	unused, err := p.UnusedFlags(ctx, time.Hour)
+	assert.NoError(t, err)
+	assert.Nil(t, unused)
+}
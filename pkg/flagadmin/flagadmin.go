@@ -0,0 +1,211 @@
+// Package flagadmin manages the lifecycle of the flags of Pulumi ESC environments: promoting flag values
+// between environments, e.g. from dev to staging to prod, archiving flags which are no longer used and
+// listing flags by state. It edits the values of the environment definitions, so every operation creates
+// a new revision of the environments it changes.
+//
+// States are read from the structured flag definitions resolved by providers using WithFlagDefinitions:
+// archived flags are definitions with archived set to true, which providers resolve as disabled.
+package flagadmin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/internal/definition"
+	"gopkg.in/yaml.v3"
+)
+
+// State is the lifecycle state of a flag
+type State string
+
+const (
+	State_Active     State = "active"
+	State_Disabled   State = "disabled"
+	State_Deprecated State = "deprecated"
+	State_Archived   State = "archived"
+)
+
+// Flag is a flag of an environment definition
+type Flag struct {
+	Key   string `json:"key"`
+	State State  `json:"state"`
+	// Deprecated is the deprecation message of a deprecated flag
+	Deprecated string `json:"deprecated,omitempty"`
+}
+
+// Admin manages the flags of the environments of an organisation
+type Admin struct {
+	client  pulumi.ESCDefinitionClient
+	orgName string
+}
+
+// New returns an Admin editing the environments of the organisation using the given client, e.g. the
+// Pulumi ESC client returned by esc.NewClient or provider.Client(). Operations must be called with a
+// context carrying the access token, e.g. returned by esc.NewAuthContext or provider.AuthContext.
+func New(client pulumi.ESCDefinitionClient, orgName string) *Admin {
+	return &Admin{client: client, orgName: orgName}
+}
+
+// ListFlags returns the flags of the "project/env" environment in the given states, or in any state if none
+// is given, sorted by key. Objects which are not flag definitions are listed by their nested values, using
+// dotted keys. Flags defined by imported environments are not listed.
+func (a *Admin) ListFlags(ctx context.Context, environment string, states ...State) ([]Flag, error) {
+	document, err := a.definition(ctx, environment)
+	if err != nil {
+		return nil, err
+	}
+	values, err := definition.Values(document)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pulumi esc environment definition of %s: %w", environment, err)
+	}
+	var flags []Flag
+	walkFlags(values, "", func(key string, node *yaml.Node) {
+		flag := flagState(key, node)
+		if len(states) == 0 || containsState(states, flag.State) {
+			flags = append(flags, flag)
+		}
+	})
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Key < flags[j].Key
+	})
+	return flags, nil
+}
+
+// Promote copies the definitions of the given flags from the "project/env" environment from to the environment
+// to, e.g. from "app/staging" to "app/prod", replacing their definitions in the target environment. Secrets
+// can not be promoted, as they are encrypted for their environment, and neither can flags referencing other
+// values using interpolations such as ${app.url} or opening providers using fn::open, as they would resolve
+// to the values and credentials of the target environment.
+func (a *Admin) Promote(ctx context.Context, from, to string, keys ...string) error {
+	if len(keys) == 0 {
+		return errors.New("no flags to promote")
+	}
+	source, err := a.definition(ctx, from)
+	if err != nil {
+		return err
+	}
+	sourceValues, err := definition.Values(source)
+	if err != nil {
+		return fmt.Errorf("invalid pulumi esc environment definition of %s: %w", from, err)
+	}
+	nodes := make([]*yaml.Node, len(keys))
+	for i, key := range keys {
+		node := definition.Lookup(sourceValues, strings.Split(key, "."))
+		if node == nil {
+			return fmt.Errorf("flag %s does not exist in %s", key, from)
+		}
+		if containsSecret(node) {
+			return fmt.Errorf("flag %s is a secret and can not be promoted", key)
+		}
+		if reference := environmentReference(node); reference != "" {
+			return fmt.Errorf("flag %s uses %s, which resolves in the context of its environment, and can not be promoted", key, reference)
+		}
+		nodes[i] = node
+	}
+	return a.update(ctx, to, func(values *yaml.Node) error {
+		for i, key := range keys {
+			if err := definition.Set(values, strings.Split(key, "."), nodes[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Archive archives a flag of the "project/env" environment, turning it into a flag definition with archived
+// set to true, which providers resolve as disabled. Archiving an archived flag is a no-op.
+func (a *Admin) Archive(ctx context.Context, environment, key string) error {
+	return a.update(ctx, environment, func(values *yaml.Node) error {
+		path := strings.Split(key, ".")
+		node := definition.Lookup(values, path)
+		if node == nil {
+			return fmt.Errorf("flag %s does not exist in %s", key, environment)
+		}
+		if !isFlagDefinition(node) {
+			flagDefinition := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if err := definition.Set(flagDefinition, []string{"value"}, node); err != nil {
+				return err
+			}
+			if err := definition.Set(values, path, flagDefinition); err != nil {
+				return err
+			}
+			node = flagDefinition
+		}
+		archived := &yaml.Node{}
+		if err := archived.Encode(true); err != nil {
+			return err
+		}
+		return definition.Set(node, []string{"archived"}, archived)
+	})
+}
+
+// definition returns the parsed definition of the "project/env" environment
+func (a *Admin) definition(ctx context.Context, environment string) (*yaml.Node, error) {
+	projectName, envName, err := splitEnvironment(environment)
+	if err != nil {
+		return nil, err
+	}
+	_, yamlDefinition, err := a.client.GetEnvironment(ctx, a.orgName, projectName, envName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pulumi esc environment definition of %s: %w", environment, err)
+	}
+	document, err := definition.Parse(yamlDefinition)
+	if err != nil {
+		return nil, fmt.Errorf("invalid environment %s: %w", environment, err)
+	}
+	return document, nil
+}
+
+// update applies the update to the values of the definition of the "project/env" environment and saves it
+func (a *Admin) update(ctx context.Context, environment string, update func(values *yaml.Node) error) error {
+	document, err := a.definition(ctx, environment)
+	if err != nil {
+		return err
+	}
+	values, err := definition.Values(document)
+	if err != nil {
+		return fmt.Errorf("invalid pulumi esc environment definition of %s: %w", environment, err)
+	}
+	if err := update(values); err != nil {
+		return err
+	}
+	updated, err := yaml.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("failed to encode pulumi esc environment definition of %s: %w", environment, err)
+	}
+	projectName, envName, _ := splitEnvironment(environment)
+	diagnostics, err := a.client.UpdateEnvironmentYaml(ctx, a.orgName, projectName, envName, string(updated))
+	if err != nil {
+		return fmt.Errorf("failed to update pulumi esc environment definition of %s: %w", environment, err)
+	}
+	if diagnostics != nil && len(diagnostics.Diagnostics) > 0 {
+		messages := make([]string, len(diagnostics.Diagnostics))
+		for i, diagnostic := range diagnostics.Diagnostics {
+			messages[i] = diagnostic.Summary
+		}
+		return fmt.Errorf("invalid pulumi esc environment definition of %s: %s", environment, strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+// splitEnvironment returns the project and the environment name of a "project/env" environment
+func splitEnvironment(environment string) (string, string, error) {
+	projectName, envName, found := strings.Cut(environment, "/")
+	if !found || projectName == "" || envName == "" {
+		return "", "", fmt.Errorf("environment %q must be of the form project/env", environment)
+	}
+	return projectName, envName, nil
+}
+
+func containsState(states []State, state State) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package flagadmin_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/flagadmin"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// definitionClient keeps the YAML definitions of environments in memory
+type definitionClient struct {
+	definitions map[string]string
+	updates     int
+}
+
+func (c *definitionClient) GetEnvironment(ctx context.Context, org, projectName, envName string) (*esc.EnvironmentDefinition, string, error) {
+	return &esc.EnvironmentDefinition{}, c.definitions[projectName+"/"+envName], nil
+}
+
+func (c *definitionClient) UpdateEnvironmentYaml(ctx context.Context, org, projectName, envName, yaml string) (*esc.EnvironmentDiagnostics, error) {
+	c.definitions[projectName+"/"+envName] = yaml
+	c.updates++
+	return &esc.EnvironmentDiagnostics{}, nil
+}
+
+func (c *definitionClient) CheckEnvironmentYaml(ctx context.Context, org, yaml string) (*esc.CheckEnvironment, error) {
+	return &esc.CheckEnvironment{}, nil
+}
+
+// values returns the decoded values of the definition of an environment
+func (c *definitionClient) values(t *testing.T, environment string) map[string]interface{} {
+	var definition struct {
+		Values map[string]interface{} `yaml:"values"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(c.definitions[environment]), &definition))
+	return definition.Values
+}
+
+func TestListFlags(t *testing.T) {
+	client := &definitionClient{definitions: map[string]string{"app/dev": `
+imports:
+  - base
+values:
+  DEBUG_MODE: true
+  API_KEY:
+    fn::secret: sk-12345
+  checkout:
+    NEW_FLOW:
+      value: true
+      deprecated: use NEW_FLOW_V2
+    OLD_FLOW:
+      value: false
+      archived: true
+    LEGACY:
+      value: false
+      enabled: false
+`}}
+	admin := flagadmin.New(client, "test-org")
+	ctx := context.Background()
+
+	flags, err := admin.ListFlags(ctx, "app/dev")
+	require.NoError(t, err)
+	assert.Equal(t, []flagadmin.Flag{
+		{Key: "API_KEY", State: flagadmin.State_Active},
+		{Key: "DEBUG_MODE", State: flagadmin.State_Active},
+		{Key: "checkout.LEGACY", State: flagadmin.State_Disabled},
+		{Key: "checkout.NEW_FLOW", State: flagadmin.State_Deprecated, Deprecated: "use NEW_FLOW_V2"},
+		{Key: "checkout.OLD_FLOW", State: flagadmin.State_Archived},
+	}, flags)
+
+	flags, err = admin.ListFlags(ctx, "app/dev", flagadmin.State_Archived, flagadmin.State_Disabled)
+	require.NoError(t, err)
+	assert.Equal(t, []flagadmin.Flag{
+		{Key: "checkout.LEGACY", State: flagadmin.State_Disabled},
+		{Key: "checkout.OLD_FLOW", State: flagadmin.State_Archived},
+	}, flags)
+
+	_, err = admin.ListFlags(ctx, "dev")
+	assert.Error(t, err, "environments must be of the form project/env")
+}
+
+func TestPromote(t *testing.T) {
+	client := &definitionClient{definitions: map[string]string{
+		"app/staging": `{"values": {"DEBUG_MODE": false, "checkout": {"NEW_FLOW": {"value": true, "rollout": 50}}, "API_KEY": {"fn::secret": "sk-1"},
+			"BANNER": "Welcome to $${app.name}", "API_URL": {"value": "https://${app.host}/api"},
+			"aws": {"fn::open::aws-login": {"oidc": {"roleArn": "arn:aws:iam::123456789012:role/staging"}}}}}`,
+		"app/prod": "imports:\n  - base\nvalues:\n  DEBUG_MODE: true\n  THEME: dark\n",
+	}}
+	admin := flagadmin.New(client, "test-org")
+	ctx := context.Background()
+
+	require.NoError(t, admin.Promote(ctx, "app/staging", "app/prod", "DEBUG_MODE", "checkout.NEW_FLOW"))
+	assert.Equal(t, map[string]interface{}{
+		"DEBUG_MODE": false,
+		"THEME":      "dark",
+		"checkout":   map[string]interface{}{"NEW_FLOW": map[string]interface{}{"value": true, "rollout": 50}},
+	}, client.values(t, "app/prod"))
+	assert.Contains(t, client.definitions["app/prod"], "imports:\n    - base", "the rest of the definition must be kept")
+
+	assert.ErrorContains(t, admin.Promote(ctx, "app/staging", "app/prod", "API_KEY"), "secret")
+	assert.ErrorContains(t, admin.Promote(ctx, "app/staging", "app/prod", "API_URL"), "flag API_URL uses ${app.host}")
+	assert.ErrorContains(t, admin.Promote(ctx, "app/staging", "app/prod", "aws"), "flag aws uses fn::open::aws-login")
+	assert.ErrorContains(t, admin.Promote(ctx, "app/staging", "app/prod", "MISSING"), "does not exist")
+	assert.Error(t, admin.Promote(ctx, "app/staging", "app/prod"))
+	assert.Equal(t, 1, client.updates, "failed promotions must not update the environment")
+
+	require.NoError(t, admin.Promote(ctx, "app/staging", "app/prod", "BANNER"), "escaped interpolations are plain strings")
+	assert.Equal(t, "Welcome to $${app.name}", client.values(t, "app/prod")["BANNER"])
+}
+
+func TestArchive(t *testing.T) {
+	client := &definitionClient{definitions: map[string]string{
+		"app/prod": "values:\n  DEBUG_MODE: true\n  checkout:\n    NEW_FLOW:\n      value: true\n      deprecated: use NEW_FLOW_V2\n",
+	}}
+	admin := flagadmin.New(client, "test-org")
+	ctx := context.Background()
+
+	require.NoError(t, admin.Archive(ctx, "app/prod", "DEBUG_MODE"))
+	require.NoError(t, admin.Archive(ctx, "app/prod", "checkout.NEW_FLOW"))
+	assert.Equal(t, map[string]interface{}{
+		"DEBUG_MODE": map[string]interface{}{"value": true, "archived": true},
+		"checkout": map[string]interface{}{
+			"NEW_FLOW": map[string]interface{}{"value": true, "deprecated": "use NEW_FLOW_V2", "archived": true},
+		},
+	}, client.values(t, "app/prod"))
+
+	flags, err := admin.ListFlags(ctx, "app/prod", flagadmin.State_Archived)
+	require.NoError(t, err)
+	assert.Len(t, flags, 2)
+
+	require.NoError(t, admin.Archive(ctx, "app/prod", "DEBUG_MODE"), "archiving an archived flag must be a no-op")
+	assert.Equal(t, map[string]interface{}{"value": true, "archived": true}, client.values(t, "app/prod")["DEBUG_MODE"])
+	assert.ErrorContains(t, admin.Archive(ctx, "app/prod", "MISSING"), "does not exist")
+}
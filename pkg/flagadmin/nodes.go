@@ -0,0 +1,125 @@
+package flagadmin
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/internal/definition"
+	"gopkg.in/yaml.v3"
+)
+
+// flagDefinitionFields are the fields of a structured flag definition, see pulumi.WithFlagDefinitions
+var flagDefinitionFields = map[string]bool{
+	"value":          true,
+	"offValue":       true,
+	"prerequisites":  true,
+	"rollout":        true,
+	"salt":           true,
+	"variants":       true,
+	"defaultVariant": true,
+	"distribution":   true,
+	"rules":          true,
+	"metadata":       true,
+	"enabled":        true,
+	"deprecated":     true,
+	"archived":       true,
+	"ttl":            true,
+}
+
+// walkFlags calls fn with every flag of the values mapping. Objects which are neither flag definitions nor
+// function calls, such as fn::secret, are walked instead of being flags.
+func walkFlags(values *yaml.Node, prefix string, fn func(key string, node *yaml.Node)) {
+	for i := 0; i+1 < len(values.Content); i += 2 {
+		key, node := prefix+values.Content[i].Value, values.Content[i+1]
+		if node.Kind == yaml.MappingNode && !isFlagDefinition(node) && !definition.IsFunctionCall(node) {
+			walkFlags(node, key+".", fn)
+			continue
+		}
+		fn(key, node)
+	}
+}
+
+// isFlagDefinition reports whether a node is a structured flag definition, an object with a value or
+// variants key and only the fields of a definition
+func isFlagDefinition(node *yaml.Node) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	hasValue := false
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		field := node.Content[i].Value
+		if !flagDefinitionFields[field] {
+			return false
+		}
+		hasValue = hasValue || field == "value" || field == "variants"
+	}
+	return hasValue
+}
+
+// interpolation matches the interpolations of a string, e.g. ${app.url}, which are not escaped as $${app.url}
+var interpolation = regexp.MustCompile(`(^|[^$])\$\{[^}]*\}`)
+
+// environmentReference returns an interpolation, e.g. ${app.url}, or fn::open call of a node or of one of its
+// nested values, which resolve in the context of their environment, or "" if there is none
+func environmentReference(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if match := interpolation.FindStringSubmatch(node.Value); match != nil {
+			return strings.TrimPrefix(match[0], match[1])
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := node.Content[i].Value; key == "fn::open" || strings.HasPrefix(key, "fn::open::") {
+				return key
+			}
+		}
+	}
+	for _, child := range node.Content {
+		if reference := environmentReference(child); reference != "" {
+			return reference
+		}
+	}
+	return ""
+}
+
+// containsSecret reports whether a node or one of its nested values is a secret
+func containsSecret(node *yaml.Node) bool {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == "fn::secret" {
+				return true
+			}
+		}
+	}
+	for _, child := range node.Content {
+		if containsSecret(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// flagState returns the flag with the state of its definition
+func flagState(key string, node *yaml.Node) Flag {
+	flag := Flag{Key: key, State: State_Active}
+	if !isFlagDefinition(node) {
+		return flag
+	}
+	var definition struct {
+		Enabled    *bool  `yaml:"enabled"`
+		Deprecated string `yaml:"deprecated"`
+		Archived   bool   `yaml:"archived"`
+	}
+	// Invalid fields are reported by the providers resolving the flag
+	_ = node.Decode(&definition)
+	flag.Deprecated = definition.Deprecated
+	switch {
+	case definition.Archived:
+		flag.State = State_Archived
+	case definition.Enabled != nil && !*definition.Enabled:
+		flag.State = State_Disabled
+	case definition.Deprecated != "":
+		flag.State = State_Deprecated
+	}
+	return flag
+}
@@ -0,0 +1,97 @@
+package pulumi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// flagdSchema is the JSON Schema of flagd flag definitions
+const flagdSchema = "https://flagd.dev/schema/v0/flags.json"
+
+// flagdVariant is the name of the single variant of the flags exported to flagd
+const flagdVariant = "value"
+
+// flagdFlag is a flagd flag definition
+type flagdFlag struct {
+	State          string                 `json:"state"`
+	Variants       map[string]interface{} `json:"variants"`
+	DefaultVariant string                 `json:"defaultVariant"`
+}
+
+// FlagdConfiguration returns the flags of the open environment as a flagd flag definition document,
+// so flagd can be fed from Pulumi ESC using its file or HTTP sync sources. Every value listed by
+// ListFlags is an enabled flag with a single variant named "value". Secrets denied using
+// WithDenySecrets are omitted, including from the objects containing them.
+func (p *PulumiESCProvider) FlagdConfiguration(ctx context.Context) ([]byte, error) {
+	snapshot, err := p.readEnvironment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Denied secrets are removed from the objects containing them too
+	values := copyValues(snapshot.Values)
+	for _, secret := range snapshot.Secrets {
+		if p.secretDenied(secret) {
+			removeValue(values, secret)
+		}
+	}
+	allowed := &environmentSnapshot{Values: values, Secrets: snapshot.Secrets}
+	flags := map[string]flagdFlag{}
+	for _, flag := range allowed.flags() {
+		_, rawValue, _ := allowed.lookup(flag.Key)
+		flags[flag.Key] = flagdFlag{
+			State:          "ENABLED",
+			Variants:       map[string]interface{}{flagdVariant: rawValue},
+			DefaultVariant: flagdVariant,
+		}
+	}
+	return json.MarshalIndent(map[string]interface{}{"$schema": flagdSchema, "flags": flags}, "", "  ")
+}
+
+// removeValue removes the value of the given property path
+func removeValue(values map[string]interface{}, propertyPath string) {
+	path, err := parsePropertyPath(propertyPath)
+	if err != nil {
+		return
+	}
+	keys, ok := objectKeys(path)
+	if !ok {
+		return
+	}
+	for _, key := range keys[:len(keys)-1] {
+		nested, ok := values[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		values = nested
+	}
+	delete(values, keys[len(keys)-1])
+}
+
+// WriteFlagdFile writes the flagd flag definition document returned by FlagdConfiguration to the given
+// path, to be watched by a flagd file sync source. The file is replaced atomically.
+func (p *PulumiESCProvider) WriteFlagdFile(ctx context.Context, path string) error {
+	configuration, err := p.FlagdConfiguration(ctx)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, configuration); err != nil {
+		return fmt.Errorf("failed to write flagd file: %w", err)
+	}
+	return nil
+}
+
+// FlagdSyncHandler returns a http.Handler serving the flagd flag definition document returned by
+// FlagdConfiguration, to be polled by a flagd HTTP sync source
+func (p *PulumiESCProvider) FlagdSyncHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		configuration, err := p.FlagdConfiguration(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(configuration)
+	})
+}
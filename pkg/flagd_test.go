@@ -0,0 +1,71 @@
+package pulumi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPulumiESCProvider_FlagdConfiguration(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		BOOL_FLAG_KEY:        BOOL_FLAG_VALUE,
+		"configs.DEBUG_MODE": false,
+	})
+	server.SetSecret("configs.GITHUB_TOKEN", "ghp-12345")
+	p := newTestProvider(t, server, WithDenySecrets())
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+
+	expected := map[string]interface{}{
+		"$schema": "https://flagd.dev/schema/v0/flags.json",
+		"flags": map[string]interface{}{
+			BOOL_FLAG_KEY: map[string]interface{}{
+				"state":          "ENABLED",
+				"variants":       map[string]interface{}{"value": true},
+				"defaultVariant": "value",
+			},
+			"configs": map[string]interface{}{
+				"state":          "ENABLED",
+				"variants":       map[string]interface{}{"value": map[string]interface{}{"DEBUG_MODE": false}},
+				"defaultVariant": "value",
+			},
+			"configs.DEBUG_MODE": map[string]interface{}{
+				"state":          "ENABLED",
+				"variants":       map[string]interface{}{"value": false},
+				"defaultVariant": "value",
+			},
+		},
+	}
+	decode := func(t *testing.T, data []byte) map[string]interface{} {
+		var configuration map[string]interface{}
+		assert.NoError(t, json.Unmarshal(data, &configuration))
+		return configuration
+	}
+
+	t.Run("configuration", func(t *testing.T) {
+		configuration, err := p.FlagdConfiguration(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, decode(t, configuration))
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "flags.json")
+		assert.NoError(t, p.WriteFlagdFile(context.Background(), path))
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, decode(t, data))
+	})
+
+	t.Run("http", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		p.FlagdSyncHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/flags.json", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, expected, decode(t, recorder.Body.Bytes()))
+	})
+}
@@ -0,0 +1,115 @@
+package pulumi
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// FlagInfo describes a flag available in the environment
+type FlagInfo struct {
+	Key    string   `json:"key"`
+	Type   FlagType `json:"type"`
+	Secret bool     `json:"secret"`
+	// Tombstone describes the deletion of a flag resolved by the provider before but no longer present in
+	// the environment. Deleted flags have no type.
+	Tombstone *Tombstone `json:"tombstone,omitempty"`
+}
+
+// ListFlags returns the flags of the open environment sorted by key. Every value is a flag, including
+// objects, whose nested values are listed using dotted keys. The type of numbers without fractional
+// part is inferred as integer. Flags resolved by the provider before but deleted since are listed with
+// their tombstone.
+func (p *PulumiESCProvider) ListFlags(ctx context.Context) ([]FlagInfo, error) {
+	snapshot, err := p.readEnvironment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	flags := snapshot.flags()
+	tombstones := p.tombstones.sweep(func(key string) bool {
+		_, _, ok := snapshot.lookup(key)
+		return ok
+	})
+	if len(tombstones) == 0 {
+		return flags, nil
+	}
+	for i := range tombstones {
+		flags = append(flags, FlagInfo{Key: tombstones[i].Key, Tombstone: &tombstones[i]})
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Key < flags[j].Key
+	})
+	return flags, nil
+}
+
+// flags returns the flags of the snapshot sorted by key
+func (s *environmentSnapshot) flags() []FlagInfo {
+	values := flattenValues(s.Values)
+	flags := make([]FlagInfo, 0, len(values))
+	for key, value := range values {
+		flags = append(flags, FlagInfo{Key: key, Type: inferFlagType(value), Secret: s.isSecret(key)})
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Key < flags[j].Key
+	})
+	return flags
+}
+
+// FlattenValues returns every value of the exported values, e.g. of ExportSnapshot, keyed by its flag key,
+// including objects and, using dotted keys, their nested values. Keys containing dots are quoted, see QuoteKey.
+func FlattenValues(values map[string]interface{}) map[string]interface{} {
+	return flattenValues(values)
+}
+
+// flattenValues returns every value keyed by its flag key, including objects and, using dotted keys,
+// their nested values. Keys containing dots are quoted, see QuoteKey.
+func flattenValues(values map[string]interface{}) map[string]interface{} {
+	flattened := map[string]interface{}{}
+	var collect func(prefix string, values map[string]interface{})
+	collect = func(prefix string, values map[string]interface{}) {
+		for key, value := range values {
+			key = joinPropertyPath(prefix, key)
+			flattened[key] = value
+			if nested, ok := value.(map[string]interface{}); ok {
+				collect(key, nested)
+			}
+		}
+	}
+	collect("", values)
+	return flattened
+}
+
+// inferFlagType returns the FlagType of a raw value
+func inferFlagType(rawValue interface{}) FlagType {
+	switch value := rawValue.(type) {
+	case bool:
+		return FlagType_Bool
+	case string:
+		return FlagType_String
+	case json.Number:
+		return FlagType_Integer
+	case float64:
+		if value == math.Trunc(value) {
+			return FlagType_Integer
+		}
+		return FlagType_Float
+	}
+	return FlagType_Object
+}
+
+// HasFlag reports whether the flag exists in the environment. It is answered from the last known good
+// snapshot or the values previously read by the provider if available, and by reading the flag otherwise.
+func (p *PulumiESCProvider) HasFlag(ctx context.Context, key string) (bool, error) {
+	if _, _, _, ok := p.fallbackValue(key); ok {
+		return true, nil
+	}
+	_, _, _, err := p.readProperty(ctx, key)
+	if isKeyNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,80 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPulumiESCProvider_ListFlags(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		BOOL_FLAG_KEY:   BOOL_FLAG_VALUE,
+		STRING_FLAG_KEY: STRING_FLAG_VALUE,
+		INT_FLAG_KEY:    INT_FLAG_VALUE,
+		FLOAT_FLAG_KEY:  FLOAT_FLAG_VALUE,
+	})
+	server.SetSecret("configs.OPENAI_API_KEY", "sk-12345")
+	p := newTestProvider(t, server, WithTombstones())
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+
+	flags, err := p.ListFlags(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []FlagInfo{
+		{Key: BOOL_FLAG_KEY, Type: FlagType_Bool},
+		{Key: FLOAT_FLAG_KEY, Type: FlagType_Float},
+		{Key: INT_FLAG_KEY, Type: FlagType_Integer},
+		{Key: STRING_FLAG_KEY, Type: FlagType_String},
+		{Key: "configs", Type: FlagType_Object},
+		{Key: "configs.OPENAI_API_KEY", Type: FlagType_String, Secret: true},
+	}, flags)
+
+	// Flags evaluated before and deleted since are listed with their tombstone
+	assert.True(t, p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, false, nil).Value)
+	server.DeleteValue(BOOL_FLAG_KEY)
+	require.NoError(t, p.openSession())
+	flags, err = p.ListFlags(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, BOOL_FLAG_KEY, flags[0].Key)
+	if assert.NotNil(t, flags[0].Tombstone) {
+		assert.Equal(t, BOOL_FLAG_KEY, flags[0].Tombstone.Key)
+		assert.Equal(t, tombstoneHash(BOOL_FLAG_VALUE), flags[0].Tombstone.LastValueHash)
+		assert.Empty(t, flags[0].Type)
+	}
+	assert.Equal(t, []Tombstone{*flags[0].Tombstone}, p.Tombstones())
+	assert.Len(t, flags, 6)
+}
+
+func TestInferFlagType(t *testing.T) {
+	assert.Equal(t, FlagType_Bool, inferFlagType(true))
+	assert.Equal(t, FlagType_String, inferFlagType("value"))
+	assert.Equal(t, FlagType_Integer, inferFlagType(float64(50)))
+	assert.Equal(t, FlagType_Float, inferFlagType(0.5))
+	assert.Equal(t, FlagType_Object, inferFlagType(map[string]interface{}{}))
+	assert.Equal(t, FlagType_Object, inferFlagType([]interface{}{}))
+}
+
+func TestPulumiESCProvider_HasFlag(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{BOOL_FLAG_KEY: BOOL_FLAG_VALUE})
+	p := newTestProvider(t, server)
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+
+	ok, err := p.HasFlag(context.Background(), BOOL_FLAG_KEY)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = p.HasFlag(context.Background(), NON_EXISTING_FLAG_KEY)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	server.SetUnavailable(true)
+	_, err = p.HasFlag(context.Background(), BOOL_FLAG_KEY)
+	assert.Error(t, err)
+
+	p.snapshot.Store(&environmentSnapshot{Values: map[string]interface{}{BOOL_FLAG_KEY: true}})
+	ok, err = p.HasFlag(context.Background(), BOOL_FLAG_KEY)
+	assert.NoError(t, err)
+	assert.True(t, ok, "the snapshot must be used when available")
+}
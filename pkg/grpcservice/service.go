@@ -0,0 +1,267 @@
+// Package grpcservice exposes the evaluations of a Pulumi ESC OpenFeature provider as a gRPC service,
+// so services written in any language can resolve flags from the provider over gRPC.
+//
+// The service is defined in proto/evaluation.proto, and its messages and service descriptor are generated
+// in the evaluationpb package, which also provides the Go client of the service.
+package grpcservice
+
+//go:generate protoc --go_out=. --go_opt=module=github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/grpcservice --go-grpc_out=. --go-grpc_opt=module=github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/grpcservice proto/evaluation.proto
+
+import (
+	"context"
+	"encoding/json"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/grpcservice/evaluationpb"
+	"github.com/open-feature/go-sdk/openfeature"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// ServiceName is the fully qualified name of the evaluation service
+	ServiceName = "pulumi.esc.openfeature.v1.EvaluationService"
+	// internalError is the error message of the failures of the Pulumi ESC API, whose errors are not
+	// disclosed to clients
+	internalError = "internal error"
+)
+
+// Service resolves flags using a provider
+type Service struct {
+	evaluationpb.UnimplementedEvaluationServiceServer
+	provider    *pulumi.PulumiESCProvider
+	interceptor grpc.UnaryServerInterceptor
+}
+
+// Option configures the evaluation service
+type Option func(s *Service)
+
+// WithInterceptor runs the interceptor around every call of the evaluation service, e.g. to authenticate
+// clients using the metadata of the incoming context. Unlike the interceptors of the gRPC server, it does
+// not apply to the other services of the server.
+func WithInterceptor(interceptor grpc.UnaryServerInterceptor) Option {
+	return func(s *Service) {
+		s.interceptor = interceptor
+	}
+}
+
+// Register registers the evaluation service backed by the given provider on the gRPC server.
+//
+// Clients are not trusted: secrets are never served and their evaluations fail with FLAG_NOT_FOUND, the
+// evaluation context keys selecting the environment of WithEnvironmentOverrides are ignored, and the errors
+// of the Pulumi ESC API are not disclosed. Use WithInterceptor to authenticate clients.
+func Register(server grpc.ServiceRegistrar, provider *pulumi.PulumiESCProvider, opts ...Option) {
+	service := &Service{provider: provider}
+	for _, opt := range opts {
+		opt(service)
+	}
+	evaluationpb.RegisterEvaluationServiceServer(server, service)
+}
+
+// resolution is the encoded resolution of a flag
+type resolution struct {
+	reason       string
+	variant      string
+	errorCode    string
+	errorMessage string
+	metadata     *structpb.Struct
+	// withheld reports whether the value must not be served, in which case the default value is returned
+	withheld bool
+}
+
+// ResolveBoolean resolves a boolean flag
+func (s *Service) ResolveBoolean(ctx context.Context, req *evaluationpb.ResolveBooleanRequest) (*evaluationpb.ResolveBooleanResponse, error) {
+	return intercept(ctx, s, req, evaluationpb.EvaluationService_ResolveBoolean_FullMethodName, func(ctx context.Context, req *evaluationpb.ResolveBooleanRequest) (*evaluationpb.ResolveBooleanResponse, error) {
+		if req.GetFlagKey() == "" {
+			return nil, errFlagKeyRequired
+		}
+		details := s.provider.BooleanEvaluation(ctx, req.GetFlagKey(), req.GetDefaultValue(), evaluationContext(req.GetContext()))
+		resolved, err := encodeResolution(details.ProviderResolutionDetail)
+		if err != nil {
+			return nil, err
+		}
+		if resolved.withheld {
+			details.Value = req.GetDefaultValue()
+		}
+		return &evaluationpb.ResolveBooleanResponse{
+			Value:        details.Value,
+			Reason:       resolved.reason,
+			Variant:      resolved.variant,
+			ErrorCode:    resolved.errorCode,
+			ErrorMessage: resolved.errorMessage,
+			Metadata:     resolved.metadata,
+		}, nil
+	})
+}
+
+// ResolveString resolves a string flag
+func (s *Service) ResolveString(ctx context.Context, req *evaluationpb.ResolveStringRequest) (*evaluationpb.ResolveStringResponse, error) {
+	return intercept(ctx, s, req, evaluationpb.EvaluationService_ResolveString_FullMethodName, func(ctx context.Context, req *evaluationpb.ResolveStringRequest) (*evaluationpb.ResolveStringResponse, error) {
+		if req.GetFlagKey() == "" {
+			return nil, errFlagKeyRequired
+		}
+		details := s.provider.StringEvaluation(ctx, req.GetFlagKey(), req.GetDefaultValue(), evaluationContext(req.GetContext()))
+		resolved, err := encodeResolution(details.ProviderResolutionDetail)
+		if err != nil {
+			return nil, err
+		}
+		if resolved.withheld {
+			details.Value = req.GetDefaultValue()
+		}
+		return &evaluationpb.ResolveStringResponse{
+			Value:        details.Value,
+			Reason:       resolved.reason,
+			Variant:      resolved.variant,
+			ErrorCode:    resolved.errorCode,
+			ErrorMessage: resolved.errorMessage,
+			Metadata:     resolved.metadata,
+		}, nil
+	})
+}
+
+// ResolveInt resolves an integer flag
+func (s *Service) ResolveInt(ctx context.Context, req *evaluationpb.ResolveIntRequest) (*evaluationpb.ResolveIntResponse, error) {
+	return intercept(ctx, s, req, evaluationpb.EvaluationService_ResolveInt_FullMethodName, func(ctx context.Context, req *evaluationpb.ResolveIntRequest) (*evaluationpb.ResolveIntResponse, error) {
+		if req.GetFlagKey() == "" {
+			return nil, errFlagKeyRequired
+		}
+		details := s.provider.IntEvaluation(ctx, req.GetFlagKey(), req.GetDefaultValue(), evaluationContext(req.GetContext()))
+		resolved, err := encodeResolution(details.ProviderResolutionDetail)
+		if err != nil {
+			return nil, err
+		}
+		if resolved.withheld {
+			details.Value = req.GetDefaultValue()
+		}
+		return &evaluationpb.ResolveIntResponse{
+			Value:        details.Value,
+			Reason:       resolved.reason,
+			Variant:      resolved.variant,
+			ErrorCode:    resolved.errorCode,
+			ErrorMessage: resolved.errorMessage,
+			Metadata:     resolved.metadata,
+		}, nil
+	})
+}
+
+// ResolveFloat resolves a float flag
+func (s *Service) ResolveFloat(ctx context.Context, req *evaluationpb.ResolveFloatRequest) (*evaluationpb.ResolveFloatResponse, error) {
+	return intercept(ctx, s, req, evaluationpb.EvaluationService_ResolveFloat_FullMethodName, func(ctx context.Context, req *evaluationpb.ResolveFloatRequest) (*evaluationpb.ResolveFloatResponse, error) {
+		if req.GetFlagKey() == "" {
+			return nil, errFlagKeyRequired
+		}
+		details := s.provider.FloatEvaluation(ctx, req.GetFlagKey(), req.GetDefaultValue(), evaluationContext(req.GetContext()))
+		resolved, err := encodeResolution(details.ProviderResolutionDetail)
+		if err != nil {
+			return nil, err
+		}
+		if resolved.withheld {
+			details.Value = req.GetDefaultValue()
+		}
+		return &evaluationpb.ResolveFloatResponse{
+			Value:        details.Value,
+			Reason:       resolved.reason,
+			Variant:      resolved.variant,
+			ErrorCode:    resolved.errorCode,
+			ErrorMessage: resolved.errorMessage,
+			Metadata:     resolved.metadata,
+		}, nil
+	})
+}
+
+// ResolveObject resolves an object flag. Only the whole environment, using pulumi.EnvironmentFlagKey, is an object flag.
+func (s *Service) ResolveObject(ctx context.Context, req *evaluationpb.ResolveObjectRequest) (*evaluationpb.ResolveObjectResponse, error) {
+	return intercept(ctx, s, req, evaluationpb.EvaluationService_ResolveObject_FullMethodName, func(ctx context.Context, req *evaluationpb.ResolveObjectRequest) (*evaluationpb.ResolveObjectResponse, error) {
+		if req.GetFlagKey() == "" {
+			return nil, errFlagKeyRequired
+		}
+		details := s.provider.ObjectEvaluation(ctx, req.GetFlagKey(), req.GetDefaultValue().AsInterface(), evaluationContext(req.GetContext()))
+		resolved, err := encodeResolution(details.ProviderResolutionDetail)
+		if err != nil {
+			return nil, err
+		}
+		value := req.GetDefaultValue()
+		if !resolved.withheld && resolved.errorCode == "" {
+			value = &structpb.Value{}
+			if err := encodeJSON(details.Value, value); err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to encode value: %v", err)
+			}
+		}
+		return &evaluationpb.ResolveObjectResponse{
+			Value:        value,
+			Reason:       resolved.reason,
+			Variant:      resolved.variant,
+			ErrorCode:    resolved.errorCode,
+			ErrorMessage: resolved.errorMessage,
+			Metadata:     resolved.metadata,
+		}, nil
+	})
+}
+
+// errFlagKeyRequired is returned for requests without a flag key
+var errFlagKeyRequired = status.Error(codes.InvalidArgument, "flag_key is required")
+
+// intercept resolves a request, through the interceptor of the service if any
+func intercept[Req, Resp any](ctx context.Context, s *Service, req *Req, method string, resolve func(context.Context, *Req) (*Resp, error)) (*Resp, error) {
+	if s.interceptor == nil {
+		return resolve(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: s, FullMethod: method}
+	resp, err := s.interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return resolve(ctx, req.(*Req))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*Resp), nil
+}
+
+// evaluationContext returns the evaluation context of a request, without the keys selecting the environment,
+// as clients may not select another environment than the one of the provider
+func evaluationContext(fields *structpb.Struct) openfeature.FlattenedContext {
+	evalCtx := fields.AsMap()
+	delete(evalCtx, pulumi.EnvironmentContextKey)
+	delete(evalCtx, pulumi.ProjectContextKey)
+	return evalCtx
+}
+
+// encodeResolution encodes the resolution of a flag. Secrets are reported as not found and withheld.
+func encodeResolution(details openfeature.ProviderResolutionDetail) (*resolution, error) {
+	detail := details.ResolutionDetail()
+	if secret, _ := detail.FlagMetadata.GetBool("secret"); secret {
+		return &resolution{
+			reason:       string(openfeature.ErrorReason),
+			errorCode:    string(openfeature.FlagNotFoundCode),
+			errorMessage: "flag not found",
+			withheld:     true,
+		}, nil
+	}
+	resolved := &resolution{
+		reason:       string(detail.Reason),
+		variant:      detail.Variant,
+		errorCode:    string(detail.ErrorCode),
+		errorMessage: detail.ErrorMessage,
+	}
+	if detail.ErrorCode == openfeature.GeneralCode {
+		resolved.errorMessage = internalError
+	}
+	if len(detail.FlagMetadata) > 0 {
+		// Flag metadata may contain structs, e.g. the trace, which are converted to their JSON representation
+		resolved.metadata = &structpb.Struct{}
+		if err := encodeJSON(detail.FlagMetadata, resolved.metadata); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to encode flag metadata: %v", err)
+		}
+	}
+	return resolved, nil
+}
+
+// encodeJSON encodes a value as a google.protobuf.Struct or google.protobuf.Value using its JSON representation
+func encodeJSON(value interface{}, message json.Unmarshaler) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return message.UnmarshalJSON(data)
+}
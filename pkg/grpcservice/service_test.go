@@ -0,0 +1,180 @@
+package grpcservice_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/grpcservice"
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/grpcservice/evaluationpb"
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/pulumitest"
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func newTestClient(t *testing.T, provider *pulumi.PulumiESCProvider, opts ...grpcservice.Option) evaluationpb.EvaluationServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	grpcservice.Register(server, provider, opts...)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return evaluationpb.NewEvaluationServiceClient(conn)
+}
+
+func newStaticClient(t *testing.T, values map[string]interface{}, opts ...grpcservice.Option) evaluationpb.EvaluationServiceClient {
+	provider, err := pulumi.NewStaticProvider(values)
+	require.NoError(t, err)
+	t.Cleanup(provider.Shutdown)
+	return newTestClient(t, provider, opts...)
+}
+
+func TestService(t *testing.T) {
+	client := newStaticClient(t, map[string]interface{}{
+		"DEBUG_MODE": true,
+		"THEME":      "dark",
+		"configs":    map[string]interface{}{"MAX_RETRIES": 3, "RATIO": 0.5, "ID": int64(9007199254740993)},
+	})
+	ctx := context.Background()
+	evalCtx, err := structpb.NewStruct(map[string]interface{}{"targetingKey": "user-1"})
+	require.NoError(t, err)
+
+	boolResp, err := client.ResolveBoolean(ctx, &evaluationpb.ResolveBooleanRequest{FlagKey: "DEBUG_MODE", Context: evalCtx})
+	assert.NoError(t, err)
+	assert.Equal(t, true, boolResp.Value)
+	assert.Equal(t, "STATIC", boolResp.Reason)
+	assert.Equal(t, false, boolResp.Metadata.AsMap()["secret"])
+
+	stringResp, err := client.ResolveString(ctx, &evaluationpb.ResolveStringRequest{FlagKey: "THEME", DefaultValue: "light"})
+	assert.NoError(t, err)
+	assert.Equal(t, "dark", stringResp.Value)
+
+	intResp, err := client.ResolveInt(ctx, &evaluationpb.ResolveIntRequest{FlagKey: "configs.MAX_RETRIES", DefaultValue: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), intResp.Value)
+
+	intResp, err = client.ResolveInt(ctx, &evaluationpb.ResolveIntRequest{FlagKey: "configs.ID"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9007199254740993), intResp.Value, "integers must not be rounded to float64")
+
+	floatResp, err := client.ResolveFloat(ctx, &evaluationpb.ResolveFloatRequest{FlagKey: "configs.RATIO", DefaultValue: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, floatResp.Value)
+
+	objectResp, err := client.ResolveObject(ctx, &evaluationpb.ResolveObjectRequest{FlagKey: pulumi.EnvironmentFlagKey, DefaultValue: structpb.NewNullValue()})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"DEBUG_MODE": true,
+		"THEME":      "dark",
+		"configs":    map[string]interface{}{"MAX_RETRIES": float64(3), "RATIO": 0.5, "ID": float64(9007199254740993)},
+	}, objectResp.Value.AsInterface())
+}
+
+func TestServiceEvaluationErrors(t *testing.T) {
+	client := newStaticClient(t, map[string]interface{}{"THEME": "dark"})
+	ctx := context.Background()
+
+	boolResp, err := client.ResolveBoolean(ctx, &evaluationpb.ResolveBooleanRequest{FlagKey: "missing", DefaultValue: true})
+	assert.NoError(t, err, "evaluation errors must be reported in the response")
+	assert.Equal(t, true, boolResp.Value)
+	assert.Equal(t, "ERROR", boolResp.Reason)
+	assert.Equal(t, "FLAG_NOT_FOUND", boolResp.ErrorCode)
+	assert.NotEmpty(t, boolResp.ErrorMessage)
+
+	intResp, err := client.ResolveInt(ctx, &evaluationpb.ResolveIntRequest{FlagKey: "THEME", DefaultValue: 7})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), intResp.Value)
+	assert.Equal(t, "TYPE_MISMATCH", intResp.ErrorCode)
+
+	objectResp, err := client.ResolveObject(ctx, &evaluationpb.ResolveObjectRequest{FlagKey: "missing", DefaultValue: structpb.NewStringValue("default")})
+	assert.NoError(t, err)
+	assert.Equal(t, "default", objectResp.Value.AsInterface(), "only the whole environment is an object flag")
+	assert.Equal(t, "GENERAL", objectResp.ErrorCode)
+}
+
+func TestServiceUntrustedClients(t *testing.T) {
+	server := pulumitest.NewServer(map[string]interface{}{"THEME": "dark"})
+	defer server.Close()
+	server.SetSecret("configs.API_KEY", "sk-12345")
+	provider, err := pulumi.NewPulumiESCProvider("test-org", "test-project", "test-env", "token",
+		pulumi.WithHTTPClient(server.Client()),
+		pulumi.WithEnvironmentOverrides("test-project/other-env"),
+	)
+	require.NoError(t, err)
+	require.NoError(t, provider.Init(openfeature.EvaluationContext{}))
+	t.Cleanup(provider.Shutdown)
+	client := newTestClient(t, provider)
+	ctx := context.Background()
+
+	stringResp, err := client.ResolveString(ctx, &evaluationpb.ResolveStringRequest{FlagKey: "configs.API_KEY", DefaultValue: "default"})
+	assert.NoError(t, err)
+	assert.Equal(t, "default", stringResp.Value, "secrets must not be served")
+	assert.Equal(t, "FLAG_NOT_FOUND", stringResp.ErrorCode)
+	assert.Nil(t, stringResp.Metadata)
+
+	objectResp, err := client.ResolveObject(ctx, &evaluationpb.ResolveObjectRequest{FlagKey: pulumi.EnvironmentFlagKey, DefaultValue: structpb.NewNullValue()})
+	assert.NoError(t, err)
+	assert.Equal(t, structpb.NewNullValue().AsInterface(), objectResp.Value.AsInterface(), "environments containing secrets must not be served")
+	assert.Equal(t, "FLAG_NOT_FOUND", objectResp.ErrorCode)
+
+	evalCtx, err := structpb.NewStruct(map[string]interface{}{pulumi.EnvironmentContextKey: "other-env"})
+	require.NoError(t, err)
+	requests := server.Requests()
+	stringResp, err = client.ResolveString(ctx, &evaluationpb.ResolveStringRequest{FlagKey: "THEME", Context: evalCtx})
+	assert.NoError(t, err)
+	assert.Equal(t, "dark", stringResp.Value)
+	assert.Equal(t, requests+1, server.Requests(), "the environment override of the client must be ignored")
+
+	server.SetUnavailable(true)
+	stringResp, err = client.ResolveString(ctx, &evaluationpb.ResolveStringRequest{FlagKey: "missing", DefaultValue: "default"})
+	assert.NoError(t, err)
+	assert.Equal(t, "GENERAL", stringResp.ErrorCode)
+	assert.Equal(t, "internal error", stringResp.ErrorMessage, "the errors of the Pulumi ESC API must not be disclosed")
+}
+
+func TestServiceInterceptor(t *testing.T) {
+	var methods []string
+	client := newStaticClient(t, map[string]interface{}{"THEME": "dark"}, grpcservice.WithInterceptor(
+		func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			methods = append(methods, info.FullMethod)
+			md, _ := metadata.FromIncomingContext(ctx)
+			if len(md.Get("authorization")) == 0 {
+				return nil, status.Error(codes.Unauthenticated, "missing authorization")
+			}
+			return handler(ctx, req)
+		},
+	))
+
+	_, err := client.ResolveString(context.Background(), &evaluationpb.ResolveStringRequest{FlagKey: "THEME"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer token")
+	resp, err := client.ResolveString(ctx, &evaluationpb.ResolveStringRequest{FlagKey: "THEME"})
+	assert.NoError(t, err)
+	assert.Equal(t, "dark", resp.Value)
+	assert.Equal(t, []string{"/" + grpcservice.ServiceName + "/ResolveString", "/" + grpcservice.ServiceName + "/ResolveString"}, methods)
+}
+
+func TestServiceInvalidRequest(t *testing.T) {
+	client := newStaticClient(t, map[string]interface{}{"THEME": "dark"})
+
+	_, err := client.ResolveString(context.Background(), &evaluationpb.ResolveStringRequest{DefaultValue: "light"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
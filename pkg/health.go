@@ -0,0 +1,70 @@
+package pulumi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// WithHealthCheck probes the Pulumi ESC API every interval by listing the latest revision of the environment,
+// so a revoked access token or an unreachable Pulumi ESC API transitions the provider state and emits the
+// matching event before the next evaluation. Probes are a single cheap request which does not read the
+// environment. The persisted snapshot, if any, is only refreshed when the revision of the open session changed.
+func WithHealthCheck(interval time.Duration) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if interval > 0 {
+			p.healthCheckInterval = interval
+		}
+	}
+}
+
+// runHealthCheck probes the session every healthCheckInterval until the context is done
+func (p *PulumiESCProvider) runHealthCheck(ctx context.Context) {
+	ticker := time.NewTicker(p.healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.probe(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// probe verifies that the access token is still valid and that the Pulumi ESC API is reachable
+func (p *PulumiESCProvider) probe(ctx context.Context) {
+	switch p.Status() {
+	case openfeature.ReadyState, openfeature.StaleState:
+	case openfeature.ErrorState:
+		p.tryRecover()
+		return
+	default:
+		return
+	}
+	if p.sessionID() == "" {
+		p.tryRecover()
+		return
+	}
+	reqCtx, cancel := p.requestContext(ctx)
+	defer cancel()
+	reqCtx, apiError := recordResponses(reqCtx)
+	projectName, envName, _ := p.environment()
+	_, err := p.escClient.ListEnvironmentRevisions(reqCtx, p.orgName, projectName, envName, 1)
+	if ctx.Err() != nil {
+		return
+	}
+	p.updateStateAfterRead(apiError(err))
+	if err != nil || p.snapshots == nil {
+		return
+	}
+	if snapshot := p.snapshot.Load(); snapshot != nil && snapshot.Revision == p.Revision() &&
+		snapshot.Environment == projectName+"/"+envName {
+		return
+	}
+	if err := p.refreshSnapshot(ctx); errors.Is(err, ErrSessionExpired) {
+		_ = p.openSession()
+	}
+}
@@ -0,0 +1,61 @@
+package pulumi
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPulumiESCProvider_probe(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"flag": true})
+	server.SetRevision(1)
+	p := newTestProvider(t, server, WithSnapshotPath(filepath.Join(t.TempDir(), "snapshot.json")))
+	assert.NoError(t, p.initialise(context.Background()))
+	assert.Empty(t, p.events, "the initial transition to READY is reported by the OpenFeature SDK")
+	assert.Eventually(t, func() bool { return p.snapshot.Load() != nil }, time.Second, time.Millisecond)
+
+	requests := server.Requests()
+	p.probe(context.Background())
+	assert.Equal(t, openfeature.ReadyState, p.Status())
+	assert.Empty(t, p.events)
+	assert.Equal(t, requests+1, server.Requests(), "a probe must only list the latest revision")
+
+	server.SetUnavailable(true)
+	p.probe(context.Background())
+	assert.Equal(t, openfeature.StaleState, p.Status())
+	assert.Equal(t, openfeature.ProviderStale, (<-p.events).EventType)
+
+	server.SetUnavailable(false)
+	p.probe(context.Background())
+	assert.Equal(t, openfeature.ReadyState, p.Status())
+	assert.Equal(t, openfeature.ProviderReady, (<-p.events).EventType)
+
+	server.SetRevision(2)
+	server.SetValue("flag", false)
+	assert.NoError(t, p.openSession())
+	assert.Eventually(t, func() bool { return p.snapshot.Load().Revision == 2 }, time.Second, time.Millisecond)
+	requests = server.Requests()
+	p.probe(context.Background())
+	assert.Equal(t, requests+1, server.Requests(), "the snapshot must not be refreshed while the revision is unchanged")
+
+	// The snapshot of the revision 1 is outdated, e.g. its refresh failed when the session was opened
+	outdated := *p.snapshot.Load()
+	outdated.Revision, outdated.Values = 1, map[string]interface{}{"flag": true}
+	p.snapshot.Store(&outdated)
+	requests = server.Requests()
+	p.probe(context.Background())
+	assert.Equal(t, requests+2, server.Requests(), "the snapshot must be refreshed once the revision changed")
+	assert.Equal(t, int32(2), p.snapshot.Load().Revision)
+	assert.Equal(t, false, p.snapshot.Load().Values["flag"])
+
+	server.SetAccessToken("other-token")
+	p.probe(context.Background())
+	assert.Equal(t, openfeature.ErrorState, p.Status())
+	event := <-p.events
+	assert.Equal(t, openfeature.ProviderError, event.EventType)
+	assert.Equal(t, openfeature.GeneralCode, event.ErrorCode)
+}
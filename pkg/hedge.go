@@ -0,0 +1,57 @@
+package pulumi
+
+import (
+	"context"
+	"time"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+// WithHedgedRequests issues a second request for an evaluation read from the Pulumi ESC API when the first
+// one has not completed after the hedge delay, and uses whichever completes first, trimming the tail latency
+// caused by slow API responses. The slower request is cancelled. Failed requests are not hedged, and a failed
+// request completing first is only used if the other one fails too. Evaluations served from the cache are
+// never hedged. Pick a delay around the p95 latency of the API, as every hedge is an extra request.
+func WithHedgedRequests(delay time.Duration) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if delay > 0 {
+			p.hedgeDelay = delay
+		}
+	}
+}
+
+// hedgedRead is the outcome of one of the requests of a hedged read
+type hedgedRead struct {
+	escValue *esc.Value
+	rawValue interface{}
+	err      error
+}
+
+// readHedged reads a property value from the ESC service, issuing a second request if the first one has not
+// completed after the hedge delay
+func (p *PulumiESCProvider) readHedged(ctx context.Context, propertyPath string) (*esc.Value, interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	// Cancels the slower request
+	defer cancel()
+	reads := make(chan hedgedRead, 2)
+	read := func() {
+		escValue, rawValue, err := p.readFromESCOnce(ctx, propertyPath)
+		reads <- hedgedRead{escValue: escValue, rawValue: rawValue, err: err}
+	}
+	go read()
+	inFlight := 1
+	hedge := time.NewTimer(p.hedgeDelay)
+	defer hedge.Stop()
+	for {
+		select {
+		case <-hedge.C:
+			inFlight++
+			go read()
+		case result := <-reads:
+			inFlight--
+			if result.err == nil || inFlight == 0 {
+				return result.escValue, result.rawValue, result.err
+			}
+		}
+	}
+}
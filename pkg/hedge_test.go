@@ -0,0 +1,69 @@
+package pulumi
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowESCClient is an ESCClient whose first property read is slow, or fails if err is set
+type slowESCClient struct {
+	ESCClient
+	reads     atomic.Int32
+	cancelled atomic.Bool
+	err       error
+}
+
+func (c *slowESCClient) ReadEnvironmentProperty(ctx context.Context, org, projectName, envName, openEnvID, propPath string) (*esc.Value, interface{}, error) {
+	if c.reads.Add(1) == 1 {
+		if c.err != nil {
+			return nil, nil, c.err
+		}
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			c.cancelled.Store(true)
+			return nil, nil, ctx.Err()
+		}
+	}
+	return c.ESCClient.ReadEnvironmentProperty(ctx, org, projectName, envName, openEnvID, propPath)
+}
+
+func TestWithHedgedRequests(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+
+	p := newTestProvider(t, server, WithHedgedRequests(20*time.Millisecond))
+	client := &slowESCClient{ESCClient: p.escClient}
+	p.escClient = client
+	require.NoError(t, p.initialise(ctx))
+	start := time.Now()
+	details := p.BooleanEvaluation(ctx, "DEBUG_MODE", false, openfeature.FlattenedContext{})
+	assert.NoError(t, details.Error())
+	assert.True(t, details.Value)
+	assert.Less(t, time.Since(start), time.Second, "the hedged request must complete first")
+	assert.Equal(t, int32(2), client.reads.Load())
+	assert.Eventually(t, client.cancelled.Load, time.Second, 10*time.Millisecond, "the slower request must be cancelled")
+
+	// Fast reads are not hedged
+	details = p.BooleanEvaluation(ctx, "DEBUG_MODE", false, openfeature.FlattenedContext{})
+	assert.NoError(t, details.Error())
+	assert.Equal(t, int32(3), client.reads.Load())
+
+	// Failed reads are not retried by the hedge
+	p = newTestProvider(t, server, WithHedgedRequests(20*time.Millisecond))
+	client = &slowESCClient{ESCClient: p.escClient, err: errors.New("connection reset")}
+	p.escClient = client
+	require.NoError(t, p.initialise(ctx))
+	details = p.BooleanEvaluation(ctx, "DEBUG_MODE", false, openfeature.FlattenedContext{})
+	assert.Error(t, details.Error())
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), client.reads.Load())
+}
@@ -0,0 +1,30 @@
+package pulumi
+
+// defaultBackendURL is the URL of the Pulumi Cloud API used unless WithCustomBackendUrl is set
+const defaultBackendURL = "https://api.pulumi.com"
+
+// ProviderInfo identifies the Pulumi ESC environment served by a provider
+type ProviderInfo struct {
+	Org     string `json:"org"`
+	Project string `json:"project"`
+	Env     string `json:"env"`
+	// BackendURL is the URL of the Pulumi Cloud API, which is not used by clients set using WithESCClient
+	BackendURL string `json:"backendUrl"`
+}
+
+// Info returns the organisation, project and environment served by the provider and its backend URL, so
+// applications using several providers and debug endpoints can tell instances apart. Metadata only returns
+// the name of the provider, as the OpenFeature metadata has no other field.
+func (p *PulumiESCProvider) Info() ProviderInfo {
+	projectName, envName, _ := p.environment()
+	info := ProviderInfo{
+		Org:        p.orgName,
+		Project:    projectName,
+		Env:        envName,
+		BackendURL: defaultBackendURL,
+	}
+	if p.customBackendUrl != nil {
+		info.BackendURL = p.customBackendUrl.String()
+	}
+	return info
+}
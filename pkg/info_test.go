@@ -0,0 +1,26 @@
+package pulumi
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfo(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	assert.Equal(t, ProviderInfo{Org: "test-org", Project: PROJECT_NAME, Env: ENV_NAME, BackendURL: "https://api.pulumi.com"}, p.Info())
+
+	require.NoError(t, p.SwitchEnvironment(ctx, PROJECT_NAME, "green"))
+	assert.Equal(t, "green", p.Info().Env, "the environment switched to must be reported")
+
+	backendURL, err := url.Parse("https://pulumi.example.com")
+	require.NoError(t, err)
+	p = newPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME, WithCustomBackendUrl(*backendURL))
+	assert.Equal(t, "https://pulumi.example.com", p.Info().BackendURL)
+}
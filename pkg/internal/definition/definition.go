@@ -0,0 +1,138 @@
+// Package definition edits the values of the YAML definitions of Pulumi ESC environments, keeping the rest
+// of the definitions, such as imports, secrets and function calls, as they are.
+package definition
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Parse parses the YAML, or JSON, definition of an environment, which may be empty
+func Parse(definition string) (*yaml.Node, error) {
+	document := &yaml.Node{}
+	if err := yaml.Unmarshal([]byte(definition), document); err != nil {
+		return nil, fmt.Errorf("failed to parse pulumi esc environment definition: %w", err)
+	}
+	if document.Kind == 0 {
+		document.Kind = yaml.DocumentNode
+		document.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	// Definitions returned as JSON are saved as block YAML
+	resetStyle(document)
+	return document, nil
+}
+
+// resetStyle clears the flow style of the collections of a node
+func resetStyle(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		node.Style &^= yaml.FlowStyle
+	}
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+// Values returns the values mapping of a parsed definition, creating it if needed
+func Values(document *yaml.Node) (*yaml.Node, error) {
+	if document.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("pulumi esc environment definition is not an object")
+	}
+	return objectValue(document.Content[0], "values")
+}
+
+// mappingValue returns the value of a key of a mapping, or nil if it does not exist
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// objectValue returns the value of a key of a mapping, which must be an object, creating it if it does not
+// exist or is null. It fails if the value is not an object, e.g. a string or a function call such as fn::secret,
+// which would be lost if it was replaced.
+func objectValue(mapping *yaml.Node, key string) (*yaml.Node, error) {
+	value := mappingValue(mapping, key)
+	switch {
+	case value == nil:
+		value = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	case value.Kind == yaml.ScalarNode && value.Tag == "!!null":
+		*value = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	case value.Kind != yaml.MappingNode:
+		return nil, fmt.Errorf("%s is not an object and can not contain flags", key)
+	case IsFunctionCall(value):
+		return nil, fmt.Errorf("%s is a function call and can not contain flags", key)
+	}
+	return value, nil
+}
+
+// IsFunctionCall reports whether a node is a call of a Pulumi ESC function, e.g. fn::secret
+func IsFunctionCall(node *yaml.Node) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.HasPrefix(node.Content[i].Value, "fn::") {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the value at the path of a mapping, or nil if it does not exist
+func Lookup(mapping *yaml.Node, path []string) *yaml.Node {
+	node := mapping
+	for _, segment := range path {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		if node = mappingValue(node, segment); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// Set sets the value at the path of a mapping, creating the intermediate mappings. It fails if an intermediate
+// value is not an object.
+func Set(mapping *yaml.Node, path []string, value *yaml.Node) error {
+	for _, segment := range path[:len(path)-1] {
+		var err error
+		if mapping, err = objectValue(mapping, segment); err != nil {
+			return err
+		}
+	}
+	key := path[len(path)-1]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return nil
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	return nil
+}
+
+// Delete removes the value at the path of a mapping and reports whether it existed. Values nested in function
+// calls are never removed.
+func Delete(mapping *yaml.Node, path []string) bool {
+	for _, segment := range path[:len(path)-1] {
+		if mapping = mappingValue(mapping, segment); mapping == nil || mapping.Kind != yaml.MappingNode || IsFunctionCall(mapping) {
+			return false
+		}
+	}
+	key := path[len(path)-1]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
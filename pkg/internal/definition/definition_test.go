@@ -0,0 +1,66 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParse(t *testing.T) {
+	for name, definition := range map[string]string{
+		"empty": "",
+		"yaml":  "imports:\n  - base\nvalues:\n  DEBUG_MODE: false\n",
+		"json":  `{"imports": ["base"], "values": {"DEBUG_MODE": false}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			document, err := Parse(definition)
+			require.NoError(t, err)
+			values, err := Values(document)
+			require.NoError(t, err)
+			require.NoError(t, Set(values, []string{"configs", "THEME"}, scalarNode(t, "dark")))
+			var decoded map[string]interface{}
+			require.NoError(t, document.Decode(&decoded))
+			decodedValues := decoded["values"].(map[string]interface{})
+			assert.Equal(t, map[string]interface{}{"THEME": "dark"}, decodedValues["configs"])
+			if name != "empty" {
+				assert.Equal(t, []interface{}{"base"}, decoded["imports"], "the rest of the definition must be kept")
+			}
+		})
+	}
+
+	_, err := Parse("values: [")
+	assert.Error(t, err)
+	document, err := Parse("- values")
+	require.NoError(t, err)
+	_, err = Values(document)
+	assert.Error(t, err)
+}
+
+func TestSet(t *testing.T) {
+	document, err := Parse("values:\n  THEME: dark\n  empty:\n  API_KEY:\n    fn::secret: sk-12345\n")
+	require.NoError(t, err)
+	values, err := Values(document)
+	require.NoError(t, err)
+
+	assert.EqualError(t, Set(values, []string{"THEME", "nested"}, scalarNode(t, true)), "THEME is not an object and can not contain flags")
+	assert.EqualError(t, Set(values, []string{"API_KEY", "nested"}, scalarNode(t, true)), "API_KEY is a function call and can not contain flags")
+	assert.False(t, Delete(values, []string{"API_KEY", "fn::secret"}), "function calls must be kept")
+	require.NoError(t, Set(values, []string{"empty", "FLAG"}, scalarNode(t, true)), "null values must be replaced")
+	assert.Equal(t, "true", Lookup(values, []string{"empty", "FLAG"}).Value)
+
+	var decoded map[string]interface{}
+	require.NoError(t, document.Decode(&decoded))
+	assert.Equal(t, map[string]interface{}{
+		"THEME":   "dark",
+		"empty":   map[string]interface{}{"FLAG": true},
+		"API_KEY": map[string]interface{}{"fn::secret": "sk-12345"},
+	}, decoded["values"])
+}
+
+func scalarNode(t *testing.T, value interface{}) *yaml.Node {
+	node := &yaml.Node{}
+	require.NoError(t, node.Encode(value))
+	return node
+}
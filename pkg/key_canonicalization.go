@@ -0,0 +1,26 @@
+package pulumi
+
+import (
+	"strings"
+)
+
+// keySeparators replaces the separators of the words and property path segments of a key with underscores
+var keySeparators = strings.NewReplacer(".", "_", "-", "_")
+
+// WithKeyCanonicalization resolves flag keys which are not found to the key of the environment with the same
+// canonical key, the key lowercased with its dots and dashes replaced with underscores, so flag naming
+// conventions carry over when migrating from other providers. OpenFeature-style dotted keys, e.g.
+// "checkout.new-flow", resolve environment variable style values, e.g. CHECKOUT_NEW_FLOW, and nested values,
+// e.g. checkout: {new_flow: ...}, and vice versa. The key of the environment is reported in the canonicalKey
+// flag metadata. Keys matching several keys of the environment, none of them exactly, are not found.
+// Case-insensitive matches, see WithCaseInsensitiveKeys, take precedence.
+func WithKeyCanonicalization() ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.keyCanonicalization = true
+	}
+}
+
+// canonicalizeKey returns the canonical key of a key
+func canonicalizeKey(key string) string {
+	return keySeparators.Replace(strings.ToLower(key))
+}
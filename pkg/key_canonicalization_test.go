@@ -0,0 +1,65 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalizeKey(t *testing.T) {
+	assert.Equal(t, "checkout_new_flow", canonicalizeKey("checkout.new-flow"))
+	assert.Equal(t, "checkout_new_flow", canonicalizeKey("CHECKOUT_NEW_FLOW"))
+	assert.Equal(t, "checkout_newflow", canonicalizeKey("checkout.newFlow"))
+}
+
+func TestWithKeyCanonicalization(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"CHECKOUT_NEW_FLOW": true,
+		"search":            map[string]interface{}{"fuzzy_match": "on"},
+		"banner-text":       "hello",
+		"banner_text":       "hi",
+		"a_b":               1,
+		"a":                 map[string]interface{}{"b": 2},
+	})
+	server.SetRevision(1)
+	ctx := context.Background()
+	p := newTestProvider(t, server, WithKeyCanonicalization())
+	require.NoError(t, p.initialise(ctx))
+
+	t.Run("dotted key to flat key", func(t *testing.T) {
+		got := p.BooleanEvaluation(ctx, "checkout.new-flow", false, nil)
+		assert.NoError(t, got.Error())
+		assert.True(t, got.Value)
+		canonicalKey, _ := got.FlagMetadata.GetString(canonicalKeyMetadataKey)
+		assert.Equal(t, "CHECKOUT_NEW_FLOW", canonicalKey)
+	})
+
+	t.Run("flat key to nested value", func(t *testing.T) {
+		got := p.StringEvaluation(ctx, "SEARCH_FUZZY_MATCH", "", nil)
+		assert.NoError(t, got.Error())
+		assert.Equal(t, "on", got.Value)
+		canonicalKey, _ := got.FlagMetadata.GetString(canonicalKeyMetadataKey)
+		assert.Equal(t, "search.fuzzy_match", canonicalKey)
+	})
+
+	t.Run("exact keys", func(t *testing.T) {
+		got := p.StringEvaluation(ctx, "banner-text", "", nil)
+		assert.Equal(t, "hello", got.Value)
+		assert.NotContains(t, got.FlagMetadata, canonicalKeyMetadataKey)
+		assert.Equal(t, int64(2), p.IntEvaluation(ctx, "a.b", 0, nil).Value)
+	})
+
+	t.Run("ambiguous keys", func(t *testing.T) {
+		assert.Equal(t, openfeature.FlagNotFoundCode, p.StringEvaluation(ctx, "banner.text", "", nil).ResolutionDetail().ErrorCode)
+		assert.Equal(t, openfeature.FlagNotFoundCode, p.IntEvaluation(ctx, "A-B", 0, nil).ResolutionDetail().ErrorCode)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		p := newTestProvider(t, server, WithCaseInsensitiveKeys())
+		require.NoError(t, p.initialise(ctx))
+		assert.Equal(t, openfeature.FlagNotFoundCode, p.BooleanEvaluation(ctx, "checkout.new-flow", false, nil).ResolutionDetail().ErrorCode)
+	})
+}
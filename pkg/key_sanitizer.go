@@ -0,0 +1,68 @@
+package pulumi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"golang.org/x/text/unicode/norm"
+)
+
+// KeySanitizer transforms a flag key before it is looked up, or returns an error if the key is invalid
+type KeySanitizer func(key string) (string, error)
+
+// WithKeySanitizer transforms the flag keys of evaluations using the sanitizer before they are looked up, e.g.
+// SanitizeKey. Evaluations of keys the sanitizer rejects fail with PARSE_ERROR instead of reaching the Pulumi
+// ESC API. Multiple sanitizers are applied in order.
+func WithKeySanitizer(sanitizer KeySanitizer) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if sanitizer != nil {
+			p.keySanitizers = append(p.keySanitizers, sanitizer)
+		}
+	}
+}
+
+// SanitizeKey is a KeySanitizer trimming the spaces around a key, normalising it to the Unicode NFC form and
+// stripping its control and invisible formatting characters, such as zero-width spaces, pasted along with it.
+// It rejects keys which are empty, have an empty property path segment, e.g. "checkout..enabled", or are
+// not valid property paths, e.g. with an unterminated quoted key.
+func SanitizeKey(key string) (string, error) {
+	key = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return r
+	}, norm.NFC.String(key))
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("flag key is empty")
+	}
+	segments, err := parsePropertyPath(key)
+	if err != nil {
+		return "", err
+	}
+	for _, segment := range segments {
+		if segment, ok := segment.(string); ok && strings.TrimSpace(segment) == "" {
+			return "", fmt.Errorf("flag key %q has an empty property path segment", key)
+		}
+	}
+	return key, nil
+}
+
+// sanitizeKey applies the key sanitizers to a flag key. If one of them rejects it, it returns the
+// resolution of the evaluation.
+func (p *PulumiESCProvider) sanitizeKey(key string) (string, *openfeature.ProviderResolutionDetail) {
+	for _, sanitize := range p.keySanitizers {
+		sanitized, err := sanitize(key)
+		if err != nil {
+			return "", &openfeature.ProviderResolutionDetail{
+				Reason:          openfeature.ErrorReason,
+				ResolutionError: openfeature.NewParseErrorResolutionError(fmt.Sprintf("invalid flag key %q: %s", key, err)),
+			}
+		}
+		key = sanitized
+	}
+	return key, nil
+}
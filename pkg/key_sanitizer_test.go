@@ -0,0 +1,66 @@
+package pulumi
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "DEBUG_MODE", want: "DEBUG_MODE"},
+		{key: "  DEBUG_MODE\n", want: "DEBUG_MODE"},
+		{key: "DEBUG\u200b_MODE\ufeff", want: "DEBUG_MODE"},
+		{key: "cafe\u0301", want: "caf\u00e9"},
+		{key: "checkout.enabled", want: "checkout.enabled"},
+		{key: " \t", wantErr: true},
+		{key: "checkout..enabled", wantErr: true},
+		{key: "checkout.", wantErr: true},
+		{key: ".checkout", wantErr: true},
+		{key: `["service.v2.enabled"]`, want: `["service.v2.enabled"]`},
+		{key: `["service.v2.enabled`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := SanitizeKey(tt.key)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWithKeySanitizer(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"DEBUG_MODE": true,
+		"checkout":   map[string]interface{}{"enabled": true},
+	})
+	ctx := context.Background()
+	p := newTestProvider(t, server, WithKeySanitizer(SanitizeKey), WithKeySanitizer(func(key string) (string, error) {
+		return strings.ToUpper(key[:1]) + key[1:], nil
+	}))
+	require.NoError(t, p.initialise(ctx))
+
+	got := p.BooleanEvaluation(ctx, " DEBUG_MODE\u200b", false, nil)
+	assert.NoError(t, got.Error())
+	assert.True(t, got.Value)
+
+	requests := server.Requests()
+	got = p.BooleanEvaluation(ctx, "checkout..enabled", false, nil)
+	assert.Equal(t, openfeature.ParseErrorCode, got.ResolutionDetail().ErrorCode)
+	assert.Equal(t, requests, server.Requests(), "invalid keys must not reach the Pulumi ESC API")
+
+	got = p.BooleanEvaluation(ctx, "checkout.enabled", false, nil)
+	assert.Equal(t, openfeature.FlagNotFoundCode, got.ResolutionDetail().ErrorCode, "sanitizers must be applied in order")
+}
@@ -0,0 +1,186 @@
+package pulumi
+
+import (
+	"context"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+// providerLifecycle is the context of the background goroutines and requests of an initialised provider, which
+// is cancelled on shutdown. It is replaced when the provider is initialised again, concurrently with evaluations.
+type providerLifecycle struct {
+	ctx  context.Context
+	stop context.CancelFunc
+}
+
+// lifecycleContext returns the context cancelled when the provider is shut down, or nil if it was never initialised
+func (p *PulumiESCProvider) lifecycleContext() context.Context {
+	if lifecycle := p.lifecycle.Load(); lifecycle != nil {
+		return lifecycle.ctx
+	}
+	return nil
+}
+
+// initialise opens the environment session, validates the required flags and starts the background
+// goroutines. If the environment can not be opened, it starts in STALE state from the last known good
+// snapshot, if any. The provider stays in NOT_READY state if the required flags are invalid.
+func (p *PulumiESCProvider) initialise(ctx context.Context) error {
+	if err := p.compileSchemas(); err != nil {
+		return err
+	}
+	if err := p.validateBucketingHash(); err != nil {
+		return err
+	}
+	if err := p.validateLongPolling(); err != nil {
+		return err
+	}
+	if err := p.validateEnvironmentOverrides(); err != nil {
+		return err
+	}
+	if err := p.validateWebhook(); err != nil {
+		return err
+	}
+	lifecycleCtx, stop := context.WithCancel(context.Background())
+	p.lifecycle.Store(&providerLifecycle{ctx: lifecycleCtx, stop: stop})
+	if p.snapshotPath != "" && p.snapshots == nil {
+		snapshots, err := newSnapshotStore(p.snapshotPath, p.snapshotKey)
+		if err != nil {
+			stop()
+			return err
+		}
+		p.snapshots = snapshots
+	}
+	if err := p.openSession(); err != nil {
+		if p.snapshots == nil || isAuthErr(err) || p.loadSnapshot() != nil {
+			stop()
+			return err
+		}
+		// Serve the last known good snapshot while the Pulumi ESC API is unavailable
+		p.stateMu.Lock()
+		p.transitionLocked(openfeature.StaleState, err.Error())
+		p.stateMu.Unlock()
+	}
+	if p.snapshotOnly() {
+		if err := p.refreshCacheSnapshot(ctx); err != nil && p.snapshot.Load() == nil {
+			stop()
+			return err
+		}
+	}
+	if len(p.requiredFlags) > 0 {
+		if err := p.validateRequiredFlags(ctx); err != nil {
+			stop()
+			p.setState(openfeature.NotReadyState)
+			return err
+		}
+	}
+	p.start()
+	return nil
+}
+
+// start starts the background goroutines of the provider. They run until the provider is shut down.
+func (p *PulumiESCProvider) start() {
+	if p.exposures != nil {
+		p.goBackground(p.exposures.run)
+	}
+	if p.healthCheckInterval > 0 {
+		p.goBackground(p.runHealthCheck)
+	}
+	if p.longPolling {
+		watcher := p.escClient.(ESCChangeWatcher)
+		p.goBackground(func(ctx context.Context) { p.runLongPolling(ctx, watcher) })
+	}
+	if p.snapshotOnly() {
+		p.goBackground(p.runCacheSnapshotRefresh)
+	}
+	if p.pollInterval > 0 || p.refreshes != nil {
+		// The values of the session opened during initialisation are the baseline of the first poll
+		previous, _ := p.readEnvironment(p.lifecycleContext())
+		p.goBackground(func(ctx context.Context) { p.runPolling(ctx, previous) })
+	}
+}
+
+// goBackground runs fn in a goroutine which is cancelled and awaited on shutdown
+func (p *PulumiESCProvider) goBackground(fn func(ctx context.Context)) {
+	ctx := p.lifecycleContext()
+	p.background.Add(1)
+	go func() {
+		defer p.background.Done()
+		fn(ctx)
+	}()
+}
+
+// credentialsContext carries the API credentials of the provider. It replaces context.WithValue, which
+// boxes the key on every request.
+type credentialsContext struct {
+	context.Context
+	apiKeys interface{}
+}
+
+func (c *credentialsContext) Value(key interface{}) interface{} {
+	if key == esc.ContextAPIKeys {
+		return c.apiKeys
+	}
+	return c.Context.Value(key)
+}
+
+// requestContext returns a context for a Pulumi ESC API request which carries the API credentials
+// and is cancelled when either the given context is done or the provider is shut down
+func (p *PulumiESCProvider) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	ctx = &credentialsContext{Context: ctx, apiKeys: p.escAuthCtx.Value(esc.ContextAPIKeys)}
+	lifecycleCtx := p.lifecycleContext()
+	if lifecycleCtx == nil {
+		return ctx, cancel
+	}
+	stop := context.AfterFunc(lifecycleCtx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// Init implements openfeature.StateHandler. It reinitialises the provider if it was shut down or
+// its required flags were invalid, and is a no-op otherwise.
+func (p *PulumiESCProvider) Init(evaluationContext openfeature.EvaluationContext) error {
+	if p.Status() != openfeature.NotReadyState {
+		return nil
+	}
+	return p.initialise(context.Background())
+}
+
+// Shutdown implements openfeature.StateHandler. It releases all the resources held by the provider,
+// see ShutdownWithContext.
+func (p *PulumiESCProvider) Shutdown() {
+	_ = p.ShutdownWithContext(context.Background())
+}
+
+// ShutdownWithContext stops the background goroutines, cancels in-flight requests to the Pulumi ESC API,
+// abandons the open environment session and transitions the provider to NOT_READY state.
+// It returns the context error if the context is done before the background goroutines exit.
+func (p *PulumiESCProvider) ShutdownWithContext(ctx context.Context) error {
+	p.stateMu.Lock()
+	p.state = openfeature.NotReadyState
+	p.escOpenEnvSessionId = ""
+	p.stateMu.Unlock()
+	p.closeOverrideSessions()
+	p.stopConfigChanges()
+
+	if lifecycle := p.lifecycle.Load(); lifecycle != nil {
+		lifecycle.stop()
+	}
+	done := make(chan struct{})
+	go func() {
+		p.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
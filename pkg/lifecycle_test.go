@@ -0,0 +1,112 @@
+package pulumi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPulumiESCProvider_ShutdownWithContext(t *testing.T) {
+	reading := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(r.URL.Path, "/open/") {
+			close(reading)
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(`{"id":"session-id"}`))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var flushed []ExposureCount
+	conf := esc.NewConfiguration()
+	conf.Servers = esc.ServerConfigurations{{URL: server.URL + "/api/esc"}}
+	p := &PulumiESCProvider{
+		state:       openfeature.NotReadyState,
+		orgName:     "test-org",
+		projectName: PROJECT_NAME,
+		envName:     ENV_NAME,
+		escClient:   newESCClient(conf),
+		escAuthCtx:  esc.NewAuthContext("token"),
+		tombstones:  newTombstoneRegistry(),
+		throttle:    &apiThrottle{},
+	}
+	WithExposureAggregation(time.Hour, ExposureSinkFunc(func(start, end time.Time, counts []ExposureCount) {
+		mu.Lock()
+		defer mu.Unlock()
+		flushed = append(flushed, counts...)
+	}))(p)
+
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+	assert.Equal(t, openfeature.ReadyState, p.Status())
+	p.ObjectEvaluation(context.Background(), "SOME_OBJECT_FLAG", nil, nil)
+
+	result := make(chan openfeature.BoolResolutionDetail)
+	go func() {
+		result <- p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, DEFAULT_BOOL_FLAG_VALUE, nil)
+	}()
+	<-reading
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, p.ShutdownWithContext(ctx))
+	assert.Equal(t, openfeature.NotReadyState, p.Status())
+	assert.Empty(t, p.sessionID())
+
+	select {
+	case got := <-result:
+		assert.Equal(t, DEFAULT_BOOL_FLAG_VALUE, got.Value)
+		assert.Equal(t, openfeature.ErrorReason, got.Reason)
+	case <-time.After(5 * time.Second):
+		t.Fatal("in-flight evaluation was not cancelled on shutdown")
+	}
+
+	got := p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, DEFAULT_BOOL_FLAG_VALUE, nil)
+	assert.Equal(t, openfeature.ProviderNotReadyCode, got.ResolutionDetail().ErrorCode)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.NotEmpty(t, flushed, "exposure counts must be flushed on shutdown")
+}
+
+func TestPulumiESCProvider_reinitialiseDuringEvaluations(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"THEME": "dark"})
+	p := newTestProvider(t, server)
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					p.StringEvaluation(context.Background(), "THEME", "light", nil)
+				}
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		p.Shutdown()
+		assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+	}
+	close(done)
+	wg.Wait()
+
+	got := p.StringEvaluation(context.Background(), "THEME", "light", nil)
+	assert.Equal(t, "dark", got.Value)
+}
@@ -0,0 +1,56 @@
+package pulumi
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// maskedValue replaces the values of secrets in the logs
+const maskedValue = "[secret]"
+
+// LoggingHook is an openfeature.Hook which logs every evaluation using log/slog.
+// The values of Pulumi ESC secrets are masked.
+type LoggingHook struct {
+	openfeature.UnimplementedHook
+	logger *slog.Logger
+	level  slog.Level
+}
+
+// NewLoggingHook returns a LoggingHook which logs at the given level using the given logger,
+// or slog.Default() if the logger is nil
+func NewLoggingHook(logger *slog.Logger, level slog.Level) *LoggingHook {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &LoggingHook{logger: logger, level: level}
+}
+
+// WithLoggingHook adds a LoggingHook to the hooks returned by Hooks
+func WithLoggingHook(logger *slog.Logger, level slog.Level) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.hooks = append(p.hooks, NewLoggingHook(logger, level))
+	}
+}
+
+// After logs a successful evaluation
+func (h *LoggingHook) After(ctx context.Context, hookContext openfeature.HookContext, details openfeature.InterfaceEvaluationDetails, hookHints openfeature.HookHints) error {
+	details = MaskSecrets(details)
+	h.logger.LogAttrs(ctx, h.level, "flag evaluated",
+		slog.String("key", details.FlagKey),
+		slog.Any("value", details.Value),
+		slog.String("variant", details.Variant),
+		slog.String("reason", string(details.Reason)),
+	)
+	return nil
+}
+
+// Error logs a failed evaluation. The default value is never logged, as it may be a secret.
+func (h *LoggingHook) Error(ctx context.Context, hookContext openfeature.HookContext, err error, hookHints openfeature.HookHints) {
+	h.logger.LogAttrs(ctx, h.level, "flag evaluation failed",
+		slog.String("key", hookContext.FlagKey()),
+		slog.String("reason", string(openfeature.ErrorReason)),
+		slog.String("error", err.Error()),
+	)
+}
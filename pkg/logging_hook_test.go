@@ -0,0 +1,58 @@
+package pulumi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggingHook(t *testing.T) {
+	var buf bytes.Buffer
+	hook := NewLoggingHook(slog.New(slog.NewTextHandler(&buf, nil)), slog.LevelInfo)
+	details := func(value interface{}, secret bool) openfeature.InterfaceEvaluationDetails {
+		return openfeature.InterfaceEvaluationDetails{
+			Value: value,
+			EvaluationDetails: openfeature.EvaluationDetails{
+				FlagKey: "configs.KEY",
+				ResolutionDetail: openfeature.ResolutionDetail{
+					Reason:       openfeature.StaticReason,
+					FlagMetadata: openfeature.FlagMetadata{"secret": secret},
+				},
+			},
+		}
+	}
+
+	assert.NoError(t, hook.After(context.Background(), openfeature.HookContext{}, details("plain-value", false), openfeature.HookHints{}))
+	assert.Contains(t, buf.String(), "key=configs.KEY value=plain-value")
+	assert.Contains(t, buf.String(), "reason=STATIC")
+
+	buf.Reset()
+	assert.NoError(t, hook.After(context.Background(), openfeature.HookContext{}, details("sk-12345", true), openfeature.HookHints{}))
+	assert.NotContains(t, buf.String(), "sk-12345")
+	assert.Contains(t, buf.String(), "value="+maskedValue)
+
+	buf.Reset()
+	hook.Error(context.Background(), openfeature.HookContext{}, errors.New("FLAG_NOT_FOUND"), openfeature.HookHints{})
+	assert.Contains(t, buf.String(), "level=INFO msg=\"flag evaluation failed\"")
+	assert.Contains(t, buf.String(), "error=FLAG_NOT_FOUND")
+}
+
+func TestLoggingHook_Level(t *testing.T) {
+	var buf bytes.Buffer
+	hook := NewLoggingHook(slog.New(slog.NewTextHandler(&buf, nil)), slog.LevelDebug)
+	assert.NoError(t, hook.After(context.Background(), openfeature.HookContext{}, openfeature.InterfaceEvaluationDetails{}, openfeature.HookHints{}))
+	assert.Empty(t, buf.String(), "debug logs must be filtered by the handler level")
+}
+
+func TestWithLoggingHook(t *testing.T) {
+	p := &PulumiESCProvider{}
+	WithLoggingHook(nil, slog.LevelInfo)(p)
+	if assert.Len(t, p.Hooks(), 1) {
+		assert.IsType(t, &LoggingHook{}, p.Hooks()[0])
+	}
+}
@@ -0,0 +1,29 @@
+// Package logrlog adapts logr loggers to log/slog, so controller-runtime based operators consuming flags
+// from Pulumi ESC can pass their logger to the options of the provider taking a *slog.Logger, such as
+// WithLoggingHook and WithDeprecationWarnings:
+//
+//	provider, err := pulumi.NewPulumiESCProvider(orgName, projectName, envName, accessToken,
+//		pulumi.WithDeprecationWarnings(logrlog.New(ctrl.Log.WithName("flags"))))
+package logrlog
+
+import (
+	"log/slog"
+
+	"github.com/go-logr/logr"
+)
+
+// New returns a *slog.Logger writing to the logr logger. Records are written with the verbosity of their
+// level: info and warning records at V(0), debug records at V(4), and error records using Error whatever
+// the verbosity of the logger.
+func New(logger logr.Logger) *slog.Logger {
+	return slog.New(NewHandler(logger))
+}
+
+// NewHandler returns a slog.Handler writing to the logr logger, or discarding the records if the logger
+// has no sink
+func NewHandler(logger logr.Logger) slog.Handler {
+	if logger.GetSink() == nil {
+		logger = logr.Discard()
+	}
+	return logr.ToSlogHandler(logger)
+}
@@ -0,0 +1,50 @@
+package logrlog_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/logrlog"
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	var lines []string
+	logger := funcr.New(func(prefix, args string) {
+		lines = append(lines, prefix+" "+args)
+	}, funcr.Options{Verbosity: 0}).WithName("flags")
+
+	slogger := logrlog.New(logger)
+	slogger.Debug("filtered")
+	slogger.Warn("deprecated flag evaluated", slog.String("key", "configs.OLD_CHECKOUT"))
+	slogger.Error("flag evaluation failed", slog.Any("error", errors.New("boom")))
+	require.Len(t, lines, 2, "debug records must be filtered by the verbosity of the logger")
+	assert.Contains(t, lines[0], `flags "level"=0 "msg"="deprecated flag evaluated" "key"="configs.OLD_CHECKOUT"`)
+	assert.Contains(t, lines[1], `"msg"="flag evaluation failed"`)
+	assert.Contains(t, lines[1], `"error"="boom"`)
+}
+
+func TestNew_nilSink(t *testing.T) {
+	assert.NotPanics(t, func() {
+		logrlog.New(logr.Logger{}).Info("discarded")
+	})
+}
+
+func TestLoggingHook(t *testing.T) {
+	var lines []string
+	logger := funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{})
+	hook := pulumi.NewLoggingHook(logrlog.New(logger), slog.LevelInfo)
+
+	hook.Error(context.Background(), openfeature.HookContext{}, errors.New("FLAG_NOT_FOUND"), openfeature.HookHints{})
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"error"="FLAG_NOT_FOUND"`)
+}
@@ -0,0 +1,68 @@
+package pulumi
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// longPollRetryDelay is the delay before waiting for changes again after the change watcher failed
+	longPollRetryDelay = time.Second
+	// longPollMinInterval is the minimum delay between the starts of two waits for changes, so a change
+	// watcher returning immediately does not spin
+	longPollMinInterval = time.Second
+)
+
+// WithLongPolling refreshes the environment as soon as the backend notifies a change, where the ESCClient
+// set using WithESCClient implements ESCChangeWatcher, reducing both the propagation latency and the API
+// traffic of interval polling. Refreshes emit the same events as WithPolling. The Pulumi ESC API does not
+// support change notifications, so initialisation fails if the ESCClient does not implement ESCChangeWatcher.
+// Waits for changes start at most once every second. The timeout of the HTTP client, if any, must be longer
+// than the backend long-poll timeout.
+func WithLongPolling() ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.longPolling = true
+		if p.refreshes == nil {
+			p.refreshes = make(chan struct{}, 1)
+		}
+	}
+}
+
+// validateLongPolling verifies that the ESCClient can watch changes if long polling is enabled
+func (p *PulumiESCProvider) validateLongPolling() error {
+	if _, ok := p.escClient.(ESCChangeWatcher); p.longPolling && !ok {
+		return errors.New("long polling requires an ESCClient implementing ESCChangeWatcher")
+	}
+	return nil
+}
+
+// runLongPolling waits for changes of the environment using the watcher and requests a refresh whenever
+// a newer revision is notified, until the context is done
+func (p *PulumiESCProvider) runLongPolling(ctx context.Context, watcher ESCChangeWatcher) {
+	environment, revision := "", int32(0)
+	for ctx.Err() == nil {
+		projectName, envName, _ := p.environment()
+		if environment != projectName+"/"+envName {
+			// The environment was switched, so the revisions of the previous one are meaningless
+			environment, revision = projectName+"/"+envName, p.Revision()
+		}
+		started := time.Now()
+		reqCtx, cancel := p.requestContext(ctx)
+		latest, err := watcher.WaitForChange(reqCtx, p.orgName, projectName, envName, revision)
+		cancel()
+		delay := longPollMinInterval - time.Since(started)
+		if err != nil {
+			delay = longPollRetryDelay
+		} else if latest > revision {
+			revision = latest
+			p.requestRefresh()
+		}
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package pulumi
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// watchingESCClient is an ESCClient notifying the revisions sent to its channel as changes
+type watchingESCClient struct {
+	ESCClient
+	revisions chan int32
+}
+
+func (c *watchingESCClient) WaitForChange(ctx context.Context, org, projectName, envName string, revision int32) (int32, error) {
+	select {
+	case latest := <-c.revisions:
+		return latest, nil
+	case <-ctx.Done():
+		return revision, ctx.Err()
+	}
+}
+
+func TestWithLongPolling(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": false})
+	server.SetRevision(1)
+	p := newTestProvider(t, server, WithLongPolling())
+	client := &watchingESCClient{ESCClient: p.escClient, revisions: make(chan int32)}
+	p.escClient = client
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	server.SetValue("DEBUG_MODE", true)
+	server.SetRevision(2)
+	client.revisions <- 2
+
+	select {
+	case event := <-p.Changes():
+		assert.Equal(t, int32(2), event.Revision)
+		assert.Equal(t, []FlagChange{{Key: "DEBUG_MODE", Type: ChangeType_Changed, OldValue: false, NewValue: true}}, event.Changes)
+	case <-time.After(time.Second):
+		t.Fatal("the notified change must refresh the environment")
+	}
+	assert.True(t, p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil).Value, "the new values must be served")
+}
+
+func TestWithLongPolling_unsupported(t *testing.T) {
+	p := newTestProvider(t, newFakeESCServer(t, nil), WithLongPolling(), WithPolling(10*time.Millisecond))
+	assert.EqualError(t, p.initialise(context.Background()), "long polling requires an ESCClient implementing ESCChangeWatcher")
+}
+
+// unchangedESCClient is an ESCClient whose watcher returns the given revision immediately
+type unchangedESCClient struct {
+	ESCClient
+	waits atomic.Int32
+}
+
+func (c *unchangedESCClient) WaitForChange(ctx context.Context, org, projectName, envName string, revision int32) (int32, error) {
+	c.waits.Add(1)
+	return revision, nil
+}
+
+func TestWithLongPolling_minInterval(t *testing.T) {
+	p := newTestProvider(t, newFakeESCServer(t, nil), WithLongPolling())
+	client := &unchangedESCClient{ESCClient: p.escClient}
+	p.escClient = client
+	require.NoError(t, p.initialise(context.Background()))
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), client.waits.Load(), "a watcher returning immediately must not spin")
+}
@@ -0,0 +1,153 @@
+package pulumi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// requestFlagsKey is the context key of the RequestFlags of a request
+type requestFlagsKey struct{}
+
+// ContextBuilder builds the evaluation context of a request, e.g. from its headers or from the claims
+// stored in its context by an authentication middleware
+type ContextBuilder func(r *http.Request) openfeature.FlattenedContext
+
+// HeaderContext returns a ContextBuilder setting the evaluation context attributes from the request headers,
+// e.g. HeaderContext(map[string]string{"X-User-ID": openfeature.TargetingKey}). Missing headers are omitted.
+// Clients can set any header, and so choose the flags targeted at them, so it must only be used behind a
+// trusted proxy which sets the headers, e.g. from the authenticated user, and strips them from client requests.
+func HeaderContext(attributes map[string]string) ContextBuilder {
+	return func(r *http.Request) openfeature.FlattenedContext {
+		evalCtx := openfeature.FlattenedContext{}
+		for header, attribute := range attributes {
+			if value := r.Header.Get(header); value != "" {
+				evalCtx[attribute] = value
+			}
+		}
+		return evalCtx
+	}
+}
+
+// RequestFlags are the values of the flags evaluated for a request by the Middleware
+type RequestFlags struct {
+	values map[string]interface{}
+}
+
+// Middleware returns net/http middleware evaluating the given flags for every request, with the evaluation
+// context built by buildContext if not nil, and storing their values in the request context. Handlers read
+// them using FlagsFromContext. Flags of the bool, string, int64, float64, []string and map[string]string types
+// are evaluated, and flags whose evaluation fails are omitted, so the getters return the default value.
+// The flags are evaluated from a single read of the environment, so they are consistent with each other,
+// unless WithCache is used, in which case they are served from the cache.
+func (p *PulumiESCProvider) Middleware(flags map[string]FlagType, buildContext ContextBuilder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var evalCtx openfeature.FlattenedContext
+			if buildContext != nil {
+				evalCtx = buildContext(r)
+			}
+			ctx := r.Context()
+			if len(flags) > 0 && (p.cacheTTL == 0 || p.snapshotOnly()) {
+				// If the environment can not be read, every flag falls back as its evaluation would
+				if snapshot, err := p.currentSnapshot(ctx); err == nil {
+					ctx = withEvaluationSnapshot(ctx, snapshot)
+				}
+			}
+			requestFlags := &RequestFlags{values: make(map[string]interface{}, len(flags))}
+			for key, flagType := range flags {
+				if value, resolutionDetails, ok := p.evaluate(ctx, key, flagType, evalCtx); ok && resolutionDetails.Error() == nil {
+					requestFlags.values[key] = value
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestFlagsKey{}, requestFlags)))
+		})
+	}
+}
+
+// evaluate evaluates the flag using the evaluation method of its type, and reports whether the type has one
+func (p *PulumiESCProvider) evaluate(ctx context.Context, key string, flagType FlagType, evalCtx openfeature.FlattenedContext) (interface{}, openfeature.ProviderResolutionDetail, bool) {
+	var value interface{}
+	var resolutionDetails openfeature.ProviderResolutionDetail
+	switch flagType {
+	case FlagType_Bool:
+		details := p.BooleanEvaluation(ctx, key, false, evalCtx)
+		value, resolutionDetails = details.Value, details.ProviderResolutionDetail
+	case FlagType_String:
+		details := p.StringEvaluation(ctx, key, "", evalCtx)
+		value, resolutionDetails = details.Value, details.ProviderResolutionDetail
+	case FlagType_Integer:
+		details := p.IntEvaluation(ctx, key, 0, evalCtx)
+		value, resolutionDetails = details.Value, details.ProviderResolutionDetail
+	case FlagType_Float:
+		details := p.FloatEvaluation(ctx, key, 0, evalCtx)
+		value, resolutionDetails = details.Value, details.ProviderResolutionDetail
+	case FlagType_StringSlice:
+		details := p.StringSliceEvaluation(ctx, key, nil, evalCtx)
+		value, resolutionDetails = details.Value, details.ProviderResolutionDetail
+	case FlagType_StringMap:
+		details := p.StringMapEvaluation(ctx, key, nil, evalCtx)
+		value, resolutionDetails = details.Value, details.ProviderResolutionDetail
+	default:
+		return nil, openfeature.ProviderResolutionDetail{}, false
+	}
+	return value, resolutionDetails, true
+}
+
+// FlagsFromContext returns the flags evaluated for the request by the Middleware. It never returns nil,
+// so the getters can be called on requests which did not go through the Middleware.
+func FlagsFromContext(ctx context.Context) *RequestFlags {
+	if requestFlags, ok := ctx.Value(requestFlagsKey{}).(*RequestFlags); ok {
+		return requestFlags
+	}
+	return &RequestFlags{}
+}
+
+// Bool returns the value of a bool flag, or the default value if it was not evaluated
+func (f *RequestFlags) Bool(key string, defaultValue bool) bool {
+	if value, ok := f.values[key].(bool); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// String returns the value of a string flag, or the default value if it was not evaluated
+func (f *RequestFlags) String(key string, defaultValue string) string {
+	if value, ok := f.values[key].(string); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// Int returns the value of an int64 flag, or the default value if it was not evaluated
+func (f *RequestFlags) Int(key string, defaultValue int64) int64 {
+	if value, ok := f.values[key].(int64); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// Float returns the value of a float64 flag, or the default value if it was not evaluated
+func (f *RequestFlags) Float(key string, defaultValue float64) float64 {
+	if value, ok := f.values[key].(float64); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// StringSlice returns the value of a []string flag, or the default value if it was not evaluated
+func (f *RequestFlags) StringSlice(key string, defaultValue []string) []string {
+	if value, ok := f.values[key].([]string); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// StringMap returns the value of a map[string]string flag, or the default value if it was not evaluated
+func (f *RequestFlags) StringMap(key string, defaultValue map[string]string) map[string]string {
+	if value, ok := f.values[key].(map[string]string); ok {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,86 @@
+package pulumi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPulumiESCProvider_Middleware(t *testing.T) {
+	p, err := NewStaticProvider(map[string]interface{}{
+		"DEBUG_MODE": true,
+		"THEME":      "dark",
+		"REGIONS":    []interface{}{"eu", "us"},
+		"configs":    map[string]interface{}{"MAX_RETRIES": 3, "RATIO": 0.5},
+	})
+	require.NoError(t, err)
+	defer p.Shutdown()
+
+	var evalCtx openfeature.FlattenedContext
+	var requestFlags *RequestFlags
+	handler := p.Middleware(map[string]FlagType{
+		"DEBUG_MODE":          FlagType_Bool,
+		"THEME":               FlagType_String,
+		"REGIONS":             FlagType_StringSlice,
+		"configs.MAX_RETRIES": FlagType_Integer,
+		"configs.RATIO":       FlagType_Float,
+		"MISSING":             FlagType_Bool,
+		"configs":             FlagType_Object,
+	}, func(r *http.Request) openfeature.FlattenedContext {
+		evalCtx = HeaderContext(map[string]string{"X-User-ID": openfeature.TargetingKey, "X-Plan": "plan"})(r)
+		return evalCtx
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestFlags = FlagsFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, openfeature.FlattenedContext{openfeature.TargetingKey: "user-1"}, evalCtx, "missing headers must be omitted")
+	require.NotNil(t, requestFlags)
+	assert.True(t, requestFlags.Bool("DEBUG_MODE", false))
+	assert.Equal(t, "dark", requestFlags.String("THEME", "light"))
+	assert.Equal(t, []string{"eu", "us"}, requestFlags.StringSlice("REGIONS", nil))
+	assert.Equal(t, int64(3), requestFlags.Int("configs.MAX_RETRIES", 1))
+	assert.Equal(t, 0.5, requestFlags.Float("configs.RATIO", 1))
+	assert.True(t, requestFlags.Bool("MISSING", true), "failed evaluations must return the default value")
+	assert.Equal(t, map[string]string{"a": "b"}, requestFlags.StringMap("configs", map[string]string{"a": "b"}), "unsupported types must not be evaluated")
+	assert.Equal(t, "light", requestFlags.String("DEBUG_MODE", "light"), "getters of another type must return the default value")
+}
+
+func TestPulumiESCProvider_Middleware_singleRead(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true, "THEME": "dark", "configs.MAX_RETRIES": 3})
+	p := newTestProvider(t, server)
+	require.NoError(t, p.initialise(context.Background()))
+
+	var requestFlags *RequestFlags
+	handler := p.Middleware(map[string]FlagType{
+		"DEBUG_MODE":          FlagType_Bool,
+		"THEME":               FlagType_String,
+		"configs.MAX_RETRIES": FlagType_Integer,
+	}, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestFlags = FlagsFromContext(r.Context())
+	}))
+
+	requests := server.Requests()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, requests+1, server.Requests(), "the flags must be evaluated from a single read of the environment")
+	assert.True(t, requestFlags.Bool("DEBUG_MODE", false))
+	assert.Equal(t, "dark", requestFlags.String("THEME", "light"))
+	assert.Equal(t, int64(3), requestFlags.Int("configs.MAX_RETRIES", 1))
+
+	server.SetUnavailable(true)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.False(t, requestFlags.Bool("DEBUG_MODE", false), "flags must fall back to the default value if the environment can not be read")
+}
+
+func TestFlagsFromContext_withoutMiddleware(t *testing.T) {
+	requestFlags := FlagsFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context())
+	assert.True(t, requestFlags.Bool("DEBUG_MODE", true))
+}
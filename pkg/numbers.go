@@ -0,0 +1,129 @@
+package pulumi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// maxExactInteger is the largest magnitude of the integers float64 represents exactly
+const maxExactInteger = 1 << 53
+
+// decodePropertyValue decodes the raw value of a Pulumi ESC API property response.
+// Unlike the Pulumi go sdk, it keeps integers float64 can not represent exactly as json.Number.
+func decodePropertyValue(body []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode property value: %w", err)
+	}
+	return primitiveValue(value), nil
+}
+
+// decodeJSON decodes a JSON document into v, keeping integers float64 can not represent exactly as
+// json.Number. Numbers of the decoded values must be normalised using normaliseValue.
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// decodeValues decodes a JSON object of raw values, as they would be read from the environment
+func decodeValues(data []byte) (map[string]interface{}, error) {
+	var values map[string]interface{}
+	if err := decodeJSON(data, &values); err != nil {
+		return nil, err
+	}
+	return normaliseValues(values), nil
+}
+
+// normaliseValues normalises the numbers of the given raw values using normaliseNumber
+func normaliseValues(values map[string]interface{}) map[string]interface{} {
+	for key, value := range values {
+		values[key] = normaliseValue(value)
+	}
+	return values
+}
+
+// normaliseValue normalises the numbers of a raw value and its nested values using normaliseNumber
+func normaliseValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return normaliseValues(value)
+	case []interface{}:
+		for i, item := range value {
+			value[i] = normaliseValue(item)
+		}
+		return value
+	case json.Number:
+		return normaliseNumber(value)
+	}
+	return value
+}
+
+// primitiveValue returns the raw value of a Pulumi ESC value, unwrapping its nested values
+func primitiveValue(value interface{}) interface{} {
+	wrapper, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	switch nested := wrapper["value"].(type) {
+	case map[string]interface{}:
+		values := make(map[string]interface{}, len(nested))
+		for key, nestedValue := range nested {
+			values[key] = primitiveValue(nestedValue)
+		}
+		return values
+	case []interface{}:
+		values := make([]interface{}, len(nested))
+		for i, nestedValue := range nested {
+			values[i] = primitiveValue(nestedValue)
+		}
+		return values
+	case json.Number:
+		return normaliseNumber(nested)
+	default:
+		return nested
+	}
+}
+
+// normaliseNumber returns a number as float64, unless it is an integer float64 can not represent exactly
+func normaliseNumber(number json.Number) interface{} {
+	if value, err := number.Int64(); err == nil && (value > maxExactInteger || value < -maxExactInteger) {
+		return number
+	}
+	value, _ := number.Float64()
+	return value
+}
+
+// intValue converts a raw number to int64.
+// It returns false if the raw value is not a number or overflows int64.
+func intValue(rawValue interface{}) (int64, bool) {
+	switch value := rawValue.(type) {
+	case float64:
+		// -math.MinInt64 can not be represented as int64, but is exactly represented as float64
+		if value < math.MinInt64 || value >= -math.MinInt64 || math.IsNaN(value) {
+			return 0, false
+		}
+		return int64(value), true
+	case json.Number:
+		integer, err := value.Int64()
+		return integer, err == nil
+	}
+	return 0, false
+}
+
+// floatValue converts a raw number to float64.
+// It returns false if the raw value is not a number.
+func floatValue(rawValue interface{}) (float64, bool) {
+	switch value := rawValue.(type) {
+	case float64:
+		return value, true
+	case json.Number:
+		float, err := value.Float64()
+		return float, err == nil
+	}
+	return 0, false
+}
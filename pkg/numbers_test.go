@@ -0,0 +1,91 @@
+package pulumi
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPulumiESCProvider_IntEvaluation_precision(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"large":    int64(9007199254740993),
+		"max":      int64(math.MaxInt64),
+		"overflow": json.Number("92233720368547758070"),
+		"huge":     1e20,
+		"configs":  map[string]interface{}{"ID": int64(-9007199254740993)},
+	})
+	p := newTestProvider(t, server)
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+	ctx := context.Background()
+
+	assert.Equal(t, int64(9007199254740993), p.IntEvaluation(ctx, "large", 0, nil).Value)
+	assert.Equal(t, int64(math.MaxInt64), p.IntEvaluation(ctx, "max", 0, nil).Value)
+	assert.Equal(t, float64(9007199254740993), p.FloatEvaluation(ctx, "large", 0, nil).Value)
+
+	for _, key := range []string{"overflow", "huge"} {
+		details := p.IntEvaluation(ctx, key, DEFAULT_INT_FLAG_VALUE, nil)
+		assert.Equal(t, DEFAULT_INT_FLAG_VALUE, details.Value)
+		assert.Equal(t, openfeature.TypeMismatchCode, details.ResolutionDetail().ErrorCode, key)
+	}
+}
+
+func TestDecodePropertyValue(t *testing.T) {
+	value, err := decodePropertyValue([]byte(`{"value":{"small":{"value":1.5},"large":{"value":9007199254740993},"list":{"value":[{"value":"a"}]}}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"small": 1.5,
+		"large": json.Number("9007199254740993"),
+		"list":  []interface{}{"a"},
+	}, value)
+
+	_, err = decodePropertyValue([]byte(`{`))
+	assert.Error(t, err)
+}
+
+func TestDecodeValues_precision(t *testing.T) {
+	values, err := decodeValues([]byte(`{"small":1,"large":9007199254740993,"nested":{"list":[-9007199254740993,1.5]}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"small":  float64(1),
+		"large":  json.Number("9007199254740993"),
+		"nested": map[string]interface{}{"list": []interface{}{json.Number("-9007199254740993"), 1.5}},
+	}, values)
+
+	p, err := NewStaticProvider(map[string]interface{}{"X": int64(9007199254740993)})
+	assert.NoError(t, err)
+	defer p.Shutdown()
+	assert.Equal(t, int64(9007199254740993), p.IntEvaluation(context.Background(), "X", 0, nil).Value)
+}
+
+func TestReadOpenEnvironment_precision(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"large":   int64(9007199254740993),
+		"configs": map[string]interface{}{"ID": int64(-9007199254740993)},
+	})
+	path := filepath.Join(t.TempDir(), "snapshot")
+	p := newTestProvider(t, server, WithSnapshotPath(path))
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+
+	_, values, err := p.escClient.ReadOpenEnvironment(p.escAuthCtx, p.orgName, p.projectName, p.envName, p.sessionID())
+	assert.NoError(t, err)
+	assert.Equal(t, json.Number("9007199254740993"), values["large"])
+	assert.Equal(t, map[string]interface{}{"ID": json.Number("-9007199254740993")}, values["configs"])
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "the snapshot must be persisted after the session is opened")
+	assert.NoError(t, p.ShutdownWithContext(context.Background()))
+	server.SetUnavailable(true)
+	p = newTestProvider(t, server, WithSnapshotPath(path))
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+	assert.Equal(t, int64(9007199254740993), p.IntEvaluation(context.Background(), "large", 0, nil).Value, "snapshots must keep integers exact")
+	assert.Equal(t, int64(-9007199254740993), p.IntEvaluation(context.Background(), "configs.ID", 0, nil).Value)
+}
@@ -0,0 +1,267 @@
+package pulumi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+const (
+	// ofrepEvaluatePath is the path of the OFREP evaluation endpoints
+	ofrepEvaluatePath = "/ofrep/v1/evaluate/flags"
+	// maxOFREPBodySize is the maximum size of the body of an OFREP request
+	maxOFREPBodySize = 1 << 20
+	// ofrepInternalError is the error details of the failures of the Pulumi ESC API, whose errors are not
+	// disclosed to clients
+	ofrepInternalError = "internal error"
+)
+
+// ofrepRequest is the body of an OFREP evaluation request
+type ofrepRequest struct {
+	Context openfeature.FlattenedContext `json:"context"`
+}
+
+// ofrepEvaluation is the successful evaluation of a flag returned by the OFREP endpoints
+type ofrepEvaluation struct {
+	Key      string                   `json:"key"`
+	Value    interface{}              `json:"value"`
+	Reason   openfeature.Reason       `json:"reason"`
+	Variant  string                   `json:"variant,omitempty"`
+	Metadata openfeature.FlagMetadata `json:"metadata,omitempty"`
+}
+
+// ofrepError is the failed evaluation of a flag, or the error of a request, returned by the OFREP endpoints
+type ofrepError struct {
+	Key          string                `json:"key,omitempty"`
+	ErrorCode    openfeature.ErrorCode `json:"errorCode"`
+	ErrorDetails string                `json:"errorDetails,omitempty"`
+}
+
+// ofrepBulkResponse is the body of the response of the OFREP bulk evaluation endpoint, whose flags are
+// ofrepEvaluations or ofrepErrors
+type ofrepBulkResponse struct {
+	Flags []interface{} `json:"flags"`
+}
+
+// OFREPHandler returns a http.Handler serving the OpenFeature Remote Evaluation Protocol endpoints, so
+// browser and mobile clients using an OFREP provider evaluate flags against the environment. Mount it at
+// the root of the OFREP base URL, e.g. mux.Handle("/ofrep/", provider.OFREPHandler()).
+//
+// POST /ofrep/v1/evaluate/flags evaluates all the flags in one round trip. Its responses carry an ETag, and
+// requests whose If-None-Match header matches the evaluations are answered with 304 Not Modified, so clients
+// re-validate cheaply. POST /ofrep/v1/evaluate/flags/{key} evaluates a single flag. The latency and cache
+// status metadata of the evaluations are omitted, as they change between requests.
+//
+// Flags are evaluated using the evaluation method of the type of their value, from a single read of the
+// environment per request. Objects are not flags, but their nested values are, and arrays of strings are
+// evaluated as []string. Secrets are never served: they are omitted from the bulk evaluation and their single
+// evaluations fail with FLAG_NOT_FOUND. The evaluation context keys selecting the environment of
+// WithEnvironmentOverrides are ignored, as clients are not trusted, and the errors of the Pulumi ESC API are
+// not disclosed.
+func (p *PulumiESCProvider) OFREPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		path := strings.TrimSuffix(r.URL.Path, "/")
+		if !strings.HasPrefix(path, ofrepEvaluatePath) {
+			http.NotFound(w, r)
+			return
+		}
+		var request ofrepRequest
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxOFREPBodySize))
+		if err == nil && len(body) > 0 {
+			err = json.Unmarshal(body, &request)
+		}
+		if err != nil {
+			writeOFREPJSON(w, http.StatusBadRequest, ofrepError{
+				ErrorCode:    openfeature.InvalidContextCode,
+				ErrorDetails: "invalid evaluation context",
+			})
+			return
+		}
+		// Clients may not select another environment than the one of the provider
+		delete(request.Context, EnvironmentContextKey)
+		delete(request.Context, ProjectContextKey)
+		if key, found := strings.CutPrefix(path, ofrepEvaluatePath+"/"); found {
+			p.serveOFREPFlag(w, r, key, request.Context)
+			return
+		}
+		if path != ofrepEvaluatePath {
+			http.NotFound(w, r)
+			return
+		}
+		p.serveOFREPBulk(w, r, request.Context)
+	})
+}
+
+// serveOFREPFlag evaluates a single flag
+func (p *PulumiESCProvider) serveOFREPFlag(w http.ResponseWriter, r *http.Request, key string, evalCtx openfeature.FlattenedContext) {
+	snapshot, err := p.currentSnapshot(r.Context())
+	if err != nil {
+		writeOFREPJSON(w, http.StatusInternalServerError, ofrepError{Key: key, ErrorCode: openfeature.GeneralCode, ErrorDetails: ofrepInternalError})
+		return
+	}
+	_, rawValue, ok := snapshot.lookup(key)
+	flagType, supported := p.ofrepFlagType(rawValue)
+	if !ok || snapshot.isSecret(key) || !supported {
+		writeOFREPJSON(w, http.StatusNotFound, ofrepError{Key: key, ErrorCode: openfeature.FlagNotFoundCode, ErrorDetails: "flag not found"})
+		return
+	}
+	evaluation, errorCode := p.ofrepEvaluate(withEvaluationSnapshot(r.Context(), snapshot), key, flagType, evalCtx)
+	switch errorCode {
+	case "":
+		writeOFREPJSON(w, http.StatusOK, evaluation)
+	case openfeature.FlagNotFoundCode:
+		writeOFREPJSON(w, http.StatusNotFound, evaluation)
+	case openfeature.GeneralCode:
+		writeOFREPJSON(w, http.StatusInternalServerError, evaluation)
+	default:
+		writeOFREPJSON(w, http.StatusBadRequest, evaluation)
+	}
+}
+
+// serveOFREPBulk evaluates all the flags and answers with 304 Not Modified if the evaluations match the
+// ETag of the request
+func (p *PulumiESCProvider) serveOFREPBulk(w http.ResponseWriter, r *http.Request, evalCtx openfeature.FlattenedContext) {
+	snapshot, err := p.currentSnapshot(r.Context())
+	if err != nil {
+		writeOFREPJSON(w, http.StatusInternalServerError, ofrepError{ErrorCode: openfeature.GeneralCode, ErrorDetails: ofrepInternalError})
+		return
+	}
+	ctx := withEvaluationSnapshot(r.Context(), snapshot)
+	flags := map[string]FlagType{}
+	p.collectOFREPFlags(snapshot, snapshot.Values, "", flags)
+	keys := make([]string, 0, len(flags))
+	for key := range flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	response := ofrepBulkResponse{Flags: make([]interface{}, 0, len(keys))}
+	for _, key := range keys {
+		evaluation, _ := p.ofrepEvaluate(ctx, key, flags[key], evalCtx)
+		response.Flags = append(response.Flags, evaluation)
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		writeOFREPJSON(w, http.StatusInternalServerError, ofrepError{ErrorCode: openfeature.GeneralCode, ErrorDetails: ofrepInternalError})
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// collectOFREPFlags adds the flags of the values which can be evaluated, and their types, to flags
+func (p *PulumiESCProvider) collectOFREPFlags(snapshot *environmentSnapshot, values map[string]interface{}, prefix string, flags map[string]FlagType) {
+	for key, value := range values {
+		key = joinPropertyPath(prefix, key)
+		if snapshot.isSecret(key) {
+			continue
+		}
+		if flagType, ok := p.ofrepFlagType(value); ok {
+			flags[key] = flagType
+		} else if nested, ok := value.(map[string]interface{}); ok {
+			p.collectOFREPFlags(snapshot, nested, key, flags)
+		}
+	}
+}
+
+// ofrepFlagType returns the type a value is evaluated as, or false if it is not a flag. The type of a flag
+// definition is the type of its value, or of its default or first variant.
+func (p *PulumiESCProvider) ofrepFlagType(rawValue interface{}) (FlagType, bool) {
+	if p.flagDefinitions {
+		definition, err := parseFlagDefinition(rawValue)
+		if err != nil {
+			// The evaluation reports the invalid definition whatever its type
+			return FlagType_String, true
+		}
+		if definition != nil {
+			rawValue = definition.value
+			if definition.variants != nil {
+				rawValue = definition.variants[definition.fallbackVariant()]
+			}
+		}
+	}
+	switch value := rawValue.(type) {
+	case map[string]interface{}, nil:
+		return "", false
+	case []interface{}:
+		for _, item := range value {
+			if _, ok := item.(string); !ok {
+				return "", false
+			}
+		}
+		return FlagType_StringSlice, true
+	}
+	return inferFlagType(rawValue), true
+}
+
+// ofrepEvaluate evaluates a flag using the evaluation method of its type and returns its ofrepEvaluation,
+// or its ofrepError and error code if it failed. Secrets are reported as not found.
+func (p *PulumiESCProvider) ofrepEvaluate(ctx context.Context, key string, flagType FlagType, evalCtx openfeature.FlattenedContext) (interface{}, openfeature.ErrorCode) {
+	value, resolutionDetails, _ := p.evaluate(ctx, key, flagType, evalCtx)
+	resolution := resolutionDetails.ResolutionDetail()
+	if secret, _ := resolution.FlagMetadata.GetBool("secret"); secret {
+		return ofrepError{Key: key, ErrorCode: openfeature.FlagNotFoundCode, ErrorDetails: "flag not found"}, openfeature.FlagNotFoundCode
+	}
+	switch resolution.ErrorCode {
+	case "":
+	case openfeature.GeneralCode:
+		return ofrepError{Key: key, ErrorCode: resolution.ErrorCode, ErrorDetails: ofrepInternalError}, resolution.ErrorCode
+	default:
+		return ofrepError{Key: key, ErrorCode: resolution.ErrorCode, ErrorDetails: resolution.ErrorMessage}, resolution.ErrorCode
+	}
+	return ofrepEvaluation{
+		Key:      key,
+		Value:    value,
+		Reason:   resolution.Reason,
+		Variant:  resolution.Variant,
+		Metadata: ofrepMetadata(resolution.FlagMetadata),
+	}, ""
+}
+
+// ofrepMetadata returns the metadata of an evaluation without the keys which change between evaluations
+// of the same values, so the ETag of the bulk evaluation is stable
+func ofrepMetadata(metadata openfeature.FlagMetadata) openfeature.FlagMetadata {
+	stable := make(openfeature.FlagMetadata, len(metadata))
+	for key, value := range metadata {
+		if key != latencyMetadataKey && key != cacheMetadataKey {
+			stable[key] = value
+		}
+	}
+	return stable
+}
+
+// etagMatches reports whether the If-None-Match header matches the ETag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func writeOFREPJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
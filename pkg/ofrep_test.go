@@ -0,0 +1,129 @@
+package pulumi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// evaluateOFREP sends an OFREP evaluation request with the given body to the handler
+func evaluateOFREP(handler http.Handler, path, body, etag string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// ofrepFlags returns the flags of a bulk evaluation response by key
+func ofrepFlags(t *testing.T, rec *httptest.ResponseRecorder) map[string]map[string]interface{} {
+	var response struct {
+		Flags []map[string]interface{} `json:"flags"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	flags := map[string]map[string]interface{}{}
+	for _, flag := range response.Flags {
+		flags[flag["key"].(string)] = flag
+	}
+	return flags
+}
+
+func TestOFREPHandler_bulk(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"DEBUG_MODE":          false,
+		"configs.MAX_RETRIES": 3,
+		"configs.REGIONS":     []interface{}{"eu", "us"},
+		"NEW_SEARCH":          map[string]interface{}{"value": true, "rollout": 50},
+	})
+	server.SetSecret("configs.API_KEY", "sk-12345")
+	p := newTestProvider(t, server, WithFlagDefinitions())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	handler := p.OFREPHandler()
+
+	rec := evaluateOFREP(handler, "/ofrep/v1/evaluate/flags", `{"context": {"targetingKey": "user-1"}}`, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	flags := ofrepFlags(t, rec)
+	assert.Len(t, flags, 4, "secrets and objects must be omitted")
+	assert.Equal(t, false, flags["DEBUG_MODE"]["value"])
+	assert.Equal(t, "STATIC", flags["DEBUG_MODE"]["reason"])
+	assert.Equal(t, 3.0, flags["configs.MAX_RETRIES"]["value"])
+	assert.Equal(t, []interface{}{"eu", "us"}, flags["configs.REGIONS"]["value"])
+	assert.Equal(t, "SPLIT", flags["NEW_SEARCH"]["reason"])
+
+	rec = evaluateOFREP(handler, "/ofrep/v1/evaluate/flags", `{"context": {"targetingKey": "user-1"}}`, etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = evaluateOFREP(handler, "/ofrep/v1/evaluate/flags", `{"context": {}}`, etag)
+	assert.Equal(t, http.StatusOK, rec.Code, "the evaluations of another context must not match the ETag")
+	assert.Equal(t, "TARGETING_KEY_MISSING", ofrepFlags(t, rec)["NEW_SEARCH"]["errorCode"])
+
+	server.SetValue("DEBUG_MODE", true)
+	rec = evaluateOFREP(handler, "/ofrep/v1/evaluate/flags", `{"context": {"targetingKey": "user-1"}}`, etag)
+	assert.Equal(t, http.StatusOK, rec.Code, "changed flags must not match the ETag")
+	assert.Equal(t, true, ofrepFlags(t, rec)["DEBUG_MODE"]["value"])
+}
+
+func TestOFREPHandler_flag(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true, "configs.MAX_RETRIES": 3})
+	server.SetSecret("configs.API_KEY", "sk-12345")
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	handler := p.OFREPHandler()
+
+	rec := evaluateOFREP(handler, "/ofrep/v1/evaluate/flags/configs.MAX_RETRIES", `{"context": {}}`, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var evaluation map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &evaluation))
+	assert.Equal(t, "configs.MAX_RETRIES", evaluation["key"])
+	assert.Equal(t, 3.0, evaluation["value"])
+
+	for _, key := range []string{"MISSING", "configs.API_KEY", "configs"} {
+		rec = evaluateOFREP(handler, "/ofrep/v1/evaluate/flags/"+key, "", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, key)
+		assert.JSONEq(t, `{"key": "`+key+`", "errorCode": "FLAG_NOT_FOUND", "errorDetails": "flag not found"}`, rec.Body.String())
+	}
+
+	assert.Equal(t, http.StatusBadRequest, evaluateOFREP(handler, "/ofrep/v1/evaluate/flags", `{"context": [`, "").Code)
+	assert.Equal(t, http.StatusNotFound, evaluateOFREP(handler, "/ofrep/v1/other", `{}`, "").Code)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ofrep/v1/evaluate/flags", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestOFREPHandler_untrustedClients(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true, "configs.MAX_RETRIES": 3, "THEME": "dark"})
+	p := newTestProvider(t, server, WithEnvironmentOverrides(PROJECT_NAME+"/tenant-a"))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	handler := p.OFREPHandler()
+
+	requests := server.Requests()
+	rec := evaluateOFREP(handler, "/ofrep/v1/evaluate/flags", `{"context": {}}`, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, ofrepFlags(t, rec), 3)
+	assert.Equal(t, requests+1, server.Requests(), "the flags must be evaluated from a single read of the environment")
+
+	requests = server.Requests()
+	rec = evaluateOFREP(handler, "/ofrep/v1/evaluate/flags/DEBUG_MODE", `{"context": {"pulumi.env": "tenant-a"}}`, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, requests+1, server.Requests(), "clients may not select another environment")
+	assert.Empty(t, p.overrides.sessions, "clients may not select another environment")
+
+	server.SetUnavailable(true)
+	rec = evaluateOFREP(handler, "/ofrep/v1/evaluate/flags", `{"context": {}}`, "")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"errorCode": "GENERAL", "errorDetails": "internal error"}`, rec.Body.String(), "API errors must not be disclosed")
+}
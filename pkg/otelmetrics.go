@@ -0,0 +1,95 @@
+package pulumi
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// meterName is the instrumentation scope of the OpenTelemetry instruments of the provider
+const meterName = "github.com/bugcacher/open-feature-pulumi-esc-provider"
+
+// otelInstruments are the OpenTelemetry metric instruments of a provider
+type otelInstruments struct {
+	evaluations metric.Int64Counter
+	apiDuration metric.Float64Histogram
+}
+
+// WithMeterProvider registers OpenTelemetry metric instruments against the given MeterProvider:
+//
+//   - feature_flag.evaluations: a counter of the evaluations, with the feature_flag.key,
+//     feature_flag.result.reason and, for failed evaluations, error.type attributes, see ErrorCounts
+//   - pulumi_esc.api.request.duration: a histogram of the duration in seconds of the Pulumi ESC API requests,
+//     with the http.request.method and http.response.status_code attributes
+//   - pulumi_esc.cache.size: a gauge of the number of values kept in memory by WithRateLimit or a ClientPool
+//
+// API requests are only measured when the provider creates the Pulumi ESC client, i.e. without WithESCClient.
+// Errors creating the instruments are reported to the global OpenTelemetry error handler.
+func WithMeterProvider(meterProvider metric.MeterProvider) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		meter := meterProvider.Meter(meterName, metric.WithInstrumentationVersion(providerVersion()))
+		instruments := &otelInstruments{}
+		var err error
+		if instruments.evaluations, err = meter.Int64Counter("feature_flag.evaluations",
+			metric.WithDescription("The number of flag evaluations"),
+			metric.WithUnit("{evaluation}")); err != nil {
+			otel.Handle(err)
+		}
+		if instruments.apiDuration, err = meter.Float64Histogram("pulumi_esc.api.request.duration",
+			metric.WithDescription("The duration of the Pulumi ESC API requests"),
+			metric.WithUnit("s")); err != nil {
+			otel.Handle(err)
+		}
+		if _, err = meter.Int64ObservableGauge("pulumi_esc.cache.size",
+			metric.WithDescription("The number of flag values kept in memory"),
+			metric.WithUnit("{value}"),
+			metric.WithInt64Callback(func(_ context.Context, observer metric.Int64Observer) error {
+				if cache := p.lastKnownValues; cache != nil {
+					observer.Observe(int64(cache.size()))
+				}
+				return nil
+			})); err != nil {
+			otel.Handle(err)
+		}
+		p.instruments = instruments
+	}
+}
+
+// recordEvaluation counts an evaluation
+func (i *otelInstruments) recordEvaluation(ctx context.Context, flag string, resolutionDetails openfeature.ProviderResolutionDetail) {
+	if i.evaluations == nil {
+		return
+	}
+	attributes := []attribute.KeyValue{
+		attribute.String("feature_flag.key", flag),
+		attribute.String("feature_flag.result.reason", string(resolutionDetails.Reason)),
+	}
+	if category := errorCategory(resolutionDetails); category != "" {
+		attributes = append(attributes, attribute.String("error.type", category))
+	}
+	i.evaluations.Add(ctx, 1, metric.WithAttributes(attributes...))
+}
+
+// metricsTransport is a http.RoundTripper recording the duration of the Pulumi ESC API requests
+type metricsTransport struct {
+	base        http.RoundTripper
+	apiDuration metric.Float64Histogram
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	attributes := []attribute.KeyValue{attribute.String("http.request.method", req.Method)}
+	if err != nil {
+		attributes = append(attributes, attribute.String("error.type", "request_failed"))
+	} else {
+		attributes = append(attributes, attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	t.apiDuration.Record(req.Context(), time.Since(start).Seconds(), metric.WithAttributes(attributes...))
+	return resp, err
+}
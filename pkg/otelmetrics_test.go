@@ -0,0 +1,71 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// collectMetrics returns the metrics collected by the reader by name
+func collectMetrics(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	var resourceMetrics metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &resourceMetrics))
+	metrics := map[string]metricdata.Aggregation{}
+	for _, scopeMetrics := range resourceMetrics.ScopeMetrics {
+		for _, m := range scopeMetrics.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func TestWithMeterProvider(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true, "MAX_RETRIES": 3})
+	reader := sdkmetric.NewManualReader()
+	p := newTestProvider(t, server, WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))), WithRateLimit(100, 10))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+	p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+	p.BooleanEvaluation(ctx, "MISSING", false, nil)
+	metrics := collectMetrics(t, reader)
+
+	evaluations := metrics["feature_flag.evaluations"].(metricdata.Sum[int64])
+	count := func(attributes ...attribute.KeyValue) int64 {
+		set := attribute.NewSet(attributes...)
+		for _, point := range evaluations.DataPoints {
+			if point.Attributes.Equals(&set) {
+				return point.Value
+			}
+		}
+		return 0
+	}
+	assert.Equal(t, int64(2), count(
+		attribute.String("feature_flag.key", "DEBUG_MODE"),
+		attribute.String("feature_flag.result.reason", "STATIC"),
+	))
+	assert.Equal(t, int64(1), count(
+		attribute.String("feature_flag.key", "MISSING"),
+		attribute.String("feature_flag.result.reason", "ERROR"),
+		attribute.String("error.type", "FLAG_NOT_FOUND"),
+	))
+
+	duration := metrics["pulumi_esc.api.request.duration"].(metricdata.Histogram[float64])
+	var requests uint64
+	for _, point := range duration.DataPoints {
+		requests += point.Count
+		method, _ := point.Attributes.Value("http.request.method")
+		assert.NotEmpty(t, method.AsString())
+	}
+	assert.NotZero(t, requests, "the requests of initialisation and evaluations must be measured")
+
+	cacheSize := metrics["pulumi_esc.cache.size"].(metricdata.Gauge[int64])
+	require.Len(t, cacheSize.DataPoints, 1)
+	assert.Equal(t, int64(1), cacheSize.DataPoints[0].Value)
+}
@@ -0,0 +1,210 @@
+package pulumi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+const (
+	// EnvironmentContextKey is the evaluation context key overriding the environment a flag is resolved from,
+	// either an environment of the project of the provider, e.g. "tenant-a", or "project/env"
+	EnvironmentContextKey = "pulumi.env"
+	// ProjectContextKey is the evaluation context key overriding the project of the environment a flag is
+	// resolved from. It defaults to the project of the provider.
+	ProjectContextKey = "pulumi.project"
+)
+
+// environmentOverrides holds the environments which may be selected in the evaluation context and the
+// sessions opened for them
+type environmentOverrides struct {
+	// allowed are the environments which may be selected
+	allowed  map[string]bool
+	mu       sync.Mutex
+	sessions map[string]overrideSession
+	// opening are the sessions being opened, so concurrent evaluations open an environment once
+	opening map[string]*openingSession
+}
+
+// overrideSession is an open session of an environment selected in the evaluation context
+type overrideSession struct {
+	id       string
+	revision int32
+	openedAt time.Time
+}
+
+// openingSession is a session of an environment selected in the evaluation context being opened
+type openingSession struct {
+	done    chan struct{}
+	session overrideSession
+	err     error
+}
+
+// WithEnvironmentOverrides resolves flags from the environment selected by the pulumi.env and pulumi.project
+// keys of the evaluation context, e.g. the environment of a tenant in multi-tenant deployments. Only the given
+// environments, of the form "project/env", may be selected, and initialisation fails if none is given.
+// Evaluations selecting another environment fail with INVALID_CONTEXT.
+//
+// Values of the selected environments are read from the Pulumi ESC API, without the cache and snapshots of
+// the environment of the provider. Their sessions are reopened at the latest revision when they expire and,
+// with WithPolling, once they are older than the polling interval. The previous session keeps being used if
+// it can not be reopened.
+func WithEnvironmentOverrides(environments ...string) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.overrides = &environmentOverrides{
+			allowed:  map[string]bool{},
+			sessions: map[string]overrideSession{},
+			opening:  map[string]*openingSession{},
+		}
+		for _, environment := range environments {
+			p.overrides.allowed[environment] = true
+		}
+	}
+}
+
+// validateEnvironmentOverrides verifies that environments may be selected if environment overrides are enabled
+func (p *PulumiESCProvider) validateEnvironmentOverrides() error {
+	if p.overrides != nil && len(p.overrides.allowed) == 0 {
+		return errors.New("environment overrides require at least one environment which may be selected")
+	}
+	return nil
+}
+
+// environmentOverride returns the "project/env" environment selected in the evaluation context, or an empty
+// string if the flag is resolved from the environment of the provider
+func (p *PulumiESCProvider) environmentOverride(evalCtx map[string]interface{}) (string, error) {
+	if p.overrides == nil {
+		return "", nil
+	}
+	projectName, envName, _ := p.environment()
+	selectedProject, selectedEnv := projectName, envName
+	if value, ok := evalCtx[ProjectContextKey]; ok {
+		project, ok := value.(string)
+		if !ok || project == "" {
+			return "", fmt.Errorf("%s must be a non-empty string", ProjectContextKey)
+		}
+		selectedProject = project
+	}
+	if value, ok := evalCtx[EnvironmentContextKey]; ok {
+		env, ok := value.(string)
+		if !ok || env == "" {
+			return "", fmt.Errorf("%s must be a non-empty string", EnvironmentContextKey)
+		}
+		if project, name, found := strings.Cut(env, "/"); found {
+			selectedProject, env = project, name
+		}
+		selectedEnv = env
+	}
+	if selectedProject == projectName && selectedEnv == envName {
+		return "", nil
+	}
+	environment := selectedProject + "/" + selectedEnv
+	if !p.overrides.allowed[environment] {
+		return "", fmt.Errorf("environment %s may not be selected in the evaluation context", environment)
+	}
+	return environment, nil
+}
+
+// readOverride reads a property value from the given "project/env" environment and returns the revision
+// of its session
+func (p *PulumiESCProvider) readOverride(ctx context.Context, environment, propertyPath string) (*esc.Value, interface{}, int32, error) {
+	if err := p.throttle.check(); err != nil {
+		return nil, nil, 0, err
+	}
+	if p.rateLimiter != nil && !p.rateLimiter.allow() {
+		return nil, nil, 0, fmt.Errorf("%w while reading %s", errRateLimited, propertyPath)
+	}
+	session, err := p.overrideSession(ctx, environment)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	escValue, rawValue, err := p.readOverrideOnce(ctx, environment, session, propertyPath)
+	if err != nil && isSessionExpiredErr(err) {
+		p.overrides.mu.Lock()
+		delete(p.overrides.sessions, environment)
+		p.overrides.mu.Unlock()
+		if session, err = p.overrideSession(ctx, environment); err != nil {
+			return nil, nil, 0, err
+		}
+		escValue, rawValue, err = p.readOverrideOnce(ctx, environment, session, propertyPath)
+	}
+	return escValue, rawValue, session.revision, err
+}
+
+// readOverrideOnce reads a property value using the given session of the "project/env" environment
+func (p *PulumiESCProvider) readOverrideOnce(ctx context.Context, environment string, session overrideSession, propertyPath string) (*esc.Value, interface{}, error) {
+	ctx, cancel := p.requestContext(ctx)
+	defer cancel()
+	projectName, envName, _ := strings.Cut(environment, "/")
+	return p.escClient.ReadEnvironmentProperty(ctx, p.orgName, projectName, envName, session.id, p.propertyPath(propertyPath))
+}
+
+// overrideSession returns the open session of the "project/env" environment, opening it if needed. Concurrent
+// callers wait for the same session to be opened, each until its own context is done.
+func (p *PulumiESCProvider) overrideSession(ctx context.Context, environment string) (overrideSession, error) {
+	p.overrides.mu.Lock()
+	session, ok := p.overrides.sessions[environment]
+	if ok && (p.pollInterval == 0 || time.Since(session.openedAt) < p.pollInterval) {
+		p.overrides.mu.Unlock()
+		return session, nil
+	}
+	opening, inflight := p.overrides.opening[environment]
+	if !inflight {
+		opening = &openingSession{done: make(chan struct{})}
+		p.overrides.opening[environment] = opening
+		go p.openOverrideSession(ctx, environment, opening)
+	}
+	p.overrides.mu.Unlock()
+
+	select {
+	case <-opening.done:
+		return opening.session, opening.err
+	case <-ctx.Done():
+		return overrideSession{}, ctx.Err()
+	}
+}
+
+// openOverrideSession opens a session of the "project/env" environment at its latest revision. The session is
+// opened with a context detached from the caller, as concurrent callers wait for it, so it is bounded by
+// coalescedReadTimeout and the lifetime of the provider instead.
+func (p *PulumiESCProvider) openOverrideSession(ctx context.Context, environment string, opening *openingSession) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), coalescedReadTimeout)
+	defer cancel()
+	reqCtx, cancelRequest := p.requestContext(ctx)
+	defer cancelRequest()
+	projectName, envName, _ := strings.Cut(environment, "/")
+	env, revision, err := p.openEnvironment(reqCtx, projectName, envName)
+
+	p.overrides.mu.Lock()
+	defer p.overrides.mu.Unlock()
+	delete(p.overrides.opening, environment)
+	previous, hasPrevious := p.overrides.sessions[environment]
+	switch {
+	case err == nil:
+		opening.session = overrideSession{id: env.Id, revision: revision, openedAt: time.Now()}
+		p.overrides.sessions[environment] = opening.session
+	case hasPrevious:
+		// The previous session is still valid, and is reopened again after another interval
+		previous.openedAt = time.Now()
+		p.overrides.sessions[environment] = previous
+		opening.session = previous
+	default:
+		opening.err = fmt.Errorf("failed to open pulumi esc environment %s: %w", environment, err)
+	}
+	close(opening.done)
+}
+
+// closeOverrideSessions abandons the sessions opened for the environments selected in the evaluation context
+func (p *PulumiESCProvider) closeOverrideSessions() {
+	if p.overrides == nil {
+		return
+	}
+	p.overrides.mu.Lock()
+	defer p.overrides.mu.Unlock()
+	p.overrides.sessions = map[string]overrideSession{}
+}
@@ -0,0 +1,131 @@
+package pulumi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithEnvironmentOverrides(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	p := newTestProvider(t, server, WithEnvironmentOverrides(PROJECT_NAME+"/tenant-a", "tenants/tenant-b"), WithTombstones())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	for env, evalCtx := range map[string]openfeature.FlattenedContext{
+		ENV_NAME:   nil,
+		"tenant-a": {EnvironmentContextKey: "tenant-a"},
+		"tenant-b": {EnvironmentContextKey: "tenants/tenant-b"},
+	} {
+		details := p.BooleanEvaluation(ctx, "DEBUG_MODE", false, evalCtx)
+		require.NoError(t, details.Error())
+		assert.True(t, details.Value)
+		assert.Equal(t, env, details.FlagMetadata["trace"].(esc.Trace).Def.Environment)
+	}
+	details := p.BooleanEvaluation(ctx, "DEBUG_MODE", false, openfeature.FlattenedContext{
+		ProjectContextKey: "tenants", EnvironmentContextKey: "tenant-b",
+	})
+	assert.NoError(t, details.Error())
+
+	details = p.BooleanEvaluation(ctx, "DEBUG_MODE", false, openfeature.FlattenedContext{EnvironmentContextKey: "tenant-c"})
+	assert.Equal(t, openfeature.ErrorReason, details.Reason)
+	assert.ErrorContains(t, details.Error(), "INVALID_CONTEXT")
+	assert.ErrorContains(t, details.Error(), "environment "+PROJECT_NAME+"/tenant-c may not be selected")
+	details = p.BooleanEvaluation(ctx, "DEBUG_MODE", false, openfeature.FlattenedContext{EnvironmentContextKey: 1})
+	assert.ErrorContains(t, details.Error(), "pulumi.env must be a non-empty string")
+
+	details = p.BooleanEvaluation(ctx, "MISSING", false, openfeature.FlattenedContext{EnvironmentContextKey: "tenant-a"})
+	assert.ErrorContains(t, details.Error(), "FLAG_NOT_FOUND")
+	assert.Empty(t, p.Tombstones())
+}
+
+func TestWithEnvironmentOverrides_expiredSession(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	p := newTestProvider(t, server, WithEnvironmentOverrides("other-project/prod"))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	evalCtx := openfeature.FlattenedContext{EnvironmentContextKey: "other-project/prod"}
+
+	assert.NoError(t, p.BooleanEvaluation(ctx, "DEBUG_MODE", false, evalCtx).Error())
+	server.ExpireSessions()
+	details := p.BooleanEvaluation(ctx, "DEBUG_MODE", false, evalCtx)
+	assert.NoError(t, details.Error(), "the expired session must be reopened")
+	assert.True(t, details.Value)
+}
+
+func TestWithEnvironmentOverrides_noEnvironments(t *testing.T) {
+	p := newTestProvider(t, newFakeESCServer(t, nil), WithEnvironmentOverrides())
+	assert.ErrorContains(t, p.initialise(context.Background()), "at least one environment")
+}
+
+func TestWithEnvironmentOverrides_concurrentEvaluations(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	var opens atomic.Int32
+	slowOpens := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/tenant-a/open") {
+			opens.Add(1)
+			time.Sleep(50 * time.Millisecond)
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	p := newTestProvider(t, server, WithEnvironmentOverrides(PROJECT_NAME+"/tenant-a"), WithHTTPClient(slowOpens))
+	require.NoError(t, p.initialise(context.Background()))
+	evalCtx := openfeature.FlattenedContext{EnvironmentContextKey: "tenant-a"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, p.BooleanEvaluation(context.Background(), "DEBUG_MODE", false, evalCtx).Error())
+		}()
+	}
+	// An evaluation whose context is done does not wait for the session being opened
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	details := p.BooleanEvaluation(ctx, "DEBUG_MODE", false, evalCtx)
+	assert.ErrorContains(t, details.Error(), "deadline exceeded")
+	assert.Less(t, time.Since(started), 40*time.Millisecond)
+	// Evaluations of the environment of the provider are not blocked by the session being opened
+	assert.NoError(t, p.BooleanEvaluation(context.Background(), "DEBUG_MODE", false, nil).Error())
+	assert.Less(t, time.Since(started), 40*time.Millisecond)
+	wg.Wait()
+	assert.Equal(t, int32(1), opens.Load(), "the environment must be opened once")
+}
+
+func TestWithEnvironmentOverrides_polling(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	server.SetRevision(1)
+	p := newTestProvider(t, server, WithEnvironmentOverrides(PROJECT_NAME+"/tenant-a"), WithPolling(20*time.Millisecond))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	evalCtx := openfeature.FlattenedContext{EnvironmentContextKey: "tenant-a"}
+
+	details := p.BooleanEvaluation(ctx, "DEBUG_MODE", false, evalCtx)
+	require.NoError(t, details.Error())
+	revision, _ := details.FlagMetadata.GetInt("revision")
+	assert.Equal(t, int64(1), revision)
+	server.SetRevision(2)
+	assert.Eventually(t, func() bool {
+		details := p.BooleanEvaluation(ctx, "DEBUG_MODE", false, evalCtx)
+		revision, _ := details.FlagMetadata.GetInt("revision")
+		return details.Error() == nil && revision == 2
+	}, 5*time.Second, 10*time.Millisecond, "the session must be reopened at the latest revision")
+}
+
+func TestEnvironmentOverride_disabled(t *testing.T) {
+	p := newPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME)
+	environment, err := p.environmentOverride(openfeature.FlattenedContext{EnvironmentContextKey: "tenant-a"})
+	assert.NoError(t, err)
+	assert.Empty(t, environment, "the evaluation context must be ignored without WithEnvironmentOverrides")
+}
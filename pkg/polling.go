@@ -0,0 +1,117 @@
+package pulumi
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// WithPolling reopens the environment session at its latest revision every interval, so changes to the
+// environment are served without restarting the provider. When flags were added, removed or changed,
+// a PROVIDER_CONFIGURATION_CHANGED event listing their keys is emitted. Polls only read the latest
+// revision number while the environment is unchanged.
+func WithPolling(interval time.Duration) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if interval > 0 {
+			p.pollInterval = interval
+		}
+	}
+}
+
+// WithPollingJitter spreads the polls of replicas started together over the polling interval, so they
+// do not all hit the Pulumi ESC API at the same time. The first poll happens at a random time within the
+// first interval, and every following poll is delayed by the interval plus or minus a random fraction of
+// it of at most jitter, e.g. 0.1 for ±10%. The jitter is capped at 1.
+func WithPollingJitter(jitter float64) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if jitter > 0 {
+			p.pollJitter = min(jitter, 1)
+		}
+	}
+}
+
+// runPolling refreshes the environment every pollInterval, if polling is enabled, and whenever a refresh
+// is requested by the webhook, until the context is done. Changes are detected against the previous
+// snapshot, if any.
+func (p *PulumiESCProvider) runPolling(ctx context.Context, previous *environmentSnapshot) {
+	var timer *time.Timer
+	var ticks <-chan time.Time
+	if p.pollInterval > 0 {
+		timer = time.NewTimer(p.pollDelay(true))
+		defer timer.Stop()
+		ticks = timer.C
+	}
+	for {
+		select {
+		case <-ticks:
+			if snapshot, ok := p.poll(ctx, previous); ok {
+				previous = snapshot
+			}
+			timer.Reset(p.pollDelay(false))
+		case <-p.refreshes:
+			if snapshot, ok := p.poll(ctx, previous); ok {
+				previous = snapshot
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pollDelay returns the delay before the next poll, randomised according to the polling jitter
+func (p *PulumiESCProvider) pollDelay(first bool) time.Duration {
+	if p.pollJitter == 0 {
+		return p.pollInterval
+	}
+	if first {
+		return max(time.Duration(rand.Float64()*float64(p.pollInterval)), time.Millisecond)
+	}
+	factor := 1 + p.pollJitter*(2*rand.Float64()-1)
+	return max(time.Duration(factor*float64(p.pollInterval)), time.Millisecond)
+}
+
+// poll opens a new environment session and reads its values. It emits a PROVIDER_CONFIGURATION_CHANGED
+// event and a ChangeEvent if flags changed since the previous snapshot, and reports whether the environment was read.
+// If the latest revision of the environment is the revision of the previous snapshot, the environment
+// is unchanged and the previous snapshot is returned without reading it again.
+func (p *PulumiESCProvider) poll(ctx context.Context, previous *environmentSnapshot) (*environmentSnapshot, bool) {
+	projectName, envName, _ := p.environment()
+	reqCtx, cancel := p.requestContext(ctx)
+	revision := p.latestRevision(reqCtx, projectName, envName)
+	cancel()
+	if ctx.Err() != nil {
+		return nil, false
+	}
+	if revision > 0 && previous != nil && previous.Revision == revision && previous.Environment == projectName+"/"+envName &&
+		p.Revision() == revision && p.sessionID() != "" {
+		return previous, true
+	}
+	if err := p.openSessionAt(projectName, envName, revision); err != nil || ctx.Err() != nil {
+		return nil, false
+	}
+	snapshot, err := p.readEnvironment(ctx)
+	if ctx.Err() != nil {
+		return nil, false
+	}
+	p.updateStateAfterRead(err)
+	if err != nil {
+		return nil, false
+	}
+	if previous == nil {
+		return snapshot, true
+	}
+	if previous.Environment != snapshot.Environment {
+		// The environment was switched, which emitted its own event
+		return snapshot, true
+	}
+	if changes := p.flagChanges(previous, snapshot); len(changes) > 0 {
+		p.emitConfigChange("pulumi esc environment changed", changedKeys(changes))
+		p.emitChanges(previous, snapshot, changes)
+	}
+	return snapshot, true
+}
+
+// changedFlags returns the keys of the flags added, removed or changed between two sets of values, sorted
+func changedFlags(previous, current map[string]interface{}) []string {
+	return changedKeys(diffValues(previous, current))
+}
@@ -0,0 +1,104 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithPolling(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"DEBUG_MODE": false,
+		"configs":    map[string]interface{}{"MAX_RETRIES": 3, "THEME": "dark"},
+	})
+	p := newTestProvider(t, server, WithPolling(10*time.Millisecond))
+	ctx := context.Background()
+	assert.NoError(t, p.initialise(ctx))
+	assert.False(t, p.BooleanEvaluation(ctx, "DEBUG_MODE", true, nil).Value)
+
+	server.SetValue("DEBUG_MODE", true)
+	server.SetValue("configs.MAX_RETRIES", 5)
+	server.SetValue("configs.TIMEOUT", 30)
+
+	// The values may change between two polls, so the changes can be reported by several events
+	changed := map[string]bool{}
+	timeout := time.After(time.Second)
+	for len(changed) < 4 {
+		select {
+		case event := <-p.EventChannel():
+			assert.Equal(t, openfeature.ProviderConfigChange, event.EventType)
+			for _, key := range event.FlagChanges {
+				changed[key] = true
+			}
+		case <-timeout:
+			t.Fatalf("missing configuration change events, got changes of %v", changed)
+		}
+	}
+	assert.Equal(t, map[string]bool{"DEBUG_MODE": true, "configs": true, "configs.MAX_RETRIES": true, "configs.TIMEOUT": true}, changed)
+	assert.True(t, p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil).Value, "the new values must be served")
+}
+
+func TestWithPolling_unchangedRevision(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": false})
+	server.SetRevision(1)
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	assert.NoError(t, p.initialise(ctx))
+	previous, err := p.readEnvironment(ctx)
+	assert.NoError(t, err)
+
+	requests := server.Requests()
+	snapshot, ok := p.poll(ctx, previous)
+	assert.True(t, ok)
+	assert.Same(t, previous, snapshot, "an unchanged environment must not be read again")
+	assert.Equal(t, requests+1, server.Requests(), "only the latest revision must be read")
+
+	server.SetValue("DEBUG_MODE", true)
+	server.SetRevision(2)
+	snapshot, ok = p.poll(ctx, previous)
+	assert.True(t, ok)
+	assert.Equal(t, int32(2), snapshot.Revision)
+	assert.Equal(t, true, snapshot.Values["DEBUG_MODE"])
+	assert.Equal(t, int32(2), p.Revision())
+	event := <-p.EventChannel()
+	assert.Equal(t, []string{"DEBUG_MODE"}, event.FlagChanges)
+}
+
+func TestWithPollingJitter(t *testing.T) {
+	p := newPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME, WithPolling(time.Minute), WithPollingJitter(0.1))
+	firstDelays := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		delay := p.pollDelay(true)
+		assert.True(t, delay > 0 && delay <= time.Minute, "the first poll must happen within the interval, got %s", delay)
+		firstDelays[delay] = true
+
+		delay = p.pollDelay(false)
+		assert.True(t, delay >= 54*time.Second && delay <= 66*time.Second, "the poll must be delayed by the interval ±10%%, got %s", delay)
+	}
+	assert.Greater(t, len(firstDelays), 1, "the first polls must be spread over the interval")
+
+	p = newPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME, WithPolling(time.Minute), WithPollingJitter(5))
+	assert.Equal(t, float64(1), p.pollJitter)
+	assert.Equal(t, time.Minute, newPulumiESCProvider("test-org", PROJECT_NAME, ENV_NAME, WithPolling(time.Minute)).pollDelay(true),
+		"polls must not be randomised without jitter")
+}
+
+func TestChangedFlags(t *testing.T) {
+	previous := map[string]interface{}{
+		"DEBUG_MODE": false,
+		"REGIONS":    []interface{}{"eu"},
+		"configs":    map[string]interface{}{"MAX_RETRIES": 3.0, "THEME": "dark"},
+	}
+	assert.Empty(t, changedFlags(previous, copyValues(previous)))
+
+	current := map[string]interface{}{
+		"DEBUG_MODE": false,
+		"REGIONS":    []interface{}{"eu", "us"},
+		"configs":    map[string]interface{}{"MAX_RETRIES": 3.0},
+		"THEME":      "light",
+	}
+	assert.Equal(t, []string{"REGIONS", "THEME", "configs", "configs.THEME"}, changedFlags(previous, current))
+}
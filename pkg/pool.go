@@ -0,0 +1,58 @@
+package pulumi
+
+import (
+	"context"
+	"fmt"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+// ClientPool creates providers sharing a single Pulumi ESC client and access token, so providers of
+// different projects and environments reuse the same TCP connections, back off together when the API
+// throttles the token, and share the limits set using WithRateLimit and WithMaxConcurrentRequests
+type ClientPool struct {
+	escClient   ESCClient
+	escAuthCtx  context.Context
+	throttle    *apiThrottle
+	rateLimiter *tokenBucket
+}
+
+// NewClientPool returns a pool of providers authenticating with the given access token.
+// Only the options configuring the API client are applied to the pool: WithCustomBackendUrl,
+// WithHTTPClient, WithApplicationID, WithESCClient, WithRateLimit and WithMaxConcurrentRequests.
+func NewClientPool(accessKey string, opts ...ProviderOption) (*ClientPool, error) {
+	template := newPulumiESCProvider("", "", "", opts...)
+	if template.escClient == nil {
+		escClient, err := template.newAPIClient()
+		if err != nil {
+			return nil, err
+		}
+		template.escClient = escClient
+	}
+	return &ClientPool{
+		escClient:   template.escClient,
+		escAuthCtx:  esc.NewAuthContext(accessKey),
+		throttle:    template.throttle,
+		rateLimiter: template.rateLimiter,
+	}, nil
+}
+
+// NewProvider returns a provider of the environment using the client and access token of the pool.
+// The options configuring the API client are ignored in favour of the ones of the pool.
+func (c *ClientPool) NewProvider(orgName, projectName, envName string, opts ...ProviderOption) (*PulumiESCProvider, error) {
+	provider := newPulumiESCProvider(orgName, projectName, envName, opts...)
+	provider.escClient = c.escClient
+	provider.escAuthCtx = c.escAuthCtx
+	provider.throttle = c.throttle
+	if c.rateLimiter != nil {
+		provider.rateLimiter = c.rateLimiter
+		if provider.coalescer == nil {
+			provider.coalescer = newReadCoalescer()
+			provider.lastKnownValues = newValueCache()
+		}
+	}
+	if err := provider.initialise(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to initialise pulumi esc provider: %w", err)
+	}
+	return provider, nil
+}
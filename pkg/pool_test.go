@@ -0,0 +1,42 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientPool(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true, "THEME": "dark"})
+	pool, err := NewClientPool("token", WithHTTPClient(server.Client()), WithRateLimit(0.001, 1))
+	require.NoError(t, err)
+
+	checkout, err := pool.NewProvider("test-org", "checkout", "prod")
+	require.NoError(t, err)
+	defer checkout.Shutdown()
+	search, err := pool.NewProvider("test-org", "search", "prod", WithHTTPClient(nil))
+	require.NoError(t, err)
+	defer search.Shutdown()
+
+	assert.Same(t, checkout.escClient, search.escClient)
+	assert.Same(t, checkout.throttle, search.throttle)
+
+	details := checkout.BooleanEvaluation(context.Background(), "DEBUG_MODE", false, nil)
+	assert.NoError(t, details.Error())
+	assert.True(t, details.Value)
+	stringDetails := search.StringEvaluation(context.Background(), "THEME", "light", nil)
+	assert.Equal(t, "light", stringDetails.Value)
+	assert.ErrorContains(t, stringDetails.Error(), "rate limit exceeded", "the rate limit must be shared by the providers")
+}
+
+func TestClientPool_invalidToken(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	server.SetAccessToken("other-token")
+	pool, err := NewClientPool("token", WithHTTPClient(server.Client()))
+	require.NoError(t, err)
+
+	_, err = pool.NewProvider("test-org", "checkout", "prod")
+	assert.ErrorContains(t, err, "failed to initialise pulumi esc provider")
+}
@@ -0,0 +1,130 @@
+package pulumi
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// QuoteKey returns the property path of a top-level key of the environment, which is the key itself unless
+// it contains dots or brackets. Keys with dots are interpreted as paths to nested values, so such keys are
+// quoted using the property path syntax of Pulumi ESC, e.g. `["service.v2.enabled"]` for the flag named
+// service.v2.enabled. Quoted keys can be nested as well, e.g. `service["v2.enabled"]`.
+func QuoteKey(key string) string {
+	if key != "" && !strings.ContainsAny(key, `.[]"`) {
+		return key
+	}
+	return "[" + strconv.Quote(key) + "]"
+}
+
+// joinPropertyPath returns the property path of a key of the object at the given property path, or of a
+// top-level key if the path is empty
+func joinPropertyPath(path, key string) string {
+	quoted := QuoteKey(key)
+	switch {
+	case path == "":
+		return quoted
+	case quoted[0] == '[':
+		return path + quoted
+	}
+	return path + "." + quoted
+}
+
+// parsePropertyPath returns the keys of a property path, whose keys are separated by dots or quoted in
+// brackets, e.g. `service["v2.enabled"]`. Keys are strings, or the int indexes of arrays, e.g.
+// ALLOWED_ORIGINS[2].
+func parsePropertyPath(path string) ([]interface{}, error) {
+	var keys []interface{}
+	for rest := path; ; {
+		var key interface{}
+		if strings.HasPrefix(rest, "[") {
+			var err error
+			if key, rest, err = cutBracketedKey(rest); err != nil {
+				return nil, fmt.Errorf("invalid property path %q: %w", path, err)
+			}
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid property path %q: empty key", path)
+			}
+			key, rest = rest[:end], rest[end:]
+		}
+		keys = append(keys, key)
+		switch {
+		case rest == "":
+			return keys, nil
+		case rest[0] == '.':
+			rest = rest[1:]
+		case rest[0] != '[':
+			return nil, fmt.Errorf("invalid property path %q: unexpected %q after a closing bracket", path, rest[0])
+		}
+	}
+}
+
+// cutBracketedKey returns the key quoted in brackets, or the array index in brackets, at the start of a
+// property path, and the rest of the path
+func cutBracketedKey(path string) (interface{}, string, error) {
+	if len(path) < 2 || path[1] != '"' {
+		end := strings.IndexByte(path, ']')
+		if end < 0 {
+			return nil, "", errors.New("missing closing bracket")
+		}
+		index, err := strconv.Atoi(path[1:end])
+		if err != nil || index < 0 || path[1] < '0' || path[1] > '9' {
+			return nil, "", fmt.Errorf("invalid array index %q, keys in brackets must be quoted", path[1:end])
+		}
+		return index, path[end+1:], nil
+	}
+	for i := 2; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case '"':
+			key, err := strconv.Unquote(path[1 : i+1])
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid quoted key %s", path[1:i+1])
+			}
+			if i+1 >= len(path) || path[i+1] != ']' {
+				return nil, "", errors.New("missing closing bracket")
+			}
+			return key, path[i+2:], nil
+		}
+	}
+	return nil, "", errors.New("unterminated quoted key")
+}
+
+// formatPropertyPath returns the property path of the keys, quoting the keys which contain dots or brackets
+func formatPropertyPath(keys []interface{}) string {
+	var path string
+	for _, key := range keys {
+		switch key := key.(type) {
+		case string:
+			path = joinPropertyPath(path, key)
+		case int:
+			path += "[" + strconv.Itoa(key) + "]"
+		}
+	}
+	return path
+}
+
+// objectKeys returns the keys of a property path, or false if it indexes an array
+func objectKeys(keys []interface{}) ([]string, bool) {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		name, ok := key.(string)
+		if !ok {
+			return nil, false
+		}
+		names[i] = name
+	}
+	return names, true
+}
+
+// withinPath reports whether a property path is the given parent path or the path of one of its nested values
+func withinPath(path, parent string) bool {
+	return path == parent || strings.HasPrefix(path, parent+".") || strings.HasPrefix(path, parent+"[")
+}
@@ -0,0 +1,137 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteKey(t *testing.T) {
+	assert.Equal(t, "DEBUG_MODE", QuoteKey("DEBUG_MODE"))
+	assert.Equal(t, `["service.v2.enabled"]`, QuoteKey("service.v2.enabled"))
+	assert.Equal(t, `["say \"hi\""]`, QuoteKey(`say "hi"`))
+	assert.Equal(t, `[""]`, QuoteKey(""))
+}
+
+func TestParsePropertyPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []interface{}
+		wantErr bool
+	}{
+		{path: "DEBUG_MODE", want: []interface{}{"DEBUG_MODE"}},
+		{path: "checkout.enabled", want: []interface{}{"checkout", "enabled"}},
+		{path: `["service.v2.enabled"]`, want: []interface{}{"service.v2.enabled"}},
+		{path: `service["v2.enabled"].rollout`, want: []interface{}{"service", "v2.enabled", "rollout"}},
+		{path: `["a"]["b.c"]`, want: []interface{}{"a", "b.c"}},
+		{path: `["say \"hi\""]`, want: []interface{}{`say "hi"`}},
+		{path: "ALLOWED_ORIGINS[2]", want: []interface{}{"ALLOWED_ORIGINS", 2}},
+		{path: `routes[0]["path.prefix"]`, want: []interface{}{"routes", 0, "path.prefix"}},
+		{path: "matrix[1][0].name", want: []interface{}{"matrix", 1, 0, "name"}},
+		{path: `ports["2"]`, want: []interface{}{"ports", "2"}},
+		{path: "checkout..enabled", wantErr: true},
+		{path: "checkout.", wantErr: true},
+		{path: `["service.v2`, wantErr: true},
+		{path: `["service"`, wantErr: true},
+		{path: `["service"]x`, wantErr: true},
+		{path: `[service]`, wantErr: true},
+		{path: "ALLOWED_ORIGINS[-1]", wantErr: true},
+		{path: "ALLOWED_ORIGINS[+1]", wantErr: true},
+		{path: "ALLOWED_ORIGINS[2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parsePropertyPath(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want, mustParse(t, formatPropertyPath(got)), "formatted paths must round-trip")
+		})
+	}
+}
+
+func mustParse(t *testing.T, path string) []interface{} {
+	keys, err := parsePropertyPath(path)
+	require.NoError(t, err)
+	return keys
+}
+
+func TestQuotedKeys(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		`["service.v2.enabled"]`: true,
+		"service":                map[string]interface{}{"v2": map[string]interface{}{"enabled": false}},
+		`flags["checkout.v3"]`:   "on",
+	})
+	server.SetSecret(`["api.key"]`, "sk-12345")
+	ctx := context.Background()
+	p := newTestProvider(t, server)
+	require.NoError(t, p.initialise(ctx))
+
+	assert.True(t, p.BooleanEvaluation(ctx, QuoteKey("service.v2.enabled"), false, nil).Value)
+	assert.False(t, p.BooleanEvaluation(ctx, "service.v2.enabled", true, nil).Value, "dotted keys must still be nested paths")
+	assert.Equal(t, "on", p.StringEvaluation(ctx, `flags["checkout.v3"]`, "", nil).Value)
+	assert.Equal(t, openfeature.FlagNotFoundCode, p.StringEvaluation(ctx, `["service.v2`, "", nil).ResolutionDetail().ErrorCode)
+
+	flags, err := p.ListFlags(ctx)
+	require.NoError(t, err)
+	var keys []string
+	for _, flag := range flags {
+		keys = append(keys, flag.Key)
+		if flag.Key == `["api.key"]` {
+			assert.True(t, flag.Secret)
+		}
+	}
+	assert.Contains(t, keys, `["service.v2.enabled"]`)
+	assert.Contains(t, keys, `flags["checkout.v3"]`)
+	assert.Contains(t, keys, `["api.key"]`)
+
+	t.Run("snapshot", func(t *testing.T) {
+		p := newTestProvider(t, server, WithCache(time.Minute, CacheFillPolicy_SnapshotOnly))
+		require.NoError(t, p.initialise(ctx))
+		assert.True(t, p.BooleanEvaluation(ctx, `["service.v2.enabled"]`, false, nil).Value)
+		secret, _ := p.StringEvaluation(ctx, `["api.key"]`, "", nil).FlagMetadata.GetBool("secret")
+		assert.True(t, secret)
+	})
+
+	t.Run("root path", func(t *testing.T) {
+		p := newTestProvider(t, server, WithRootPath("flags"))
+		require.NoError(t, p.initialise(ctx))
+		assert.Equal(t, "on", p.StringEvaluation(ctx, QuoteKey("checkout.v3"), "", nil).Value)
+	})
+}
+
+func TestArrayIndexKeys(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"ALLOWED_ORIGINS": []interface{}{"https://a.example", "https://b.example", "https://c.example"},
+		"routes":          []interface{}{map[string]interface{}{"path": "/checkout", "enabled": true}},
+		"configs":         map[string]interface{}{"RETRIES": []interface{}{1, 2, 5}},
+	})
+	ctx := context.Background()
+
+	for name, opts := range map[string][]ProviderOption{
+		"api":      nil,
+		"snapshot": {WithCache(time.Minute, CacheFillPolicy_SnapshotOnly)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := newTestProvider(t, server, opts...)
+			require.NoError(t, p.initialise(ctx))
+
+			got := p.StringEvaluation(ctx, "ALLOWED_ORIGINS[2]", "", nil)
+			assert.NoError(t, got.Error())
+			assert.Equal(t, "https://c.example", got.Value)
+			assert.True(t, p.BooleanEvaluation(ctx, "routes[0].enabled", false, nil).Value)
+			assert.Equal(t, int64(5), p.IntEvaluation(ctx, "configs.RETRIES[2]", 0, nil).Value)
+
+			for _, key := range []string{"ALLOWED_ORIGINS[3]", "ALLOWED_ORIGINS[-1]", "routes[1].enabled", "configs[0]"} {
+				assert.Equal(t, openfeature.FlagNotFoundCode, p.StringEvaluation(ctx, key, "", nil).ResolutionDetail().ErrorCode, key)
+			}
+		})
+	}
+}
@@ -5,74 +5,168 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"net/url"
-	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/open-feature/go-sdk/openfeature"
 	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"github.com/santhosh-tekuri/jsonschema/v6"
 )
 
 type FlagType string
 
 const (
-	FlagType_Bool    FlagType = "bool"
-	FlagType_String  FlagType = "string"
-	FlagType_Integer FlagType = "int64"
-	FlagType_Float   FlagType = "float64"
-	FlagType_Object  FlagType = "object"
+	FlagType_Bool        FlagType = "bool"
+	FlagType_String      FlagType = "string"
+	FlagType_Integer     FlagType = "int64"
+	FlagType_Float       FlagType = "float64"
+	FlagType_Object      FlagType = "object"
+	FlagType_StringSlice FlagType = "[]string"
+	FlagType_StringMap   FlagType = "map[string]string"
 )
 
 const (
 	ProviderName = "PulumiESCProvider"
 )
 
+const latencyMetadataKey = "latencyMs"
+
 // PulumiESCProvider implements the FeatureProvider interface and provides functions for evaluating flags
 type PulumiESCProvider struct {
+	stateMu             sync.RWMutex
 	state               openfeature.State
+	lastSessionAttempt  time.Time
+	sessionFailures     int
+	recovering          bool
 	orgName             string
 	projectName         string
 	envName             string
-	escClient           *esc.EscClient
+	escClient           ESCClient
 	escAuthCtx          context.Context
 	escOpenEnvSessionId string
+	revision            int32
 	customBackendUrl    *url.URL
+	httpClient          *http.Client
+	applicationID       string
+	tombstones          *tombstoneRegistry
+	errorCounts         errorCounter
+	evaluationLog       *evaluationLog
+	rateLimiter         *tokenBucket
+	coalescer           *readCoalescer
+	lastKnownValues     *valueCache
+	exposures           *exposureAggregator
+	vars                *providerVars
+	metricsSink         MetricsSink
+	instruments         *otelInstruments
+	flagUsage           *flagUsageTracker
+	slowThreshold       time.Duration
+	evaluationTimeout   time.Duration
+	debugErrors         bool
+	hedgeDelay          time.Duration
+	cacheTTL            time.Duration
+	cachePolicy         CacheFillPolicy
+	refreshingPaths     sync.Map
+	boolCoercion        bool
+	numericStrings      bool
+	enrichers           []ContextEnricher
+	keySanitizers       []KeySanitizer
+	caseInsensitiveKeys bool
+	keyCanonicalization bool
+	keyIndex            atomic.Pointer[keyIndex]
+	keyIndexMu          sync.Mutex
+	throttle            *apiThrottle
+	requestSlots        chan struct{}
+	lifecycle           atomic.Pointer[providerLifecycle]
+	background          sync.WaitGroup
+	events              chan openfeature.Event
+	changes             chan ChangeEvent
+	healthCheckInterval time.Duration
+	pollInterval        time.Duration
+	pollJitter          float64
+	webhookSecret       []byte
+	refreshes           chan struct{}
+	longPolling         bool
+	flagDefinitions     bool
+	bucketStore         BucketStore
+	bucketingHash       BucketingHash
+	deprecationLogger   *slog.Logger
+	warnedDeprecations  sync.Map
+	configChanges       *configChangeDebouncer
+	configChangeMaxWait time.Duration
+	trackingSink        TrackingSink
+	hooks               []openfeature.Hook
+	requiredFlags       map[string]FlagType
+	auditSink           AuditSink
+	denySecrets         bool
+	allowedSecrets      map[string]bool
+	snapshotKey         []byte
+	omitTrace           bool
+	snapshotPath        string
+	snapshots           *snapshotStore
+	snapshot            atomic.Pointer[environmentSnapshot]
+	timeLayouts         []string
+	enums               map[string][]string
+	schemaDocuments     map[string][]byte
+	schemas             map[string]*jsonschema.Schema
+	overrides           *environmentOverrides
+	rootPath            string
+	definitionMu        sync.Mutex
 }
 
 type ProviderOption func(p *PulumiESCProvider)
 
 func NewPulumiESCProvider(orgName, projectName, envName, accessKey string, opts ...ProviderOption) (*PulumiESCProvider, error) {
-	provider := &PulumiESCProvider{
-		state:       openfeature.NotReadyState,
-		orgName:     orgName,
-		projectName: projectName,
-		envName:     envName,
+	provider := newPulumiESCProvider(orgName, projectName, envName, opts...)
+
+	if provider.escClient == nil {
+		escClient, err := provider.newAPIClient()
+		if err != nil {
+			return nil, err
+		}
+		provider.escClient = escClient
 	}
-	for _, opt := range opts {
-		opt(provider)
+	provider.escAuthCtx = esc.NewAuthContext(accessKey)
+	if err := provider.initialise(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to initialise pulumi esc provider: %w", err)
 	}
+	return provider, nil
+}
 
+// newAPIClient returns a Pulumi ESC client configured with the backend URL, HTTP client and transports of the provider
+func (p *PulumiESCProvider) newAPIClient() (ESCClient, error) {
 	conf := esc.NewConfiguration()
-	if provider.customBackendUrl != nil {
-		customConf, err := esc.NewCustomBackendConfiguration(*provider.customBackendUrl)
+	if p.customBackendUrl != nil {
+		customConf, err := esc.NewCustomBackendConfiguration(*p.customBackendUrl)
 		if err != nil {
 			return nil, fmt.Errorf("failed to initialise pulumi esc provider with custom backend url: %w", err)
 		}
 		conf = customConf
 	}
+	conf.HTTPClient = p.newAPIHTTPClient(p.httpClient)
+	return newESCClient(conf), nil
+}
 
-	escClient := esc.NewClient(conf)
-	escAuthCtx := esc.NewAuthContext(accessKey)
-	env, err := escClient.OpenEnvironment(escAuthCtx, orgName, projectName, envName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialise pulumi esc provider: %w", err)
+// newPulumiESCProvider returns a provider in NOT_READY state with the given options applied
+func newPulumiESCProvider(orgName, projectName, envName string, opts ...ProviderOption) *PulumiESCProvider {
+	provider := &PulumiESCProvider{
+		state:       openfeature.NotReadyState,
+		orgName:     orgName,
+		projectName: projectName,
+		envName:     envName,
+		throttle:    &apiThrottle{},
+		events:      make(chan openfeature.Event, eventBufferSize),
+		changes:     make(chan ChangeEvent, eventBufferSize),
 	}
-
-	provider.escClient = escClient
-	provider.escAuthCtx = escAuthCtx
-	provider.escOpenEnvSessionId = env.Id
-	provider.state = openfeature.ReadyState
-	return provider, nil
+	for _, opt := range opts {
+		opt(provider)
+	}
+	return provider
 }
 
 // WithCustomBackendUrl sets the specified URL as the Pulumi ESC backend API endpoint
@@ -82,6 +176,20 @@ func WithCustomBackendUrl(url url.URL) ProviderOption {
 	}
 }
 
+// WithHTTPClient sets the HTTP client used for requests to the Pulumi ESC API
+func WithHTTPClient(client *http.Client) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.httpClient = client
+	}
+}
+
+// WithoutTraceMetadata omits the Pulumi ESC trace from the FlagMetadata, to keep resolutions lightweight
+func WithoutTraceMetadata() ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.omitTrace = true
+	}
+}
+
 // Metadata returns the metadata of the provider
 func (p *PulumiESCProvider) Metadata() openfeature.Metadata {
 	return openfeature.Metadata{
@@ -89,106 +197,354 @@ func (p *PulumiESCProvider) Metadata() openfeature.Metadata {
 	}
 }
 
-// Hooks returns a collection of openfeature.Hook defined by this provider
-func (p *PulumiESCProvider) Hooks() []openfeature.Hook {
-	return []openfeature.Hook{}
+// Tombstones returns the flags which were resolved successfully before but are no longer present
+// in the Pulumi ESC environment. It is empty without WithTombstones.
+func (p *PulumiESCProvider) Tombstones() []Tombstone {
+	return p.tombstones.list()
 }
 
-// Status expose the status of the provider
-func (p *PulumiESCProvider) Status() openfeature.State {
-	return p.state
+// Hooks returns a collection of openfeature.Hook defined by this provider
+func (p *PulumiESCProvider) Hooks() []openfeature.Hook {
+	return append([]openfeature.Hook{}, p.hooks...)
 }
 
 // BooleanEvaluation returns a boolean flag
 func (p *PulumiESCProvider) BooleanEvaluation(ctx context.Context, flag string, defaultValue bool, evalCtx openfeature.FlattenedContext) openfeature.BoolResolutionDetail {
-	value, resolutionDetails := p.resolveValue(ctx, flag, FlagType_Bool)
+	value, resolutionDetails := p.resolveValue(ctx, flag, FlagType_Bool, evalCtx)
 	boolResolutionDetails := openfeature.BoolResolutionDetail{ProviderResolutionDetail: resolutionDetails}
 	if value != nil {
 		boolResolutionDetails.Value = value.(bool)
 	} else {
 		boolResolutionDetails.Value = defaultValue
 	}
+	p.observeEvaluation(ctx, flag, evalCtx, resolutionDetails)
 	return boolResolutionDetails
 }
 
 // StringEvaluation returns a string flag
 func (p *PulumiESCProvider) StringEvaluation(ctx context.Context, flag string, defaultValue string, evalCtx openfeature.FlattenedContext) openfeature.StringResolutionDetail {
-	value, resolutionDetails := p.resolveValue(ctx, flag, FlagType_String)
+	value, resolutionDetails := p.resolveValue(ctx, flag, FlagType_String, evalCtx)
 	stringResolutionDetails := openfeature.StringResolutionDetail{ProviderResolutionDetail: resolutionDetails}
 	if value != nil {
 		stringResolutionDetails.Value = value.(string)
 	} else {
 		stringResolutionDetails.Value = defaultValue
 	}
+	p.observeEvaluation(ctx, flag, evalCtx, resolutionDetails)
 	return stringResolutionDetails
 }
 
 // FloatEvaluation returns a float flag
 func (p *PulumiESCProvider) FloatEvaluation(ctx context.Context, flag string, defaultValue float64, evalCtx openfeature.FlattenedContext) openfeature.FloatResolutionDetail {
-	value, resolutionDetails := p.resolveValue(ctx, flag, FlagType_Float)
+	value, resolutionDetails := p.resolveValue(ctx, flag, FlagType_Float, evalCtx)
 	floatResolutionDetails := openfeature.FloatResolutionDetail{ProviderResolutionDetail: resolutionDetails}
 	if value != nil {
-		floatResolutionDetails.Value = value.(float64)
+		floatResolutionDetails.Value, _ = floatValue(value)
 	} else {
 		floatResolutionDetails.Value = defaultValue
 	}
+	p.observeEvaluation(ctx, flag, evalCtx, resolutionDetails)
 	return floatResolutionDetails
 
 }
 
 // IntEvaluation returns an int flag
 func (p *PulumiESCProvider) IntEvaluation(ctx context.Context, flag string, defaultValue int64, evalCtx openfeature.FlattenedContext) openfeature.IntResolutionDetail {
-	value, resolutionDetails := p.resolveValue(ctx, flag, FlagType_Integer)
+	value, resolutionDetails := p.resolveValue(ctx, flag, FlagType_Integer, evalCtx)
 	intResolutionDetails := openfeature.IntResolutionDetail{ProviderResolutionDetail: resolutionDetails}
 	if value != nil {
-		intResolutionDetails.Value = int64(value.(float64))
+		intResolutionDetails.Value, _ = intValue(value)
 	} else {
 		intResolutionDetails.Value = defaultValue
 	}
+	p.observeEvaluation(ctx, flag, evalCtx, resolutionDetails)
 	return intResolutionDetails
 
 }
 
-// ObjectEvaluation returns an object flag
+// ObjectEvaluation returns an object flag. Only the whole environment can be evaluated, using EnvironmentFlagKey.
 func (p *PulumiESCProvider) ObjectEvaluation(ctx context.Context, flag string, defaultValue interface{}, evalCtx openfeature.FlattenedContext) openfeature.InterfaceResolutionDetail {
+	if isEnvironmentFlag(flag) {
+		value, resolutionDetails := p.resolveEnvironment(ctx, evalCtx)
+		objectResolutionDetails := openfeature.InterfaceResolutionDetail{ProviderResolutionDetail: resolutionDetails}
+		if value != nil {
+			objectResolutionDetails.Value = value
+		} else {
+			objectResolutionDetails.Value = defaultValue
+		}
+		p.observeEvaluation(ctx, flag, evalCtx, resolutionDetails)
+		return objectResolutionDetails
+	}
+	resolutionDetails := openfeature.ProviderResolutionDetail{
+		Reason:          openfeature.ErrorReason,
+		ResolutionError: openfeature.NewGeneralResolutionError("ObjectEvaluation not implemented"),
+	}
+	p.observeEvaluation(ctx, flag, evalCtx, resolutionDetails)
 	return openfeature.InterfaceResolutionDetail{
-		ProviderResolutionDetail: openfeature.ProviderResolutionDetail{
-			Reason:          openfeature.ErrorReason,
-			ResolutionError: openfeature.NewGeneralResolutionError("ObjectEvaluation not implemented"),
-		},
+		ProviderResolutionDetail: resolutionDetails,
+	}
+}
+
+// observeEvaluation records the outcome of an evaluation for the enabled observability features
+func (p *PulumiESCProvider) observeEvaluation(ctx context.Context, flag string, evalCtx openfeature.FlattenedContext, resolutionDetails openfeature.ProviderResolutionDetail) {
+	p.recordEvaluation(flag, evalCtx, resolutionDetails)
+	p.auditSecretAccess(flag, evalCtx, resolutionDetails)
+	p.errorCounts.record(resolutionDetails)
+	if p.flagUsage != nil {
+		p.flagUsage.record(flag, resolutionDetails)
+	}
+	if p.exposures != nil {
+		p.exposures.record(flag, resolutionDetails)
+	}
+	if p.vars != nil {
+		p.vars.recordEvaluation(flag, resolutionDetails)
+	}
+	if p.metricsSink != nil {
+		p.sendMetrics(flag, resolutionDetails)
+	}
+	if p.instruments != nil {
+		p.instruments.recordEvaluation(ctx, flag, resolutionDetails)
 	}
 }
 
 // resolveValue retrieves a property value from the ESC service and validates its type.
 // It returns the resolved value and resolution details, or an error if the property
 // is not found, has a type mismatch, or any other error occurs.
-func (p *PulumiESCProvider) resolveValue(ctx context.Context, propertyPath string, flagType FlagType) (interface{}, openfeature.ProviderResolutionDetail) {
-	escValue, rawValue, err := p.escClient.ReadEnvironmentProperty(p.escAuthCtx, p.orgName, p.projectName, p.envName, p.escOpenEnvSessionId, propertyPath)
+func (p *PulumiESCProvider) resolveValue(ctx context.Context, propertyPath string, flagType FlagType, evalCtx openfeature.FlattenedContext) (_ interface{}, resolution openfeature.ProviderResolutionDetail) {
+	var cacheStatus CacheStatus
+	if p.slowThreshold > 0 {
+		defer p.warnSlowEvaluation(ctx, propertyPath, time.Now(), &cacheStatus)
+	}
+	propertyPath, invalidKey := p.sanitizeKey(propertyPath)
+	if invalidKey != nil {
+		return nil, *invalidKey
+	}
+	evalCtx = p.enrichContext(ctx, evalCtx)
+	if !p.tryRecover() {
+		state := p.Status()
+		return nil, openfeature.ProviderResolutionDetail{
+			Reason:          openfeature.ErrorReason,
+			ResolutionError: openfeature.NewProviderNotReadyResolutionError(fmt.Sprintf("provider is in %s state", state)),
+		}
+	}
+	environment, err := p.environmentOverride(evalCtx)
 	if err != nil {
-		var genErr *esc.GenericOpenAPIError
-		if errors.As(err, &genErr) && isKeyNotFoundErr(genErr) {
+		return nil, openfeature.ProviderResolutionDetail{
+			Reason:          openfeature.ErrorReason,
+			ResolutionError: openfeature.NewInvalidContextResolutionError(err.Error()),
+		}
+	}
+	if (p.caseInsensitiveKeys || p.keyCanonicalization) && environment == "" {
+		if canonicalKey := p.canonicalKey(ctx, propertyPath); canonicalKey != propertyPath {
+			propertyPath = canonicalKey
+			defer func() { resolution = withCanonicalKey(resolution, canonicalKey) }()
+		}
+	}
+	start := time.Now()
+	var escValue *esc.Value
+	var rawValue interface{}
+	var revision int32
+	readCtx, cancel := p.readContext(ctx)
+	defer cancel()
+	if environment != "" {
+		escValue, rawValue, revision, err = p.readOverride(readCtx, environment, propertyPath)
+		cacheStatus = CacheStatus_Bypass
+	} else {
+		escValue, rawValue, cacheStatus, err = p.readProperty(readCtx, propertyPath)
+		revision = p.revisionFor(cacheStatus)
+	}
+	latency := time.Since(start)
+	if err != nil && isEvaluationTimeout(readCtx) {
+		return nil, p.timeoutResolution(propertyPath)
+	}
+	if err != nil {
+		if environment != "" && isKeyNotFound(err) {
+			// Tombstones are only kept for the environment of the provider
 			return nil, openfeature.ProviderResolutionDetail{
+				Reason:          openfeature.ErrorReason,
+				ResolutionError: openfeature.NewFlagNotFoundResolutionError(fmt.Sprintf("%s not found in %s", propertyPath, environment)),
+			}
+		}
+		if isKeyNotFound(err) {
+			resolutionDetails := openfeature.ProviderResolutionDetail{
 				Reason:          openfeature.ErrorReason,
 				ResolutionError: openfeature.NewFlagNotFoundResolutionError(fmt.Sprintf("%s not found", propertyPath)),
 			}
+			if tombstone, ok := p.tombstones.markMissing(propertyPath); ok {
+				resolutionDetails.ResolutionError = openfeature.NewFlagNotFoundResolutionError(
+					fmt.Sprintf("%s not found, deleted at %s", propertyPath, tombstone.DeletedAt.Format(time.RFC3339)))
+				resolutionDetails.FlagMetadata = tombstone.flagMetadata()
+			}
+			return nil, resolutionDetails
 		}
-		return nil, openfeature.ProviderResolutionDetail{
-			Reason:          openfeature.ErrorReason,
-			ResolutionError: openfeature.NewGeneralResolutionError(err.Error()),
+		return nil, p.apiErrorResolution(err)
+	}
+	if environment == "" {
+		p.tombstones.markSeen(propertyPath, rawValue, escValue.GetSecret())
+	}
+	if escValue.GetSecret() && p.secretDenied(propertyPath) {
+		return nil, secretDeniedResolution(propertyPath)
+	}
+	var variant, deprecation string
+	var definitionReason openfeature.Reason
+	var definitionMetadata map[string]interface{}
+	if p.flagDefinitions {
+		definition, err := parseFlagDefinition(rawValue)
+		if err != nil {
+			return nil, openfeature.ProviderResolutionDetail{
+				Reason:          openfeature.ErrorReason,
+				ResolutionError: openfeature.NewParseErrorResolutionError(fmt.Sprintf("invalid definition of %s: %s", propertyPath, err)),
+			}
+		}
+		if definition != nil {
+			deprecation, definitionMetadata = definition.deprecated, definition.metadata
+			p.warnDeprecated(ctx, propertyPath, deprecation)
+			var resolutionDetails *openfeature.ProviderResolutionDetail
+			if rawValue, variant, definitionReason, resolutionDetails = p.resolveDefinition(ctx, propertyPath, definition, flagType, evalCtx); resolutionDetails != nil {
+				return rawValue, withDefinitionMetadata(withDeprecation(*resolutionDetails, deprecation), definitionMetadata)
+			}
 		}
 	}
+	rawValue = p.coerceValue(rawValue, flagType)
 	if !validateType(rawValue, flagType) {
 		return nil, openfeature.ProviderResolutionDetail{
 			Reason:          openfeature.ErrorReason,
-			ResolutionError: openfeature.NewTypeMismatchResolutionError(fmt.Sprintf("%s is of type %s, not of type %s", propertyPath, reflect.TypeOf(rawValue), flagType))}
+			ResolutionError: openfeature.NewTypeMismatchResolutionError(typeMismatchMessage(propertyPath, escValue, rawValue, flagType))}
+	}
+	if !p.enumAllowed(propertyPath, rawValue) {
+		return nil, p.enumResolution(propertyPath)
+	}
+	if err := p.validateSchema(propertyPath, rawValue); err != nil {
+		return nil, openfeature.ProviderResolutionDetail{
+			Reason:          openfeature.ErrorReason,
+			ResolutionError: openfeature.NewParseErrorResolutionError(err.Error()),
+		}
+	}
+	reason := openfeature.StaticReason
+	if cacheStatus == CacheStatus_Hit || cacheStatus == CacheStatus_Stale {
+		reason = openfeature.CachedReason
+	}
+	if definitionReason != "" {
+		reason = definitionReason
+	}
+	metadata := openfeature.FlagMetadata{
+		"secret":         escValue.GetSecret(),
+		cacheMetadataKey: cacheStatus.metadataValue(),
+		// latencyMs is the wall-clock time of the read from the Pulumi ESC API or from memory
+		latencyMetadataKey: float64(latency.Microseconds()) / 1000,
+	}
+	if !p.omitTrace {
+		metadata["trace"] = valueTrace(escValue)
 	}
-	return rawValue, openfeature.ProviderResolutionDetail{
-		Reason: openfeature.StaticReason,
-		FlagMetadata: openfeature.FlagMetadata{
-			"secret": escValue.GetSecret(),
-			"trace":  escValue.GetTrace(),
-		},
+	if revision > 0 {
+		metadata[revisionMetadataKey] = int64(revision)
 	}
+	if deprecation != "" {
+		metadata[deprecatedMetadataKey] = deprecation
+	}
+	return rawValue, withDefinitionMetadata(openfeature.ProviderResolutionDetail{
+		Reason:       reason,
+		Variant:      variant,
+		FlagMetadata: metadata,
+	}, definitionMetadata)
+}
+
+// readProperty reads a property value from the ESC service. The returned CacheStatus reports whether
+// the value was served from memory instead of the ESC service.
+func (p *PulumiESCProvider) readProperty(ctx context.Context, propertyPath string) (*esc.Value, interface{}, CacheStatus, error) {
+	if snapshot, ok := evaluationSnapshot(ctx); ok {
+		cacheStatus := p.missStatus()
+		if p.snapshotOnly() {
+			cacheStatus = CacheStatus_Hit
+		}
+		escValue, rawValue, ok := snapshot.lookup(propertyPath)
+		if !ok {
+			return nil, nil, cacheStatus, errKeyNotFound
+		}
+		return escValue, rawValue, cacheStatus, nil
+	}
+	if p.snapshotOnly() {
+		return p.readSnapshotProperty(propertyPath)
+	}
+	if p.cacheTTL > 0 {
+		if escValue, rawValue, ok := p.cachedProperty(propertyPath); ok {
+			return escValue, rawValue, CacheStatus_Hit, nil
+		}
+	}
+	if err := p.throttle.check(); err != nil {
+		if escValue, rawValue, cacheStatus, ok := p.fallbackValue(propertyPath); ok {
+			return escValue, rawValue, cacheStatus, nil
+		}
+		return nil, nil, p.missStatus(), err
+	}
+	escValue, rawValue, cacheStatus, err := p.readPropertyOnce(ctx, propertyPath)
+	if err != nil && isSessionExpiredErr(err) {
+		if err := p.openSession(); err != nil {
+			if escValue, rawValue, ok := p.snapshotValue(propertyPath); ok {
+				return escValue, rawValue, CacheStatus_Stale, nil
+			}
+			return nil, nil, cacheStatus, err
+		}
+		escValue, rawValue, cacheStatus, err = p.readPropertyOnce(ctx, propertyPath)
+	}
+	if !isCallerCancellation(ctx, err) {
+		p.updateStateAfterRead(err)
+	}
+	if err == nil && p.cacheTTL > 0 && cacheStatus == CacheStatus_Miss {
+		p.lastKnownValues.set(propertyPath, escValue, rawValue)
+	}
+	if err != nil && isEvaluationTimeout(ctx) && p.lastKnownValues != nil {
+		// The expired cached value is the last known value of the flag
+		if cached, ok := p.lastKnownValues.get(propertyPath); ok {
+			return cached.escValue, cached.rawValue, CacheStatus_Stale, nil
+		}
+	}
+	if err != nil && !isKeyNotFound(err) {
+		if escValue, rawValue, ok := p.snapshotValue(propertyPath); ok {
+			return escValue, rawValue, CacheStatus_Stale, nil
+		}
+	}
+	return escValue, rawValue, cacheStatus, err
+}
+
+// fallbackValue returns the last known value of the given property path, read by this provider
+// or from the last known good snapshot
+func (p *PulumiESCProvider) fallbackValue(propertyPath string) (*esc.Value, interface{}, CacheStatus, bool) {
+	if p.lastKnownValues != nil {
+		if cached, ok := p.lastKnownValues.get(propertyPath); ok {
+			return cached.escValue, cached.rawValue, CacheStatus_Hit, true
+		}
+	}
+	escValue, rawValue, ok := p.snapshotValue(propertyPath)
+	return escValue, rawValue, CacheStatus_Stale, ok
+}
+
+// readPropertyOnce reads a property value using the current environment session
+func (p *PulumiESCProvider) readPropertyOnce(ctx context.Context, propertyPath string) (*esc.Value, interface{}, CacheStatus, error) {
+	if p.sessionID() == "" {
+		return nil, nil, p.missStatus(), errNoSession
+	}
+	if p.rateLimiter != nil {
+		return p.readRateLimited(ctx, propertyPath)
+	}
+	escValue, rawValue, err := p.readFromESC(ctx, propertyPath)
+	return escValue, rawValue, p.missStatus(), err
+}
+
+// readFromESC reads a property value from the ESC service, hedging the request if enabled
+func (p *PulumiESCProvider) readFromESC(ctx context.Context, propertyPath string) (*esc.Value, interface{}, error) {
+	if p.hedgeDelay > 0 {
+		return p.readHedged(ctx, propertyPath)
+	}
+	return p.readFromESCOnce(ctx, propertyPath)
+}
+
+// readFromESCOnce reads a property value from the ESC service with a single request
+func (p *PulumiESCProvider) readFromESCOnce(ctx context.Context, propertyPath string) (*esc.Value, interface{}, error) {
+	ctx, cancel := p.requestContext(ctx)
+	defer cancel()
+	projectName, envName, sessionID := p.environment()
+	return p.escClient.ReadEnvironmentProperty(ctx, p.orgName, projectName, envName, sessionID, p.propertyPath(propertyPath))
 }
 
 // validateType checks if the given raw value can be parsed into the given FlagType
@@ -201,17 +557,37 @@ func validateType(rawValue interface{}, flagType FlagType) bool {
 		_, ok := rawValue.(string)
 		return ok
 	case FlagType_Integer:
-		// Integer values from Pulumi ESC are returned as float64 by the Pulumi go sdk
-		_, ok := rawValue.(float64)
+		// Integer values from Pulumi ESC are returned as float64, or json.Number when float64 can not
+		// represent them exactly
+		_, ok := intValue(rawValue)
 		return ok
 	case FlagType_Float:
-		_, ok := rawValue.(float64)
+		_, ok := floatValue(rawValue)
+		return ok
+	case FlagType_StringSlice:
+		_, ok := stringSliceValue(rawValue)
+		return ok
+	case FlagType_StringMap:
+		_, ok := stringMapValue(rawValue)
 		return ok
 	case FlagType_Object:
+		switch rawValue.(type) {
+		case map[string]interface{}, []interface{}:
+			return true
+		}
 	}
 	return false
 }
 
+// isKeyNotFound determines whether the given error indicates a 'key not found' condition
+func isKeyNotFound(err error) bool {
+	if errors.Is(err, errKeyNotFound) {
+		return true
+	}
+	var genErr *esc.GenericOpenAPIError
+	return errors.As(err, &genErr) && isKeyNotFoundErr(genErr)
+}
+
 // isKeyNotFoundErr determines whether the given GenericOpenAPIError indicates a 'key not found' condition.
 func isKeyNotFoundErr(openApiErr *esc.GenericOpenAPIError) bool {
 	type OpenAPIErrResp struct {
@@ -225,3 +601,21 @@ func isKeyNotFoundErr(openApiErr *esc.GenericOpenAPIError) bool {
 	}
 	return errResp.Code == 400 && strings.Contains(errResp.Message, "not found")
 }
+
+// apiStatusCode returns the HTTP status code of the given GenericOpenAPIError.
+// It returns 0 if the status code can not be determined.
+func apiStatusCode(openApiErr *esc.GenericOpenAPIError) int {
+	var errResp struct {
+		Code int `json:"code"`
+	}
+	if err := json.Unmarshal(openApiErr.Body(), &errResp); err == nil && errResp.Code != 0 {
+		return errResp.Code
+	}
+	// The error message starts with the HTTP status, e.g. "429 Too Many Requests"
+	status, _, _ := strings.Cut(openApiErr.Error(), " ")
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return 0
+	}
+	return code
+}
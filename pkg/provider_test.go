@@ -9,7 +9,9 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/pulumitest"
 	"github.com/open-feature/go-sdk/openfeature"
 	esc "github.com/pulumi/esc-sdk/sdk/go"
 	"github.com/stretchr/testify/assert"
@@ -38,8 +40,19 @@ const (
 	DEFAULT_FLOAT_FLAG_VALUE  = float64(0.1)
 )
 
+const (
+	// SYNTHETIC_FIXTURE_PATH is the fixture replayed when no fixture was recorded. It was generated against the
+	// pulumitest fake server rather than recorded from the Pulumi ESC API, so its session ids, traces and
+	// revisions are those of the fake server.
+	SYNTHETIC_FIXTURE_PATH = "testdata/synthetic_provider_fixture.json"
+	// RECORDED_FIXTURE_PATH is the fixture recorded from the live Pulumi ESC API with PULUMI_ESC_RECORD
+	RECORDED_FIXTURE_PATH = "testdata/recorded_provider_fixture.json"
+	FIXTURE_ORG_NAME      = "of-pulumi-esc-provider-test-org"
+)
+
 var (
 	provider *PulumiESCProvider
+	recorder *pulumitest.Recorder
 )
 
 func TestPulumiESCProvider_Metadata(t *testing.T) {
@@ -172,6 +185,22 @@ func TestPulumiESCProvider_BooleanEvaluation(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "bool-flag-provider-not-ready",
+			p:    &PulumiESCProvider{state: openfeature.NotReadyState},
+			args: args{
+				ctx:          context.TODO(),
+				flag:         BOOL_FLAG_KEY,
+				defaultValue: DEFAULT_BOOL_FLAG_VALUE,
+			},
+			want: openfeature.BoolResolutionDetail{
+				Value: DEFAULT_BOOL_FLAG_VALUE,
+				ProviderResolutionDetail: openfeature.ProviderResolutionDetail{
+					Reason:          openfeature.ErrorReason,
+					ResolutionError: openfeature.NewProviderNotReadyResolutionError(""),
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -244,6 +273,22 @@ func TestPulumiESCProvider_StringEvaluation(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "string-flag-provider-not-ready",
+			p:    &PulumiESCProvider{state: openfeature.NotReadyState},
+			args: args{
+				ctx:          context.TODO(),
+				flag:         STRING_FLAG_KEY,
+				defaultValue: DEFAULT_STRING_FLAG_VALUE,
+			},
+			want: openfeature.StringResolutionDetail{
+				Value: DEFAULT_STRING_FLAG_VALUE,
+				ProviderResolutionDetail: openfeature.ProviderResolutionDetail{
+					Reason:          openfeature.ErrorReason,
+					ResolutionError: openfeature.NewProviderNotReadyResolutionError(""),
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -316,6 +361,22 @@ func TestPulumiESCProvider_FloatEvaluation(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "float-flag-provider-not-ready",
+			p:    &PulumiESCProvider{state: openfeature.NotReadyState},
+			args: args{
+				ctx:          context.TODO(),
+				flag:         FLOAT_FLAG_KEY,
+				defaultValue: DEFAULT_FLOAT_FLAG_VALUE,
+			},
+			want: openfeature.FloatResolutionDetail{
+				Value: DEFAULT_FLOAT_FLAG_VALUE,
+				ProviderResolutionDetail: openfeature.ProviderResolutionDetail{
+					Reason:          openfeature.ErrorReason,
+					ResolutionError: openfeature.NewProviderNotReadyResolutionError(""),
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -388,6 +449,22 @@ func TestPulumiESCProvider_IntEvaluation(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "int-flag-provider-not-ready",
+			p:    &PulumiESCProvider{state: openfeature.NotReadyState},
+			args: args{
+				ctx:          context.TODO(),
+				flag:         INT_FLAG_KEY,
+				defaultValue: DEFAULT_INT_FLAG_VALUE,
+			},
+			want: openfeature.IntResolutionDetail{
+				Value: DEFAULT_INT_FLAG_VALUE,
+				ProviderResolutionDetail: openfeature.ProviderResolutionDetail{
+					Reason:          openfeature.ErrorReason,
+					ResolutionError: openfeature.NewProviderNotReadyResolutionError(""),
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -438,6 +515,74 @@ func TestPulumiESCProvider_ObjectEvaluation(t *testing.T) {
 	}
 }
 
+func TestPulumiESCProvider_LatencyMetadata(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{BOOL_FLAG_KEY: BOOL_FLAG_VALUE})
+	server.SetLatency(20 * time.Millisecond)
+	p := newTestProvider(t, server)
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+
+	got := p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, false, nil)
+	latency, err := got.FlagMetadata.GetFloat(latencyMetadataKey)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, latency, float64(20))
+}
+
+func TestWithoutTraceMetadata(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{BOOL_FLAG_KEY: BOOL_FLAG_VALUE})
+
+	p := newTestProvider(t, server)
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+	assert.Contains(t, p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, false, nil).FlagMetadata, "trace")
+
+	p = newTestProvider(t, server, WithoutTraceMetadata())
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+	got := p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, false, nil)
+	assert.Equal(t, BOOL_FLAG_VALUE, got.Value)
+	assert.NotContains(t, got.FlagMetadata, "trace")
+	assert.Contains(t, got.FlagMetadata, "secret")
+}
+
+// benchmarkEvaluation measures the evaluations of the flag by a static provider with the given options
+func benchmarkEvaluation(b *testing.B, flag string, opts ...ProviderOption) {
+	p, err := NewStaticProvider(map[string]interface{}{
+		BOOL_FLAG_KEY:   BOOL_FLAG_VALUE,
+		STRING_FLAG_KEY: STRING_FLAG_VALUE,
+		INT_FLAG_KEY:    INT_FLAG_VALUE,
+	}, opts...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer p.Shutdown()
+	ctx := context.Background()
+	evalCtx := openfeature.FlattenedContext{openfeature.TargetingKey: "user-1"}
+	// The first evaluation fills the cache of cached benchmarks
+	p.BooleanEvaluation(ctx, flag, false, evalCtx)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.BooleanEvaluation(ctx, flag, false, evalCtx)
+	}
+}
+
+func BenchmarkBooleanEvaluation(b *testing.B) {
+	b.Run("uncached", func(b *testing.B) {
+		benchmarkEvaluation(b, BOOL_FLAG_KEY)
+	})
+	b.Run("cached", func(b *testing.B) {
+		// The rate limit is exhausted by the first evaluation, so the next ones are served from the cache
+		benchmarkEvaluation(b, BOOL_FLAG_KEY, WithRateLimit(1e-9, 1))
+	})
+	b.Run("without trace", func(b *testing.B) {
+		benchmarkEvaluation(b, BOOL_FLAG_KEY, WithoutTraceMetadata())
+	})
+	b.Run("not found", func(b *testing.B) {
+		benchmarkEvaluation(b, NON_EXISTING_FLAG_KEY)
+	})
+	b.Run("type mismatch", func(b *testing.B) {
+		benchmarkEvaluation(b, STRING_FLAG_KEY)
+	})
+}
+
 func TestMain(t *testing.M) {
 	if err := setupTestProvider(); err != nil {
 		fmt.Printf("Error during esc test provider setup: %v", err)
@@ -450,17 +595,33 @@ func TestMain(t *testing.M) {
 	os.Exit(code)
 }
 
-// setupTestProvider requires the PULUMI_ORG and PULUMI_ACCESS_KEY environment variables to be set.
-// If either of these variables is missing, the provider setup will fail.
+// setupTestProvider requires the PULUMI_ORG and PULUMI_ACCESS_TOKEN environment variables to be set.
+// If PULUMI_ACCESS_TOKEN is missing, the test provider replays the Pulumi ESC API interactions of the recorded
+// fixture, or of the synthetic fixture if none was recorded. Setting PULUMI_ESC_RECORD records the fixture
+// against the live Pulumi ESC API.
 func setupTestProvider() error {
+	accessKey := os.Getenv("PULUMI_ACCESS_TOKEN")
+	if accessKey == "" {
+		fixturePath := RECORDED_FIXTURE_PATH
+		if _, err := os.Stat(fixturePath); err != nil {
+			fixturePath = SYNTHETIC_FIXTURE_PATH
+		}
+		var err error
+		recorder, err = pulumitest.NewRecorder(fixturePath, pulumitest.RecorderMode_Replay, nil)
+		if err != nil {
+			return fmt.Errorf("PULUMI_ACCESS_TOKEN env variable can not be empty without recorded fixture: %w", err)
+		}
+		escProvider, err := NewPulumiESCProvider(FIXTURE_ORG_NAME, PROJECT_NAME, ENV_NAME, "", WithHTTPClient(recorder.Client()))
+		if err != nil {
+			return err
+		}
+		provider = escProvider
+		return nil
+	}
 	orgName := os.Getenv("PULUMI_ORG")
 	if orgName == "" {
 		return errors.New("PULUMI_ORG env variable can not be empty")
 	}
-	accessKey := os.Getenv("PULUMI_ACCESS_KEY")
-	if accessKey == "" {
-		return errors.New("PULUMI_ACCESS_KEY env variable can not be empty")
-	}
 
 	// Create or update test environment in Pulumi
 	if err := createOrUpdatePulumiTestEnv(
@@ -476,13 +637,21 @@ func setupTestProvider() error {
 	if err != nil {
 		return err
 	}
+	opts := []ProviderOption{WithCustomBackendUrl(*customUrl)}
+	if os.Getenv("PULUMI_ESC_RECORD") != "" {
+		if recorder, err = pulumitest.NewRecorder(RECORDED_FIXTURE_PATH, pulumitest.RecorderMode_Record, nil); err != nil {
+			return err
+		}
+		recorder.Replace(orgName, FIXTURE_ORG_NAME)
+		opts = append(opts, WithHTTPClient(recorder.Client()))
+	}
 	// Set test provider
 	escProvider, err := NewPulumiESCProvider(
 		orgName,
 		PROJECT_NAME,
 		ENV_NAME,
 		accessKey,
-		WithCustomBackendUrl(*customUrl),
+		opts...,
 	)
 	if err != nil {
 		return err
@@ -492,8 +661,13 @@ func setupTestProvider() error {
 }
 
 func cleanup() error {
+	if recorder != nil {
+		if err := recorder.Save(); err != nil {
+			return fmt.Errorf("failed to save recorded fixture: %w", err)
+		}
+	}
 	orgName := os.Getenv("PULUMI_ORG")
-	if orgName != "" {
+	if orgName != "" && os.Getenv("PULUMI_ACCESS_TOKEN") != "" {
 		if err := removePulumiTestEnv(orgName, PROJECT_NAME, ENV_NAME); err != nil {
 			return fmt.Errorf("failed to delete pulumi test environment: %w\n", err)
 		}
@@ -550,5 +724,5 @@ func environemntAlreadyExists(err error) bool {
 }
 
 func removePulumiTestEnv(orgName, projectName, envName string) error {
-	return provider.escClient.DeleteEnvironment(provider.escAuthCtx, orgName, projectName, envName)
+	return esc.NewClient(esc.NewConfiguration()).DeleteEnvironment(provider.escAuthCtx, orgName, projectName, envName)
 }
@@ -0,0 +1,183 @@
+package pulumitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// RecorderMode is the mode of a Recorder
+type RecorderMode string
+
+const (
+	// RecorderMode_Record forwards requests to the Pulumi ESC API and records the responses
+	RecorderMode_Record RecorderMode = "record"
+	// RecorderMode_Replay answers requests with the recorded responses, without network access
+	RecorderMode_Replay RecorderMode = "replay"
+)
+
+// Interaction is a recorded request to the Pulumi ESC API and its response
+type Interaction struct {
+	Method       string `json:"method"`
+	URL          string `json:"url"`
+	RequestBody  string `json:"requestBody,omitempty"`
+	StatusCode   int    `json:"statusCode"`
+	ContentType  string `json:"contentType,omitempty"`
+	ResponseBody string `json:"responseBody"`
+}
+
+// Recorder is a http.RoundTripper recording Pulumi ESC API interactions to a fixture file and
+// replaying them, so tests written against a real environment can run without an access token.
+// Request headers, including the access token, are never recorded, but response bodies are, so
+// record environments which do not contain real secrets.
+type Recorder struct {
+	path         string
+	mode         RecorderMode
+	base         http.RoundTripper
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     map[int]bool
+	replacements []string
+}
+
+// NewRecorder returns a Recorder using the fixture file at the given path.
+// In RecorderMode_Record, requests are sent using the base http.RoundTripper, or http.DefaultTransport if nil,
+// and the interactions are written to the fixture by Save. In RecorderMode_Replay, the fixture is loaded.
+func NewRecorder(path string, mode RecorderMode, base http.RoundTripper) (*Recorder, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	r := &Recorder{path: path, mode: mode, base: base, replayed: map[int]bool{}}
+	switch mode {
+	case RecorderMode_Record:
+	case RecorderMode_Replay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("failed to decode fixture: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported recorder mode %q", mode)
+	}
+	return r, nil
+}
+
+// Replace replaces the given value, e.g. the name of the organisation, by the placeholder in the recorded
+// interactions. Tests replaying the fixture then use the placeholder in place of the value.
+func (r *Recorder) Replace(value, placeholder string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.replacements = append(r.replacements, value, placeholder)
+}
+
+// Client returns a http.Client using the Recorder as transport
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Save writes the recorded interactions to the fixture file. It does nothing in RecorderMode_Replay.
+func (r *Recorder) Save() error {
+	if r.mode != RecorderMode_Record {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		if requestBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	if r.mode == RecorderMode_Replay {
+		return r.replay(req, string(requestBody))
+	}
+	return r.record(req, requestBody)
+}
+
+func (r *Recorder) record(req *http.Request, requestBody []byte) (*http.Response, error) {
+	// RoundTrippers must not modify the original request
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(requestBody))
+	// Responses are recorded uncompressed, so fixtures stay readable and are replayed as plain JSON
+	req.Header.Del("Accept-Encoding")
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	replacer := strings.NewReplacer(r.replacements...)
+	r.interactions = append(r.interactions, Interaction{
+		Method:       req.Method,
+		URL:          replacer.Replace(req.URL.RequestURI()),
+		RequestBody:  replacer.Replace(string(requestBody)),
+		StatusCode:   resp.StatusCode,
+		ContentType:  resp.Header.Get("Content-Type"),
+		ResponseBody: replacer.Replace(string(responseBody)),
+	})
+	return resp, nil
+}
+
+// replay answers the request with the first matching interaction not replayed yet, or with the last
+// matching interaction once all of them were replayed
+func (r *Recorder) replay(req *http.Request, requestBody string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	match := -1
+	for i, interaction := range r.interactions {
+		if interaction.Method != req.Method || interaction.URL != req.URL.RequestURI() || interaction.RequestBody != requestBody {
+			continue
+		}
+		match = i
+		if !r.replayed[i] {
+			break
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("no recorded interaction for %s %s", req.Method, req.URL.RequestURI())
+	}
+	r.replayed[match] = true
+	interaction := r.interactions[match]
+	header := http.Header{}
+	if interaction.ContentType != "" {
+		header.Set("Content-Type", interaction.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+		StatusCode:    interaction.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(interaction.ResponseBody)),
+		ContentLength: int64(len(interaction.ResponseBody)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,45 @@
+package pulumitest_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/pulumitest"
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	ctx := context.Background()
+
+	server := pulumitest.NewServer(map[string]interface{}{"bool-flag": true})
+	recorder, err := pulumitest.NewRecorder(path, pulumitest.RecorderMode_Record, server.Client().Transport)
+	assert.NoError(t, err)
+	recorder.Replace("real-org", "test-org")
+	p, err := pulumi.NewPulumiESCProvider("real-org", "test-project", "test-env", "token", pulumi.WithHTTPClient(recorder.Client()))
+	assert.NoError(t, err)
+	assert.True(t, p.BooleanEvaluation(ctx, "bool-flag", false, nil).Value)
+	assert.Equal(t, openfeature.FlagNotFoundCode, p.BooleanEvaluation(ctx, "missing", false, nil).ResolutionDetail().ErrorCode)
+	p.Shutdown()
+	server.Close()
+	assert.NoError(t, recorder.Save())
+
+	recorder, err = pulumitest.NewRecorder(path, pulumitest.RecorderMode_Replay, nil)
+	assert.NoError(t, err)
+	p, err = pulumi.NewPulumiESCProvider("test-org", "test-project", "test-env", "", pulumi.WithHTTPClient(recorder.Client()))
+	assert.NoError(t, err)
+	defer p.Shutdown()
+	for i := 0; i < 2; i++ {
+		assert.True(t, p.BooleanEvaluation(ctx, "bool-flag", false, nil).Value, "interactions must be replayable")
+	}
+	assert.Equal(t, openfeature.FlagNotFoundCode, p.BooleanEvaluation(ctx, "missing", false, nil).ResolutionDetail().ErrorCode)
+	assert.Equal(t, openfeature.GeneralCode, p.BooleanEvaluation(ctx, "unrecorded", false, nil).ResolutionDetail().ErrorCode)
+}
+
+func TestNewRecorder_missingFixture(t *testing.T) {
+	_, err := pulumitest.NewRecorder(filepath.Join(t.TempDir(), "missing.json"), pulumitest.RecorderMode_Replay, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,545 @@
+// Package pulumitest provides a fake Pulumi ESC API for testing code evaluating flags through the
+// Pulumi ESC OpenFeature provider, without a Pulumi organisation or access token.
+package pulumitest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"gopkg.in/yaml.v3"
+)
+
+// Server is a fake Pulumi ESC API serving the values of an environment.
+// It implements the endpoints used by the provider: opening an environment, optionally at a revision,
+// listing the revisions, reading a single property or all properties of an open environment, and reading,
+// checking and updating the YAML definition of the environment. Secrets are defined using fn::secret, and
+// every update creates a new revision. Definitions are served with an ETag, and updates with an If-Match
+// header fail with 412 Precondition Failed if the definition changed.
+type Server struct {
+	// URL is the base URL of the server, of the form http://ipaddr:port with no trailing slash
+	URL string
+
+	server      *httptest.Server
+	mu          sync.Mutex
+	properties  map[string]*property
+	sessions    map[string]bool
+	nextSession int
+	revision    int32
+	accessToken string
+	unavailable bool
+	latency     time.Duration
+	requests    int
+}
+
+// property is a value of the environment
+type property struct {
+	// value is either a plain value, a map[string]*property or a []*property, unless raw is set
+	value  interface{}
+	secret bool
+	trace  *esc.Trace
+	raw    bool
+}
+
+// NewServer starts and returns a new Server serving the given values.
+// Keys may be dotted paths to values nested in objects, whose keys containing dots are quoted in brackets as
+// in Pulumi ESC property paths, e.g. `["service.v2.enabled"]`. The caller should call Close when finished.
+func NewServer(values map[string]interface{}) *Server {
+	s := &Server{
+		properties: map[string]*property{},
+		sessions:   map[string]bool{},
+	}
+	for key, value := range values {
+		s.SetValue(key, value)
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a http.Client sending all requests to the server, whatever their host.
+// Use it with the WithHTTPClient provider option to point the provider at the server.
+func (s *Server) Client() *http.Client {
+	return &http.Client{Transport: &redirectTransport{target: s.server.URL, base: s.server.Client().Transport}}
+}
+
+// SetValue sets the value of a key, which may be a dotted path to a value nested in an object
+func (s *Server) SetValue(key string, value interface{}) {
+	s.set(key, newProperty(value, false))
+}
+
+// SetSecret sets the secret value of a key, which may be a dotted path to a value nested in an object
+func (s *Server) SetSecret(key string, value interface{}) {
+	s.set(key, newProperty(value, true))
+}
+
+// SetESCValue sets the value of a key exactly as returned by the Pulumi ESC API.
+// A default trace definition is added when the value has none.
+func (s *Server) SetESCValue(key string, value esc.Value) {
+	secret := value.Secret != nil && *value.Secret
+	trace := value.Trace
+	s.set(key, &property{value: value.Value, secret: secret, trace: &trace, raw: true})
+}
+
+// DeleteValue deletes the value of a key, which may be a dotted path to a value nested in an object
+func (s *Server) DeleteValue(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	parent, name := s.parent(key, false)
+	if parent != nil {
+		delete(parent, name)
+	}
+}
+
+// SetRevision sets the latest revision of the environment. 0 means the environment has no revisions.
+func (s *Server) SetRevision(revision int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revision = revision
+}
+
+// SetAccessToken makes the server reject requests not authenticated with the given access token.
+// An empty access token accepts all requests, which is the default.
+func (s *Server) SetAccessToken(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accessToken = accessToken
+}
+
+// SetUnavailable makes the server respond to all requests with 503 Service Unavailable
+func (s *Server) SetUnavailable(unavailable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unavailable = unavailable
+}
+
+// SetLatency delays the responses to property reads by the given duration
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+}
+
+// ExpireSessions expires all open environment sessions, so the next reads fail with 404 Not Found
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = map[string]bool{}
+}
+
+// Requests returns the number of requests received by the server
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// newProperty converts a plain value to a property, converting nested objects and arrays
+func newProperty(value interface{}, secret bool) *property {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties := make(map[string]*property, len(value))
+		for key, nested := range value {
+			properties[key] = newProperty(nested, secret)
+		}
+		return &property{value: properties}
+	case []interface{}:
+		properties := make([]*property, len(value))
+		for i, nested := range value {
+			properties[i] = newProperty(nested, secret)
+		}
+		return &property{value: properties}
+	default:
+		return &property{value: value, secret: secret}
+	}
+}
+
+func (s *Server) set(key string, value *property) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	parent, name := s.parent(key, true)
+	parent[name] = value
+}
+
+// parent returns the object containing the given dotted path and the name of the path in this object.
+// Missing objects are created if create is set, otherwise nil is returned.
+func (s *Server) parent(key string, create bool) (map[string]*property, string) {
+	var segments []string
+	for _, segment := range splitPath(key) {
+		segments = append(segments, fmt.Sprint(segment))
+	}
+	properties := s.properties
+	for _, segment := range segments[:len(segments)-1] {
+		nested, ok := properties[segment]
+		if ok && !nested.raw {
+			if object, ok := nested.value.(map[string]*property); ok {
+				properties = object
+				continue
+			}
+		}
+		if !create {
+			return nil, ""
+		}
+		object := map[string]*property{}
+		properties[segment] = &property{value: object}
+		properties = object
+	}
+	return properties, segments[len(segments)-1]
+}
+
+// splitPath returns the keys of a dotted path, whose keys may be quoted in brackets, e.g. `service["v2.enabled"]`,
+// and which may index arrays, e.g. ALLOWED_ORIGINS[2]. Keys are strings, and array indexes ints.
+func splitPath(path string) []interface{} {
+	var keys []interface{}
+	for rest := path; rest != ""; {
+		var key interface{}
+		switch {
+		case strings.HasPrefix(rest, `["`):
+			end := 2
+			for end < len(rest) && rest[end] != '"' {
+				if rest[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			quoted, err := strconv.Unquote(rest[1:min(end+1, len(rest))])
+			if err != nil {
+				// Invalid paths are not found, as by the Pulumi ESC API
+				return []interface{}{path}
+			}
+			key, rest = quoted, strings.TrimPrefix(rest[end+1:], "]")
+		case strings.HasPrefix(rest, "["):
+			end := strings.IndexByte(rest, ']')
+			index, err := strconv.Atoi(rest[1:max(end, 1)])
+			if end < 0 || err != nil {
+				return []interface{}{path}
+			}
+			key, rest = index, rest[end+1:]
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key, rest = rest[:end], rest[end:]
+		}
+		keys = append(keys, key)
+		rest = strings.TrimPrefix(rest, ".")
+	}
+	if len(keys) == 0 {
+		return []interface{}{path}
+	}
+	return keys
+}
+
+// lookup returns the property at the given dotted path
+func (s *Server) lookup(key string) (*property, bool) {
+	value := &property{value: s.properties}
+	for _, key := range splitPath(key) {
+		if value.raw {
+			return nil, false
+		}
+		switch nested := value.value.(type) {
+		case map[string]*property:
+			name, ok := key.(string)
+			if value, ok = nested[name]; !ok {
+				return nil, false
+			}
+		case []*property:
+			index, ok := key.(int)
+			if !ok || index < 0 || index >= len(nested) {
+				return nil, false
+			}
+			value = nested[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// encode returns the Pulumi ESC API representation of a property
+func (p *property) encode(envName string) map[string]interface{} {
+	trace := esc.Trace{}
+	if p.trace != nil {
+		trace = *p.trace
+	}
+	if trace.Def == nil {
+		// The Pulumi ESC API always returns the definition of a value, and the Go SDK relies on it
+		trace.Def = &esc.Range{Environment: envName}
+	}
+	var value interface{}
+	switch nested := p.value.(type) {
+	case map[string]*property:
+		value = encodeProperties(nested, envName)
+	case []*property:
+		values := make([]interface{}, len(nested))
+		for i, property := range nested {
+			values[i] = property.encode(envName)
+		}
+		value = values
+	default:
+		value = p.value
+	}
+	return map[string]interface{}{"value": value, "secret": p.secret, "trace": trace}
+}
+
+func encodeProperties(properties map[string]*property, envName string) map[string]interface{} {
+	values := make(map[string]interface{}, len(properties))
+	for key, property := range properties {
+		values[key] = property.encode(envName)
+	}
+	return values
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	requestID := s.requests
+	unavailable, accessToken, latency := s.unavailable, s.accessToken, s.latency
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Pulumi-Request-Id", fmt.Sprintf("req-%d", requestID))
+	if unavailable {
+		writeError(w, http.StatusServiceUnavailable, "service unavailable")
+		return
+	}
+	if accessToken != "" && r.Header.Get("Authorization") != "token "+accessToken {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// Paths are of the form /api/esc/environments/{org}/{project}/{env}/...
+	path := strings.TrimPrefix(r.URL.Path, "/api/esc")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) == 4 && segments[0] == "environments" {
+		switch {
+		case r.Method == http.MethodPost && segments[2] == "yaml" && segments[3] == "check":
+			s.checkDefinition(w, r)
+		case r.Method == http.MethodGet:
+			s.definition(w)
+		case r.Method == http.MethodPatch:
+			s.updateDefinition(w, r)
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+		return
+	}
+	if len(segments) < 5 || segments[0] != "environments" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	envName, operation := segments[3], segments[4:]
+	switch {
+	case r.Method == http.MethodGet && len(operation) == 1 && operation[0] == "versions":
+		s.listRevisions(w)
+	case r.Method == http.MethodPost && operation[len(operation)-1] == "open":
+		s.open(w)
+	case r.Method == http.MethodGet && operation[0] == "open":
+		time.Sleep(latency)
+		s.read(w, r, envName, operation[len(operation)-1])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) listRevisions(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	revisions := []esc.EnvironmentRevision{}
+	if s.revision > 0 {
+		revisions = append(revisions, esc.EnvironmentRevision{Number: s.revision})
+	}
+	_ = json.NewEncoder(w).Encode(revisions)
+}
+
+func (s *Server) open(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextSession++
+	id := fmt.Sprintf("session-%d", s.nextSession)
+	s.sessions[id] = true
+	_ = json.NewEncoder(w).Encode(esc.OpenEnvironment{Id: id})
+}
+
+func (s *Server) read(w http.ResponseWriter, r *http.Request, envName, sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.sessions[sessionID] {
+		writeError(w, http.StatusNotFound, "environment session not found")
+		return
+	}
+	if !r.URL.Query().Has("property") {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"properties": encodeProperties(s.properties, envName)})
+		return
+	}
+	value, ok := s.lookup(r.URL.Query().Get("property"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "key not found")
+		return
+	}
+	_ = json.NewEncoder(w).Encode(value.encode(envName))
+}
+
+// environmentDefinition is the YAML definition of an environment
+type environmentDefinition struct {
+	Values map[string]interface{} `yaml:"values,omitempty"`
+}
+
+func (s *Server) definition(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	body, err := s.marshalDefinition()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Header().Set("ETag", definitionETag(body))
+	_, _ = w.Write(body)
+}
+
+// marshalDefinition returns the YAML definition of the environment. s.mu must be held.
+func (s *Server) marshalDefinition() ([]byte, error) {
+	return yaml.Marshal(environmentDefinition{Values: defineProperties(s.properties)})
+}
+
+// definitionETag returns the ETag of a YAML definition
+func definitionETag(definition []byte) string {
+	return fmt.Sprintf(`"%x"`, sha256.Sum256(definition))
+}
+
+func (s *Server) updateDefinition(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var definition environmentDefinition
+	if err := yaml.Unmarshal(body, &definition); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid environment definition: %v", err))
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if etag := r.Header.Get("If-Match"); etag != "" {
+		current, err := s.marshalDefinition()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if etag != definitionETag(current) {
+			writeError(w, http.StatusPreconditionFailed, "environment definition changed")
+			return
+		}
+	}
+	s.properties = map[string]*property{}
+	for key, value := range definition.Values {
+		s.properties[key] = definedProperty(value)
+	}
+	s.revision++
+	_ = json.NewEncoder(w).Encode(esc.EnvironmentDiagnostics{})
+}
+
+func (s *Server) checkDefinition(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var definition environmentDefinition
+	if err := yaml.Unmarshal(body, &definition); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(esc.CheckEnvironment{
+			Diagnostics: []esc.EnvironmentDiagnostic{{Summary: fmt.Sprintf("invalid environment definition: %v", err)}},
+		})
+		return
+	}
+	properties := make(map[string]*property, len(definition.Values))
+	for key, value := range definition.Values {
+		properties[key] = definedProperty(value)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"properties": encodeProperties(properties, "<yaml>")})
+}
+
+// defineProperties returns the definition of properties, with secrets defined using fn::secret
+func defineProperties(properties map[string]*property) map[string]interface{} {
+	values := make(map[string]interface{}, len(properties))
+	for key, property := range properties {
+		values[key] = property.define()
+	}
+	return values
+}
+
+// define returns the definition of a property
+func (p *property) define() interface{} {
+	switch nested := p.value.(type) {
+	case map[string]*property:
+		return defineProperties(nested)
+	case []*property:
+		values := make([]interface{}, len(nested))
+		for i, property := range nested {
+			values[i] = property.define()
+		}
+		return values
+	}
+	if p.secret {
+		return map[string]interface{}{"fn::secret": p.value}
+	}
+	return p.value
+}
+
+// definedProperty converts the definition of a value to a property, converting fn::secret to secrets
+func definedProperty(value interface{}) *property {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if secretValue, ok := value["fn::secret"]; ok && len(value) == 1 {
+			return newProperty(secretValue, true)
+		}
+		properties := make(map[string]*property, len(value))
+		for key, nested := range value {
+			properties[key] = definedProperty(nested)
+		}
+		return &property{value: properties}
+	case []interface{}:
+		properties := make([]*property, len(value))
+		for i, nested := range value {
+			properties[i] = definedProperty(nested)
+		}
+		return &property{value: properties}
+	default:
+		return newProperty(value, false)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(esc.Error{Code: int32(code), Message: message})
+}
+
+// redirectTransport sends all requests to the target server
+type redirectTransport struct {
+	target string
+	base   http.RoundTripper
+}
+
+func (t *redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = "http"
+	r.URL.Host = strings.TrimPrefix(t.target, "http://")
+	r.Host = r.URL.Host
+	return t.base.RoundTrip(r)
+}
@@ -0,0 +1,102 @@
+package pulumitest_test
+
+import (
+	"context"
+	"testing"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/pulumitest"
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	server := pulumitest.NewServer(map[string]interface{}{
+		"bool-flag":           true,
+		"configs.DEBUG_MODE":  false,
+		"configs.MAX_RETRIES": 3,
+	})
+	defer server.Close()
+	server.SetSecret("configs.OPENAI_API_KEY", "sk-12345")
+	server.SetRevision(7)
+
+	p, err := pulumi.NewPulumiESCProvider("test-org", "test-project", "test-env", "token", pulumi.WithHTTPClient(server.Client()))
+	assert.NoError(t, err)
+	defer p.Shutdown()
+	ctx := context.Background()
+
+	t.Run("values", func(t *testing.T) {
+		assert.True(t, p.BooleanEvaluation(ctx, "bool-flag", false, nil).Value)
+		assert.Equal(t, int64(3), p.IntEvaluation(ctx, "configs.MAX_RETRIES", 0, nil).Value)
+		assert.False(t, p.BooleanEvaluation(ctx, "configs.DEBUG_MODE", true, nil).Value)
+		assert.Equal(t, int32(7), p.Revision())
+	})
+
+	t.Run("secrets", func(t *testing.T) {
+		details := p.StringEvaluation(ctx, "configs.OPENAI_API_KEY", "", nil)
+		assert.Equal(t, "sk-12345", details.Value)
+		secret, err := details.FlagMetadata.GetBool("secret")
+		assert.NoError(t, err)
+		assert.True(t, secret)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		server.DeleteValue("bool-flag")
+		details := p.BooleanEvaluation(ctx, "bool-flag", false, nil)
+		assert.Equal(t, openfeature.FlagNotFoundCode, details.ResolutionDetail().ErrorCode)
+	})
+
+	t.Run("expired sessions", func(t *testing.T) {
+		server.ExpireSessions()
+		assert.False(t, p.BooleanEvaluation(ctx, "configs.DEBUG_MODE", true, nil).Value)
+		assert.Equal(t, openfeature.ReadyState, p.Status())
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		server.SetUnavailable(true)
+		defer server.SetUnavailable(false)
+		details := p.BooleanEvaluation(ctx, "configs.DEBUG_MODE", true, nil)
+		assert.Equal(t, openfeature.GeneralCode, details.ResolutionDetail().ErrorCode)
+	})
+}
+
+func TestServer_SetAccessToken(t *testing.T) {
+	server := pulumitest.NewServer(map[string]interface{}{"bool-flag": true})
+	defer server.Close()
+	server.SetAccessToken("secret-token")
+
+	_, err := pulumi.NewPulumiESCProvider("test-org", "test-project", "test-env", "wrong-token", pulumi.WithHTTPClient(server.Client()))
+	assert.Error(t, err)
+
+	p, err := pulumi.NewPulumiESCProvider("test-org", "test-project", "test-env", "secret-token", pulumi.WithHTTPClient(server.Client()))
+	assert.NoError(t, err)
+	defer p.Shutdown()
+	assert.True(t, p.BooleanEvaluation(context.Background(), "bool-flag", false, nil).Value)
+	assert.Positive(t, server.Requests())
+}
+
+func TestServer_definition(t *testing.T) {
+	server := pulumitest.NewServer(map[string]interface{}{"configs.DEBUG_MODE": false})
+	defer server.Close()
+	server.SetSecret("configs.API_KEY", "sk-12345")
+	server.SetRevision(1)
+
+	p, err := pulumi.NewPulumiESCProvider("test-org", "test-project", "test-env", "token", pulumi.WithHTTPClient(server.Client()))
+	assert.NoError(t, err)
+	defer p.Shutdown()
+	ctx := p.AuthContext(context.Background())
+
+	_, definition, err := p.Client().GetEnvironment(ctx, "test-org", "test-project", "test-env")
+	assert.NoError(t, err)
+	assert.YAMLEq(t, "values:\n  configs:\n    DEBUG_MODE: false\n    API_KEY:\n      fn::secret: sk-12345\n", definition)
+
+	_, err = p.Client().UpdateEnvironmentYaml(ctx, "test-org", "test-project", "test-env",
+		"values:\n  configs:\n    DEBUG_MODE: true\n    API_KEY:\n      fn::secret: sk-67890\n")
+	assert.NoError(t, err)
+	assert.True(t, p.BooleanEvaluation(context.Background(), "configs.DEBUG_MODE", false, nil).Value)
+	details := p.StringEvaluation(context.Background(), "configs.API_KEY", "", nil)
+	assert.Equal(t, "sk-67890", details.Value)
+	secret, err := details.FlagMetadata.GetBool("secret")
+	assert.NoError(t, err)
+	assert.True(t, secret)
+}
@@ -0,0 +1,150 @@
+package pulumi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+var errRateLimited = errors.New("rate limit exceeded")
+
+// tokenBucket is a token bucket limiter refilled at a constant rate of tokens per second
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// allow consumes a token if one is available. It never blocks.
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// coalescedReadTimeout bounds the property reads shared by concurrent evaluations, which are not bound by
+// the context of any of them
+const coalescedReadTimeout = 30 * time.Second
+
+// inflightRead is a property read shared by all the callers asking for the same property path
+type inflightRead struct {
+	done     chan struct{}
+	escValue *esc.Value
+	rawValue interface{}
+	err      error
+}
+
+// wait waits for the read to complete or for the context to be done, whichever happens first
+func (r *inflightRead) wait(ctx context.Context) (*esc.Value, interface{}, error) {
+	select {
+	case <-r.done:
+		return r.escValue, r.rawValue, r.err
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+// readCoalescer deduplicates concurrent reads of the same property path
+type readCoalescer struct {
+	mu    sync.Mutex
+	reads map[string]*inflightRead
+}
+
+func newReadCoalescer() *readCoalescer {
+	return &readCoalescer{reads: map[string]*inflightRead{}}
+}
+
+// do executes read, unless a read of the same property path is already in flight, in which case
+// the result of that read is returned instead. The read runs with a context detached from the
+// cancellation of the callers, bounded by coalescedReadTimeout, so the cancellation or the timeout
+// of a caller does not fail the others. Every caller stops waiting once its own context is done.
+func (c *readCoalescer) do(ctx context.Context, propertyPath string, read func(ctx context.Context) (*esc.Value, interface{}, error)) (*esc.Value, interface{}, error) {
+	c.mu.Lock()
+	inflight, ok := c.reads[propertyPath]
+	if !ok {
+		inflight = &inflightRead{done: make(chan struct{})}
+		c.reads[propertyPath] = inflight
+		go func() {
+			readCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), coalescedReadTimeout)
+			defer cancel()
+			inflight.escValue, inflight.rawValue, inflight.err = read(readCtx)
+			c.mu.Lock()
+			delete(c.reads, propertyPath)
+			c.mu.Unlock()
+			close(inflight.done)
+		}()
+	}
+	c.mu.Unlock()
+	return inflight.wait(ctx)
+}
+
+// join returns the in-flight read of the given property path, or nil if there is no read in flight
+func (c *readCoalescer) join(propertyPath string) *inflightRead {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reads[propertyPath]
+}
+
+// WithRateLimit limits the rate of requests made to the Pulumi ESC API to rps requests per second,
+// allowing bursts of up to burst requests. Evaluations exceeding the limit are served with the last
+// known value of the flag, or share the result of an in-flight request for the same flag, and fail
+// otherwise instead of being queued. Evaluations sharing a request stop waiting for it at their own deadline,
+// and their cancellation does not cancel the request for the others.
+func WithRateLimit(rps float64, burst int) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if rps <= 0 || burst <= 0 {
+			return
+		}
+		p.rateLimiter = newTokenBucket(rps, burst)
+		p.coalescer = newReadCoalescer()
+		p.lastKnownValues = newValueCache()
+	}
+}
+
+// readRateLimited reads the property through the rate limiter. The returned CacheStatus reports whether
+// the value was served from the last known values instead of the ESC service.
+func (p *PulumiESCProvider) readRateLimited(ctx context.Context, propertyPath string) (*esc.Value, interface{}, CacheStatus, error) {
+	if !p.rateLimiter.allow() {
+		if cached, ok := p.lastKnownValues.get(propertyPath); ok {
+			return cached.escValue, cached.rawValue, CacheStatus_Hit, nil
+		}
+		if inflight := p.coalescer.join(propertyPath); inflight != nil {
+			escValue, rawValue, err := inflight.wait(ctx)
+			return escValue, rawValue, CacheStatus_Miss, err
+		}
+		return nil, nil, CacheStatus_Miss, fmt.Errorf("%w while reading %s", errRateLimited, propertyPath)
+	}
+	escValue, rawValue, err := p.coalescer.do(ctx, propertyPath, func(ctx context.Context) (*esc.Value, interface{}, error) {
+		escValue, rawValue, err := p.readFromESC(ctx, propertyPath)
+		if err == nil {
+			p.lastKnownValues.set(propertyPath, escValue, rawValue)
+		}
+		return escValue, rawValue, err
+	})
+	return escValue, rawValue, CacheStatus_Miss, err
+}
@@ -0,0 +1,80 @@
+package pulumi
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	bucket := newTokenBucket(1, 2)
+	assert.True(t, bucket.allow())
+	assert.True(t, bucket.allow())
+	assert.False(t, bucket.allow(), "burst must be exhausted")
+
+	bucket.last = bucket.last.Add(-time.Second)
+	assert.True(t, bucket.allow(), "a token must be refilled after a second")
+	assert.False(t, bucket.allow())
+}
+
+func TestReadCoalescer(t *testing.T) {
+	coalescer := newReadCoalescer()
+	assert.Nil(t, coalescer.join(STRING_FLAG_KEY))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var reads atomic.Int32
+	read := func(ctx context.Context) (*esc.Value, interface{}, error) {
+		if reads.Add(1) == 1 {
+			close(started)
+		}
+		select {
+		case <-release:
+			return &esc.Value{}, STRING_FLAG_VALUE, nil
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+
+	// The caller starting the read gives up, e.g. because of its evaluation timeout
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error)
+	go func() {
+		_, _, err := coalescer.do(leaderCtx, STRING_FLAG_KEY, read)
+		leaderErr <- err
+	}()
+	<-started
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, rawValue, err := coalescer.do(context.Background(), STRING_FLAG_KEY, read)
+		assert.NoError(t, err, "the cancellation of another caller must not fail the shared read")
+		assert.Equal(t, STRING_FLAG_VALUE, rawValue)
+	}()
+	inflight := coalescer.join(STRING_FLAG_KEY)
+	require.NotNil(t, inflight)
+
+	cancelLeader()
+	assert.ErrorIs(t, <-leaderErr, context.Canceled, "callers must stop waiting once their context is done")
+
+	joinerCtx, cancelJoiner := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelJoiner()
+	_, _, err := inflight.wait(joinerCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "joiners must be bound by their own timeout")
+
+	close(release)
+	wg.Wait()
+	_, rawValue, err := inflight.wait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, STRING_FLAG_VALUE, rawValue)
+	assert.Equal(t, int32(1), reads.Load())
+	assert.Eventually(t, func() bool { return coalescer.join(STRING_FLAG_KEY) == nil }, time.Second, time.Millisecond)
+}
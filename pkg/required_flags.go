@@ -0,0 +1,56 @@
+package pulumi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// WithRequiredFlags verifies during initialisation that every listed flag exists in the environment
+// and has the given type. If any flag is missing or of another type, the initialisation fails with
+// an error listing all of them. Flags resolved by the provider before but deleted since, e.g. when the
+// provider is initialised again, are reported as a DeletedFlagError with their tombstone.
+func WithRequiredFlags(flags map[string]FlagType) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.requiredFlags = flags
+	}
+}
+
+// validateRequiredFlags reads every required flag and reports all the flags which are missing,
+// of another type or could not be read
+func (p *PulumiESCProvider) validateRequiredFlags(ctx context.Context) error {
+	keys := make([]string, 0, len(p.requiredFlags))
+	for key := range p.requiredFlags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		flagType := p.requiredFlags[key]
+		escValue, rawValue, _, err := p.readProperty(ctx, key)
+		switch {
+		case isKeyNotFound(err):
+			if tombstone, ok := p.tombstones.markMissing(key); ok {
+				errs = append(errs, withSentinel(&DeletedFlagError{Tombstone: tombstone}, ErrFlagNotFound))
+			} else {
+				errs = append(errs, withSentinel(fmt.Errorf("%s not found", key), ErrFlagNotFound))
+			}
+		case err != nil:
+			errs = append(errs, fmt.Errorf("failed to read %s: %w", key, classifyAPIError(err)))
+		case !validateType(rawValue, flagType):
+			errs = append(errs, withSentinel(errors.New(typeMismatchMessage(key, escValue, rawValue, flagType)), ErrTypeMismatch))
+		case !p.enumAllowed(key, rawValue):
+			errs = append(errs, errors.New(p.enumMessage(key)))
+		default:
+			if err := p.validateSchema(key, rawValue); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid required flags:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRequiredFlags(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		BOOL_FLAG_KEY:   BOOL_FLAG_VALUE,
+		STRING_FLAG_KEY: STRING_FLAG_VALUE,
+		INT_FLAG_KEY:    INT_FLAG_VALUE,
+		"configs":       map[string]interface{}{"DEBUG_MODE": true},
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		p := newTestProvider(t, server, WithRequiredFlags(map[string]FlagType{
+			BOOL_FLAG_KEY:   FlagType_Bool,
+			STRING_FLAG_KEY: FlagType_String,
+			INT_FLAG_KEY:    FlagType_Integer,
+			"configs":       FlagType_Object,
+		}))
+		assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+		assert.Equal(t, openfeature.ReadyState, p.Status())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		p := newTestProvider(t, server, WithRequiredFlags(map[string]FlagType{
+			BOOL_FLAG_KEY:         FlagType_Bool,
+			STRING_FLAG_KEY:       FlagType_Integer,
+			NON_EXISTING_FLAG_KEY: FlagType_String,
+		}))
+		err := p.Init(openfeature.EvaluationContext{})
+		if assert.Error(t, err) {
+			assert.Equal(t, "invalid required flags:\n"+
+				NON_EXISTING_FLAG_KEY+" not found\n"+
+				STRING_FLAG_KEY+" is of type string, not of type int64", err.Error())
+		}
+		assert.Equal(t, openfeature.NotReadyState, p.Status())
+
+		got := p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, false, nil)
+		assert.Equal(t, openfeature.ProviderNotReadyCode, got.ResolutionDetail().ErrorCode)
+	})
+	t.Run("deleted", func(t *testing.T) {
+		server := newFakeESCServer(t, map[string]interface{}{BOOL_FLAG_KEY: BOOL_FLAG_VALUE})
+		p := newTestProvider(t, server, WithRequiredFlags(map[string]FlagType{BOOL_FLAG_KEY: FlagType_Bool}), WithTombstones())
+		require.NoError(t, p.Init(openfeature.EvaluationContext{}))
+		assert.True(t, p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, false, nil).Value)
+		p.Shutdown()
+
+		server.DeleteValue(BOOL_FLAG_KEY)
+		err := p.Init(openfeature.EvaluationContext{})
+		assert.ErrorIs(t, err, ErrFlagNotFound)
+		var deletedErr *DeletedFlagError
+		if assert.ErrorAs(t, err, &deletedErr) {
+			assert.Equal(t, BOOL_FLAG_KEY, deletedErr.Tombstone.Key)
+			assert.Equal(t, tombstoneHash(BOOL_FLAG_VALUE), deletedErr.Tombstone.LastValueHash)
+			assert.Contains(t, err.Error(), BOOL_FLAG_KEY+" not found, deleted at ")
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package pulumi
+
+import (
+	"context"
+	"strconv"
+)
+
+const revisionMetadataKey = "revision"
+
+// latestRevision returns the number of the latest revision of the environment, or 0 if it can not be read
+func (p *PulumiESCProvider) latestRevision(ctx context.Context, projectName, envName string) int32 {
+	revisions, err := p.escClient.ListEnvironmentRevisions(ctx, p.orgName, projectName, envName, 1)
+	if err != nil || len(revisions) == 0 {
+		return 0
+	}
+	return revisions[0].Number
+}
+
+// Revision returns the number of the environment revision the open session was opened at,
+// or 0 if it is unknown
+func (p *PulumiESCProvider) Revision() int32 {
+	p.stateMu.RLock()
+	defer p.stateMu.RUnlock()
+	return p.revision
+}
+
+// revisionFor returns the revision which produced a value served with the given CacheStatus
+func (p *PulumiESCProvider) revisionFor(cacheStatus CacheStatus) int32 {
+	if cacheStatus == CacheStatus_Stale {
+		if snapshot := p.snapshot.Load(); snapshot != nil {
+			return snapshot.Revision
+		}
+		return 0
+	}
+	return p.Revision()
+}
+
+// formatRevision formats a revision number, or returns an empty string if it is unknown
+func formatRevision(revision int32) string {
+	if revision == 0 {
+		return ""
+	}
+	return strconv.Itoa(int(revision))
+}
@@ -0,0 +1,35 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevisionMetadata(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{BOOL_FLAG_KEY: BOOL_FLAG_VALUE})
+
+	t.Run("known", func(t *testing.T) {
+		server.SetRevision(42)
+		p := newTestProvider(t, server, WithEvaluationLog(1))
+		assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+		assert.Equal(t, int32(42), p.Revision())
+
+		got := p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, false, nil)
+		revision, err := got.FlagMetadata.GetInt(revisionMetadataKey)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), revision)
+		assert.Equal(t, "42", p.RecentEvaluations()[0].Revision)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		server.SetRevision(0)
+		p := newTestProvider(t, server)
+		assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+		got := p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, false, nil)
+		assert.Equal(t, BOOL_FLAG_VALUE, got.Value)
+		assert.NotContains(t, got.FlagMetadata, revisionMetadataKey)
+	})
+}
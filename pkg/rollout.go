@@ -0,0 +1,235 @@
+package pulumi
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"sync"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+const (
+	// rolloutOnVariant is the variant of the targeting keys served the value by a rollout
+	rolloutOnVariant = "on"
+	// rolloutOffVariant is the variant of the targeting keys served the off value by a rollout
+	rolloutOffVariant = "off"
+)
+
+// BucketingHash is the hashing algorithm assigning targeting keys to the buckets of rollouts
+type BucketingHash string
+
+const (
+	// BucketingHash_SHA256 maps the first 53 bits of the SHA-256 hash of the bucketing input to a bucket
+	BucketingHash_SHA256 BucketingHash = "sha256"
+	// BucketingHash_SHA1 maps the first 15 hexadecimal digits of the SHA-1 hash of the flag key, a dot, the
+	// salt, a dot and the targeting key, divided by 0xFFFFFFFFFFFFFFF, to a bucket, as LaunchDarkly does
+	BucketingHash_SHA1 BucketingHash = "sha1"
+	// BucketingHash_Murmur3 maps the 32-bit MurmurHash3 hash of the bucketing input, with seed 0, to a bucket
+	BucketingHash_Murmur3 BucketingHash = "murmur3"
+)
+
+// WithBucketingHash sets the hashing algorithm assigning targeting keys to the buckets of rollouts, so
+// assignments can be made consistent with another system when migrating. It defaults to BucketingHash_SHA256.
+// The bucketing input is the salt of the flag definition, which defaults to the flag key, a dot and the
+// targeting key, e.g. "NEW_CHECKOUT.user-1", except for BucketingHash_SHA1 which prefixes it with the flag
+// key and a dot, e.g. "NEW_CHECKOUT.abc.user-1" for the salt abc, like LaunchDarkly. Changing the hash or
+// the salt re-randomizes the assignments.
+func WithBucketingHash(hash BucketingHash) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.bucketingHash = hash
+	}
+}
+
+// validateBucketingHash returns an error if the bucketing hash is not supported
+func (p *PulumiESCProvider) validateBucketingHash() error {
+	switch p.bucketingHash {
+	case "", BucketingHash_SHA256, BucketingHash_SHA1, BucketingHash_Murmur3:
+		return nil
+	}
+	return fmt.Errorf("unsupported bucketing hash %q", p.bucketingHash)
+}
+
+// BucketStore pins the variants assigned to targeting keys by percentage rollouts, so users keep their
+// variant when the rollout percentage changes. Implementations must be safe for concurrent use.
+type BucketStore interface {
+	// GetVariant returns the variant pinned to the targeting key for the flag, if any
+	GetVariant(ctx context.Context, flag, targetingKey string) (string, bool, error)
+	// SetVariant pins the variant to the targeting key for the flag
+	SetVariant(ctx context.Context, flag, targetingKey, variant string) error
+}
+
+// memoryBucketStore is a BucketStore keeping the variants in memory
+type memoryBucketStore struct {
+	mu       sync.RWMutex
+	variants map[[2]string]string
+}
+
+// NewMemoryBucketStore returns a BucketStore keeping the variants in memory, e.g. for a single long-lived
+// process or tests. Assignments are lost on restart, so replicated services need a shared store.
+func NewMemoryBucketStore() BucketStore {
+	return &memoryBucketStore{variants: map[[2]string]string{}}
+}
+
+func (s *memoryBucketStore) GetVariant(ctx context.Context, flag, targetingKey string) (string, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	variant, ok := s.variants[[2]string{flag, targetingKey}]
+	return variant, ok, nil
+}
+
+func (s *memoryBucketStore) SetVariant(ctx context.Context, flag, targetingKey, variant string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.variants[[2]string{flag, targetingKey}] = variant
+	return nil
+}
+
+// WithStickyBucketing pins the variant assigned to a targeting key by the rollout or distribution of a flag
+// definition in the store on its first evaluation, so users don't flip-flop between variants when the rollout
+// percentage or the weights change. Assignments are recomputed when the store fails or the weight of the pinned
+// variant drops to 0, and are not pinned while a single variant has a weight, e.g. a rollout at 0% or 100%.
+func WithStickyBucketing(store BucketStore) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.bucketStore = store
+	}
+}
+
+// variantWeight is the weight of a variant in the distribution of the targeting keys
+type variantWeight struct {
+	variant string
+	weight  float64
+}
+
+// resolveSplit assigns the targeting key of the evaluation context to a variant of the rollout or distribution
+// of the flag definition and returns its value. If the variant has no value, it also returns its resolution.
+func (p *PulumiESCProvider) resolveSplit(ctx context.Context, propertyPath string, definition *flagDefinition, flagType FlagType, evalCtx openfeature.FlattenedContext) (interface{}, string, *openfeature.ProviderResolutionDetail) {
+	targetingKey, _ := evalCtx[openfeature.TargetingKey].(string)
+	if targetingKey == "" {
+		return nil, "", &openfeature.ProviderResolutionDetail{
+			Reason:          openfeature.ErrorReason,
+			ResolutionError: openfeature.NewTargetingKeyMissingResolutionError(fmt.Sprintf("%s is split by targeting key", propertyPath)),
+		}
+	}
+	salt := definition.salt
+	if definition.variants != nil {
+		variant := p.assignVariant(ctx, propertyPath, salt, targetingKey, definition.distribution)
+		return definition.variants[variant], variant, nil
+	}
+	variant := p.assignVariant(ctx, propertyPath, salt, targetingKey, []variantWeight{
+		{variant: rolloutOnVariant, weight: *definition.rollout},
+		{variant: rolloutOffVariant, weight: 100 - *definition.rollout},
+	})
+	if variant == rolloutOnVariant {
+		return definition.value, variant, nil
+	}
+	if resolutionDetails := offValueTypeMismatch(propertyPath, definition, flagType); resolutionDetails != nil {
+		return nil, "", resolutionDetails
+	}
+	if definition.offValue == nil {
+		return nil, variant, &openfeature.ProviderResolutionDetail{
+			Reason:  openfeature.SplitReason,
+			Variant: variant,
+		}
+	}
+	return definition.offValue, variant, nil
+}
+
+// assignVariant returns the variant of the targeting key, pinned in the bucket store if any
+func (p *PulumiESCProvider) assignVariant(ctx context.Context, flag, salt, targetingKey string, weights []variantWeight) string {
+	weighted := 0
+	for _, weight := range weights {
+		if weight.weight > 0 {
+			weighted++
+		}
+	}
+	if p.bucketStore == nil || weighted < 2 {
+		return p.bucketVariant(flag, salt, targetingKey, weights)
+	}
+	if variant, ok, err := p.bucketStore.GetVariant(ctx, flag, targetingKey); err == nil && ok {
+		for _, weight := range weights {
+			if weight.variant == variant && weight.weight > 0 {
+				return variant
+			}
+		}
+	}
+	variant := p.bucketVariant(flag, salt, targetingKey, weights)
+	_ = p.bucketStore.SetVariant(ctx, flag, targetingKey, variant)
+	return variant
+}
+
+// bucketVariant returns the variant of the bucket of the targeting key, the buckets being split between the
+// variants in proportion to their weights, in order
+func (p *PulumiESCProvider) bucketVariant(flag, salt, targetingKey string, weights []variantWeight) string {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight.weight
+	}
+	position := bucket(p.bucketingHash, flag, salt, targetingKey) / 100 * total
+	variant := ""
+	for _, weight := range weights {
+		if weight.weight == 0 {
+			continue
+		}
+		variant = weight.variant
+		if position < weight.weight {
+			break
+		}
+		position -= weight.weight
+	}
+	return variant
+}
+
+// bucket deterministically maps the targeting key to a percentage in [0, 100) using the hash, or [0, 100]
+// for BucketingHash_SHA1 like LaunchDarkly. The salt, which defaults to the flag key, makes targeting keys be
+// assigned independently for every flag.
+func bucket(hash BucketingHash, flag, salt, targetingKey string) float64 {
+	if salt == "" {
+		salt = flag
+	}
+	input := []byte(salt + "." + targetingKey)
+	switch hash {
+	case BucketingHash_SHA1:
+		sum := sha1.Sum([]byte(flag + "." + salt + "." + targetingKey))
+		return float64(binary.BigEndian.Uint64(sum[:8])>>4) / 0xFFFFFFFFFFFFFFF * 100
+	case BucketingHash_Murmur3:
+		return float64(murmur3(input)) / (1 << 32) * 100
+	default:
+		sum := sha256.Sum256(input)
+		return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53) * 100
+	}
+}
+
+// murmur3 returns the 32-bit MurmurHash3 (x86) hash of the data with seed 0
+func murmur3(data []byte) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	var h uint32
+	n := len(data) / 4 * 4
+	for i := 0; i < n; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k = bits.RotateLeft32(k*c1, 15) * c2
+		h = bits.RotateLeft32(h^k, 13)*5 + 0xe6546b64
+	}
+	var k uint32
+	switch len(data) - n {
+	case 3:
+		k ^= uint32(data[n+2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(data[n+1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(data[n])
+		h ^= bits.RotateLeft32(k*c1, 15) * c2
+	}
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,183 @@
+package pulumi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucket(t *testing.T) {
+	for _, hash := range []BucketingHash{BucketingHash_SHA256, BucketingHash_SHA1, BucketingHash_Murmur3} {
+		t.Run(string(hash), func(t *testing.T) {
+			on := 0
+			for i := 0; i < 10000; i++ {
+				b := bucket(hash, "NEW_CHECKOUT", "", fmt.Sprintf("user-%d", i))
+				require.True(t, b >= 0 && b < 100)
+				if b < 30 {
+					on++
+				}
+			}
+			assert.InDelta(t, 3000, on, 200, "targeting keys must be spread uniformly")
+			assert.Equal(t, bucket(hash, "NEW_CHECKOUT", "", "user-1"), bucket(hash, "NEW_CHECKOUT", "", "user-1"))
+			assert.NotEqual(t, bucket(hash, "NEW_CHECKOUT", "", "user-1"), bucket(hash, "NEW_SEARCH", "", "user-1"), "buckets must be salted")
+			assert.NotEqual(t, bucket(hash, "NEW_CHECKOUT", "", "user-1"), bucket(hash, "NEW_CHECKOUT", "abc", "user-1"), "buckets must be salted")
+		})
+	}
+	assert.Equal(t, uint32(0x248bfa47), murmur3([]byte("hello")))
+	// The bucket of LaunchDarkly of "user-1" for the flag NEW_CHECKOUT with the salt abc, i.e. the first 15
+	// hexadecimal digits of the SHA-1 hash of "NEW_CHECKOUT.abc.user-1" divided by 0xFFFFFFFFFFFFFFF
+	assert.Equal(t, float64(0x1bfa13a129bcbcf)/0xFFFFFFFFFFFFFFF*100, bucket(BucketingHash_SHA1, "NEW_CHECKOUT", "abc", "user-1"))
+}
+
+func TestWithBucketingHash(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"NEW_CHECKOUT": map[string]interface{}{"value": true, "offValue": false, "rollout": 50, "salt": "migrated"},
+	})
+	p := newTestProvider(t, server, WithFlagDefinitions(), WithBucketingHash(BucketingHash_Murmur3))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	for i := 0; i < 20; i++ {
+		targetingKey := fmt.Sprintf("user-%d", i)
+		got := p.BooleanEvaluation(ctx, "NEW_CHECKOUT", false, openfeature.FlattenedContext{openfeature.TargetingKey: targetingKey})
+		assert.Equal(t, bucket(BucketingHash_Murmur3, "NEW_CHECKOUT", "migrated", targetingKey) < 50, got.Value)
+	}
+
+	p = newTestProvider(t, server, WithBucketingHash("md5"))
+	assert.ErrorContains(t, p.initialise(ctx), `unsupported bucketing hash "md5"`)
+}
+
+func TestWithFlagDefinitions_rollout(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"NEW_CHECKOUT": map[string]interface{}{"value": true, "offValue": false, "rollout": 50},
+		"NEW_THEME":    map[string]interface{}{"value": "dark", "rollout": 0},
+		"INVALID":      map[string]interface{}{"value": true, "rollout": 150},
+	})
+	p := newTestProvider(t, server, WithFlagDefinitions())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	evalCtx := openfeature.FlattenedContext{openfeature.TargetingKey: "user-1"}
+	got := p.BooleanEvaluation(ctx, "NEW_CHECKOUT", false, evalCtx)
+	assert.NoError(t, got.Error())
+	assert.Equal(t, openfeature.SplitReason, got.Reason)
+	assert.Equal(t, bucket(BucketingHash_SHA256, "NEW_CHECKOUT", "", "user-1") < 50, got.Value)
+	assert.Equal(t, map[bool]string{true: "on", false: "off"}[got.Value], got.Variant)
+
+	theme := p.StringEvaluation(ctx, "NEW_THEME", "light", evalCtx)
+	assert.NoError(t, theme.Error())
+	assert.Equal(t, "light", theme.Value, "the default value must be served without off value")
+	assert.Equal(t, openfeature.SplitReason, theme.Reason)
+	assert.Equal(t, "off", theme.Variant)
+
+	got = p.BooleanEvaluation(ctx, "NEW_CHECKOUT", false, nil)
+	assert.Equal(t, openfeature.TargetingKeyMissingCode, got.ResolutionDetail().ErrorCode)
+	got = p.BooleanEvaluation(ctx, "INVALID", false, evalCtx)
+	assert.Equal(t, openfeature.ParseErrorCode, got.ResolutionDetail().ErrorCode)
+}
+
+func TestWithStickyBucketing(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"NEW_CHECKOUT": map[string]interface{}{"value": true, "offValue": false, "rollout": 50},
+	})
+	store := NewMemoryBucketStore()
+	sticky := newTestProvider(t, server, WithFlagDefinitions(), WithStickyBucketing(store))
+	plain := newTestProvider(t, server, WithFlagDefinitions())
+	ctx := context.Background()
+	require.NoError(t, sticky.initialise(ctx))
+	require.NoError(t, plain.initialise(ctx))
+
+	assigned := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		targetingKey := fmt.Sprintf("user-%d", i)
+		assigned[targetingKey] = sticky.BooleanEvaluation(ctx, "NEW_CHECKOUT", false, openfeature.FlattenedContext{openfeature.TargetingKey: targetingKey}).Value
+	}
+
+	server.SetValue("NEW_CHECKOUT.rollout", 10)
+	flipped := 0
+	for targetingKey, value := range assigned {
+		evalCtx := openfeature.FlattenedContext{openfeature.TargetingKey: targetingKey}
+		assert.Equal(t, value, sticky.BooleanEvaluation(ctx, "NEW_CHECKOUT", false, evalCtx).Value, "the variant of %s must be pinned", targetingKey)
+		if plain.BooleanEvaluation(ctx, "NEW_CHECKOUT", false, evalCtx).Value != value {
+			flipped++
+		}
+	}
+	assert.Positive(t, flipped, "variants must flip without sticky bucketing")
+
+	server.SetValue("NEW_CHECKOUT.rollout", 0)
+	for targetingKey := range assigned {
+		evalCtx := openfeature.FlattenedContext{openfeature.TargetingKey: targetingKey}
+		assert.False(t, sticky.BooleanEvaluation(ctx, "NEW_CHECKOUT", true, evalCtx).Value, "a rollout at 0% must not be pinned")
+	}
+}
+
+func TestWithFlagDefinitions_variants(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"CHECKOUT_LAYOUT": map[string]interface{}{
+			"variants":     map[string]interface{}{"control": "classic", "v1": "grid", "v2": "list"},
+			"distribution": map[string]interface{}{"control": 50, "v1": 25, "v2": 25},
+		},
+		"UNKNOWN_VARIANT": map[string]interface{}{
+			"variants":     map[string]interface{}{"control": "classic"},
+			"distribution": map[string]interface{}{"v1": 100},
+		},
+		"WITH_VALUE": map[string]interface{}{
+			"value":        "classic",
+			"variants":     map[string]interface{}{"control": "classic"},
+			"distribution": map[string]interface{}{"control": 100},
+		},
+	})
+	p := newTestProvider(t, server, WithFlagDefinitions())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	values := map[string]string{"control": "classic", "v1": "grid", "v2": "list"}
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		got := p.StringEvaluation(ctx, "CHECKOUT_LAYOUT", "", openfeature.FlattenedContext{openfeature.TargetingKey: fmt.Sprintf("user-%d", i)})
+		require.NoError(t, got.Error())
+		assert.Equal(t, openfeature.SplitReason, got.Reason)
+		assert.Equal(t, values[got.Variant], got.Value, "the value of the variant must be served")
+		counts[got.Variant]++
+	}
+	assert.InDelta(t, 1000, counts["control"], 150)
+	assert.InDelta(t, 500, counts["v1"], 100)
+	assert.InDelta(t, 500, counts["v2"], 100)
+
+	evalCtx := openfeature.FlattenedContext{openfeature.TargetingKey: "user-1"}
+	assert.Equal(t, p.StringEvaluation(ctx, "CHECKOUT_LAYOUT", "", evalCtx).Variant, p.StringEvaluation(ctx, "CHECKOUT_LAYOUT", "", evalCtx).Variant)
+	assert.Equal(t, openfeature.ParseErrorCode, p.StringEvaluation(ctx, "UNKNOWN_VARIANT", "", evalCtx).ResolutionDetail().ErrorCode)
+	assert.Equal(t, openfeature.ParseErrorCode, p.StringEvaluation(ctx, "WITH_VALUE", "", evalCtx).ResolutionDetail().ErrorCode)
+}
+
+func TestWithStickyBucketing_variants(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"CHECKOUT_LAYOUT": map[string]interface{}{
+			"variants":     map[string]interface{}{"control": "classic", "v1": "grid", "v2": "list"},
+			"distribution": map[string]interface{}{"control": 50, "v1": 25, "v2": 25},
+		},
+	})
+	p := newTestProvider(t, server, WithFlagDefinitions(), WithStickyBucketing(NewMemoryBucketStore()))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	assigned := map[string]string{}
+	for i := 0; i < 50; i++ {
+		targetingKey := fmt.Sprintf("user-%d", i)
+		assigned[targetingKey] = p.StringEvaluation(ctx, "CHECKOUT_LAYOUT", "", openfeature.FlattenedContext{openfeature.TargetingKey: targetingKey}).Variant
+	}
+
+	server.SetValue("CHECKOUT_LAYOUT.distribution.v2", 0)
+	server.SetValue("CHECKOUT_LAYOUT.distribution.control", 10)
+	for targetingKey, variant := range assigned {
+		got := p.StringEvaluation(ctx, "CHECKOUT_LAYOUT", "", openfeature.FlattenedContext{openfeature.TargetingKey: targetingKey})
+		if variant == "v2" {
+			assert.NotEqual(t, "v2", got.Variant, "variants without weight must be reassigned")
+		} else {
+			assert.Equal(t, variant, got.Variant, "the variant of %s must be pinned", targetingKey)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package pulumi
+
+import (
+	"strings"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+// WithRootPath resolves all flag keys relative to the given property path of the environment, e.g.
+// "values.flags", so a single environment can hold both flags and unrelated configuration. Listing,
+// exporting and watching the environment are restricted to the flags under the root path as well.
+func WithRootPath(rootPath string) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.rootPath = strings.Trim(rootPath, ".")
+	}
+}
+
+// propertyPath returns the path in the environment of the property holding the flag
+func (p *PulumiESCProvider) propertyPath(flag string) string {
+	switch {
+	case p.rootPath == "":
+		return flag
+	case strings.HasPrefix(flag, "["):
+		return p.rootPath + flag
+	}
+	return p.rootPath + "." + flag
+}
+
+// newSnapshot returns the snapshot of the flags of the given environment, read using ReadOpenEnvironment
+func (p *PulumiESCProvider) newSnapshot(env *esc.Environment, values map[string]interface{}, revision int32, projectName, envName string) *environmentSnapshot {
+	snapshot := newEnvironmentSnapshot(env, values, revision)
+	snapshot.Organization = p.orgName
+	snapshot.Environment = projectName + "/" + envName
+	if p.rootPath != "" {
+		snapshot.restrictTo(p.rootPath)
+	}
+	return snapshot
+}
+
+// restrictTo replaces the values of the snapshot with the values under the given property path,
+// which is empty if the path does not exist or is not an object
+func (s *environmentSnapshot) restrictTo(rootPath string) {
+	_, rawValue, _ := s.lookup(rootPath)
+	values, ok := rawValue.(map[string]interface{})
+	if !ok {
+		values = map[string]interface{}{}
+	}
+	var secrets []string
+	for _, secret := range s.Secrets {
+		switch {
+		case withinPath(rootPath, secret):
+			// The whole subtree is secret
+			secrets = secrets[:0]
+			for key := range values {
+				secrets = append(secrets, key)
+			}
+			s.Values, s.Secrets = values, secrets
+			return
+		case withinPath(secret, rootPath):
+			secrets = append(secrets, strings.TrimPrefix(strings.TrimPrefix(secret, rootPath), "."))
+		}
+	}
+	s.Values, s.Secrets = values, secrets
+}
@@ -0,0 +1,63 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRootPath(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"aws": map[string]interface{}{"region": "eu-west-1"},
+		"values": map[string]interface{}{
+			"flags": map[string]interface{}{
+				"DEBUG_MODE": true,
+				"configs":    map[string]interface{}{"MAX_RETRIES": 3},
+			},
+		},
+	})
+	server.SetSecret("values.flags.API_KEY", "secret-key")
+	p := newTestProvider(t, server, WithRootPath("values.flags"), WithDenySecrets())
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	assert.True(t, p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil).Value)
+	assert.Equal(t, int64(3), p.IntEvaluation(ctx, "configs.MAX_RETRIES", 0, nil).Value)
+	assert.ErrorContains(t, p.StringEvaluation(ctx, "aws.region", "", nil).Error(), "FLAG_NOT_FOUND",
+		"values outside of the root path must not be flags")
+
+	flags, err := p.ListFlags(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []FlagInfo{
+		{Key: "API_KEY", Type: FlagType_String, Secret: true},
+		{Key: "DEBUG_MODE", Type: FlagType_Bool},
+		{Key: "configs", Type: FlagType_Object},
+		{Key: "configs.MAX_RETRIES", Type: FlagType_Integer},
+	}, flags)
+	values, err := p.ExportSnapshot(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, maskedValue, values["API_KEY"])
+	assert.NotContains(t, values, "aws")
+}
+
+func TestEnvironmentSnapshot_restrictTo(t *testing.T) {
+	snapshot := &environmentSnapshot{
+		Values:  map[string]interface{}{"flags": map[string]interface{}{"TOKEN": "a", "THEME": "dark"}, "DB_PASSWORD": "b"},
+		Secrets: []string{"flags.TOKEN", "DB_PASSWORD"},
+	}
+	snapshot.restrictTo("flags")
+	assert.Equal(t, map[string]interface{}{"TOKEN": "a", "THEME": "dark"}, snapshot.Values)
+	assert.Equal(t, []string{"TOKEN"}, snapshot.Secrets)
+
+	snapshot = &environmentSnapshot{
+		Values:  map[string]interface{}{"flags": map[string]interface{}{"TOKEN": "a"}},
+		Secrets: []string{"flags"},
+	}
+	snapshot.restrictTo("flags")
+	assert.True(t, snapshot.isSecret("TOKEN"), "values nested in a secret root must be secret")
+
+	snapshot.restrictTo("missing")
+	assert.Empty(t, snapshot.Values)
+}
@@ -0,0 +1,167 @@
+package pulumi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+)
+
+// WithJSONSchema validates the values of the given flag against the given JSON Schema document.
+// Evaluations of values which do not match the schema fail with PARSE_ERROR describing the mismatches,
+// so drift of the environment from the expected shape is detected. Schemas are validated using
+// santhosh-tekuri/jsonschema, which supports drafts 4, 6, 7, 2019-09 and 2020-12 (the default if
+// $schema is not set), including anyOf, oneOf, allOf, not, if/then/else and local $ref. The format
+// keyword is an annotation only and remote $ref are not loaded. Initialisation fails if the schema
+// is invalid. The mismatches never include the value, as it may be a secret.
+func WithJSONSchema(key string, schema []byte) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if p.schemaDocuments == nil {
+			p.schemaDocuments = map[string][]byte{}
+		}
+		p.schemaDocuments[key] = schema
+	}
+}
+
+// compileSchemas compiles the JSON Schemas set using WithJSONSchema
+func (p *PulumiESCProvider) compileSchemas() error {
+	if p.schemas != nil || len(p.schemaDocuments) == 0 {
+		return nil
+	}
+	schemas := make(map[string]*jsonschema.Schema, len(p.schemaDocuments))
+	for key, document := range p.schemaDocuments {
+		schema, err := compileSchema(key, document)
+		if err != nil {
+			return fmt.Errorf("invalid JSON Schema of %s: %w", key, err)
+		}
+		schemas[key] = schema
+	}
+	p.schemas = schemas
+	return nil
+}
+
+// compileSchema compiles the JSON Schema document of the given flag
+func compileSchema(key string, document []byte) (*jsonschema.Schema, error) {
+	value, err := jsonschema.UnmarshalJSON(bytes.NewReader(document))
+	if err != nil {
+		return nil, err
+	}
+	location := "flag:///" + url.PathEscape(key)
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(location, value); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(location)
+}
+
+// validateSchema validates the raw value of the given flag against its JSON Schema, if any
+func (p *PulumiESCProvider) validateSchema(propertyPath string, rawValue interface{}) error {
+	schema, ok := p.schemas[propertyPath]
+	if !ok {
+		return nil
+	}
+	err := schema.Validate(rawValue)
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+	mismatches := schemaMismatches(validationErr, rawValue, propertyPath)
+	return fmt.Errorf("%s does not match its JSON Schema: %s", propertyPath, strings.Join(mismatches, "; "))
+}
+
+// schemaMismatches describes the leaf errors of the validation error.
+// The mismatches never include the value, as it may be a secret.
+func schemaMismatches(err *jsonschema.ValidationError, rawValue interface{}, propertyPath string) []string {
+	switch err.ErrorKind.(type) {
+	case *kind.Schema, *kind.Group, *kind.AllOf, *kind.Reference:
+		var mismatches []string
+		for _, cause := range err.Causes {
+			mismatches = append(mismatches, schemaMismatches(cause, rawValue, propertyPath)...)
+		}
+		if len(mismatches) > 0 {
+			return mismatches
+		}
+	}
+	path := instancePath(rawValue, propertyPath, err.InstanceLocation)
+	switch errorKind := err.ErrorKind.(type) {
+	case *kind.Required:
+		mismatches := make([]string, len(errorKind.Missing))
+		for i, name := range errorKind.Missing {
+			mismatches[i] = fmt.Sprintf("%s is required", joinPropertyPath(path, name))
+		}
+		return mismatches
+	case *kind.AdditionalProperties:
+		mismatches := make([]string, len(errorKind.Properties))
+		for i, name := range errorKind.Properties {
+			mismatches[i] = fmt.Sprintf("%s is not allowed", joinPropertyPath(path, name))
+		}
+		return mismatches
+	case *kind.Type:
+		return []string{fmt.Sprintf("%s must be of type %s", path, strings.Join(errorKind.Want, " or "))}
+	case *kind.Enum:
+		return []string{fmt.Sprintf("%s must be one of the enumerated values", path)}
+	case *kind.Const:
+		return []string{fmt.Sprintf("%s must be the constant value", path)}
+	case *kind.FalseSchema:
+		return []string{fmt.Sprintf("%s is not allowed", path)}
+	case *kind.AnyOf:
+		return []string{fmt.Sprintf("%s must match at least one of the anyOf schemas", path)}
+	case *kind.OneOf:
+		return []string{fmt.Sprintf("%s must match exactly one of the oneOf schemas", path)}
+	case *kind.Not:
+		return []string{fmt.Sprintf("%s must not match the not schema", path)}
+	case *kind.MinLength:
+		return []string{fmt.Sprintf("%s must be at least %d characters long", path, errorKind.Want)}
+	case *kind.MaxLength:
+		return []string{fmt.Sprintf("%s must be at most %d characters long", path, errorKind.Want)}
+	case *kind.Pattern:
+		return []string{fmt.Sprintf("%s must match the pattern %q", path, errorKind.Want)}
+	case *kind.MinItems:
+		return []string{fmt.Sprintf("%s must have at least %d items", path, errorKind.Want)}
+	case *kind.MaxItems:
+		return []string{fmt.Sprintf("%s must have at most %d items", path, errorKind.Want)}
+	case *kind.MinProperties:
+		return []string{fmt.Sprintf("%s must have at least %d properties", path, errorKind.Want)}
+	case *kind.MaxProperties:
+		return []string{fmt.Sprintf("%s must have at most %d properties", path, errorKind.Want)}
+	case *kind.Minimum:
+		return []string{fmt.Sprintf("%s must be >= %s", path, errorKind.Want.RatString())}
+	case *kind.Maximum:
+		return []string{fmt.Sprintf("%s must be <= %s", path, errorKind.Want.RatString())}
+	case *kind.ExclusiveMinimum:
+		return []string{fmt.Sprintf("%s must be > %s", path, errorKind.Want.RatString())}
+	case *kind.ExclusiveMaximum:
+		return []string{fmt.Sprintf("%s must be < %s", path, errorKind.Want.RatString())}
+	case *kind.MultipleOf:
+		return []string{fmt.Sprintf("%s must be a multiple of %s", path, errorKind.Want.RatString())}
+	case *kind.UniqueItems:
+		return []string{fmt.Sprintf("%s must have unique items", path)}
+	}
+	return []string{fmt.Sprintf("%s does not match the %s keyword", path, strings.Join(err.ErrorKind.KeywordPath(), "/"))}
+}
+
+// instancePath returns the property path of the value at the given location of the raw value
+func instancePath(rawValue interface{}, propertyPath string, location []string) string {
+	path := propertyPath
+	for _, token := range location {
+		switch value := rawValue.(type) {
+		case []interface{}:
+			index, _ := strconv.Atoi(token)
+			path = fmt.Sprintf("%s[%d]", path, index)
+			if index >= 0 && index < len(value) {
+				rawValue = value[index]
+			}
+		case map[string]interface{}:
+			path = joinPropertyPath(path, token)
+			rawValue = value[token]
+		default:
+			path = joinPropertyPath(path, token)
+		}
+	}
+	return path
+}
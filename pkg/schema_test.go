@@ -0,0 +1,76 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithJSONSchema(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"headers": map[string]interface{}{"X-Tenant": "acme"},
+		"regions": []interface{}{"eu-west-1"},
+		"retries": 3,
+	})
+	p := newTestProvider(t, server,
+		WithJSONSchema("headers", []byte(`{"type":"object","required":["X-Tenant"],"additionalProperties":{"type":"string","maxLength":8}}`)),
+		WithJSONSchema("regions", []byte(`{"type":"array","minItems":1,"items":{"enum":["eu-west-1","us-east-1"]}}`)),
+		WithJSONSchema("retries", []byte(`{"type":"integer","minimum":1,"maximum":5}`)),
+	)
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+	ctx := context.Background()
+
+	assert.Equal(t, map[string]string{"X-Tenant": "acme"}, p.StringMapEvaluation(ctx, "headers", nil, nil).Value)
+	assert.Equal(t, []string{"eu-west-1"}, p.StringSliceEvaluation(ctx, "regions", nil, nil).Value)
+	assert.Equal(t, int64(3), p.IntEvaluation(ctx, "retries", 1, nil).Value)
+
+	server.SetValue("headers", map[string]interface{}{"X-Region": "eu-west-1-long"})
+	details := p.StringMapEvaluation(ctx, "headers", nil, nil)
+	assert.Equal(t, openfeature.ParseErrorCode, details.ResolutionDetail().ErrorCode)
+	assert.Equal(t, "headers does not match its JSON Schema: headers.X-Tenant is required; headers.X-Region must be at most 8 characters long",
+		details.ResolutionDetail().ErrorMessage)
+
+	server.SetValue("regions", []interface{}{"ap-south-1"})
+	assert.Equal(t, "regions does not match its JSON Schema: regions[0] must be one of the enumerated values",
+		p.StringSliceEvaluation(ctx, "regions", nil, nil).ResolutionDetail().ErrorMessage)
+
+	server.SetValue("retries", 10)
+	intDetails := p.IntEvaluation(ctx, "retries", 1, nil)
+	assert.Equal(t, int64(1), intDetails.Value)
+	assert.Equal(t, "retries does not match its JSON Schema: retries must be <= 5", intDetails.ResolutionDetail().ErrorMessage)
+}
+
+func TestWithJSONSchema_invalid(t *testing.T) {
+	server := newFakeESCServer(t, nil)
+	for _, schema := range []string{`{"type":`, `{"properties":{"a":{"minimum":"1"}}}`, `{"$ref":"#/$defs/missing"}`} {
+		p := newTestProvider(t, server, WithJSONSchema("key", []byte(schema)))
+		assert.ErrorContains(t, p.Init(openfeature.EvaluationContext{}), "invalid JSON Schema of key: ", schema)
+	}
+}
+
+func TestPulumiESCProvider_validateSchema(t *testing.T) {
+	p := newTestProvider(t, newFakeESCServer(t, nil),
+		WithJSONSchema("key", []byte(`{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"$defs": {"ratio": {"type": "number", "exclusiveMaximum": 1, "format": "ratio"}},
+			"anyOf": [{"type": "null"}, {
+				"type": "object",
+				"properties": {"mode": {"const": "strict"}, "ratio": {"$ref": "#/$defs/ratio"}},
+				"additionalProperties": false
+			}]
+		}`)),
+		WithJSONSchema("rules", []byte(`{"type":"array","items":{"oneOf":[{"type":"string"},{"type":"object","required":["key"]}]}}`)),
+	)
+	assert.NoError(t, p.compileSchemas())
+
+	assert.NoError(t, p.validateSchema("key", nil))
+	assert.NoError(t, p.validateSchema("key", map[string]interface{}{"mode": "strict", "ratio": 0.5}))
+	assert.EqualError(t, p.validateSchema("key", map[string]interface{}{"mode": "lax", "ratio": 1.0, "secret": "value"}),
+		"key does not match its JSON Schema: key must match at least one of the anyOf schemas")
+	assert.NoError(t, p.validateSchema("rules", []interface{}{"a", map[string]interface{}{"key": "b"}}))
+	assert.EqualError(t, p.validateSchema("rules", []interface{}{"a", map[string]interface{}{"value": "secret"}}),
+		"rules does not match its JSON Schema: rules[1] must match exactly one of the oneOf schemas")
+	assert.NoError(t, p.validateSchema("unknown", "value"))
+}
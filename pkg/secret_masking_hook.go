@@ -0,0 +1,55 @@
+package pulumi
+
+import (
+	"context"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// SecretMaskingHook wraps an openfeature.Hook, e.g. a generic logging hook, and passes it evaluation
+// details in which the values of Pulumi ESC secrets are replaced with a redacted placeholder
+type SecretMaskingHook struct {
+	hook openfeature.Hook
+}
+
+// NewSecretMaskingHook returns a SecretMaskingHook wrapping the given hook
+func NewSecretMaskingHook(hook openfeature.Hook) *SecretMaskingHook {
+	return &SecretMaskingHook{hook: hook}
+}
+
+// Before calls the wrapped hook
+func (h *SecretMaskingHook) Before(ctx context.Context, hookContext openfeature.HookContext, hookHints openfeature.HookHints) (*openfeature.EvaluationContext, error) {
+	return h.hook.Before(ctx, hookContext, hookHints)
+}
+
+// After calls the wrapped hook with the secret values masked
+func (h *SecretMaskingHook) After(ctx context.Context, hookContext openfeature.HookContext, details openfeature.InterfaceEvaluationDetails, hookHints openfeature.HookHints) error {
+	return h.hook.After(ctx, hookContext, MaskSecrets(details), hookHints)
+}
+
+// Error calls the wrapped hook
+func (h *SecretMaskingHook) Error(ctx context.Context, hookContext openfeature.HookContext, err error, hookHints openfeature.HookHints) {
+	h.hook.Error(ctx, hookContext, err, hookHints)
+}
+
+// Finally calls the wrapped hook
+func (h *SecretMaskingHook) Finally(ctx context.Context, hookContext openfeature.HookContext, hookHints openfeature.HookHints) {
+	h.hook.Finally(ctx, hookContext, hookHints)
+}
+
+// MaskSecrets returns a copy of the evaluation details in which the value of a Pulumi ESC secret is
+// replaced with a redacted placeholder and the trace, which may contain the value, is removed
+func MaskSecrets(details openfeature.InterfaceEvaluationDetails) openfeature.InterfaceEvaluationDetails {
+	if secret, _ := details.FlagMetadata.GetBool("secret"); !secret {
+		return details
+	}
+	details.Value = maskedValue
+	metadata := make(openfeature.FlagMetadata, len(details.FlagMetadata))
+	for key, value := range details.FlagMetadata {
+		if key != "trace" {
+			metadata[key] = value
+		}
+	}
+	details.FlagMetadata = metadata
+	return details
+}
@@ -0,0 +1,60 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingHook struct {
+	openfeature.UnimplementedHook
+	details []openfeature.InterfaceEvaluationDetails
+}
+
+func (h *recordingHook) After(ctx context.Context, hookContext openfeature.HookContext, details openfeature.InterfaceEvaluationDetails, hookHints openfeature.HookHints) error {
+	h.details = append(h.details, details)
+	return nil
+}
+
+func TestSecretMaskingHook(t *testing.T) {
+	tests := []struct {
+		name         string
+		metadata     openfeature.FlagMetadata
+		wantValue    interface{}
+		wantMetadata openfeature.FlagMetadata
+	}{
+		{
+			name:         "secret",
+			metadata:     openfeature.FlagMetadata{"secret": true, "trace": "sk-12345"},
+			wantValue:    maskedValue,
+			wantMetadata: openfeature.FlagMetadata{"secret": true},
+		},
+		{
+			name:         "not-secret",
+			metadata:     openfeature.FlagMetadata{"secret": false, "trace": "trace"},
+			wantValue:    "sk-12345",
+			wantMetadata: openfeature.FlagMetadata{"secret": false, "trace": "trace"},
+		},
+		{
+			name:      "no-metadata",
+			wantValue: "sk-12345",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &recordingHook{}
+			details := openfeature.InterfaceEvaluationDetails{Value: "sk-12345"}
+			details.FlagMetadata = tt.metadata
+			hook := NewSecretMaskingHook(inner)
+
+			assert.NoError(t, hook.After(context.Background(), openfeature.HookContext{}, details, openfeature.HookHints{}))
+			if assert.Len(t, inner.details, 1) {
+				assert.Equal(t, tt.wantValue, inner.details[0].Value)
+				assert.Equal(t, tt.wantMetadata, inner.details[0].FlagMetadata)
+			}
+			assert.Equal(t, tt.metadata, details.FlagMetadata, "the original details must not be modified")
+		})
+	}
+}
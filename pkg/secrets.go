@@ -0,0 +1,74 @@
+package pulumi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+// WithDenySecrets makes evaluations of values which Pulumi ESC marks as secret fail instead of
+// returning the plaintext, except for the given keys, to keep secrets out of the feature flag path
+func WithDenySecrets(allowedKeys ...string) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.denySecrets = true
+		p.allowedSecrets = make(map[string]bool, len(allowedKeys))
+		for _, key := range allowedKeys {
+			p.allowedSecrets[key] = true
+		}
+	}
+}
+
+// secretDenied reports whether the secret value of the given flag must not be returned
+func (p *PulumiESCProvider) secretDenied(flag string) bool {
+	return p.denySecrets && !p.allowedSecrets[flag]
+}
+
+// secretDeniedResolution returns the resolution details of an evaluation of a denied secret
+func secretDeniedResolution(flag string) openfeature.ProviderResolutionDetail {
+	resolutionDetails := errorResolution(
+		openfeature.NewGeneralResolutionError(fmt.Sprintf("%s is a secret and secrets are denied", flag)),
+		ErrorType_SecretDenied)
+	resolutionDetails.FlagMetadata["secret"] = true
+	return resolutionDetails
+}
+
+// valueTrace returns the trace of the value. The trace of a secret only keeps the location of its
+// definition, as the base values it references may contain the plaintext.
+func valueTrace(escValue *esc.Value) esc.Trace {
+	trace := escValue.GetTrace()
+	if escValue.GetSecret() {
+		return esc.Trace{Def: trace.Def}
+	}
+	return trace
+}
+
+// typeMismatchMessage describes a value of another type than the flag type. The type of a secret
+// is not disclosed.
+func typeMismatchMessage(propertyPath string, escValue *esc.Value, rawValue interface{}, flagType FlagType) string {
+	if escValue.GetSecret() {
+		return fmt.Sprintf("%s is a secret not of type %s", propertyPath, flagType)
+	}
+	if values, ok := rawValue.([]interface{}); ok && flagType == FlagType_StringSlice {
+		for i, value := range values {
+			if _, ok := value.(string); !ok {
+				return fmt.Sprintf("%s[%d] is of type %s, not of type string", propertyPath, i, reflect.TypeOf(value))
+			}
+		}
+	}
+	if values, ok := rawValue.(map[string]interface{}); ok && flagType == FlagType_StringMap {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, ok := values[key].(string); !ok {
+				return fmt.Sprintf("%s.%s is of type %s, not of type string", propertyPath, key, reflect.TypeOf(values[key]))
+			}
+		}
+	}
+	return fmt.Sprintf("%s is of type %s, not of type %s", propertyPath, reflect.TypeOf(rawValue), flagType)
+}
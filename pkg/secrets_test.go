@@ -0,0 +1,63 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDenySecrets(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{STRING_FLAG_KEY: STRING_FLAG_VALUE})
+	server.SetSecret("configs.OPENAI_API_KEY", "sk-12345")
+	server.SetSecret("configs.GITHUB_TOKEN", "ghp-12345")
+
+	p := newTestProvider(t, server, WithDenySecrets("configs.GITHUB_TOKEN"))
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+
+	got := p.StringEvaluation(context.Background(), "configs.OPENAI_API_KEY", DEFAULT_STRING_FLAG_VALUE, nil)
+	assert.Equal(t, DEFAULT_STRING_FLAG_VALUE, got.Value)
+	assert.Equal(t, openfeature.ErrorReason, got.Reason)
+	assert.Equal(t, openfeature.GeneralCode, got.ResolutionDetail().ErrorCode)
+	assert.NotContains(t, got.ResolutionDetail().ErrorMessage, "sk-12345")
+	errorType, _ := got.FlagMetadata.GetString(errorTypeMetadataKey)
+	assert.Equal(t, string(ErrorType_SecretDenied), errorType)
+
+	got = p.StringEvaluation(context.Background(), "configs.GITHUB_TOKEN", DEFAULT_STRING_FLAG_VALUE, nil)
+	assert.Equal(t, "ghp-12345", got.Value, "explicitly allowed secrets must be returned")
+
+	got = p.StringEvaluation(context.Background(), STRING_FLAG_KEY, DEFAULT_STRING_FLAG_VALUE, nil)
+	assert.Equal(t, STRING_FLAG_VALUE, got.Value)
+}
+
+func TestSecretRedaction(t *testing.T) {
+	server := newFakeESCServer(t, nil)
+	secret := true
+	def := esc.Range{Environment: ENV_NAME}
+	base := esc.Value{Value: "sk-12345"}
+	server.SetESCValue("configs.OPENAI_API_KEY", esc.Value{Value: "sk-12345", Secret: &secret, Trace: esc.Trace{Def: &def, Base: &base}})
+	server.SetESCValue("configs.PLAIN", esc.Value{Value: "plain", Trace: esc.Trace{Def: &def, Base: &base}})
+
+	p := newTestProvider(t, server)
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+
+	got := p.StringEvaluation(context.Background(), "configs.OPENAI_API_KEY", DEFAULT_STRING_FLAG_VALUE, nil)
+	assert.Equal(t, "sk-12345", got.Value)
+	trace, ok := got.FlagMetadata["trace"].(esc.Trace)
+	if assert.True(t, ok) {
+		assert.Nil(t, trace.Base, "the trace of a secret must not contain base values")
+		assert.Equal(t, ENV_NAME, trace.Def.Environment)
+	}
+
+	got = p.StringEvaluation(context.Background(), "configs.PLAIN", DEFAULT_STRING_FLAG_VALUE, nil)
+	trace, ok = got.FlagMetadata["trace"].(esc.Trace)
+	if assert.True(t, ok) {
+		assert.NotNil(t, trace.Base)
+	}
+
+	mismatch := p.BooleanEvaluation(context.Background(), "configs.OPENAI_API_KEY", false, nil)
+	assert.Equal(t, openfeature.TypeMismatchCode, mismatch.ResolutionDetail().ErrorCode)
+	assert.Equal(t, "configs.OPENAI_API_KEY is a secret not of type bool", mismatch.ResolutionDetail().ErrorMessage)
+}
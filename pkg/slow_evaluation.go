@@ -0,0 +1,33 @@
+package pulumi
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// WithSlowEvaluationThreshold logs a warning using slog.Default() whenever a single evaluation takes longer
+// than the threshold, with the flag key, the duration of the evaluation and whether the value was read from
+// the Pulumi ESC API, to catch latency regressions hidden by averages
+func WithSlowEvaluationThreshold(threshold time.Duration) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		if threshold > 0 {
+			p.slowThreshold = threshold
+		}
+	}
+}
+
+// warnSlowEvaluation logs the evaluation of the flag started at start if it exceeded the threshold. The cache
+// status is read once the evaluation completed.
+func (p *PulumiESCProvider) warnSlowEvaluation(ctx context.Context, flag string, start time.Time, cacheStatus *CacheStatus) {
+	duration := time.Since(start)
+	if duration <= p.slowThreshold {
+		return
+	}
+	slog.Default().LogAttrs(ctx, slog.LevelWarn, "slow flag evaluation",
+		slog.String("key", flag),
+		slog.Duration("duration", duration),
+		slog.Duration("threshold", p.slowThreshold),
+		slog.Bool("network", *cacheStatus == CacheStatus_Miss || *cacheStatus == CacheStatus_Bypass),
+	)
+}
@@ -0,0 +1,32 @@
+package pulumi
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSlowEvaluationThreshold(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	p := newTestProvider(t, server, WithSlowEvaluationThreshold(20*time.Millisecond))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+	assert.Empty(t, buf.String(), "fast evaluations must not be logged")
+
+	server.SetLatency(50 * time.Millisecond)
+	p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+	assert.Contains(t, buf.String(), `level=WARN msg="slow flag evaluation" key=DEBUG_MODE`)
+	assert.Contains(t, buf.String(), "threshold=20ms network=true")
+}
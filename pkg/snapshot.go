@@ -0,0 +1,213 @@
+package pulumi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+// WithSnapshotPath persists the most recent successfully read environment snapshot to the given file.
+// If the Pulumi ESC API is unavailable on startup, the provider loads the snapshot and serves its
+// values in STALE state instead of failing, and it falls back to the snapshot whenever a read fails.
+func WithSnapshotPath(path string) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.snapshotPath = path
+	}
+}
+
+// environmentSnapshot holds the values of an open environment
+type environmentSnapshot struct {
+	Values map[string]interface{} `json:"values"`
+	// Secrets are the property paths of the values which Pulumi ESC marks as secret
+	Secrets []string `json:"secrets,omitempty"`
+	// Revision is the environment revision the snapshot was read at, or 0 if it is unknown
+	Revision int32 `json:"revision,omitempty"`
+	// Organization is the organization the snapshot was read from
+	Organization string `json:"organization,omitempty"`
+	// Environment is the project and environment the snapshot was read from, e.g. "my-project/dev"
+	Environment string    `json:"environment,omitempty"`
+	SavedAt     time.Time `json:"savedAt"`
+}
+
+// newEnvironmentSnapshot returns the snapshot of an environment read using ReadOpenEnvironment
+func newEnvironmentSnapshot(env *esc.Environment, values map[string]interface{}, revision int32) *environmentSnapshot {
+	snapshot := &environmentSnapshot{Values: values, Revision: revision, SavedAt: time.Now()}
+	if env != nil && env.Properties != nil {
+		for key, value := range *env.Properties {
+			snapshot.Secrets = collectSecrets(snapshot.Secrets, QuoteKey(key), value)
+		}
+	}
+	return snapshot
+}
+
+// collectSecrets appends the paths of the secrets of the given value and its nested values
+func collectSecrets(secrets []string, path string, value esc.Value) []string {
+	if value.GetSecret() {
+		return append(secrets, path)
+	}
+	if nested, ok := value.Value.(map[string]esc.Value); ok {
+		for key, nestedValue := range nested {
+			secrets = collectSecrets(secrets, joinPropertyPath(path, key), nestedValue)
+		}
+	}
+	return secrets
+}
+
+// lookup returns the value of the given property path
+func (s *environmentSnapshot) lookup(propertyPath string) (*esc.Value, interface{}, bool) {
+	if strings.ContainsAny(propertyPath, `["`) {
+		return s.lookupPath(propertyPath)
+	}
+	var value interface{} = s.Values
+	// The path is walked without splitting it, as lookups serve evaluations
+	for path, more := propertyPath, true; more; {
+		var key string
+		key, path, more = strings.Cut(path, ".")
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, nil, false
+		}
+		if value, ok = values[key]; !ok {
+			return nil, nil, false
+		}
+	}
+	secret := s.isSecret(propertyPath)
+	return &esc.Value{Value: value, Secret: &secret}, value, true
+}
+
+// lookupPath returns the value of the given property path with quoted keys or array indexes
+func (s *environmentSnapshot) lookupPath(propertyPath string) (*esc.Value, interface{}, bool) {
+	keys, err := parsePropertyPath(propertyPath)
+	if err != nil {
+		return nil, nil, false
+	}
+	var value interface{} = s.Values
+	for _, key := range keys {
+		switch key := key.(type) {
+		case string:
+			values, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, nil, false
+			}
+			if value, ok = values[key]; !ok {
+				return nil, nil, false
+			}
+		case int:
+			items, ok := value.([]interface{})
+			if !ok || key >= len(items) {
+				return nil, nil, false
+			}
+			value = items[key]
+		}
+	}
+	// Secrets are recorded with the keys quoted only when needed
+	secret := s.isSecret(formatPropertyPath(keys))
+	return &esc.Value{Value: value, Secret: &secret}, value, true
+}
+
+// isSecret reports whether the value of the given property path is or is nested in a secret
+func (s *environmentSnapshot) isSecret(propertyPath string) bool {
+	for _, secret := range s.Secrets {
+		if withinPath(propertyPath, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadSnapshot loads the snapshot persisted by a previous run. Snapshots of another environment than the
+// configured one, e.g. left by a previous configuration sharing the snapshot path, are refused.
+func (p *PulumiESCProvider) loadSnapshot() error {
+	data, err := p.snapshots.read()
+	if err != nil {
+		return err
+	}
+	var snapshot environmentSnapshot
+	if err := decodeJSON(data, &snapshot); err != nil {
+		return fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	projectName, envName, _ := p.environment()
+	if snapshot.Organization != p.orgName || snapshot.Environment != projectName+"/"+envName {
+		return fmt.Errorf("snapshot of %s/%s does not match the environment %s/%s/%s",
+			snapshot.Organization, snapshot.Environment, p.orgName, projectName, envName)
+	}
+	snapshot.Values = normaliseValues(snapshot.Values)
+	p.snapshot.Store(&snapshot)
+	return nil
+}
+
+// refreshSnapshot reads the open environment and persists it as the last known good snapshot
+func (p *PulumiESCProvider) refreshSnapshot(ctx context.Context) error {
+	snapshot, err := p.readEnvironment(ctx)
+	if err != nil {
+		return err
+	}
+	return p.saveSnapshot(snapshot)
+}
+
+// readEnvironment reads all the values of the open environment
+func (p *PulumiESCProvider) readEnvironment(ctx context.Context) (*environmentSnapshot, error) {
+	reqCtx, cancel := p.requestContext(ctx)
+	defer cancel()
+	reqCtx, apiError := recordResponses(reqCtx)
+	revision := p.Revision()
+	projectName, envName, sessionID := p.environment()
+	env, values, err := p.escClient.ReadOpenEnvironment(reqCtx, p.orgName, projectName, envName, sessionID)
+	err = apiError(err)
+	if isSessionExpiredErr(err) {
+		err = withSentinel(err, ErrSessionExpired)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pulumi esc environment: %w", classifyAPIError(err))
+	}
+	return p.newSnapshot(env, values, revision, projectName, envName), nil
+}
+
+// saveSnapshot keeps the snapshot in memory and persists it
+func (p *PulumiESCProvider) saveSnapshot(snapshot *environmentSnapshot) error {
+	p.snapshot.Store(snapshot)
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	return p.snapshots.write(data)
+}
+
+// snapshotValue returns the value of the given property path from the last known good snapshot
+func (p *PulumiESCProvider) snapshotValue(propertyPath string) (*esc.Value, interface{}, bool) {
+	snapshot := p.snapshot.Load()
+	if snapshot == nil {
+		return nil, nil, false
+	}
+	return snapshot.lookup(propertyPath)
+}
+
+// evaluationSnapshotKey is the context key of the snapshot the evaluations of a request are served from
+type evaluationSnapshotKey struct{}
+
+// withEvaluationSnapshot returns a context whose evaluations read their values from the given snapshot
+// instead of the Pulumi ESC API, so evaluating many flags costs a single read of the environment
+func withEvaluationSnapshot(ctx context.Context, snapshot *environmentSnapshot) context.Context {
+	return context.WithValue(ctx, evaluationSnapshotKey{}, snapshot)
+}
+
+// evaluationSnapshot returns the snapshot evaluations of the request are served from, if any
+func evaluationSnapshot(ctx context.Context) (*environmentSnapshot, bool) {
+	snapshot, ok := ctx.Value(evaluationSnapshotKey{}).(*environmentSnapshot)
+	return snapshot, ok
+}
+
+// currentSnapshot returns the snapshot evaluations are served from with CacheFillPolicy_SnapshotOnly, or
+// reads the environment
+func (p *PulumiESCProvider) currentSnapshot(ctx context.Context) (*environmentSnapshot, error) {
+	if p.snapshotOnly() {
+		if snapshot := p.snapshot.Load(); snapshot != nil {
+			return snapshot, nil
+		}
+	}
+	return p.readEnvironment(ctx)
+}
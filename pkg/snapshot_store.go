@@ -0,0 +1,108 @@
+package pulumi
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// encryptedSnapshotHeader prefixes snapshot files encrypted using AES-GCM
+var encryptedSnapshotHeader = []byte("pulumi-esc-snapshot:aes-gcm:v1\n")
+
+// WithSnapshotEncryptionKey encrypts the environment snapshot persisted on disk using AES-GCM with
+// the given key, so flag values, which may include secrets, are never written in plaintext.
+// The key must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+func WithSnapshotEncryptionKey(key []byte) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.snapshotKey = key
+	}
+}
+
+// snapshotStore reads and writes a snapshot file, encrypted if an AEAD is set
+type snapshotStore struct {
+	path string
+	aead cipher.AEAD
+}
+
+func newSnapshotStore(path string, key []byte) (*snapshotStore, error) {
+	store := &snapshotStore{path: path}
+	if key != nil {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot encryption key: %w", err)
+		}
+		if store.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, fmt.Errorf("invalid snapshot encryption key: %w", err)
+		}
+	}
+	return store, nil
+}
+
+// write replaces the snapshot file atomically, so a crash never leaves a partial snapshot behind
+func (s *snapshotStore) write(data []byte) error {
+	if s.aead != nil {
+		nonce := make([]byte, s.aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return fmt.Errorf("failed to generate snapshot nonce: %w", err)
+		}
+		sealed := append([]byte(nil), encryptedSnapshotHeader...)
+		sealed = append(sealed, nonce...)
+		data = s.aead.Seal(sealed, nonce, data, encryptedSnapshotHeader)
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file renamed to the given path, so readers never
+// observe a partially written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// read returns the content of the snapshot file. An encrypted snapshot can only be read with the key
+// it was written with, and a plaintext snapshot is rejected if a key is set.
+func (s *snapshotStore) read() ([]byte, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	encrypted := bytes.HasPrefix(data, encryptedSnapshotHeader)
+	switch {
+	case s.aead == nil && encrypted:
+		return nil, errors.New("failed to read snapshot: snapshot is encrypted but no encryption key is set")
+	case s.aead == nil:
+		return data, nil
+	case !encrypted:
+		return nil, errors.New("failed to read snapshot: snapshot is not encrypted")
+	}
+	data = data[len(encryptedSnapshotHeader):]
+	if len(data) < s.aead.NonceSize() {
+		return nil, errors.New("failed to read snapshot: snapshot is truncated")
+	}
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, encryptedSnapshotHeader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt snapshot: %w", err)
+	}
+	return plaintext, nil
+}
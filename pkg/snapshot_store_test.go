@@ -0,0 +1,58 @@
+package pulumi
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotStore(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	snapshot := []byte(`{"configs":{"OPENAI_API_KEY":"sk-12345"}}`)
+
+	t.Run("encrypted", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot.json")
+		store, err := newSnapshotStore(path, key)
+		assert.NoError(t, err)
+		assert.NoError(t, store.write(snapshot))
+
+		onDisk, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.NotContains(t, string(onDisk), "sk-12345")
+
+		got, err := store.read()
+		assert.NoError(t, err)
+		assert.Equal(t, snapshot, got)
+
+		otherKey, _ := newSnapshotStore(path, bytes.Repeat([]byte{2}, 32))
+		_, err = otherKey.read()
+		assert.ErrorContains(t, err, "failed to decrypt snapshot")
+
+		noKey, _ := newSnapshotStore(path, nil)
+		_, err = noKey.read()
+		assert.ErrorContains(t, err, "no encryption key is set")
+	})
+
+	t.Run("plaintext", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot.json")
+		store, err := newSnapshotStore(path, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, store.write(snapshot))
+
+		got, err := store.read()
+		assert.NoError(t, err)
+		assert.Equal(t, snapshot, got)
+
+		encrypted, _ := newSnapshotStore(path, key)
+		_, err = encrypted.read()
+		assert.ErrorContains(t, err, "snapshot is not encrypted")
+	})
+
+	t.Run("invalid-key", func(t *testing.T) {
+		_, err := newSnapshotStore("snapshot.json", []byte("short"))
+		assert.ErrorContains(t, err, "invalid snapshot encryption key")
+	})
+}
@@ -0,0 +1,117 @@
+package pulumi
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSnapshotPath(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{BOOL_FLAG_KEY: BOOL_FLAG_VALUE})
+	server.SetSecret("configs.OPENAI_API_KEY", "sk-12345")
+	server.SetValue("configs.DEBUG_MODE", true)
+	path := filepath.Join(t.TempDir(), "snapshot")
+	key := bytes.Repeat([]byte{1}, 32)
+
+	p := newTestProvider(t, server, WithSnapshotPath(path), WithSnapshotEncryptionKey(key))
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "the snapshot must be persisted after the session is opened")
+	assert.NoError(t, p.ShutdownWithContext(context.Background()))
+
+	server.SetUnavailable(true)
+	p = newTestProvider(t, server, WithSnapshotPath(path), WithSnapshotEncryptionKey(key))
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}), "the provider must start from the snapshot while the api is down")
+	assert.Equal(t, openfeature.StaleState, p.Status())
+
+	gotBool := p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, false, nil)
+	assert.Equal(t, BOOL_FLAG_VALUE, gotBool.Value)
+	assert.Equal(t, openfeature.CachedReason, gotBool.Reason)
+	cacheStatus, _ := gotBool.FlagMetadata.GetString(cacheMetadataKey)
+	assert.Equal(t, string(CacheStatus_Stale), cacheStatus)
+
+	gotBool = p.BooleanEvaluation(context.Background(), "configs.DEBUG_MODE", false, nil)
+	assert.Equal(t, true, gotBool.Value)
+
+	gotString := p.StringEvaluation(context.Background(), "configs.OPENAI_API_KEY", DEFAULT_STRING_FLAG_VALUE, nil)
+	assert.Equal(t, "sk-12345", gotString.Value)
+	isSecret, _ := gotString.FlagMetadata.GetBool("secret")
+	assert.True(t, isSecret, "secrets must stay marked as secret in the snapshot")
+
+	gotString = p.StringEvaluation(context.Background(), NON_EXISTING_FLAG_KEY, DEFAULT_STRING_FLAG_VALUE, nil)
+	assert.Equal(t, DEFAULT_STRING_FLAG_VALUE, gotString.Value)
+
+	server.SetUnavailable(false)
+	p.stateMu.Lock()
+	p.lastSessionAttempt = time.Now().Add(-recoveryInterval)
+	p.stateMu.Unlock()
+	gotBool = p.BooleanEvaluation(context.Background(), BOOL_FLAG_KEY, false, nil)
+	assert.Equal(t, openfeature.StaticReason, gotBool.Reason)
+	assert.Equal(t, openfeature.ReadyState, p.Status())
+}
+
+func TestWithSnapshotPath_otherEnvironment(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{BOOL_FLAG_KEY: BOOL_FLAG_VALUE})
+	path := filepath.Join(t.TempDir(), "snapshot")
+
+	p := newTestProvider(t, server, WithSnapshotPath(path))
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, p.ShutdownWithContext(context.Background()))
+
+	server.SetUnavailable(true)
+	other := newPulumiESCProvider("test-org", PROJECT_NAME, "other-env", WithSnapshotPath(path))
+	other.escClient, other.escAuthCtx = p.escClient, p.escAuthCtx
+	t.Cleanup(other.Shutdown)
+	assert.Error(t, other.Init(openfeature.EvaluationContext{}), "the snapshot of another environment must not be served")
+
+	other = newPulumiESCProvider("other-org", PROJECT_NAME, ENV_NAME, WithSnapshotPath(path))
+	other.escClient, other.escAuthCtx = p.escClient, p.escAuthCtx
+	t.Cleanup(other.Shutdown)
+	assert.Error(t, other.Init(openfeature.EvaluationContext{}), "the snapshot of another organization must not be served")
+
+	p = newTestProvider(t, server, WithSnapshotPath(path))
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+	assert.Equal(t, openfeature.StaleState, p.Status())
+}
+
+func TestWithSnapshotPath_NoSnapshot(t *testing.T) {
+	server := newFakeESCServer(t, nil)
+	server.SetUnavailable(true)
+	p := newTestProvider(t, server, WithSnapshotPath(filepath.Join(t.TempDir(), "snapshot")))
+	assert.Error(t, p.Init(openfeature.EvaluationContext{}))
+}
+
+func TestEnvironmentSnapshot_lookup(t *testing.T) {
+	snapshot := &environmentSnapshot{
+		Values: map[string]interface{}{
+			"configs": map[string]interface{}{"DEBUG_MODE": true, "aws": map[string]interface{}{"key": "secret"}},
+		},
+		Secrets: []string{"configs.aws"},
+	}
+	escValue, rawValue, ok := snapshot.lookup("configs.DEBUG_MODE")
+	assert.True(t, ok)
+	assert.Equal(t, true, rawValue)
+	assert.False(t, escValue.GetSecret())
+
+	escValue, rawValue, ok = snapshot.lookup("configs.aws.key")
+	assert.True(t, ok)
+	assert.Equal(t, "secret", rawValue)
+	assert.True(t, escValue.GetSecret(), "values nested in a secret are secret")
+
+	_, _, ok = snapshot.lookup("configs.DEBUG_MODE.nested")
+	assert.False(t, ok)
+	_, _, ok = snapshot.lookup("missing")
+	assert.False(t, ok)
+}
@@ -0,0 +1,210 @@
+package pulumi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+const (
+	// recoveryInterval is the minimum time between two attempts to reopen the environment
+	// session while the provider is in error state
+	recoveryInterval = 5 * time.Second
+	// maxRecoveryInterval bounds the exponential backoff of recoveryInterval after consecutive failed attempts
+	maxRecoveryInterval = time.Minute
+)
+
+// errNoSession is returned for reads while no environment session is open, e.g. after the provider
+// started from the last known good snapshot
+var errNoSession = errors.New("no open pulumi esc environment session")
+
+// Status expose the status of the provider
+func (p *PulumiESCProvider) Status() openfeature.State {
+	p.stateMu.RLock()
+	defer p.stateMu.RUnlock()
+	return p.state
+}
+
+// setState transitions the provider to the given state
+func (p *PulumiESCProvider) setState(state openfeature.State) {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	p.state = state
+}
+
+// sessionID returns the id of the open environment session used for reads
+func (p *PulumiESCProvider) sessionID() string {
+	p.stateMu.RLock()
+	defer p.stateMu.RUnlock()
+	return p.escOpenEnvSessionId
+}
+
+// environment returns the project and environment names of the provider and the id of its open session.
+// They are read together, so reads are not sent to the session of another environment while
+// SwitchEnvironment swaps it.
+func (p *PulumiESCProvider) environment() (projectName, envName, sessionID string) {
+	p.stateMu.RLock()
+	defer p.stateMu.RUnlock()
+	return p.projectName, p.envName, p.escOpenEnvSessionId
+}
+
+// environmentPath returns the path of the environment of the provider, e.g. "my-org/my-project/dev"
+func (p *PulumiESCProvider) environmentPath() string {
+	projectName, envName, _ := p.environment()
+	return p.orgName + "/" + projectName + "/" + envName
+}
+
+// openEnvironment opens a session of the given environment at its latest revision, so the revision of the
+// served values is known, and returns the session and its revision. The requests are made with the given
+// request context.
+func (p *PulumiESCProvider) openEnvironment(ctx context.Context, projectName, envName string) (*esc.OpenEnvironment, int32, error) {
+	return p.openEnvironmentAt(ctx, projectName, envName, p.latestRevision(ctx, projectName, envName))
+}
+
+// openEnvironmentAt opens a session of the given environment at the given revision, or at the latest
+// revision if it is unknown
+func (p *PulumiESCProvider) openEnvironmentAt(ctx context.Context, projectName, envName string, revision int32) (*esc.OpenEnvironment, int32, error) {
+	ctx, apiError := recordResponses(ctx)
+	if revision > 0 {
+		env, err := p.escClient.OpenEnvironmentAtVersion(ctx, p.orgName, projectName, envName, formatRevision(revision))
+		return env, revision, apiError(err)
+	}
+	env, err := p.escClient.OpenEnvironment(ctx, p.orgName, projectName, envName)
+	return env, revision, apiError(err)
+}
+
+// openSession opens a new environment session at the latest revision and transitions the provider to ready state.
+// If the environment can not be opened, the provider transitions to error state.
+func (p *PulumiESCProvider) openSession() error {
+	projectName, envName, _ := p.environment()
+	return p.openSessionAt(projectName, envName, p.latestRevision(p.escAuthCtx, projectName, envName))
+}
+
+// openSessionAt opens a new session of the environment at the given revision, or at the latest revision
+// if it is unknown, like openSession
+func (p *PulumiESCProvider) openSessionAt(projectName, envName string, revision int32) error {
+	env, revision, err := p.openEnvironmentAt(p.escAuthCtx, projectName, envName, revision)
+
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	if projectName != p.projectName || envName != p.envName {
+		// The environment was switched while the session was being opened
+		return nil
+	}
+	p.lastSessionAttempt = time.Now()
+	if err != nil {
+		p.sessionFailures++
+		err = fmt.Errorf("failed to open pulumi esc environment: %w", classifyAPIError(err))
+		if p.snapshot.Load() != nil && !isAuthErr(err) {
+			// The last known good snapshot is served until the environment can be opened again
+			p.transitionLocked(openfeature.StaleState, err.Error())
+		} else {
+			p.transitionLocked(openfeature.ErrorState, err.Error())
+		}
+		return err
+	}
+	p.escOpenEnvSessionId = env.Id
+	p.revision = revision
+	p.sessionFailures = 0
+	p.transitionLocked(openfeature.ReadyState, "pulumi esc environment session opened")
+	if p.vars != nil {
+		p.vars.recordRefresh()
+	}
+	if ctx := p.lifecycleContext(); p.snapshots != nil && ctx != nil && ctx.Err() == nil {
+		p.goBackground(func(ctx context.Context) { _ = p.refreshSnapshot(ctx) })
+	}
+	return nil
+}
+
+// tryRecover reopens the environment session if the provider is in error state and the last
+// attempt is older than recoveryInterval, doubled after every consecutive failed attempt up to
+// maxRecoveryInterval. Only one caller reopens the session at a time, the others do not wait for it.
+// It reports whether the provider is ready to serve reads.
+func (p *PulumiESCProvider) tryRecover() bool {
+	p.stateMu.Lock()
+	state := p.state
+	attempt := false
+	if state == openfeature.ErrorState || (state == openfeature.StaleState && p.escOpenEnvSessionId == "") {
+		attempt = !p.recovering && time.Since(p.lastSessionAttempt) >= p.recoveryDelayLocked()
+		p.recovering = p.recovering || attempt
+	}
+	p.stateMu.Unlock()
+
+	recovered := false
+	if attempt {
+		recovered = p.openSession() == nil
+		p.stateMu.Lock()
+		p.recovering = false
+		p.stateMu.Unlock()
+	}
+	switch state {
+	case openfeature.ReadyState, openfeature.StaleState:
+		return true
+	case openfeature.ErrorState:
+		return recovered
+	}
+	return false
+}
+
+// recoveryDelayLocked returns the minimum time since the last attempt to reopen the environment session
+// before the next one. The caller must hold stateMu.
+func (p *PulumiESCProvider) recoveryDelayLocked() time.Duration {
+	return min(recoveryInterval<<min(max(p.sessionFailures-1, 0), 4), maxRecoveryInterval)
+}
+
+// updateStateAfterRead transitions the provider according to the outcome of a read:
+// READY → STALE on transport or server errors, STALE → READY on success,
+// and to ERROR when the access token is rejected
+func (p *PulumiESCProvider) updateStateAfterRead(err error) {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	if p.state != openfeature.ReadyState && p.state != openfeature.StaleState {
+		return
+	}
+	if err == nil {
+		p.transitionLocked(openfeature.ReadyState, "pulumi esc api recovered")
+		return
+	}
+	var genErr *esc.GenericOpenAPIError
+	if !errors.As(err, &genErr) {
+		if !errors.Is(err, errRateLimited) {
+			// Transport errors, e.g. the Pulumi ESC API is unreachable
+			p.transitionLocked(openfeature.StaleState, err.Error())
+		}
+		return
+	}
+	switch statusCode := apiStatusCode(genErr); {
+	case statusCode == http.StatusUnauthorized, statusCode == http.StatusForbidden:
+		p.transitionLocked(openfeature.ErrorState, err.Error())
+	case statusCode >= http.StatusInternalServerError:
+		p.transitionLocked(openfeature.StaleState, err.Error())
+	}
+}
+
+// isCallerCancellation determines whether a read failed because the context of the evaluation was cancelled
+// or expired, e.g. by the evaluation timeout, which says nothing about the health of the Pulumi ESC API
+func isCallerCancellation(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// isAuthErr determines whether the given error indicates that the access token was rejected
+func isAuthErr(err error) bool {
+	var genErr *esc.GenericOpenAPIError
+	if !errors.As(err, &genErr) {
+		return false
+	}
+	statusCode := apiStatusCode(genErr)
+	return statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden
+}
+
+// isSessionExpiredErr determines whether the given error indicates that the open environment
+// session no longer exists and must be reopened
+func isSessionExpiredErr(err error) bool {
+	var genErr *esc.GenericOpenAPIError
+	return errors.As(err, &genErr) && apiStatusCode(genErr) == http.StatusNotFound
+}
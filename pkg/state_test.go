@@ -0,0 +1,167 @@
+package pulumi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPulumiESCProvider_updateStateAfterRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		state openfeature.State
+		err   error
+		want  openfeature.State
+	}{
+		{name: "ready-success", state: openfeature.ReadyState, err: nil, want: openfeature.ReadyState},
+		{name: "stale-success", state: openfeature.StaleState, err: nil, want: openfeature.ReadyState},
+		{name: "ready-transport-error", state: openfeature.ReadyState, err: errors.New("connection refused"), want: openfeature.StaleState},
+		{name: "ready-client-rate-limited", state: openfeature.ReadyState, err: errRateLimited, want: openfeature.ReadyState},
+		{name: "ready-server-error", state: openfeature.ReadyState, err: apiError(t, http.StatusBadGateway), want: openfeature.StaleState},
+		{name: "ready-unauthorized", state: openfeature.ReadyState, err: apiError(t, http.StatusUnauthorized), want: openfeature.ErrorState},
+		{name: "stale-forbidden", state: openfeature.StaleState, err: apiError(t, http.StatusForbidden), want: openfeature.ErrorState},
+		{name: "ready-not-found", state: openfeature.ReadyState, err: apiError(t, http.StatusBadRequest), want: openfeature.ReadyState},
+		{name: "not-ready-success", state: openfeature.NotReadyState, err: nil, want: openfeature.NotReadyState},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PulumiESCProvider{state: tt.state}
+			p.updateStateAfterRead(tt.err)
+			assert.Equal(t, tt.want, p.Status())
+		})
+	}
+}
+
+func TestPulumiESCProvider_transitionLocked_fullBuffer(t *testing.T) {
+	p := &PulumiESCProvider{state: openfeature.ReadyState, events: make(chan openfeature.Event, eventBufferSize)}
+	states := []openfeature.State{openfeature.StaleState, openfeature.ReadyState}
+	for i := 0; i <= eventBufferSize; i++ {
+		p.transitionLocked(states[i%2], "")
+	}
+	p.transitionLocked(openfeature.ErrorState, "unauthorized")
+
+	require.Len(t, p.events, eventBufferSize, "events must not block the provider")
+	var last openfeature.Event
+	for len(p.events) > 0 {
+		last = <-p.events
+	}
+	assert.Equal(t, openfeature.ProviderError, last.EventType, "the latest state must be delivered")
+	assert.Equal(t, "unauthorized", last.Message)
+}
+
+func TestPulumiESCProvider_openSession(t *testing.T) {
+	var available atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !available.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"code":503,"message":"unavailable"}`))
+			return
+		}
+		w.Write([]byte(`{"id":"session-id"}`))
+	}))
+	defer server.Close()
+
+	conf := esc.NewConfiguration()
+	conf.Servers = esc.ServerConfigurations{{URL: server.URL + "/api/esc"}}
+	p := &PulumiESCProvider{
+		state:       openfeature.NotReadyState,
+		orgName:     "test-org",
+		projectName: PROJECT_NAME,
+		envName:     ENV_NAME,
+		escClient:   newESCClient(conf),
+		escAuthCtx:  esc.NewAuthContext("token"),
+	}
+
+	assert.Error(t, p.openSession())
+	assert.Equal(t, openfeature.ErrorState, p.Status())
+	assert.False(t, p.tryRecover(), "recovery must not be attempted before recoveryInterval")
+
+	available.Store(true)
+	p.lastSessionAttempt = time.Now().Add(-recoveryInterval)
+	assert.True(t, p.tryRecover())
+	assert.Equal(t, openfeature.ReadyState, p.Status())
+	assert.Equal(t, "session-id", p.sessionID())
+}
+
+// slowOpenESCClient is an ESCClient counting the environment sessions opened, which take some time
+type slowOpenESCClient struct {
+	ESCClient
+	opens atomic.Int32
+}
+
+func (c *slowOpenESCClient) OpenEnvironment(ctx context.Context, org, projectName, envName string) (*esc.OpenEnvironment, error) {
+	c.opens.Add(1)
+	time.Sleep(50 * time.Millisecond)
+	return c.ESCClient.OpenEnvironment(ctx, org, projectName, envName)
+}
+
+func TestPulumiESCProvider_tryRecover(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"someFlag": true})
+	p := newTestProvider(t, server)
+	client := &slowOpenESCClient{ESCClient: p.escClient}
+	p.escClient = client
+
+	server.SetUnavailable(true)
+	require.Error(t, p.openSession())
+	require.Equal(t, openfeature.ErrorState, p.Status())
+
+	t.Run("single flight", func(t *testing.T) {
+		client.opens.Store(0)
+		p.lastSessionAttempt = time.Now().Add(-recoveryInterval)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p.BooleanEvaluation(context.Background(), "someFlag", false, nil)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), client.opens.Load(), "concurrent evaluations must reopen the session once")
+		assert.Equal(t, openfeature.ErrorState, p.Status())
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		client.opens.Store(0)
+		p.lastSessionAttempt = time.Now().Add(-recoveryInterval)
+		assert.False(t, p.tryRecover())
+		assert.Zero(t, client.opens.Load(), "recovery must back off after consecutive failed attempts")
+
+		server.SetUnavailable(false)
+		p.lastSessionAttempt = time.Now().Add(-2 * recoveryInterval)
+		assert.True(t, p.tryRecover())
+		assert.Equal(t, int32(1), client.opens.Load())
+		assert.Equal(t, openfeature.ReadyState, p.Status())
+		assert.Zero(t, p.sessionFailures, "the backoff must be reset once the session is reopened")
+	})
+}
+
+func TestPulumiESCProvider_cancelledEvaluation(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"someFlag": true})
+	ctx := context.Background()
+	p := newTestProvider(t, server)
+	require.NoError(t, p.initialise(ctx))
+	server.SetLatency(time.Second)
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	time.AfterFunc(50*time.Millisecond, cancel)
+	got := p.BooleanEvaluation(cancelCtx, "someFlag", false, nil)
+	assert.Error(t, got.Error())
+	assert.Equal(t, openfeature.ReadyState, p.Status(), "a cancelled evaluation must not make the provider stale")
+
+	deadlineCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	p.BooleanEvaluation(deadlineCtx, "someFlag", false, nil)
+	assert.Equal(t, openfeature.ReadyState, p.Status(), "an expired evaluation must not make the provider stale")
+}
@@ -0,0 +1,67 @@
+package pulumi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+)
+
+// staticEnvName is the organisation, project and environment name of static providers
+const staticEnvName = "static"
+
+// errKeyNotFound is returned by in-memory clients for properties missing from the environment
+var errKeyNotFound = errors.New("key not found")
+
+// staticClient is an ESCClient serving an in-memory environment
+type staticClient struct {
+	snapshot *environmentSnapshot
+}
+
+// NewStaticProvider returns a provider evaluating flags against the given in-memory values instead of
+// a Pulumi ESC environment, with the same type validation, errors and flag metadata. Keys are resolved
+// like Pulumi ESC property paths, so nested values of objects are evaluated using dotted keys.
+// It is meant for local development and tests.
+func NewStaticProvider(values map[string]interface{}, opts ...ProviderOption) (*PulumiESCProvider, error) {
+	// Values are normalised to the types decoded from Pulumi ESC API responses, e.g. float64 for numbers
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode static values: %w", err)
+	}
+	normalised, err := decodeValues(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode static values: %w", err)
+	}
+	if normalised == nil {
+		normalised = map[string]interface{}{}
+	}
+	client := &staticClient{snapshot: newEnvironmentSnapshot(nil, normalised, 0)}
+	return NewPulumiESCProvider(staticEnvName, staticEnvName, staticEnvName, "", append(opts, WithESCClient(client))...)
+}
+
+func (c *staticClient) OpenEnvironment(ctx context.Context, org, projectName, envName string) (*esc.OpenEnvironment, error) {
+	return &esc.OpenEnvironment{Id: staticEnvName}, nil
+}
+
+func (c *staticClient) OpenEnvironmentAtVersion(ctx context.Context, org, projectName, envName, version string) (*esc.OpenEnvironment, error) {
+	return &esc.OpenEnvironment{Id: staticEnvName}, nil
+}
+
+func (c *staticClient) ReadOpenEnvironment(ctx context.Context, org, projectName, envName, openEnvID string) (*esc.Environment, map[string]interface{}, error) {
+	return &esc.Environment{}, copyValues(c.snapshot.Values), nil
+}
+
+func (c *staticClient) ReadEnvironmentProperty(ctx context.Context, org, projectName, envName, openEnvID, propPath string) (*esc.Value, interface{}, error) {
+	value, rawValue, ok := c.snapshot.lookup(propPath)
+	if !ok {
+		return nil, nil, errKeyNotFound
+	}
+	value.Trace.Def = &esc.Range{Environment: envName}
+	return value, rawValue, nil
+}
+
+func (c *staticClient) ListEnvironmentRevisions(ctx context.Context, org, projectName, envName string, count int32) ([]esc.EnvironmentRevision, error) {
+	return nil, nil
+}
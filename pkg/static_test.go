@@ -0,0 +1,48 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStaticProvider(t *testing.T) {
+	p, err := NewStaticProvider(map[string]interface{}{
+		BOOL_FLAG_KEY: BOOL_FLAG_VALUE,
+		INT_FLAG_KEY:  INT_FLAG_VALUE,
+		"configs":     map[string]interface{}{"MAX_RETRIES": 3},
+	})
+	assert.NoError(t, err)
+	defer p.Shutdown()
+	ctx := context.Background()
+	evalCtx := openfeature.FlattenedContext{}
+
+	assert.Equal(t, openfeature.ReadyState, p.Status())
+
+	details := p.BooleanEvaluation(ctx, BOOL_FLAG_KEY, DEFAULT_BOOL_FLAG_VALUE, evalCtx)
+	assert.Equal(t, BOOL_FLAG_VALUE, details.Value)
+	assert.Equal(t, openfeature.StaticReason, details.Reason)
+	secret, err := details.FlagMetadata.GetBool("secret")
+	assert.NoError(t, err)
+	assert.False(t, secret)
+
+	assert.Equal(t, int64(3), p.IntEvaluation(ctx, "configs.MAX_RETRIES", 0, evalCtx).Value, "integers must be normalised")
+
+	details = p.BooleanEvaluation(ctx, INT_FLAG_KEY, DEFAULT_BOOL_FLAG_VALUE, evalCtx)
+	assert.Equal(t, DEFAULT_BOOL_FLAG_VALUE, details.Value)
+	assert.Equal(t, openfeature.TypeMismatchCode, details.ResolutionDetail().ErrorCode)
+
+	details = p.BooleanEvaluation(ctx, "missing", DEFAULT_BOOL_FLAG_VALUE, evalCtx)
+	assert.Equal(t, openfeature.FlagNotFoundCode, details.ResolutionDetail().ErrorCode)
+
+	exists, err := p.HasFlag(ctx, "configs.MAX_RETRIES")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestNewStaticProvider_invalidValues(t *testing.T) {
+	_, err := NewStaticProvider(map[string]interface{}{"channel": make(chan int)})
+	assert.Error(t, err)
+}
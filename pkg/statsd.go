@@ -0,0 +1,104 @@
+package pulumi
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// MetricsSink receives the metrics of every evaluation, e.g. to forward them to StatsD or Datadog.
+// Tags are "name:value" pairs, as in DogStatsD.
+//
+// The sink receives the following metrics, tagged with the flag key and, if any, the error code and the
+// ErrorType of the error:
+//
+//   - evaluations: a count of 1 per evaluation, also tagged with the reason
+//   - errors: a count of 1 per failed evaluation
+//   - latency: the time of the read of the value from the Pulumi ESC API or from memory
+type MetricsSink interface {
+	Count(name string, value int64, tags []string)
+	Timing(name string, value time.Duration, tags []string)
+}
+
+// WithMetricsSink sends the metrics of every evaluation to the sink, e.g. a StatsdSink
+func WithMetricsSink(sink MetricsSink) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.metricsSink = sink
+	}
+}
+
+// sendMetrics sends the metrics of an evaluation to the metrics sink
+func (p *PulumiESCProvider) sendMetrics(flag string, resolutionDetails openfeature.ProviderResolutionDetail) {
+	tags := []string{"flag:" + flag}
+	if errorCode := resolutionDetails.ResolutionDetail().ErrorCode; errorCode != "" {
+		tags = append(tags, "error_code:"+string(errorCode))
+		if errorType, ok := resolutionDetails.FlagMetadata[errorTypeMetadataKey].(string); ok && errorType != "" {
+			tags = append(tags, "error_type:"+errorType)
+		}
+		p.metricsSink.Count("errors", 1, tags)
+	}
+	p.metricsSink.Count("evaluations", 1, append(tags, "reason:"+string(resolutionDetails.Reason)))
+	if latency, ok := resolutionDetails.FlagMetadata[latencyMetadataKey].(float64); ok {
+		p.metricsSink.Timing("latency", time.Duration(latency*float64(time.Millisecond)), tags)
+	}
+}
+
+// StatsdSink is a MetricsSink sending the metrics to a StatsD server over UDP, with DogStatsD tags
+// so they can be sent to the Datadog agent. Metrics which can not be sent are dropped.
+type StatsdSink struct {
+	conn   net.Conn
+	prefix string
+}
+
+// NewStatsdSink returns a StatsdSink sending the metrics to the StatsD server at the address, e.g.
+// "127.0.0.1:8125", with the given prefix, e.g. "pulumi_esc." for pulumi_esc.evaluations
+func NewStatsdSink(addr, prefix string) (*StatsdSink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to statsd server %s: %w", addr, err)
+	}
+	return &StatsdSink{conn: conn, prefix: prefix}, nil
+}
+
+// Count sends a counter
+func (s *StatsdSink) Count(name string, value int64, tags []string) {
+	s.send(name, strconv.FormatInt(value, 10), "c", tags)
+}
+
+// Timing sends a timer in milliseconds
+func (s *StatsdSink) Timing(name string, value time.Duration, tags []string) {
+	s.send(name, strconv.FormatFloat(float64(value.Microseconds())/1000, 'f', -1, 64), "ms", tags)
+}
+
+// Close closes the connection to the StatsD server
+func (s *StatsdSink) Close() error {
+	return s.conn.Close()
+}
+
+// send sends a metric in the StatsD line protocol
+func (s *StatsdSink) send(name, value, metricType string, tags []string) {
+	var line strings.Builder
+	line.WriteString(s.prefix)
+	line.WriteString(name)
+	line.WriteByte(':')
+	line.WriteString(value)
+	line.WriteByte('|')
+	line.WriteString(metricType)
+	if len(tags) > 0 {
+		line.WriteString("|#")
+		for i, tag := range tags {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			line.WriteString(statsdTagReplacer.Replace(tag))
+		}
+	}
+	_, _ = s.conn.Write([]byte(line.String()))
+}
+
+// statsdTagReplacer replaces the characters delimiting the tags of a StatsD line
+var statsdTagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
@@ -0,0 +1,71 @@
+package pulumi
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMetricsSink records the metrics it receives as StatsD lines without the values of timers
+type recordingMetricsSink struct {
+	mu      sync.Mutex
+	metrics []string
+}
+
+func (s *recordingMetricsSink) Count(name string, value int64, tags []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = append(s.metrics, name+"|c|"+strings.Join(tags, ","))
+}
+
+func (s *recordingMetricsSink) Timing(name string, value time.Duration, tags []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = append(s.metrics, name+"|ms|"+strings.Join(tags, ","))
+}
+
+func TestWithMetricsSink(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	sink := &recordingMetricsSink{}
+	p := newTestProvider(t, server, WithMetricsSink(sink))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil)
+	p.BooleanEvaluation(ctx, "MISSING", false, nil)
+	assert.Equal(t, []string{
+		"evaluations|c|flag:DEBUG_MODE,reason:STATIC",
+		"latency|ms|flag:DEBUG_MODE",
+		"errors|c|flag:MISSING,error_code:FLAG_NOT_FOUND",
+		"evaluations|c|flag:MISSING,error_code:FLAG_NOT_FOUND,reason:ERROR",
+	}, sink.metrics)
+}
+
+func TestStatsdSink(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	sink, err := NewStatsdSink(listener.LocalAddr().String(), "pulumi_esc.")
+	require.NoError(t, err)
+	defer sink.Close()
+
+	read := func() string {
+		buf := make([]byte, 1024)
+		require.NoError(t, listener.SetReadDeadline(time.Now().Add(5*time.Second)))
+		n, _, err := listener.ReadFrom(buf)
+		require.NoError(t, err)
+		return string(buf[:n])
+	}
+	sink.Count("evaluations", 1, []string{"flag:DEBUG_MODE", "reason:STATIC"})
+	assert.Equal(t, "pulumi_esc.evaluations:1|c|#flag:DEBUG_MODE,reason:STATIC", read())
+	sink.Timing("latency", 1500*time.Microsecond, []string{"flag:a,b|c"})
+	assert.Equal(t, "pulumi_esc.latency:1.5|ms|#flag:a_b_c", read())
+	sink.Count("errors", 2, nil)
+	assert.Equal(t, "pulumi_esc.errors:2|c", read())
+}
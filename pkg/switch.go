@@ -0,0 +1,60 @@
+package pulumi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// SwitchEnvironment switches the provider to another environment of the organisation, e.g. to roll out the
+// configuration of a green environment without restarting the service. The new environment is opened and
+// read before it is swapped in, so evaluations are served from the previous environment until the switch,
+// and the provider keeps serving the previous environment if the new one can not be opened or read.
+// A PROVIDER_CONFIGURATION_CHANGED event and a ChangeEvent listing the flags which differ between the
+// environments are emitted.
+func (p *PulumiESCProvider) SwitchEnvironment(ctx context.Context, projectName, envName string) error {
+	previous, _ := p.readEnvironment(ctx)
+
+	reqCtx, cancel := p.requestContext(ctx)
+	defer cancel()
+	env, revision, err := p.openEnvironment(reqCtx, projectName, envName)
+	if err != nil {
+		return fmt.Errorf("failed to open pulumi esc environment %s/%s: %w", projectName, envName, classifyAPIError(err))
+	}
+	reqCtx, apiError := recordResponses(reqCtx)
+	escEnv, values, err := p.escClient.ReadOpenEnvironment(reqCtx, p.orgName, projectName, envName, env.Id)
+	if err != nil {
+		return fmt.Errorf("failed to read pulumi esc environment %s/%s: %w", projectName, envName, classifyAPIError(apiError(err)))
+	}
+	snapshot := p.newSnapshot(escEnv, values, revision, projectName, envName)
+
+	p.stateMu.Lock()
+	p.projectName = projectName
+	p.envName = envName
+	p.escOpenEnvSessionId = env.Id
+	p.revision = revision
+	p.lastSessionAttempt = time.Now()
+	// The values cached for the previous environment must not be served for the new one
+	if p.lastKnownValues != nil {
+		p.lastKnownValues.replace(snapshot)
+	}
+	if p.snapshots != nil || p.snapshot.Load() != nil {
+		p.snapshot.Store(snapshot)
+	}
+	p.tombstones.reset()
+	p.transitionLocked(openfeature.ReadyState, fmt.Sprintf("switched to pulumi esc environment %s/%s", projectName, envName))
+	p.stateMu.Unlock()
+
+	if p.snapshots != nil {
+		if err := p.saveSnapshot(snapshot); err != nil {
+			return err
+		}
+	}
+
+	changes := p.flagChanges(previous, snapshot)
+	p.emitConfigChange(fmt.Sprintf("switched to pulumi esc environment %s/%s", projectName, envName), changedKeys(changes))
+	p.emitChanges(previous, snapshot, changes)
+	return nil
+}
@@ -0,0 +1,50 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	esc "github.com/pulumi/esc-sdk/sdk/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// evaluatedEnvironment returns the environment the flag was read from according to its trace
+func evaluatedEnvironment(t *testing.T, p *PulumiESCProvider, flag string) string {
+	details := p.BooleanEvaluation(context.Background(), flag, false, nil)
+	require.NoError(t, details.Error())
+	trace, ok := details.FlagMetadata["trace"].(esc.Trace)
+	require.True(t, ok)
+	return trace.Def.Environment
+}
+
+func TestSwitchEnvironment(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	p := newTestProvider(t, server, WithRateLimit(1000, 1000))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	assert.Equal(t, ENV_NAME, evaluatedEnvironment(t, p, "DEBUG_MODE"))
+
+	require.NoError(t, p.SwitchEnvironment(ctx, PROJECT_NAME, "green"))
+	assert.Equal(t, openfeature.ReadyState, p.Status())
+	assert.Equal(t, "test-org/"+PROJECT_NAME+"/green", p.environmentPath())
+	_, ok := p.lastKnownValues.get("DEBUG_MODE")
+	assert.True(t, ok, "the cache must be warmed with the values of the new environment")
+	event := <-p.EventChannel()
+	assert.Equal(t, openfeature.ProviderConfigChange, event.EventType)
+	assert.Empty(t, event.FlagChanges, "the environments have the same values")
+	assert.Equal(t, "green", evaluatedEnvironment(t, p, "DEBUG_MODE"))
+}
+
+func TestSwitchEnvironment_error(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": true})
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+
+	server.SetUnavailable(true)
+	assert.ErrorContains(t, p.SwitchEnvironment(ctx, PROJECT_NAME, "green"), "failed to open pulumi esc environment "+PROJECT_NAME+"/green")
+	server.SetUnavailable(false)
+	assert.Equal(t, ENV_NAME, evaluatedEnvironment(t, p, "DEBUG_MODE"), "the previous environment must still be served")
+}
@@ -0,0 +1,155 @@
+package pulumi
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// targetingRule is a targeting rule of a structured flag definition, serving a variant to the evaluation
+// contexts matching all its conditions, such as
+//
+//	rules:
+//	  - when: {country: [FR, DE], plan: enterprise}
+//	    variant: v2
+type targetingRule struct {
+	// conditions are the values matched by the attributes of the evaluation context, by attribute
+	conditions map[string][]interface{}
+	variant    string
+}
+
+// parseRules parses the targeting rules of a structured flag definition serving the given variants
+func parseRules(rawRules interface{}, variants map[string]interface{}) ([]targetingRule, error) {
+	items, ok := rawRules.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("rules must be a list of targeting rules")
+	}
+	if variants == nil {
+		return nil, fmt.Errorf("rules require variants")
+	}
+	rules := make([]targetingRule, len(items))
+	for i, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("rule %d must be an object with when and variant fields", i)
+		}
+		for field := range fields {
+			if field != "when" && field != "variant" {
+				return nil, fmt.Errorf("rule %d has unknown field %q", i, field)
+			}
+		}
+		variant, ok := fields["variant"].(string)
+		if _, exists := variants[variant]; !ok || !exists {
+			return nil, fmt.Errorf("rule %d must serve one of the variants", i)
+		}
+		when, ok := fields["when"].(map[string]interface{})
+		if !ok || len(when) == 0 {
+			return nil, fmt.Errorf("rule %d must have conditions on the attributes of the evaluation context", i)
+		}
+		rules[i] = targetingRule{conditions: make(map[string][]interface{}, len(when)), variant: variant}
+		for attribute, rawValues := range when {
+			values, isList := rawValues.([]interface{})
+			if !isList {
+				values = []interface{}{rawValues}
+			}
+			for _, value := range values {
+				switch value.(type) {
+				case string, bool:
+				default:
+					if _, isNumber := floatValue(value); !isNumber {
+						return nil, fmt.Errorf("the values of attribute %q of rule %d must be strings, numbers or booleans", attribute, i)
+					}
+				}
+			}
+			rules[i].conditions[attribute] = values
+		}
+	}
+	return rules, nil
+}
+
+// matches reports whether the attributes of the evaluation context match all the conditions of the rule
+func (r targetingRule) matches(evalCtx openfeature.FlattenedContext) bool {
+	for attribute, values := range r.conditions {
+		actual, ok := evalCtx[attribute]
+		if !ok || !containsAttributeValue(values, actual) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAttributeValue reports whether the value of an attribute of the evaluation context is one of the
+// values of a condition. Numbers match whatever their type.
+func containsAttributeValue(values []interface{}, actual interface{}) bool {
+	actualNumber, actualIsNumber := contextNumber(actual)
+	for _, value := range values {
+		if number, isNumber := floatValue(value); isNumber {
+			if actualIsNumber && number == actualNumber {
+				return true
+			}
+		} else if value == actual {
+			return true
+		}
+	}
+	return false
+}
+
+// contextNumber converts a number of the evaluation context to float64
+func contextNumber(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case int:
+		return float64(number), true
+	case int32:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case float32:
+		return float64(number), true
+	}
+	return floatValue(value)
+}
+
+// parseMetadata parses the metadata of a structured flag definition, whose values must be scalars
+func parseMetadata(rawMetadata interface{}) (map[string]interface{}, error) {
+	fields, ok := rawMetadata.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("metadata must be an object")
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	metadata := make(map[string]interface{}, len(fields))
+	for _, key := range keys {
+		switch value := fields[key].(type) {
+		case string, bool:
+			metadata[key] = value
+		default:
+			number, ok := floatValue(value)
+			if !ok {
+				return nil, fmt.Errorf("metadata %q must be a string, a number or a boolean", key)
+			}
+			metadata[key] = number
+		}
+	}
+	return metadata, nil
+}
+
+// withDefinitionMetadata adds the metadata of a structured flag definition to the metadata of its resolution.
+// The metadata set by the provider takes precedence.
+func withDefinitionMetadata(resolutionDetails openfeature.ProviderResolutionDetail, definitionMetadata map[string]interface{}) openfeature.ProviderResolutionDetail {
+	if len(definitionMetadata) == 0 {
+		return resolutionDetails
+	}
+	metadata := make(openfeature.FlagMetadata, len(definitionMetadata)+len(resolutionDetails.FlagMetadata))
+	for key, value := range definitionMetadata {
+		metadata[key] = value
+	}
+	for key, value := range resolutionDetails.FlagMetadata {
+		metadata[key] = value
+	}
+	resolutionDetails.FlagMetadata = metadata
+	return resolutionDetails
+}
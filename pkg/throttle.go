@@ -0,0 +1,78 @@
+package pulumi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultRetryAfter is the backoff used when a 429 response has no valid Retry-After header
+const defaultRetryAfter = time.Second
+
+var errThrottled = errors.New("rate limited by the Pulumi ESC API")
+
+// throttledError is returned for reads which are skipped because the Pulumi ESC API asked us to back off
+type throttledError struct {
+	retryAfter time.Time
+}
+
+func (e *throttledError) Error() string {
+	return errThrottled.Error() + ", retry after " + e.retryAfter.Format(time.RFC3339)
+}
+
+func (e *throttledError) Unwrap() error {
+	return errThrottled
+}
+
+// apiThrottle tracks the backoff requested by the Pulumi ESC API through 429 responses
+type apiThrottle struct {
+	mu         sync.Mutex
+	retryAfter time.Time
+}
+
+// backoffUntil extends the backoff window to the given time
+func (t *apiThrottle) backoffUntil(retryAfter time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if retryAfter.After(t.retryAfter) {
+		t.retryAfter = retryAfter
+	}
+}
+
+// check returns an error if requests must not be sent to the Pulumi ESC API yet
+func (t *apiThrottle) check() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if time.Now().Before(t.retryAfter) {
+		return &throttledError{retryAfter: t.retryAfter}
+	}
+	return nil
+}
+
+// throttleTransport is a http.RoundTripper which starts a backoff window whenever the
+// Pulumi ESC API responds with 429 Too Many Requests
+type throttleTransport struct {
+	base     http.RoundTripper
+	throttle *apiThrottle
+}
+
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		t.throttle.backoffUntil(parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
+	}
+	return resp, err
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of seconds or a HTTP date
+func parseRetryAfter(header string, now time.Time) time.Time {
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return now.Add(time.Duration(seconds) * time.Second)
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return date
+	}
+	return now.Add(defaultRetryAfter)
+}
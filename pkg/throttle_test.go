@@ -0,0 +1,42 @@
+package pulumi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 4, 6, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, now.Add(30*time.Second), parseRetryAfter("30", now))
+	assert.Equal(t, now.Add(time.Minute), parseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now))
+	assert.Equal(t, now.Add(defaultRetryAfter), parseRetryAfter("", now))
+	assert.Equal(t, now.Add(defaultRetryAfter), parseRetryAfter("soon", now))
+}
+
+func TestThrottleTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	throttle := &apiThrottle{}
+	assert.NoError(t, throttle.check())
+
+	client := &http.Client{Transport: &throttleTransport{base: http.DefaultTransport, throttle: throttle}}
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	err = throttle.check()
+	assert.True(t, errors.Is(err, errThrottled))
+	var throttledErr *throttledError
+	if assert.True(t, errors.As(err, &throttledErr)) {
+		assert.WithinDuration(t, time.Now().Add(time.Minute), throttledErr.retryAfter, 5*time.Second)
+	}
+}
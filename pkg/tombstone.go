@@ -0,0 +1,230 @@
+package pulumi
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// Tombstone describes a flag that was resolved successfully at some point but has since
+// disappeared from the Pulumi ESC environment.
+// LastValueHash is an HMAC-SHA256 of the last value keyed per process, so it can only be compared with the
+// hashes of the same process, and is empty for secrets.
+type Tombstone struct {
+	Key           string    `json:"key"`
+	LastSeenAt    time.Time `json:"lastSeenAt"`
+	DeletedAt     time.Time `json:"deletedAt"`
+	LastValueHash string    `json:"lastValueHash,omitempty"`
+}
+
+// WithTombstones records the flags resolved successfully, so the evaluations of flags deleted since report
+// tombstone flag metadata, ListFlags lists them and WithRequiredFlags fails with a DeletedFlagError.
+// Tombstones only exist for flags already evaluated by this process, so deletions of flags which were never
+// evaluated, e.g. before a restart, are reported as missing flags.
+func WithTombstones() ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.tombstones = newTombstoneRegistry()
+	}
+}
+
+// DeletedFlagError is the error of a required flag which was resolved before but has since been deleted
+// from the environment. It matches ErrFlagNotFound.
+type DeletedFlagError struct {
+	Tombstone Tombstone
+}
+
+func (e *DeletedFlagError) Error() string {
+	if e.Tombstone.LastValueHash == "" {
+		return fmt.Sprintf("%s not found, deleted at %s (last seen at %s)", e.Tombstone.Key,
+			e.Tombstone.DeletedAt.Format(time.RFC3339), e.Tombstone.LastSeenAt.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s not found, deleted at %s (last seen at %s with value hash %s)", e.Tombstone.Key,
+		e.Tombstone.DeletedAt.Format(time.RFC3339), e.Tombstone.LastSeenAt.Format(time.RFC3339), e.Tombstone.LastValueHash)
+}
+
+// seenFlag is the last successful resolution observed for a flag key
+type seenFlag struct {
+	lastSeenAt    time.Time
+	lastValueHash [sha256.Size]byte
+	// secret reports whether the last value was a secret, whose hash is not computed
+	secret bool
+}
+
+// tombstoneKey is the per-process key of the HMAC of the last values of flags, so their hashes can not be
+// compared with the hashes of guessed values outside of the process
+var tombstoneKey = sync.OnceValue(func() []byte {
+	key := make([]byte, sha256.Size)
+	_, _ = rand.Read(key)
+	return key
+})
+
+// tombstoneRegistry keeps track of resolved flag keys so that a later 'not found'
+// can be reported as a deletion instead of a flag that never existed.
+// A nil registry, without WithTombstones, records nothing.
+type tombstoneRegistry struct {
+	mu         sync.Mutex
+	seen       map[string]seenFlag
+	tombstones map[string]Tombstone
+}
+
+func newTombstoneRegistry() *tombstoneRegistry {
+	return &tombstoneRegistry{
+		seen:       map[string]seenFlag{},
+		tombstones: map[string]Tombstone{},
+	}
+}
+
+// markSeen records a successful resolution of the given key and clears any previous tombstone.
+// The values of secrets are not hashed.
+func (r *tombstoneRegistry) markSeen(key string, value interface{}, secret bool) {
+	if r == nil {
+		return
+	}
+	seen := seenFlag{lastSeenAt: time.Now(), secret: secret}
+	if !secret {
+		seen.lastValueHash = sumValue(value)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen[key] = seen
+	delete(r.tombstones, key)
+}
+
+// markMissing returns the tombstone for the given key if it was seen before.
+// The deletion time is the first time the key was observed missing.
+func (r *tombstoneRegistry) markMissing(key string) (Tombstone, bool) {
+	if r == nil {
+		return Tombstone{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if tombstone, ok := r.tombstones[key]; ok {
+		return tombstone, true
+	}
+	seen, ok := r.seen[key]
+	if !ok {
+		return Tombstone{}, false
+	}
+	tombstone := seen.tombstone(key, time.Now())
+	r.tombstones[key] = tombstone
+	return tombstone, true
+}
+
+// sweep marks the resolved flag keys which no longer exist as missing, and returns the tombstones of the
+// keys which do not exist sorted by key, e.g. to list flags deleted since they were last evaluated
+func (r *tombstoneRegistry) sweep(exists func(key string) bool) []Tombstone {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for key, seen := range r.seen {
+		if _, ok := r.tombstones[key]; !ok && !exists(key) {
+			r.tombstones[key] = seen.tombstone(key, now)
+		}
+	}
+	var tombstones []Tombstone
+	for key, tombstone := range r.tombstones {
+		// The tombstones of restored flags are only cleared when they are evaluated again
+		if !exists(key) {
+			tombstones = append(tombstones, tombstone)
+		}
+	}
+	sort.Slice(tombstones, func(i, j int) bool {
+		return tombstones[i].Key < tombstones[j].Key
+	})
+	return tombstones
+}
+
+// tombstone returns the tombstone of a flag deleted at the given time
+func (s seenFlag) tombstone(key string, deletedAt time.Time) Tombstone {
+	tombstone := Tombstone{Key: key, LastSeenAt: s.lastSeenAt, DeletedAt: deletedAt}
+	if !s.secret {
+		tombstone.LastValueHash = hex.EncodeToString(s.lastValueHash[:])
+	}
+	return tombstone
+}
+
+// reset forgets the resolved flag keys and the tombstones, e.g. after switching to another environment
+func (r *tombstoneRegistry) reset() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen = map[string]seenFlag{}
+	r.tombstones = map[string]Tombstone{}
+}
+
+// list returns all known tombstones sorted by key
+func (r *tombstoneRegistry) list() []Tombstone {
+	if r == nil {
+		return []Tombstone{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tombstones := make([]Tombstone, 0, len(r.tombstones))
+	for _, tombstone := range r.tombstones {
+		tombstones = append(tombstones, tombstone)
+	}
+	sort.Slice(tombstones, func(i, j int) bool {
+		return tombstones[i].Key < tombstones[j].Key
+	})
+	return tombstones
+}
+
+// flagMetadata returns the tombstone as FlagMetadata attached to 'not found' resolutions
+func (t Tombstone) flagMetadata() openfeature.FlagMetadata {
+	metadata := openfeature.FlagMetadata{
+		"tombstone":  true,
+		"lastSeenAt": t.LastSeenAt.Format(time.RFC3339),
+		"deletedAt":  t.DeletedAt.Format(time.RFC3339),
+	}
+	if t.LastValueHash != "" {
+		metadata["lastValueHash"] = t.LastValueHash
+	}
+	return metadata
+}
+
+// hashValue returns a SHA-256 hash of the JSON encoding of the given value, so values can be compared
+// after the fact without being retained
+func hashValue(value interface{}) string {
+	sum := sha256.Sum256(encodeValue(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// sumValue returns the HMAC-SHA256 of the JSON encoding of the given value, keyed by tombstoneKey. Flags are
+// marked as seen on every evaluation, so the sum is only hex encoded when a tombstone is created.
+func sumValue(value interface{}) [sha256.Size]byte {
+	mac := hmac.New(sha256.New, tombstoneKey())
+	mac.Write(encodeValue(value))
+	var sum [sha256.Size]byte
+	mac.Sum(sum[:0])
+	return sum
+}
+
+// encodeValue returns the JSON encoding of the given value, or its default format if it can not be encoded
+func encodeValue(value interface{}) []byte {
+	switch value := value.(type) {
+	case bool:
+		// Booleans are encoded without allocating
+		if value {
+			return []byte("true")
+		}
+		return []byte("false")
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%v", value))
+	}
+	return data
+}
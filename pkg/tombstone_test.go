@@ -0,0 +1,87 @@
+package pulumi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tombstoneHash returns the expected LastValueHash of a tombstone of the given value
+func tombstoneHash(value interface{}) string {
+	sum := sumValue(value)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestTombstoneRegistry(t *testing.T) {
+	registry := newTombstoneRegistry()
+
+	_, ok := registry.markMissing(NON_EXISTING_FLAG_KEY)
+	assert.False(t, ok, "never seen flags must not be tombstoned")
+
+	registry.markSeen(STRING_FLAG_KEY, STRING_FLAG_VALUE, false)
+	tombstone, ok := registry.markMissing(STRING_FLAG_KEY)
+	assert.True(t, ok)
+	assert.Equal(t, STRING_FLAG_KEY, tombstone.Key)
+	assert.Equal(t, tombstoneHash(STRING_FLAG_VALUE), tombstone.LastValueHash)
+	assert.NotEqual(t, hashValue(STRING_FLAG_VALUE), tombstone.LastValueHash, "values must not be hashed with a plain hash")
+	assert.False(t, tombstone.DeletedAt.Before(tombstone.LastSeenAt))
+
+	again, ok := registry.markMissing(STRING_FLAG_KEY)
+	assert.True(t, ok)
+	assert.Equal(t, tombstone.DeletedAt, again.DeletedAt, "deletion time must be the first time the flag went missing")
+	assert.Equal(t, []Tombstone{tombstone}, registry.list())
+
+	metadata := tombstone.flagMetadata()
+	isTombstone, err := metadata.GetBool("tombstone")
+	assert.NoError(t, err)
+	assert.True(t, isTombstone)
+
+	registry.markSeen(STRING_FLAG_KEY, STRING_FLAG_VALUE, false)
+	assert.Empty(t, registry.list(), "restored flags must clear their tombstone")
+
+	registry.markSeen("configs.API_KEY", "sk-12345", true)
+	tombstone, ok = registry.markMissing("configs.API_KEY")
+	assert.True(t, ok)
+	assert.Empty(t, tombstone.LastValueHash, "secrets must not be hashed")
+	assert.NotContains(t, tombstone.flagMetadata(), "lastValueHash")
+	assert.NotContains(t, (&DeletedFlagError{Tombstone: tombstone}).Error(), "hash")
+}
+
+func TestSumValue(t *testing.T) {
+	mac := hmac.New(sha256.New, tombstoneKey())
+	mac.Write([]byte(`"value"`))
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), tombstoneHash("value"))
+	assert.Equal(t, tombstoneKey(), tombstoneKey(), "the key must be the same for the whole process")
+}
+
+func TestWithTombstones(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{BOOL_FLAG_KEY: BOOL_FLAG_VALUE})
+	p := newTestProvider(t, server)
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	assert.True(t, p.BooleanEvaluation(ctx, BOOL_FLAG_KEY, false, nil).Value)
+	server.DeleteValue(BOOL_FLAG_KEY)
+
+	details := p.BooleanEvaluation(ctx, BOOL_FLAG_KEY, false, nil)
+	assert.Equal(t, openfeature.FlagNotFoundCode, details.ResolutionDetail().ErrorCode)
+	assert.NotContains(t, details.FlagMetadata, "tombstone", "flags must not be tracked without WithTombstones")
+	assert.Empty(t, p.Tombstones())
+
+	server.SetValue(BOOL_FLAG_KEY, BOOL_FLAG_VALUE)
+	p = newTestProvider(t, server, WithTombstones())
+	require.NoError(t, p.initialise(ctx))
+	assert.True(t, p.BooleanEvaluation(ctx, BOOL_FLAG_KEY, false, nil).Value)
+	server.DeleteValue(BOOL_FLAG_KEY)
+
+	details = p.BooleanEvaluation(ctx, BOOL_FLAG_KEY, false, nil)
+	assert.Equal(t, openfeature.FlagNotFoundCode, details.ResolutionDetail().ErrorCode)
+	isTombstone, _ := details.FlagMetadata.GetBool("tombstone")
+	assert.True(t, isTombstone)
+	assert.Len(t, p.Tombstones(), 1)
+}
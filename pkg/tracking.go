@@ -0,0 +1,51 @@
+package pulumi
+
+import (
+	"context"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// TrackingEvent is a tracking event recorded using the OpenFeature client's Track
+type TrackingEvent struct {
+	Name              string
+	EvaluationContext openfeature.EvaluationContext
+	Details           openfeature.TrackingEventDetails
+	// Environment identifies the Pulumi ESC environment the flags were evaluated against,
+	// formatted as org/project/env
+	Environment string
+}
+
+// TrackingSink receives the tracking events, e.g. to forward them to an experimentation pipeline
+type TrackingSink interface {
+	EmitTrackingEvent(ctx context.Context, event TrackingEvent)
+}
+
+// TrackingSinkFunc is an adapter to allow the use of ordinary functions as TrackingSink
+type TrackingSinkFunc func(ctx context.Context, event TrackingEvent)
+
+// EmitTrackingEvent calls f(ctx, event)
+func (f TrackingSinkFunc) EmitTrackingEvent(ctx context.Context, event TrackingEvent) {
+	f(ctx, event)
+}
+
+// WithTrackingSink forwards the tracking events to the given sink
+func WithTrackingSink(sink TrackingSink) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.trackingSink = sink
+	}
+}
+
+// Track implements openfeature.Tracker. It forwards the tracking event to the sink configured
+// using WithTrackingSink, and is a no-op otherwise.
+func (p *PulumiESCProvider) Track(ctx context.Context, trackingEventName string, evaluationContext openfeature.EvaluationContext, details openfeature.TrackingEventDetails) {
+	if p.trackingSink == nil {
+		return
+	}
+	p.trackingSink.EmitTrackingEvent(ctx, TrackingEvent{
+		Name:              trackingEventName,
+		EvaluationContext: evaluationContext,
+		Details:           details,
+		Environment:       p.environmentPath(),
+	})
+}
@@ -0,0 +1,36 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPulumiESCProvider_Track(t *testing.T) {
+	var events []TrackingEvent
+	p := &PulumiESCProvider{orgName: "test-org", projectName: PROJECT_NAME, envName: ENV_NAME}
+	WithTrackingSink(TrackingSinkFunc(func(ctx context.Context, event TrackingEvent) {
+		events = append(events, event)
+	}))(p)
+
+	evalCtx := openfeature.NewEvaluationContext("user-1", map[string]interface{}{"plan": "pro"})
+	details := openfeature.NewTrackingEventDetails(9.99).Add("currency", "EUR")
+	p.Track(context.Background(), "checkout", evalCtx, details)
+
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "checkout", events[0].Name)
+		assert.Equal(t, "user-1", events[0].EvaluationContext.TargetingKey())
+		assert.Equal(t, 9.99, events[0].Details.Value())
+		assert.Equal(t, "EUR", events[0].Details.Attribute("currency"))
+		assert.Equal(t, "test-org/"+PROJECT_NAME+"/"+ENV_NAME, events[0].Environment)
+	}
+}
+
+func TestPulumiESCProvider_Track_Disabled(t *testing.T) {
+	p := &PulumiESCProvider{}
+	assert.NotPanics(t, func() {
+		p.Track(context.Background(), "checkout", openfeature.EvaluationContext{}, openfeature.TrackingEventDetails{})
+	})
+}
@@ -0,0 +1,161 @@
+package pulumi
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+const (
+	// modulePath is the path of the Go module of the provider
+	modulePath = "github.com/bugcacher/open-feature-pulumi-esc-provider"
+	// defaultProviderVersion is the version of the provider if it is not set at build time and can not be
+	// read from the build info, e.g. in tests, using the placeholder of the go command for unversioned modules
+	defaultProviderVersion = "(devel)"
+)
+
+// ProviderVersion overrides the version of the provider reported in the User-Agent of Pulumi ESC API requests
+// and in the OpenTelemetry instrumentation scope. It is meant to be set at build time, using
+// -ldflags "-X github.com/bugcacher/open-feature-pulumi-esc-provider/pkg.ProviderVersion=v1.2.3".
+// If it is empty, the version of the module is read from the build info.
+var ProviderVersion string
+
+// providerVersion returns the version of the provider, resolved once
+var providerVersion = sync.OnceValue(func() string {
+	if ProviderVersion != "" {
+		return ProviderVersion
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return defaultProviderVersion
+	}
+	return moduleVersion(info)
+})
+
+// moduleVersion returns the version of the provider module in the build info, or defaultProviderVersion if
+// it is unknown, e.g. when the module is built from a local checkout
+func moduleVersion(info *debug.BuildInfo) string {
+	var module *debug.Module
+	if info.Main.Path == modulePath {
+		module = &info.Main
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			module = dep
+		}
+	}
+	if module == nil {
+		return defaultProviderVersion
+	}
+	if module.Replace != nil {
+		module = module.Replace
+	}
+	if module.Version == "" {
+		return defaultProviderVersion
+	}
+	return module.Version
+}
+
+// userAgentTransport is a http.RoundTripper which identifies the provider, and optionally the
+// application, in the User-Agent header of every request
+type userAgentTransport struct {
+	base          http.RoundTripper
+	applicationID string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	parts := []string{ProviderName + "/" + providerVersion()}
+	if sdkUserAgent := req.Header.Get("User-Agent"); sdkUserAgent != "" {
+		parts = append(parts, sdkUserAgent)
+	}
+	if t.applicationID != "" {
+		parts = append(parts, t.applicationID)
+	}
+	// RoundTrippers must not modify the original request
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", strings.Join(parts, " "))
+	return t.base.RoundTrip(req)
+}
+
+// WithApplicationID appends the given application identifier, e.g. "checkout-service/1.4.2", to the
+// User-Agent of Pulumi ESC API requests, so API traffic can be attributed per service
+func WithApplicationID(applicationID string) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.applicationID = applicationID
+	}
+}
+
+// newAPIHTTPClient returns a copy of the given client with the provider transports installed
+func (p *PulumiESCProvider) newAPIHTTPClient(client *http.Client) *http.Client {
+	apiClient := &http.Client{}
+	if client != nil {
+		*apiClient = *client
+	}
+	transport := apiClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	transport = &requestIDTransport{base: transport}
+	if p.instruments != nil && p.instruments.apiDuration != nil {
+		transport = &metricsTransport{base: transport, apiDuration: p.instruments.apiDuration}
+	}
+	if p.requestSlots != nil {
+		transport = &concurrencyTransport{base: transport, slots: p.requestSlots}
+	}
+	transport = &gzipTransport{base: transport}
+	transport = &throttleTransport{base: transport, throttle: p.throttle}
+	transport = &userAgentTransport{base: transport, applicationID: p.applicationID}
+	apiClient.Transport = transport
+	return apiClient
+}
+
+// gzipTransport is a http.RoundTripper requesting gzip compressed responses and decompressing them.
+// http.Transport only does so when compression is enabled, and other base transports may not do it at all,
+// while whole environments can be megabytes of JSON.
+type gzipTransport struct {
+	base http.RoundTripper
+}
+
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") != "" || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+	// RoundTrippers must not modify the original request
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return resp, err
+	}
+	resp.Body = &gzipBody{body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// gzipBody decompresses a gzip compressed response body. The gzip header is read on the first Read,
+// so reading the response headers does not block on the body.
+type gzipBody struct {
+	body   io.ReadCloser
+	reader *gzip.Reader
+	err    error
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		b.reader, b.err = gzip.NewReader(b.body)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.reader.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,108 @@
+package pulumi
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserAgentTransport(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+	}))
+	defer server.Close()
+
+	p := &PulumiESCProvider{throttle: &apiThrottle{}}
+	WithApplicationID("checkout-service/1.4.2")(p)
+	client := p.newAPIHTTPClient(nil)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	assert.NoError(t, err)
+	req.Header.Set("User-Agent", "esc-sdk/go")
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, ProviderName+"/"+providerVersion()+" esc-sdk/go checkout-service/1.4.2", userAgent)
+	assert.Equal(t, "esc-sdk/go", req.Header.Get("User-Agent"), "the original request must not be modified")
+}
+
+func TestGzipTransport(t *testing.T) {
+	body := `{"properties":{"DEBUG_MODE":{"value":true}}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			_, _ = io.WriteString(w, body)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		writer := gzip.NewWriter(w)
+		_, _ = io.WriteString(writer, body)
+		_ = writer.Close()
+	}))
+	defer server.Close()
+
+	for name, base := range map[string]http.RoundTripper{
+		"default transport":    http.DefaultTransport,
+		"compression disabled": &http.Transport{DisableCompression: true},
+		"non http.Transport":   roundTripperFunc(http.DefaultTransport.RoundTrip),
+	} {
+		p := &PulumiESCProvider{throttle: &apiThrottle{}}
+		client := p.newAPIHTTPClient(&http.Client{Transport: base})
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err, name)
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err, name)
+		assert.Equal(t, body, string(data), name)
+		assert.True(t, resp.Uncompressed, name)
+		assert.Empty(t, resp.Header.Get("Content-Encoding"), name)
+	}
+}
+
+func TestGzipTransport_invalidBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = io.WriteString(w, "not gzip")
+	}))
+	defer server.Close()
+
+	client := (&PulumiESCProvider{throttle: &apiThrottle{}}).newAPIHTTPClient(nil)
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	_, err = io.ReadAll(resp.Body)
+	assert.Error(t, err)
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestModuleVersion(t *testing.T) {
+	dependency := &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/service", Version: "(devel)"},
+		Deps: []*debug.Module{{Path: modulePath, Version: "v1.2.3"}},
+	}
+	assert.Equal(t, "v1.2.3", moduleVersion(dependency))
+
+	dependency.Deps[0].Replace = &debug.Module{Path: "../provider"}
+	assert.Equal(t, defaultProviderVersion, moduleVersion(dependency), "local replacements have no version")
+
+	main := &debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "v1.3.0"}}
+	assert.Equal(t, "v1.3.0", moduleVersion(main))
+	main.Main.Version = "(devel)"
+	assert.Equal(t, defaultProviderVersion, moduleVersion(main))
+
+	assert.Equal(t, defaultProviderVersion, moduleVersion(&debug.BuildInfo{Main: debug.Module{Path: "example.com/service"}}))
+}
@@ -0,0 +1,131 @@
+package pulumi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// StringSliceResolutionDetail is the resolution of a string slice flag
+type StringSliceResolutionDetail struct {
+	Value []string
+	openfeature.ProviderResolutionDetail
+}
+
+// StringSliceEvaluation returns a string slice flag, resolved from an array of strings
+func (p *PulumiESCProvider) StringSliceEvaluation(ctx context.Context, flag string, defaultValue []string, evalCtx openfeature.FlattenedContext) StringSliceResolutionDetail {
+	value, resolutionDetails := p.resolveValue(ctx, flag, FlagType_StringSlice, evalCtx)
+	stringSliceResolutionDetails := StringSliceResolutionDetail{ProviderResolutionDetail: resolutionDetails}
+	if value != nil {
+		stringSliceResolutionDetails.Value, _ = stringSliceValue(value)
+	} else {
+		stringSliceResolutionDetails.Value = defaultValue
+	}
+	p.observeEvaluation(ctx, flag, evalCtx, resolutionDetails)
+	return stringSliceResolutionDetails
+}
+
+// StringMapResolutionDetail is the resolution of a string map flag
+type StringMapResolutionDetail struct {
+	Value map[string]string
+	openfeature.ProviderResolutionDetail
+}
+
+// StringMapEvaluation returns a string map flag, resolved from an object of strings
+func (p *PulumiESCProvider) StringMapEvaluation(ctx context.Context, flag string, defaultValue map[string]string, evalCtx openfeature.FlattenedContext) StringMapResolutionDetail {
+	value, resolutionDetails := p.resolveValue(ctx, flag, FlagType_StringMap, evalCtx)
+	stringMapResolutionDetails := StringMapResolutionDetail{ProviderResolutionDetail: resolutionDetails}
+	if value != nil {
+		stringMapResolutionDetails.Value, _ = stringMapValue(value)
+	} else {
+		stringMapResolutionDetails.Value = defaultValue
+	}
+	p.observeEvaluation(ctx, flag, evalCtx, resolutionDetails)
+	return stringMapResolutionDetails
+}
+
+// TimeResolutionDetail is the resolution of a timestamp flag
+type TimeResolutionDetail struct {
+	Value time.Time
+	openfeature.ProviderResolutionDetail
+}
+
+// WithTimeLayouts sets the layouts, as accepted by time.Parse, tried in order by TimeEvaluation.
+// The default layout is time.RFC3339.
+func WithTimeLayouts(layouts ...string) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.timeLayouts = layouts
+	}
+}
+
+// TimeEvaluation returns a timestamp flag, parsed from a string using the layouts set by WithTimeLayouts.
+// Strings not matching any of the layouts fail with PARSE_ERROR.
+func (p *PulumiESCProvider) TimeEvaluation(ctx context.Context, flag string, defaultValue time.Time, evalCtx openfeature.FlattenedContext) TimeResolutionDetail {
+	value, resolutionDetails := p.resolveValue(ctx, flag, FlagType_String, evalCtx)
+	timeResolutionDetails := TimeResolutionDetail{ProviderResolutionDetail: resolutionDetails, Value: defaultValue}
+	if value != nil {
+		if timestamp, ok := p.timeValue(value.(string)); ok {
+			timeResolutionDetails.Value = timestamp
+		} else {
+			// The value is not part of the error, as it may be a secret
+			timeResolutionDetails.ProviderResolutionDetail = openfeature.ProviderResolutionDetail{
+				Reason: openfeature.ErrorReason,
+				ResolutionError: openfeature.NewParseErrorResolutionError(
+					fmt.Sprintf("%s does not match the time layouts %q", flag, p.timeLayoutsOrDefault())),
+			}
+		}
+	}
+	p.observeEvaluation(ctx, flag, evalCtx, timeResolutionDetails.ProviderResolutionDetail)
+	return timeResolutionDetails
+}
+
+// timeValue parses a timestamp using the first matching time layout
+func (p *PulumiESCProvider) timeValue(value string) (time.Time, bool) {
+	for _, layout := range p.timeLayoutsOrDefault() {
+		if timestamp, err := time.Parse(layout, value); err == nil {
+			return timestamp, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (p *PulumiESCProvider) timeLayoutsOrDefault() []string {
+	if len(p.timeLayouts) == 0 {
+		return []string{time.RFC3339}
+	}
+	return p.timeLayouts
+}
+
+// stringSliceValue converts a raw array of strings to []string.
+// It returns false if the raw value is not an array or any of its elements is not a string.
+func stringSliceValue(rawValue interface{}) ([]string, bool) {
+	values, ok := rawValue.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	strings := make([]string, len(values))
+	for i, value := range values {
+		if strings[i], ok = value.(string); !ok {
+			return nil, false
+		}
+	}
+	return strings, true
+}
+
+// stringMapValue converts a raw object of strings to map[string]string.
+// It returns false if the raw value is not an object or any of its values is not a string.
+func stringMapValue(rawValue interface{}) (map[string]string, bool) {
+	values, ok := rawValue.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	strings := make(map[string]string, len(values))
+	for key, value := range values {
+		if strings[key], ok = value.(string); !ok {
+			return nil, false
+		}
+	}
+	return strings, true
+}
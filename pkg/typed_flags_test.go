@@ -0,0 +1,92 @@
+package pulumi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPulumiESCProvider_StringSliceEvaluation(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"regions": []interface{}{"eu-west-1", "us-east-1"},
+		"empty":   []interface{}{},
+		"mixed":   []interface{}{"eu-west-1", 1},
+	})
+	p := newTestProvider(t, server)
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+	ctx := context.Background()
+	defaultValue := []string{"default"}
+
+	details := p.StringSliceEvaluation(ctx, "regions", defaultValue, nil)
+	assert.Equal(t, []string{"eu-west-1", "us-east-1"}, details.Value)
+	assert.Equal(t, openfeature.StaticReason, details.Reason)
+
+	assert.Equal(t, []string{}, p.StringSliceEvaluation(ctx, "empty", defaultValue, nil).Value)
+
+	details = p.StringSliceEvaluation(ctx, "mixed", defaultValue, nil)
+	assert.Equal(t, defaultValue, details.Value)
+	assert.Equal(t, openfeature.TypeMismatchCode, details.ResolutionDetail().ErrorCode)
+	assert.Equal(t, "mixed[1] is of type float64, not of type string", details.ResolutionDetail().ErrorMessage)
+
+	details = p.StringSliceEvaluation(ctx, "missing", defaultValue, nil)
+	assert.Equal(t, defaultValue, details.Value)
+	assert.Equal(t, openfeature.FlagNotFoundCode, details.ResolutionDetail().ErrorCode)
+}
+
+func TestPulumiESCProvider_StringMapEvaluation(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"headers": map[string]interface{}{"X-Tenant": "acme", "X-Region": "eu"},
+		"mixed":   map[string]interface{}{"a": "1", "b": 2},
+		"list":    []interface{}{"a"},
+	})
+	p := newTestProvider(t, server)
+	assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+	ctx := context.Background()
+	defaultValue := map[string]string{"X-Tenant": "default"}
+
+	details := p.StringMapEvaluation(ctx, "headers", defaultValue, nil)
+	assert.Equal(t, map[string]string{"X-Tenant": "acme", "X-Region": "eu"}, details.Value)
+	assert.Equal(t, openfeature.StaticReason, details.Reason)
+
+	details = p.StringMapEvaluation(ctx, "mixed", defaultValue, nil)
+	assert.Equal(t, defaultValue, details.Value)
+	assert.Equal(t, openfeature.TypeMismatchCode, details.ResolutionDetail().ErrorCode)
+	assert.Equal(t, "mixed.b is of type float64, not of type string", details.ResolutionDetail().ErrorMessage)
+
+	details = p.StringMapEvaluation(ctx, "list", defaultValue, nil)
+	assert.Equal(t, openfeature.TypeMismatchCode, details.ResolutionDetail().ErrorCode)
+}
+
+func TestPulumiESCProvider_TimeEvaluation(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{
+		"launch":      "2026-03-01T09:00:00Z",
+		"maintenance": "2026-03-01 22:00",
+	})
+	ctx := context.Background()
+	defaultValue := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("default layout", func(t *testing.T) {
+		p := newTestProvider(t, server)
+		assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+
+		details := p.TimeEvaluation(ctx, "launch", defaultValue, nil)
+		assert.Equal(t, time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), details.Value)
+		assert.Equal(t, openfeature.StaticReason, details.Reason)
+
+		details = p.TimeEvaluation(ctx, "maintenance", defaultValue, nil)
+		assert.Equal(t, defaultValue, details.Value)
+		assert.Equal(t, openfeature.ParseErrorCode, details.ResolutionDetail().ErrorCode)
+		assert.NotContains(t, details.ResolutionDetail().ErrorMessage, "22:00")
+	})
+
+	t.Run("custom layouts", func(t *testing.T) {
+		p := newTestProvider(t, server, WithTimeLayouts(time.RFC3339, "2006-01-02 15:04"))
+		assert.NoError(t, p.Init(openfeature.EvaluationContext{}))
+
+		details := p.TimeEvaluation(ctx, "maintenance", defaultValue, nil)
+		assert.Equal(t, time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC), details.Value)
+	})
+}
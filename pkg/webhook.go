@@ -0,0 +1,111 @@
+package pulumi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+const (
+	// webhookSignatureHeader is the header carrying the hex encoded HMAC-SHA256 signature of a Pulumi Cloud
+	// webhook delivery, computed over the body using the secret of the webhook
+	webhookSignatureHeader = "Pulumi-Webhook-Signature"
+	// webhookKindHeader is the header carrying the kind of a Pulumi Cloud webhook delivery
+	webhookKindHeader = "Pulumi-Webhook-Kind"
+	// maxWebhookBodySize is the maximum size of the body of a webhook delivery
+	maxWebhookBodySize = 1 << 20
+)
+
+// webhookPayload holds the fields of an environment webhook delivery identifying the environment
+type webhookPayload struct {
+	ProjectName     string `json:"projectName"`
+	EnvironmentName string `json:"environmentName"`
+}
+
+// WithWebhook enables the WebhookHandler, which refreshes the environment as soon as Pulumi Cloud notifies
+// it of a change, validating the deliveries using the secret of the webhook. Refreshes emit the same events
+// as WithPolling, which may be used alongside with a longer interval as a fallback for missed deliveries.
+// Initialisation fails if the secret is empty, as anyone could then sign deliveries.
+func WithWebhook(secret string) ProviderOption {
+	return func(p *PulumiESCProvider) {
+		p.webhookSecret = []byte(secret)
+		p.refreshes = make(chan struct{}, 1)
+	}
+}
+
+// validateWebhook verifies that the secret of the webhook is set if the webhook is enabled
+func (p *PulumiESCProvider) validateWebhook() error {
+	if p.webhookSecret != nil && len(p.webhookSecret) == 0 {
+		return errors.New("webhook secret must not be empty")
+	}
+	return nil
+}
+
+// WebhookHandler returns a http.Handler receiving the Pulumi Cloud webhook deliveries of the environment,
+// enabled using WithWebhook. Deliveries with an invalid signature are rejected, and deliveries of other
+// environments of the organisation are ignored. The refresh happens in the background, so Pulumi Cloud is
+// answered immediately, and deliveries received while a refresh is pending are coalesced into it.
+func (p *PulumiESCProvider) WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(p.webhookSecret) == 0 {
+			http.Error(w, "webhook is not enabled", http.StatusNotFound)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+		if err != nil {
+			http.Error(w, "failed to read the webhook delivery", http.StatusBadRequest)
+			return
+		}
+		if !validWebhookSignature(p.webhookSecret, body, r.Header.Get(webhookSignatureHeader)) {
+			http.Error(w, "invalid webhook signature", http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get(webhookKindHeader) == "ping" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		var payload webhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			http.Error(w, "invalid webhook payload", http.StatusBadRequest)
+			return
+		}
+		projectName, envName, _ := p.environment()
+		if (payload.ProjectName != "" && payload.ProjectName != projectName) ||
+			(payload.EnvironmentName != "" && payload.EnvironmentName != envName) {
+			// The webhook is registered for the organisation and the change is of another environment
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		p.requestRefresh()
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// requestRefresh wakes up the refresh loop without blocking. A refresh which is already pending
+// covers the request.
+func (p *PulumiESCProvider) requestRefresh() {
+	select {
+	case p.refreshes <- struct{}{}:
+	default:
+	}
+}
+
+// validWebhookSignature reports whether the signature is the HMAC-SHA256 of the body using the secret
+func validWebhookSignature(secret, body []byte, signature string) bool {
+	decoded, err := hex.DecodeString(signature)
+	if err != nil || len(decoded) != sha256.Size {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(decoded, mac.Sum(nil))
+}
@@ -0,0 +1,84 @@
+package pulumi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const webhookSecret = "webhook-secret"
+
+// deliverWebhook sends a webhook delivery of the given kind signed with the secret to the handler
+func deliverWebhook(handler http.Handler, secret, kind, body string) *httptest.ResponseRecorder {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	req.Header.Set(webhookSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	req.Header.Set(webhookKindHeader, kind)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWebhookHandler(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": false})
+	p := newTestProvider(t, server, WithWebhook(webhookSecret))
+	ctx := context.Background()
+	require.NoError(t, p.initialise(ctx))
+	handler := p.WebhookHandler()
+
+	server.SetValue("DEBUG_MODE", true)
+	body := `{"projectName": "` + PROJECT_NAME + `", "environmentName": "` + ENV_NAME + `", "revision": 2}`
+	assert.Equal(t, http.StatusAccepted, deliverWebhook(handler, webhookSecret, "environment_revision_created", body).Code)
+
+	select {
+	case event := <-p.Changes():
+		assert.Equal(t, []FlagChange{{Key: "DEBUG_MODE", Type: ChangeType_Changed, OldValue: false, NewValue: true}}, event.Changes)
+	case <-time.After(time.Second):
+		t.Fatal("the webhook delivery must refresh the environment")
+	}
+	assert.True(t, p.BooleanEvaluation(ctx, "DEBUG_MODE", false, nil).Value, "the new values must be served")
+}
+
+func TestWebhookHandler_rejected(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": false})
+	p := newTestProvider(t, server, WithWebhook(webhookSecret))
+	require.NoError(t, p.initialise(context.Background()))
+	handler := p.WebhookHandler()
+	body := `{"projectName": "` + PROJECT_NAME + `", "environmentName": "` + ENV_NAME + `"}`
+
+	assert.Equal(t, http.StatusUnauthorized, deliverWebhook(handler, "other-secret", "environment_revision_created", body).Code)
+	assert.Equal(t, http.StatusOK, deliverWebhook(handler, webhookSecret, "ping", `{}`).Code)
+	other := `{"projectName": "` + PROJECT_NAME + `", "environmentName": "other"}`
+	assert.Equal(t, http.StatusNoContent, deliverWebhook(handler, webhookSecret, "environment_revision_created", other).Code)
+	assert.Equal(t, http.StatusBadRequest, deliverWebhook(handler, webhookSecret, "environment_revision_created", "not json").Code)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/webhook", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Empty(t, p.refreshes, "rejected deliveries must not refresh the environment")
+}
+
+func TestWebhookHandler_disabled(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": false})
+	p := newTestProvider(t, server)
+	require.NoError(t, p.initialise(context.Background()))
+	assert.Equal(t, http.StatusNotFound, deliverWebhook(p.WebhookHandler(), webhookSecret, "environment_revision_created", `{}`).Code)
+}
+
+func TestWithWebhook_emptySecret(t *testing.T) {
+	server := newFakeESCServer(t, map[string]interface{}{"DEBUG_MODE": false})
+	p := newTestProvider(t, server, WithWebhook(""))
+	assert.EqualError(t, p.initialise(context.Background()), "webhook secret must not be empty")
+	assert.Equal(t, http.StatusNotFound, deliverWebhook(p.WebhookHandler(), "", "environment_revision_created", `{}`).Code,
+		"deliveries signed with an empty secret must be rejected")
+}
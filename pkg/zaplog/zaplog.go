@@ -0,0 +1,129 @@
+// Package zaplog adapts zap loggers to log/slog, so services standardised on zap can pass their logger to
+// the options of the provider taking a *slog.Logger, such as WithLoggingHook and WithDeprecationWarnings:
+//
+//	provider, err := pulumi.NewPulumiESCProvider(orgName, projectName, envName, accessToken,
+//		pulumi.WithLoggingHook(zaplog.New(logger), slog.LevelInfo))
+package zaplog
+
+import (
+	"context"
+	"log/slog"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// New returns a *slog.Logger writing to the zap logger
+func New(logger *zap.Logger) *slog.Logger {
+	return slog.New(NewHandler(logger))
+}
+
+// Handler is a slog.Handler writing to a zap logger. Groups are written as zap namespaces.
+type Handler struct {
+	logger *zap.Logger
+}
+
+// NewHandler returns a Handler writing to the zap logger, or to a no-op logger if it is nil
+func NewHandler(logger *zap.Logger) *Handler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	// The caller of Handle is slog, not the code logging the record
+	return &Handler{logger: logger.WithOptions(zap.WithCaller(false))}
+}
+
+// Enabled reports whether the zap logger writes records of the level
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Core().Enabled(zapLevel(level))
+}
+
+// Handle writes the record to the zap logger
+func (h *Handler) Handle(_ context.Context, record slog.Record) error {
+	entry := h.logger.Check(zapLevel(record.Level), record.Message)
+	if entry == nil {
+		return nil
+	}
+	if !record.Time.IsZero() {
+		entry.Time = record.Time
+	}
+	fields := make([]zap.Field, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendField(fields, attr)
+		return true
+	})
+	entry.Write(fields...)
+	return nil
+}
+
+// WithAttrs returns a Handler writing the attributes with every record
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = appendField(fields, attr)
+	}
+	return &Handler{logger: h.logger.With(fields...)}
+}
+
+// WithGroup returns a Handler writing the attributes of the following records in the group
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{logger: h.logger.With(zap.Namespace(name))}
+}
+
+// zapLevel returns the zap level of a slog level. Levels between the slog levels are rounded down.
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// appendField appends the zap field of a slog attribute. Empty attributes are omitted and the attributes
+// of groups without a key are inlined, as documented by slog.Handler.
+func appendField(fields []zap.Field, attr slog.Attr) []zap.Field {
+	value := attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	switch value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(attr.Key, value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(attr.Key, value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(attr.Key, value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(attr.Key, value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(attr.Key, value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(attr.Key, value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(attr.Key, value.Time()))
+	case slog.KindGroup:
+		var group []zap.Field
+		for _, nested := range value.Group() {
+			group = appendField(group, nested)
+		}
+		if attr.Key == "" {
+			return append(fields, group...)
+		}
+		if len(group) == 0 {
+			return fields
+		}
+		return append(fields, zap.Dict(attr.Key, group...))
+	default:
+		if err, ok := value.Any().(error); ok {
+			return append(fields, zap.NamedError(attr.Key, err))
+		}
+		return append(fields, zap.Any(attr.Key, value.Any()))
+	}
+}
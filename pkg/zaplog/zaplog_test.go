@@ -0,0 +1,59 @@
+package zaplog_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	pulumi "github.com/bugcacher/open-feature-pulumi-esc-provider/pkg"
+	"github.com/bugcacher/open-feature-pulumi-esc-provider/pkg/zaplog"
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNew(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zaplog.New(zap.New(core))
+
+	logger.Debug("filtered")
+	logger.Warn("flag evaluated", slog.String("key", "configs.DEBUG_MODE"), slog.Int("attempt", 2),
+		slog.Group("flag", slog.Bool("secret", false)), slog.Any("error", errors.New("boom")))
+	require.Equal(t, 1, logs.Len(), "records below the level of the zap core must be filtered")
+
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.WarnLevel, entry.Level)
+	assert.Equal(t, "flag evaluated", entry.Message)
+	assert.Equal(t, map[string]interface{}{
+		"key":     "configs.DEBUG_MODE",
+		"attempt": int64(2),
+		"flag":    map[string]interface{}{"secret": false},
+		"error":   "boom",
+	}, entry.ContextMap())
+}
+
+func TestHandler_WithAttrs(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zaplog.New(zap.New(core)).With(slog.String("provider", "pulumi-esc")).WithGroup("evaluation")
+
+	logger.Debug("flag evaluated", slog.String("key", "configs.DEBUG_MODE"))
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, zapcore.DebugLevel, logs.All()[0].Level)
+	assert.Equal(t, map[string]interface{}{
+		"provider":   "pulumi-esc",
+		"evaluation": map[string]interface{}{"key": "configs.DEBUG_MODE"},
+	}, logs.All()[0].ContextMap())
+}
+
+func TestLoggingHook(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	hook := pulumi.NewLoggingHook(zaplog.New(zap.New(core)), slog.LevelInfo)
+
+	hook.Error(context.Background(), openfeature.HookContext{}, errors.New("FLAG_NOT_FOUND"), openfeature.HookHints{})
+	require.Equal(t, 1, logs.FilterMessage("flag evaluation failed").Len())
+	assert.Equal(t, "FLAG_NOT_FOUND", logs.All()[0].ContextMap()["error"])
+}